@@ -0,0 +1,4 @@
+// Package scraperv1 - сообщения и сервис kultscraper.v1, сгенерированные из scraper.proto
+package scraperv1
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative scraper.proto
@@ -0,0 +1,212 @@
+// Контракт gRPC-сервиса скрапера. Сервер: internal/rpc, включается командой serve с GRPC_ADDR.
+// Код сообщений и сервиса генерируется в этот каталог: go generate ./api/...
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: scraper.proto
+
+package scraperv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Scraper_SubmitTask_FullMethodName    = "/kultscraper.v1.Scraper/SubmitTask"
+	Scraper_GetResult_FullMethodName     = "/kultscraper.v1.Scraper/GetResult"
+	Scraper_StreamResults_FullMethodName = "/kultscraper.v1.Scraper/StreamResults"
+)
+
+// ScraperClient is the client API for Scraper service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ScraperClient interface {
+	// SubmitTask запускает задачу. Если persist, задача только добавляется в файл задач
+	// и запускается по своему расписанию (без расписания - сразу), run_id пуст
+	SubmitTask(ctx context.Context, in *SubmitTaskRequest, opts ...grpc.CallOption) (*SubmitTaskResponse, error)
+	// GetResult возвращает сохраненный результат по ID
+	GetResult(ctx context.Context, in *GetResultRequest, opts ...grpc.CallOption) (*Result, error)
+	// StreamResults передает новые и измененные результаты по мере сохранения
+	StreamResults(ctx context.Context, in *StreamResultsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ResultEvent], error)
+}
+
+type scraperClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewScraperClient(cc grpc.ClientConnInterface) ScraperClient {
+	return &scraperClient{cc}
+}
+
+func (c *scraperClient) SubmitTask(ctx context.Context, in *SubmitTaskRequest, opts ...grpc.CallOption) (*SubmitTaskResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitTaskResponse)
+	err := c.cc.Invoke(ctx, Scraper_SubmitTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *scraperClient) GetResult(ctx context.Context, in *GetResultRequest, opts ...grpc.CallOption) (*Result, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Result)
+	err := c.cc.Invoke(ctx, Scraper_GetResult_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *scraperClient) StreamResults(ctx context.Context, in *StreamResultsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ResultEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Scraper_ServiceDesc.Streams[0], Scraper_StreamResults_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamResultsRequest, ResultEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Scraper_StreamResultsClient = grpc.ServerStreamingClient[ResultEvent]
+
+// ScraperServer is the server API for Scraper service.
+// All implementations must embed UnimplementedScraperServer
+// for forward compatibility.
+type ScraperServer interface {
+	// SubmitTask запускает задачу. Если persist, задача только добавляется в файл задач
+	// и запускается по своему расписанию (без расписания - сразу), run_id пуст
+	SubmitTask(context.Context, *SubmitTaskRequest) (*SubmitTaskResponse, error)
+	// GetResult возвращает сохраненный результат по ID
+	GetResult(context.Context, *GetResultRequest) (*Result, error)
+	// StreamResults передает новые и измененные результаты по мере сохранения
+	StreamResults(*StreamResultsRequest, grpc.ServerStreamingServer[ResultEvent]) error
+	mustEmbedUnimplementedScraperServer()
+}
+
+// UnimplementedScraperServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedScraperServer struct{}
+
+func (UnimplementedScraperServer) SubmitTask(context.Context, *SubmitTaskRequest) (*SubmitTaskResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitTask not implemented")
+}
+func (UnimplementedScraperServer) GetResult(context.Context, *GetResultRequest) (*Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetResult not implemented")
+}
+func (UnimplementedScraperServer) StreamResults(*StreamResultsRequest, grpc.ServerStreamingServer[ResultEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamResults not implemented")
+}
+func (UnimplementedScraperServer) mustEmbedUnimplementedScraperServer() {}
+func (UnimplementedScraperServer) testEmbeddedByValue()                 {}
+
+// UnsafeScraperServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ScraperServer will
+// result in compilation errors.
+type UnsafeScraperServer interface {
+	mustEmbedUnimplementedScraperServer()
+}
+
+func RegisterScraperServer(s grpc.ServiceRegistrar, srv ScraperServer) {
+	// If the following call pancis, it indicates UnimplementedScraperServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Scraper_ServiceDesc, srv)
+}
+
+func _Scraper_SubmitTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScraperServer).SubmitTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Scraper_SubmitTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScraperServer).SubmitTask(ctx, req.(*SubmitTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Scraper_GetResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetResultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScraperServer).GetResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Scraper_GetResult_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScraperServer).GetResult(ctx, req.(*GetResultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Scraper_StreamResults_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamResultsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ScraperServer).StreamResults(m, &grpc.GenericServerStream[StreamResultsRequest, ResultEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Scraper_StreamResultsServer = grpc.ServerStreamingServer[ResultEvent]
+
+// Scraper_ServiceDesc is the grpc.ServiceDesc for Scraper service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Scraper_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kultscraper.v1.Scraper",
+	HandlerType: (*ScraperServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitTask",
+			Handler:    _Scraper_SubmitTask_Handler,
+		},
+		{
+			MethodName: "GetResult",
+			Handler:    _Scraper_GetResult_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamResults",
+			Handler:       _Scraper_StreamResults_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "scraper.proto",
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/rx3lixir/kultscraper/internal/bench"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	applog "github.com/rx3lixir/kultscraper/internal/lib/logger"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/rx3lixir/kultscraper/internal/scraper"
+	"github.com/rx3lixir/kultscraper/scrapertest"
+	"github.com/spf13/cobra"
+)
+
+// newBenchCmd создает команду, которая выполняет нагрузочный прогон на встроенном тестовом сервере:
+// kultscraper bench --tasks 100 --workers 6 --pages 10 [--mock]
+func newBenchCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "bench", Short: "load test the scraper against a built-in server", Args: cobra.NoArgs}
+	fs := cmd.Flags()
+	tasks := fs.Int("tasks", 50, "number of synthetic tasks")
+	workers := fs.Int("workers", numWorkers, "number of pool workers")
+	pages := fs.Int("pages", maxPages, "maximum browser pages")
+	delay := fs.Duration("delay", 0, "test server response delay")
+	mock := fs.Bool("mock", false, "use HTTP mock engine instead of Chromium")
+
+	cmd.RunE = runCode(func() int {
+		logger := applog.InitLogger(os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"))
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		server := bench.NewServer(*delay)
+		defer server.Close()
+
+		cfg := bench.Config{
+			Tasks:       *tasks,
+			Workers:     *workers,
+			TaskTimeout: config.DefaultTaskTimeouts.Scrape,
+		}
+
+		var s scraper.Scraper
+		if *mock {
+			s = httpMockScraper()
+		} else {
+			b := rod.New()
+			if err := b.Connect(); err != nil {
+				logger.Error("Failed to connect to browser", "error", err)
+				return 1
+			}
+			rodScraper := scraper.NewRodScraper(b, *applog.ForModule(logger, nil, applog.ModuleScraper), *pages)
+			rodScraper.CaptureConsole = false
+
+			monitor := scraper.NewBrowserMonitor(rodScraper, 0, scraper.BrowserLimits{}, scraper.LimitActionLog)
+			cfg.BrowserUsage = monitor.Collect
+			s = rodScraper
+		}
+		defer s.Close()
+
+		logger.Info("Starting benchmark", "tasks", *tasks, "workers", *workers, "pages", *pages, "mock", *mock)
+
+		report, err := bench.Run(ctx, cfg, s, bench.Tasks(server.URL, *tasks))
+		if err != nil {
+			logger.Error("Benchmark failed", "error", err)
+			return 1
+		}
+
+		if err := report.Write(os.Stdout); err != nil {
+			return 1
+		}
+		return 0
+	})
+	return cmd
+}
+
+// httpMockScraper загружает страницы обычным HTTP-клиентом, чтобы измерить накладные расходы
+// пула и конвейера без браузера
+func httpMockScraper() *scrapertest.MockScraper {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	m := scrapertest.NewMockScraper()
+	m.ScrapeFunc = func(ctx context.Context, task config.ScraperTask) (*models.ScrapingResult, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, task.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		result := models.NewScrapingResult(task.URL, task.Type, task.Name, map[string]string{"Body": string(body)})
+		result.Metadata.HTTPStatus = resp.StatusCode
+		result.Metadata.Engine = "http-mock"
+		return result, nil
+	}
+	return m
+}
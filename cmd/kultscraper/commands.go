@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/db"
+	applog "github.com/rx3lixir/kultscraper/internal/lib/logger"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// version задается при сборке: -ldflags "-X main.version=v1.2.3"
+var version = ""
+
+// exitCode - ошибка команды, завершающая процесс с этим кодом. Причина уже выведена
+type exitCode int
+
+func (c exitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(c))
+}
+
+// runCode превращает тело команды, возвращающее код выхода, в RunE
+func runCode(run func() int) func(*cobra.Command, []string) error {
+	return func(*cobra.Command, []string) error {
+		if code := run(); code != 0 {
+			return exitCode(code)
+		}
+		return nil
+	}
+}
+
+// newRootCmd создает команду kultscraper со всеми подкомандами
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:               "kultscraper",
+		Short:             "Scrape event listings with a headless browser",
+		SilenceErrors:     true,
+		SilenceUsage:      true,
+		CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
+	}
+	root.AddCommand(
+		newScrapeCmd(modeRun, "scrape tasks from CONFIG_PATH once, on their schedules or with --daemon until stopped"),
+		newScrapeCmd(modeServe, "run as a daemon with REST and gRPC API"),
+		newScrapeCmd(modeRetryFailed, "re-run only tasks in the dead-letter queue or with error results"),
+		newValidateCmd(),
+		newExportCmd(),
+		newListResultsCmd(),
+		newDeadLettersCmd(),
+		newBenchCmd(),
+		newVersionCmd(),
+	)
+	return root
+}
+
+// execute выполняет подкоманду из args и возвращает код выхода. Без подкоманды или с флагом
+// первым аргументом выполняется run, как до появления подкоманд
+func execute(args []string) int {
+	if len(args) == 0 || (strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "--help") {
+		args = append([]string{modeRun}, args...)
+	}
+
+	root := newRootCmd()
+	root.SetArgs(args)
+	cmd, err := root.ExecuteC()
+
+	var code exitCode
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &code):
+		return int(code)
+	}
+	// Неизвестная команда или неверные флаги
+	fmt.Fprintf(os.Stderr, "Error: %v\n\n%s", err, cmd.UsageString())
+	return 2
+}
+
+// configFlags - общие флаги команд, переопределяющие переменные окружения
+type configFlags struct {
+	envFile string
+	tasks   string
+	filter  config.TaskFilter
+	storage string // Пустое значение - STORAGE_BACKEND
+	// withStorage - команда открывает хранилище, его настройки проверяются при загрузке
+	withStorage bool
+	logLevel    string
+	logFormat   string
+}
+
+func addConfigFlags(fs *pflag.FlagSet) *configFlags {
+	f := &configFlags{}
+	fs.StringVar(&f.envFile, "env-file", "", "file with environment variables, overrides ENV_FILE (default .env if present)")
+	fs.StringVar(&f.tasks, "config", "", "tasks file, overrides CONFIG_PATH")
+	fs.StringVar(&f.logLevel, "log-level", "", "log level, overrides LOG_LEVEL")
+	fs.StringVar(&f.logFormat, "log-format", "", "log format, overrides LOG_FORMAT")
+	return f
+}
+
+// addStorage добавляет флаг хранилища для команд, работающих с результатами
+func (f *configFlags) addStorage(fs *pflag.FlagSet) {
+	f.withStorage = true
+	fs.StringVar(&f.storage, "storage", "", "storage backend, overrides STORAGE_BACKEND")
+}
+
+// addTaskFilter добавляет флаги отбора задач для команд, выполняющих задачи
+func (f *configFlags) addTaskFilter(fs *pflag.FlagSet) {
+	fs.StringVar(&f.filter.Name, "task", "", "only the task with this name")
+	fs.StringVar(&f.filter.URLMatch, "url-match", "", "only tasks whose URL contains this text")
+}
+
+// load загружает конфигурацию из окружения, применяет заданные флаги и проверяет результат
+func (f *configFlags) load() (*config.AppConfig, error) {
+	envFile := f.envFile
+	if envFile == "" {
+		envFile = os.Getenv("ENV_FILE")
+	}
+	cfg, err := config.LoadConfigFrom(envFile)
+	if err != nil {
+		return nil, err
+	}
+	if f.tasks != "" {
+		cfg.ConfigPath = f.tasks
+	}
+	if f.storage != "" {
+		cfg.StorageBackend = f.storage
+	}
+	if f.logLevel != "" {
+		cfg.Log.Level = f.logLevel
+	}
+	if f.logFormat != "" {
+		cfg.Log.Format = f.logFormat
+	}
+	cfg.TaskFilter = f.filter
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if f.withStorage {
+		if err := cfg.ValidateStorage(); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// newValidateCmd создает команду, которая проверяет файл задач без запуска браузера и хранилища:
+// kultscraper validate [--config tasks.json]
+func newValidateCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "validate", Short: "check the tasks file and report every invalid task", Args: cobra.NoArgs}
+	fs := cmd.Flags()
+	configFlags := addConfigFlags(fs)
+	configFlags.addTaskFilter(fs)
+
+	cmd.RunE = runCode(func() int {
+		cfg, err := configFlags.load()
+		if err != nil {
+			applog.InitLogger("", "").Error("Failed to load configuration", "error", err)
+			return 1
+		}
+
+		tasks, err := loadTasks(context.Background(), cfg, applog.InitLogger(cfg.Log.Level, cfg.Log.Format))
+		if err == nil {
+			_, _, _, err = groupTasks(tasks, "")
+		}
+
+		var invalid *config.ValidationError
+		switch {
+		case errors.As(err, &invalid):
+			// Каждая ошибка на своей строке с номером строки файла
+			fmt.Fprintln(os.Stderr, invalid.Error())
+			return 1
+		case err != nil:
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		fmt.Printf("%s: %d task(s) OK\n", cfg.ConfigPath, len(tasks))
+		return 0
+	})
+	return cmd
+}
+
+// newListResultsCmd создает команду, которая печатает сохраненные результаты:
+// kultscraper list-results [--type Кино] [--name фест] [--sort=-updated_at] [--limit 20] [--json]
+func newListResultsCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "list-results", Short: "print saved results with filters and paging", Args: cobra.NoArgs}
+	fs := cmd.Flags()
+	scraperType := fs.String("type", "", "only results of this type")
+	tag := fs.String("tag", "", "only results with this tag")
+	project := fs.String("project", "", "only results of this project")
+	status := fs.String("status", "", "only results with this status: invalid or error")
+	name := fs.String("name", "", "only results whose name contains this text")
+	updatedAfter := fs.Duration("updated-within", 0, "only results updated within this period, e.g. 24h")
+	sortBy := fs.String("sort", "", "sort field, prefix with - for descending: "+strings.Join(db.SortFields, ", "))
+	limit := fs.Int("limit", 20, "maximum number of results, 0 for all")
+	offset := fs.Int("offset", 0, "number of results to skip")
+	includeExpired := fs.Bool("include-expired", false, "include results of expired events")
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	configFlags := addConfigFlags(fs)
+	configFlags.addStorage(fs)
+
+	cmd.RunE = runCode(func() int {
+		cfg, err := configFlags.load()
+		if err != nil {
+			applog.InitLogger("", "").Error("Failed to load configuration", "error", err)
+			return 1
+		}
+		logger := applog.InitLogger(cfg.Log.Level, cfg.Log.Format)
+
+		opts := []db.QueryOption{db.Page(*limit, *offset)}
+		if *scraperType != "" {
+			opts = append(opts, db.OfType(*scraperType))
+		}
+		if *tag != "" {
+			opts = append(opts, db.Tagged(*tag))
+		}
+		if *project != "" {
+			opts = append(opts, db.InProject(*project))
+		}
+		if *status != "" {
+			opts = append(opts, db.WithStatus(*status))
+		}
+		if *name != "" {
+			opts = append(opts, db.NameContains(*name))
+		}
+		if *updatedAfter > 0 {
+			opts = append(opts, db.UpdatedAfter(time.Now().Add(-*updatedAfter)))
+		}
+		if *includeExpired {
+			opts = append(opts, db.IncludeExpired())
+		}
+		if *sortBy != "" {
+			field, desc := strings.CutPrefix(*sortBy, "-")
+			if !db.ValidSortField(field) {
+				logger.Error("Unknown sort field", "sort", *sortBy, "available", db.SortFields)
+				return 2
+			}
+			opts = append(opts, db.SortBy(field, desc))
+		}
+
+		ctx := context.Background()
+
+		storage, err := db.NewStorage(ctx, cfg, applog.NewAdapter(applog.ForModule(logger, cfg.Log.Modules, applog.ModuleDB)))
+		if err != nil {
+			logger.Error("Failed to open storage", "error", err)
+			return 1
+		}
+		defer storage.Close()
+
+		results, err := storage.Results.GetAllResults(ctx, opts...)
+		if err != nil {
+			logger.Error("Failed to load results", "error", err)
+			return 1
+		}
+
+		if *asJSON {
+			if results == nil {
+				results = []*models.ScrapingResult{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(results); err != nil {
+				logger.Error("Failed to write results", "error", err)
+				return 1
+			}
+			return 0
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTYPE\tNAME\tUPDATED\tFIELDS\tURL")
+		for _, r := range results {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+				r.ID.Hex(), r.Type, r.Name, r.UpdatedAt.Local().Format(time.DateTime), len(r.Data), r.URL)
+		}
+		if err := w.Flush(); err != nil {
+			logger.Error("Failed to write results", "error", err)
+			return 1
+		}
+		return 0
+	})
+	return cmd
+}
+
+// newVersionCmd создает команду, которая печатает версию сборки
+func newVersionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "print version",
+		Args:  cobra.NoArgs,
+		Run: func(*cobra.Command, []string) {
+			v := version
+			if v == "" {
+				v = "(devel)"
+				if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+					v = info.Main.Version
+				}
+			}
+			fmt.Println("kultscraper", v)
+		},
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	applog "github.com/rx3lixir/kultscraper/internal/lib/logger"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/rx3lixir/kultscraper/internal/scheduler"
+)
+
+// daemon держит задачи в планировщике и перечитывает файл задач при его изменении
+// или по сигналу SIGHUP. Задачи без расписания перезапускаются каждые DAEMON_INTERVAL.
+// Идущие запуски не прерываются: начатые попытки завершаются, а задачи, удаленные
+// из файла, пропускаются, если их попытка еще не началась
+type daemon struct {
+	cfg         *config.AppConfig
+	logger      *log.Logger
+	runs        *runner
+	sched       *scheduler.Scheduler
+	runCtx      context.Context // Контекст запусков, отменяется позже контекста планирования
+	defaultSpec string          // Расписание задач без поля Schedule
+
+	mu          sync.Mutex          // Защищает поля ниже: задачи применяются из watch и из API
+	fingerprint string              // Отпечаток текущего списка задач
+	specs       map[string]struct{} // Расписания, зарегистрированные в планировщике
+	known       map[string]struct{} // Отпечатки уже запланированных задач
+}
+
+func newDaemon(cfg *config.AppConfig, logger *log.Logger, runs *runner, runCtx context.Context) *daemon {
+	return &daemon{
+		cfg:         cfg,
+		logger:      logger,
+		runs:        runs,
+		sched:       scheduler.NewWithLogger(applog.NewAdapter(applog.ForModule(logger, cfg.Log.Modules, applog.ModuleScheduler))),
+		runCtx:      runCtx,
+		defaultSpec: "@every " + cfg.Daemon.Interval.String(),
+		specs:       make(map[string]struct{}),
+		known:       make(map[string]struct{}),
+	}
+}
+
+// Load планирует начальный список задач
+func (d *daemon) Load(tasks []config.ScraperTask) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.apply(tasks)
+}
+
+// Run работает до отмены ctx, затем ожидает завершения идущих запусков
+func (d *daemon) Run(ctx context.Context) {
+	d.logger.Info("Daemon started",
+		"interval", d.cfg.Daemon.Interval,
+		"watch_interval", d.cfg.Daemon.WatchInterval)
+
+	if d.cfg.Daemon.WatchInterval > 0 {
+		go d.watch(ctx)
+	}
+	go d.watchSignal(ctx)
+
+	d.sched.Run(ctx)
+	d.logger.Info("Daemon stopped")
+}
+
+// apply заменяет задачи в планировщике. Группы с новыми задачами без расписания
+// запускаются сразу, остальные ждут своего расписания. Вызывается под d.mu
+func (d *daemon) apply(tasks []config.ScraperTask) error {
+	_, groups, specs, err := groupTasks(tasks, d.defaultSpec)
+	if err != nil {
+		return err
+	}
+
+	for spec := range d.specs {
+		if _, ok := groups[spec]; !ok {
+			d.sched.Remove(spec)
+			delete(d.specs, spec)
+		}
+	}
+
+	known := make(map[string]struct{}, len(tasks))
+	for _, spec := range specs {
+		group := groups[spec]
+		err := d.sched.Add(spec, spec, func(_ context.Context, planned time.Time) {
+			// Запуск использует runCtx, чтобы остановка планировщика не прерывала его сразу
+			d.runs.run(d.runCtx, group, runOptions{
+				Trigger:     models.TriggerSchedule,
+				TriggeredBy: spec,
+				Schedule:    spec,
+				PlannedAt:   planned,
+				Active:      d.active,
+			})
+		})
+		if err != nil {
+			return err
+		}
+		d.specs[spec] = struct{}{}
+
+		added := 0
+		for _, task := range group {
+			fp := task.Fingerprint()
+			if _, ok := d.known[fp]; !ok {
+				added++
+			}
+			known[fp] = struct{}{}
+		}
+		if added > 0 && spec == d.defaultSpec {
+			d.logger.Info("New tasks found, starting run", "count", added)
+			d.sched.Trigger(spec)
+		}
+	}
+
+	d.known = known
+	d.fingerprint = config.TasksFingerprint(tasks)
+	return nil
+}
+
+// watch проверяет файл задач каждые DAEMON_WATCH_INTERVAL и применяет изменения.
+// Удаленный источник перечитывается на каждой проверке
+func (d *daemon) watch(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.Daemon.WatchInterval)
+	defer ticker.Stop()
+
+	var modTime time.Time
+	if info, err := os.Stat(d.cfg.ConfigPath); err == nil {
+		modTime = info.ModTime()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// У удаленного источника нет времени изменения, reload сравнивает отпечаток задач
+		if !config.IsLocalTaskSource(d.cfg.ConfigPath) {
+			d.reload()
+			continue
+		}
+
+		info, err := os.Stat(d.cfg.ConfigPath)
+		if err != nil {
+			d.logger.Warn("Failed to check tasks file", "source", config.RedactURL(d.cfg.ConfigPath), "error", err)
+			continue
+		}
+		if info.ModTime().Equal(modTime) {
+			continue
+		}
+		modTime = info.ModTime()
+
+		d.reload()
+	}
+}
+
+// watchSignal перечитывает файл задач по SIGHUP
+func (d *daemon) watchSignal(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			d.logger.Info("SIGHUP received, reloading tasks", "source", config.RedactURL(d.cfg.ConfigPath))
+			d.reload()
+		}
+	}
+}
+
+// active сообщает, осталась ли задача в текущем списке
+func (d *daemon) active(task config.ScraperTask) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.known[task.Fingerprint()]
+	return ok
+}
+
+// reload перечитывает файл задач и применяет изменения.
+// Если файл не читается или содержит ошибки, остаются прежние задачи
+func (d *daemon) reload() {
+	tasks, err := loadTasks(d.runCtx, d.cfg, d.logger)
+	if err != nil {
+		d.logger.Error("Failed to reload tasks, keeping previous", "source", config.RedactURL(d.cfg.ConfigPath), "error", err)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if config.TasksFingerprint(tasks) == d.fingerprint {
+		return
+	}
+	if err := d.apply(tasks); err != nil {
+		d.logger.Error("Invalid tasks, keeping previous", "source", config.RedactURL(d.cfg.ConfigPath), "error", err)
+		return
+	}
+	d.logger.Info("Tasks reloaded", "count", len(tasks))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/db"
+	applog "github.com/rx3lixir/kultscraper/internal/lib/logger"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// newDeadLettersCmd создает команду, которая печатает задачи, не выполненные после всех попыток, и управляет очередью:
+// kultscraper dead-letters [--status pending] [--limit 20] [--json]
+// kultscraper dead-letters --requeue <id>|all
+// kultscraper dead-letters --delete <id>
+func newDeadLettersCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "dead-letters", Short: "list tasks that failed after all retries, requeue or delete them", Args: cobra.NoArgs}
+	fs := cmd.Flags()
+	status := fs.String("status", models.DeadLetterPending, "only entries with this status: pending or requeued, empty for all")
+	limit := fs.Int64("limit", 20, "maximum number of entries, 0 for all")
+	requeue := fs.String("requeue", "", "requeue the entry with this id, or all pending entries, for the next run")
+	remove := fs.String("delete", "", "delete the entry with this id")
+	asJSON := fs.Bool("json", false, "print entries as JSON")
+	configFlags := addConfigFlags(fs)
+	configFlags.addStorage(fs)
+
+	cmd.RunE = runCode(func() int {
+		cfg, err := configFlags.load()
+		if err != nil {
+			applog.InitLogger("", "").Error("Failed to load configuration", "error", err)
+			return 1
+		}
+		logger := applog.InitLogger(cfg.Log.Level, cfg.Log.Format)
+
+		ctx := context.Background()
+
+		storage, err := db.NewStorage(ctx, cfg, applog.NewAdapter(applog.ForModule(logger, cfg.Log.Modules, applog.ModuleDB)))
+		if err != nil {
+			logger.Error("Failed to open storage", "error", err)
+			return 1
+		}
+		defer storage.Close()
+
+		switch {
+		case *requeue == "all":
+			letters, err := storage.DeadLetters.GetDeadLetters(ctx, models.DeadLetterPending, 0)
+			if err != nil {
+				logger.Error("Failed to load dead letters", "error", err)
+				return 1
+			}
+			for _, letter := range letters {
+				if err := storage.DeadLetters.Requeue(ctx, letter.ID.Hex()); err != nil {
+					logger.Error("Failed to requeue task", "id", letter.ID.Hex(), "error", err)
+					return 1
+				}
+			}
+			logger.Info("Tasks requeued for the next run", "count", len(letters))
+			return 0
+		case *requeue != "":
+			if err := storage.DeadLetters.Requeue(ctx, *requeue); err != nil {
+				logger.Error("Failed to requeue task", "id", *requeue, "error", err)
+				return 1
+			}
+			logger.Info("Task requeued for the next run", "id", *requeue)
+			return 0
+		case *remove != "":
+			if err := storage.DeadLetters.DeleteDeadLetter(ctx, *remove); err != nil {
+				logger.Error("Failed to delete dead letter", "id", *remove, "error", err)
+				return 1
+			}
+			logger.Info("Dead letter deleted", "id", *remove)
+			return 0
+		}
+
+		letters, err := storage.DeadLetters.GetDeadLetters(ctx, *status, *limit)
+		if err != nil {
+			logger.Error("Failed to load dead letters", "error", err)
+			return 1
+		}
+
+		if *asJSON {
+			if letters == nil {
+				letters = []*models.DeadLetter{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(letters); err != nil {
+				logger.Error("Failed to write dead letters", "error", err)
+				return 1
+			}
+			return 0
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSTATUS\tTYPE\tNAME\tFAILED\tFAILURES\tATTEMPTS\tERROR\tURL")
+		for _, l := range letters {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+				l.ID.Hex(), l.Status, l.Type, l.Name, l.FailedAt.Local().Format(time.DateTime),
+				l.Failures, len(l.Attempts), l.Error, l.URL)
+		}
+		if err := w.Flush(); err != nil {
+			logger.Error("Failed to write dead letters", "error", err)
+			return 1
+		}
+		return 0
+	})
+	return cmd
+}
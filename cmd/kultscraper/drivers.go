@@ -0,0 +1,7 @@
+package main
+
+// Драйверы database/sql для STORAGE_BACKEND=postgres и sqlite регистрируются импортом
+import (
+	_ "github.com/jackc/pgx/v5/stdlib" // db.DefaultPostgresDriver
+	_ "modernc.org/sqlite"             // db.DefaultSQLiteDriver, без cgo
+)
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/rx3lixir/kultscraper/internal/db"
+	"github.com/rx3lixir/kultscraper/internal/export"
+	applog "github.com/rx3lixir/kultscraper/internal/lib/logger"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// newExportCmd создает команду, которая выгружает сохраненные результаты из хранилища:
+// kultscraper export --format csv [--out results.csv] [--columns "Название=title,Дата=date"] [--type Кино]
+// kultscraper export --format ics [--group type|name --out calendars/]
+func newExportCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "export", Short: "export saved results as csv, jsonld or ics", Args: cobra.NoArgs}
+	fs := cmd.Flags()
+	format := fs.String("format", "csv", "output format: csv, jsonld or ics")
+	out := fs.String("out", "", "output file, stdout by default")
+	columns := fs.String("columns", "", "csv column mapping header=source, overrides CSV_COLUMNS")
+	scraperType := fs.String("type", "", "export only results of this type")
+	tag := fs.String("tag", "", "export only results with this tag")
+	project := fs.String("project", "", "export only results of this project")
+	group := fs.String("group", "", "ics: one calendar per type or name, written to the --out directory")
+	configFlags := addConfigFlags(fs)
+	configFlags.addStorage(fs)
+
+	cmd.RunE = runCode(func() int {
+		cfg, err := configFlags.load()
+		if err != nil {
+			applog.InitLogger("", "").Error("Failed to load configuration", "error", err)
+			return 1
+		}
+
+		logger := applog.InitLogger(cfg.Log.Level, cfg.Log.Format)
+
+		var csvColumns []export.CSVColumn
+		switch *format {
+		case "csv":
+			mapping := cfg.CSVColumns
+			if *columns != "" {
+				mapping = *columns
+			}
+			if csvColumns, err = export.ParseCSVColumns(mapping); err != nil {
+				logger.Error("Invalid column mapping", "error", err)
+				return 2
+			}
+		case "jsonld":
+		case "ics":
+			if *group != "" && *out == "" {
+				logger.Error("Grouped calendars require --out directory")
+				return 2
+			}
+		default:
+			logger.Error("Unknown export format", "format", *format)
+			return 2
+		}
+
+		ctx := context.Background()
+
+		storage, err := db.NewStorage(ctx, cfg, applog.NewAdapter(applog.ForModule(logger, cfg.Log.Modules, applog.ModuleDB)))
+		if err != nil {
+			logger.Error("Failed to open storage", "error", err)
+			return 1
+		}
+		defer storage.Close()
+
+		var opts []db.QueryOption
+		if *project != "" {
+			opts = append(opts, db.InProject(*project))
+		}
+
+		var results []*models.ScrapingResult
+		switch {
+		case *scraperType != "":
+			results, err = storage.Results.GetResultsByType(ctx, *scraperType, opts...)
+		case *tag != "":
+			results, err = storage.Results.GetResultsByTag(ctx, *tag, opts...)
+		default:
+			results, err = storage.Results.GetAllResults(ctx, opts...)
+		}
+		if err != nil {
+			logger.Error("Failed to load results", "error", err)
+			return 1
+		}
+
+		if *format == "ics" {
+			loc := time.Local
+			if cfg.Expiry.Location != "" {
+				if loc, err = time.LoadLocation(cfg.Expiry.Location); err != nil {
+					logger.Error("Invalid expiry timezone", "timezone", cfg.Expiry.Location, "error", err)
+					return 2
+				}
+			}
+			if err := exportCalendars(results, *group, *out, loc, logger); err != nil {
+				logger.Error("Export failed", "error", err)
+				return 1
+			}
+			return 0
+		}
+
+		var w io.Writer = os.Stdout
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				logger.Error("Failed to create output file", "error", err)
+				return 1
+			}
+			defer f.Close()
+			w = f
+		}
+
+		switch *format {
+		case "csv":
+			err = export.WriteCSV(w, results, csvColumns)
+		case "jsonld":
+			err = export.WriteJSONLD(w, results)
+		}
+		if err != nil {
+			logger.Error("Export failed", "error", err)
+			return 1
+		}
+
+		logger.Info("Export finished", "format", *format, "results", len(results))
+		return 0
+	})
+	return cmd
+}
+
+// exportCalendars записывает результаты с датами в календари iCalendar: один календарь
+// в out (или stdout) без группировки, иначе файл <группа>.ics на каждую группу в каталоге out
+func exportCalendars(results []*models.ScrapingResult, group, out string, loc *time.Location, logger *log.Logger) error {
+	calendars, err := export.GroupCalendars(results, group, "kultscraper")
+	if err != nil {
+		return err
+	}
+	if group != "" {
+		if err := os.MkdirAll(out, 0o755); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for _, cal := range calendars {
+		var w io.Writer = os.Stdout
+		path := out
+		if group != "" {
+			path = filepath.Join(out, calendarFileName(cal.Name))
+		}
+		if path != "" {
+			f, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+
+		written, err := export.WriteICS(w, cal, now, loc)
+		if err != nil {
+			return err
+		}
+		logger.Info("Calendar exported", "calendar", cal.Name, "path", path, "events", written, "skipped", len(cal.Results)-written)
+	}
+	return nil
+}
+
+// calendarFileName возвращает имя файла календаря группы без символов, недопустимых в путях
+func calendarFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "untitled"
+	}
+	return name + ".ics"
+}
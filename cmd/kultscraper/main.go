@@ -2,17 +2,19 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 	"os"
-	"os/signal"
-	"syscall"
+	"strings"
 	"time"
 
-	"github.com/go-rod/rod"
+	"github.com/charmbracelet/log"
 	"github.com/rx3lixir/kultscraper/internal/config"
 	"github.com/rx3lixir/kultscraper/internal/db"
-	"github.com/rx3lixir/kultscraper/internal/lib/logger"
-	"github.com/rx3lixir/kultscraper/internal/lib/work"
+	"github.com/rx3lixir/kultscraper/internal/export"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
 	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/rx3lixir/kultscraper/internal/scheduler"
 	"github.com/rx3lixir/kultscraper/internal/scraper"
 )
 
@@ -20,186 +22,157 @@ const (
 	numWorkers       = 6
 	maxPages         = 10
 	defaultTimeout   = 3 * time.Minute
-	scrapeTimeout    = 30 * time.Second
 	gracefulShutdown = 10 * time.Second
 )
 
 func main() {
-	logger := logger.InitLogger()
-	logger.Info("Starting Scrapper")
+	os.Exit(execute(os.Args[1:]))
+}
 
-	// Загружаем конфигурацию
-	cfg, err := config.LoadConfig()
+// loadTasks загружает задачи из CONFIG_PATH и отбирает их по cfg.TaskFilter.
+// Задачи удаленных источников кэшируются в TASKS_CACHE_DIR и читаются из кэша,
+// если источник недоступен. Задачи без явного проекта относятся к проекту по умолчанию
+func loadTasks(ctx context.Context, cfg *config.AppConfig, logger *log.Logger) ([]config.ScraperTask, error) {
+	source, err := config.NewTaskSource(cfg.ConfigPath)
 	if err != nil {
-		logger.Error("Error loading config file", "error", err)
-		os.Exit(1)
+		return nil, err
+	}
+	if !config.IsLocalTaskSource(cfg.ConfigPath) && cfg.TasksCacheDir != "" {
+		cached := config.NewCachedSource(source, cfg.TasksCacheDir)
+		cached.OnFallback = func(err error) {
+			logger.Warn("Task source unavailable, using cached tasks", "source", source, "cache", cached.Path, "error", err)
+		}
+		cached.OnCacheError = func(err error) {
+			logger.Warn("Failed to cache tasks", "source", source, "cache", cached.Path, "error", err)
+		}
+		source = cached
 	}
 
-	// Создаем контекст, который будет отменен по сигналу
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel() // Гарантированный вызов функции отмены
-
-	// Обработка сигналов завершения
-	signalCh := make(chan os.Signal, 1)
-	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		sig := <-signalCh
-		logger.Info("Received signal", "signal", sig)
-		cancel()
-	}()
-
-	// Загружаем задачи
-	tasks, err := config.LoadTasks(cfg.ConfigPath)
+	tasks, err := config.LoadTasksFrom(ctx, source)
 	if err != nil {
-		logger.Error("Failed to load tasks", "error", err)
-		os.Exit(1)
+		return nil, err
 	}
-	logger.Info("Loaded tasks", "count", len(tasks))
-
-	// Инициализация подключения к MongoDB
-	mongoConfig := db.NewDefaultConfig(
-		cfg.MongoDB.URI,
-		cfg.MongoDB.Database,
-		cfg.MongoDB.Collection,
-	)
-	mongoConfig.Username = cfg.MongoDB.Username
-	mongoConfig.Password = cfg.MongoDB.Password
-	mongoConfig.Timeout = cfg.MongoDB.ConnectTimeout
-
-	mongoClient, err := db.ConnectMongo(ctx, mongoConfig)
-	if err != nil {
-		logger.Error("Failed to connect to MongoDB", "error", err)
-		os.Exit(1)
+	if tasks, err = cfg.TaskFilter.Apply(tasks); err != nil {
+		return nil, err
 	}
-	logger.Info("Successfully connected to MongoDB")
-
-	// Создание репозитория для работы с данными скраппинга
-	repository, err := db.NewMongoScraperRepo(
-		mongoClient,
-		mongoConfig.Database,
-		mongoConfig.CollectionName,
-	)
-	if err != nil {
-		logger.Error("Failed to create repository", "error", err)
-		os.Exit(1)
+	return withDefaultProject(tasks, cfg.Project), nil
+}
+
+// withDefaultProject назначает проект по умолчанию задачам без явного проекта
+func withDefaultProject(tasks []config.ScraperTask, project string) []config.ScraperTask {
+	for i := range tasks {
+		if tasks[i].Project == "" {
+			tasks[i].Project = project
+		}
 	}
-	logger.Info("Created MongoDB repository")
-
-	// Гарантируем закрытие соединения с MongoDB
-	defer func() {
-		if err := repository.Close(); err != nil {
-			logger.Error("Failed to close MongoDB connection", "error", err)
-		} else {
-			logger.Info("Mongo connetion closed successfully")
+	return tasks
+}
+
+// groupTasks группирует задачи по cron-выражению, specs - выражения в порядке появления.
+// Задачи без расписания получают defaultSpec, а при пустом defaultSpec попадают в once
+func groupTasks(tasks []config.ScraperTask, defaultSpec string) (once []config.ScraperTask, groups map[string][]config.ScraperTask, specs []string, err error) {
+	groups = make(map[string][]config.ScraperTask)
+	for _, task := range tasks {
+		spec := task.Schedule
+		if spec == "" {
+			spec = defaultSpec
+		}
+		if spec == "" {
+			once = append(once, task)
+			continue
+		}
+		if _, err := scheduler.Validate(spec); err != nil {
+			return nil, nil, nil, fmt.Errorf("task %s: %w", task.URL, err)
 		}
-	}()
+		if _, ok := groups[spec]; !ok {
+			specs = append(specs, spec)
+		}
+		groups[spec] = append(groups[spec], task)
+	}
+	return once, groups, specs, nil
+}
 
-	// Инициализируем браузер
-	browser := rod.New().MustConnect()
-	defer browser.Close()
+// requestBudget учитывает каждый запрос к сайту в его дневном бюджете: страницы задач,
+// следующие страницы, обход ссылок, sitemap и повторные попытки
+type requestBudget struct {
+	repo    db.BudgetRepository
+	budgets config.RequestBudgets
+	// deny - при недоступном счетчике запросы отклоняются, иначе выполняются без учета
+	deny   bool
+	logger *log.Logger
+}
 
-	// Создаем скрапер
-	rodScraper := scraper.NewRodScraper(browser, *logger, maxPages)
-	defer rodScraper.Close()
+// newRequestBudget создает учет бюджета или возвращает nil, если лимиты не заданы
+func newRequestBudget(cfg *config.AppConfig, repo db.BudgetRepository, logger *log.Logger) scraper.RequestBudget {
+	if len(cfg.RequestBudgets) == 0 || repo == nil {
+		return nil
+	}
+	return &requestBudget{
+		repo:    repo,
+		budgets: cfg.RequestBudgets,
+		deny:    cfg.BudgetOnError == config.BudgetDeny,
+		logger:  logger,
+	}
+}
 
-	// Создаем пул работников
-	pool, err := work.NewPool(numWorkers, len(tasks))
+// Consume учитывает запрос к домену rawURL. Возвращает ошибку, если бюджет исчерпан
+// или счетчик недоступен при REQUEST_BUDGET_ON_ERROR=deny
+func (b *requestBudget) Consume(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
 	if err != nil {
-		logger.Error("Failed to create worker pool", "error", err)
-		os.Exit(1)
+		return err
 	}
+	domain := strings.ToLower(u.Hostname())
 
-	// Запускаем пул
-	if err := pool.Start(ctx); err != nil {
-		logger.Error("Failed to start worker pool", "error", err)
-		os.Exit(1)
+	limit := b.budgets.For(domain)
+	if limit <= 0 {
+		return nil
 	}
 
-	// Гарантируем остановку пула
-	defer func() {
-		// Создаем контекст с таймаутом для грейсфул шатдауна
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), gracefulShutdown)
-		defer shutdownCancel() // Гарантированный вызов функции отмены
-
-		// Запускаем горутину для остановки пула
-		done := make(chan struct{})
-		go func() {
-			pool.Stop()
-			close(done)
-		}()
-
-		// Ожидаем либо завершения остановки, либо таймаута
-		select {
-		case <-done:
-			logger.Info("Pool stopped gracefully")
-		case <-shutdownCtx.Done():
-			logger.Warn("Pool shutdown timed out")
+	allowed, used, err := b.repo.Consume(ctx, domain, time.Now(), limit)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
-	}()
-
-	// Добавляем задачи в пул
-	for _, task := range tasks {
-		// Создаем таймаут контекст для каждой задачи
-		taskCtx, taskCancel := context.WithTimeout(ctx, scrapeTimeout)
-		scraperTask := scraper.NewTaskToScrape(task, taskCtx, rodScraper, *logger)
-
-		if err := pool.AddTask(scraperTask); err != nil {
-			logger.Error("Failed to add task", "url", task.URL, "error", err)
-			taskCancel() // Отменяем контекст, если не удалось добавить задачу
-			continue
+		if b.deny {
+			b.logger.Error("Request budget unavailable, denying request", "domain", domain, "url", rawURL, "error", err)
+			return errs.Wrap(errs.CodeBudgetExhausted, "budget", fmt.Errorf("%s: budget counter unavailable: %w", domain, err))
 		}
+		b.logger.Warn("Request budget unavailable, allowing request", "domain", domain, "url", rawURL, "error", err)
+		return nil
+	}
+	if !allowed {
+		return errs.Wrap(errs.CodeBudgetExhausted, "budget", fmt.Errorf("%s: %d of %d daily requests used", domain, used, limit))
+	}
+	return nil
+}
 
-		// Отмена контекста задачи будет выполнена после завершения задачи
-		// или при отмене родительского контекста через defer
-		go func(cancel context.CancelFunc) {
-			// Ожидание завершения задачи или отмены родительского контекста
-			select {
-			case <-taskCtx.Done():
-				// Задача завершена или превысила таймаут
-			case <-ctx.Done():
-				// Родительский контекст отменен
-			}
-			cancel() // Отменяем контекст задачи
-		}(taskCancel)
+// resultValidators берет валидаторы HTTP-кэша из сохраненного результата задачи
+type resultValidators struct {
+	repo db.ScraperRepository
+}
+
+func (v resultValidators) Validators(ctx context.Context, task config.ScraperTask) (scraper.Validators, error) {
+	results, err := v.repo.GetResultsByType(ctx, task.Type,
+		db.InProject(task.Project), db.AtURL(task.URL), db.IncludeExpired(), db.Page(1, 0))
+	if err != nil || len(results) == 0 {
+		return scraper.Validators{}, err
+	}
+	meta := results[0].Metadata
+	// Неудача и другая версия задачи не подтверждают сохраненные данные
+	if meta.TaskFingerprint != task.Fingerprint() || meta.Status == models.StatusError {
+		return scraper.Validators{}, nil
 	}
+	return scraper.Validators{ETag: meta.ETag, LastModified: meta.LastModified}, nil
+}
 
-	// Обрабатываем результаты
-	resultsProcessed := 0
-	for {
-		select {
-		case res, ok := <-pool.Results():
-			if !ok {
-				logger.Info("Results channel closed")
-				return
-			}
-
-			logger.Info("Got result", "data", res)
-
-			// Преобразуем результат к типу *models.ScrapingResult
-			scrapingResult, ok := res.(*models.ScrapingResult)
-			if !ok {
-				logger.Error("Failed to convert result to ScrapingResult", "err")
-				continue
-			}
-
-			id, err := repository.SaveResult(ctx, scrapingResult)
-			if err != nil {
-				logger.Error("Failed to save result to MongoDB", "error", err)
-			} else {
-				logger.Info("Result saved to MongoDB", "id", id)
-			}
-
-			resultsProcessed++
-
-			// Если все задачи обработаны, выходим
-			if resultsProcessed >= len(tasks) {
-				logger.Info("All tasks completed", "count", resultsProcessed)
-				return
-			}
-
-		case <-ctx.Done():
-			logger.Info("Context cancelled, stopping")
-			return
-		}
+// writeJSONLD записывает результаты в файл в формате schema.org/Event JSON-LD
+func writeJSONLD(path string, results []*models.ScrapingResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
 	}
+	defer f.Close()
+
+	return export.WriteJSONLD(f, results)
 }
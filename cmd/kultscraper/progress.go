@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/rx3lixir/kultscraper/hooks"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/rx3lixir/kultscraper/internal/scraper"
+)
+
+// runProgress - счетчики хода одного запуска, обновляются из воркеров пула
+type runProgress struct {
+	mu sync.Mutex
+	p  models.RunProgress
+}
+
+func newRunProgress(runID string, total int) *runProgress {
+	return &runProgress{p: models.RunProgress{RunID: runID, Total: total, StartedAt: time.Now()}}
+}
+
+// update изменяет счетчики и возвращает снимок хода с оценкой оставшегося времени
+func (rp *runProgress) update(change func(p *models.RunProgress)) models.RunProgress {
+	rp.mu.Lock()
+	change(&rp.p)
+	snapshot := rp.p
+	rp.mu.Unlock()
+
+	snapshot.Estimate(time.Now())
+	return snapshot
+}
+
+// snapshot возвращает текущий ход запуска
+func (rp *runProgress) snapshot() models.RunProgress {
+	return rp.update(func(*models.RunProgress) {})
+}
+
+// trackProgress подписывает runner на начало и завершение задач и сообщает
+// подписчикам OnProgress об изменении хода запуска
+func (r *runner) trackProgress() {
+	notify := func(ctx context.Context, runID string, change func(p *models.RunProgress)) {
+		run := r.active(runID)
+		if run == nil {
+			return
+		}
+		r.lifecycle.ProgressUpdated(ctx, hooks.ProgressEvent{RunID: runID, Progress: run.progress.update(change)})
+	}
+
+	r.lifecycle.OnTaskStart(func(ctx context.Context, e hooks.TaskEvent) {
+		notify(ctx, e.RunID, func(p *models.RunProgress) {
+			p.Queued--
+			p.Running++
+		})
+	})
+	r.lifecycle.OnTaskFinish(func(ctx context.Context, e hooks.TaskEvent) {
+		notify(ctx, e.RunID, func(p *models.RunProgress) {
+			p.Running--
+			switch {
+			case e.Retrying:
+				p.Queued++
+			case errors.Is(e.Err, scraper.ErrNotModified):
+				p.Skipped++
+			case e.Err != nil:
+				p.Failed++
+			default:
+				p.Done++
+			}
+		})
+	})
+}
+
+// progress возвращает ход выполняющегося запуска
+func (r *runner) progress(runID string) (models.RunProgress, bool) {
+	run := r.active(runID)
+	if run == nil {
+		return models.RunProgress{}, false
+	}
+	return run.progress.snapshot(), true
+}
+
+// logProgress пишет ход запуска в лог каждые interval до отмены ctx
+func logProgress(ctx context.Context, logger *log.Logger, progress *runProgress, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p := progress.snapshot()
+			logger.Info("Run progress",
+				"done", p.Done,
+				"failed", p.Failed,
+				"skipped", p.Skipped,
+				"running", p.Running,
+				"queued", p.Queued,
+				"total", p.Total,
+				"percent", int(p.Percent()),
+				"elapsed", p.Elapsed.Round(time.Second),
+				"eta", p.ETA.Round(time.Second))
+		}
+	}
+}
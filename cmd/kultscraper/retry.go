@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	"github.com/charmbracelet/log"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/db"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// Источники задач retry-failed
+const (
+	retryDeadLetters = "dead-letters" // Очередь задач, не выполненных после всех попыток
+	retryErrors      = "errors"       // Результаты со статусом error
+	retryAll         = "all"
+)
+
+func validRetrySource(source string) bool {
+	return slices.Contains([]string{retryDeadLetters, retryErrors, retryAll}, source)
+}
+
+// failedTasks отбирает задачи для retry-failed. Задачи результатов со статусом error ищутся
+// в файле задач по отпечатку и возвращаются в failed. Ожидающие записи очереди недоставленных
+// задач возвращаются в очередь, runner добавит их в запуск сам; requeued - их число.
+// Фильтр задач (--task, --url-match) применяется к обоим источникам
+func failedTasks(ctx context.Context, cfg *config.AppConfig, storage *db.Storage, tasks []config.ScraperTask, source string, logger *log.Logger) (failed []config.ScraperTask, requeued int, err error) {
+	if source == retryErrors || source == retryAll {
+		results, err := storage.Results.GetAllResults(ctx, db.WithStatus(models.StatusError), db.IncludeExpired())
+		if err != nil {
+			return nil, 0, err
+		}
+
+		byFingerprint := make(map[string]config.ScraperTask, len(tasks))
+		for _, task := range tasks {
+			byFingerprint[task.Fingerprint()] = task
+		}
+		// Задача выполняется один раз, даже если ей соответствует несколько результатов
+		seen := make(map[string]bool)
+		for _, res := range results {
+			task, ok := byFingerprint[res.Metadata.TaskFingerprint]
+			if !ok {
+				logger.Warn("Failed task is no longer in the tasks file", "url", res.URL, "type", res.Type)
+				continue
+			}
+			if seen[res.Metadata.TaskFingerprint] {
+				continue
+			}
+			seen[res.Metadata.TaskFingerprint] = true
+			failed = append(failed, task)
+		}
+	}
+
+	if source == retryDeadLetters || source == retryAll {
+		letters, err := storage.DeadLetters.GetDeadLetters(ctx, models.DeadLetterPending, 0)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, letter := range letters {
+			var task config.ScraperTask
+			if err := json.Unmarshal(letter.Task, &task); err != nil {
+				logger.Warn("Skipping invalid dead letter", "id", letter.ID.Hex(), "url", letter.URL, "error", err)
+				continue
+			}
+			if !cfg.TaskFilter.Empty() && !cfg.TaskFilter.Match(task) {
+				continue
+			}
+			if err := storage.DeadLetters.Requeue(ctx, letter.ID.Hex()); err != nil {
+				return nil, 0, err
+			}
+			requeued++
+		}
+	}
+
+	return failed, requeued, nil
+}
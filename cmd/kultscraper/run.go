@@ -0,0 +1,640 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/rx3lixir/kultscraper/hooks"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/db"
+	"github.com/rx3lixir/kultscraper/internal/discover"
+	"github.com/rx3lixir/kultscraper/internal/enrich"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+	applog "github.com/rx3lixir/kultscraper/internal/lib/logger"
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+	"github.com/rx3lixir/kultscraper/internal/lib/work"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/rx3lixir/kultscraper/internal/robots"
+	"github.com/rx3lixir/kultscraper/internal/scraper"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// runner выполняет запуски скраппинга на общем пуле. Запуски по расписанию
+// могут идти одновременно, поэтому результаты и ошибки распределяются по run_id
+type runner struct {
+	cfg           *config.AppConfig
+	logger        *log.Logger
+	scraperLogger *log.Logger
+	pool          *work.Pool[*models.ScrapingResult]
+	scraper       scraper.Scraper
+	repository    db.ScraperRepository
+	auditRepo     db.AuditRepository
+	runRepo       db.RunRepository
+	deadLetters   db.DeadLetterRepository // Задачи, не выполненные после всех попыток, nil - не сохраняются
+	eventRepo     db.EventRepository      // События задач с Event, nil - не сохраняются
+	enrichers     enrich.Chain
+	lifecycle     *hooks.Registry
+	limiter       *scraper.SourceLimiter
+	images        scraper.ImageDownloader // Скачивание изображений, nil - выключено
+	sitemaps      *discover.Sitemaps
+	crawler       *discover.Crawler
+	robots        *robots.Checker // Проверка robots.txt, nil - выключена
+	retry         work.RetryPolicy
+
+	mu         sync.Mutex
+	runs       map[string]*activeRun
+	preparing  map[string]struct{} // Запуски, начатые через start, задачи которых еще обнаруживаются
+	projects   map[string][]string // Проекты задач выполняющихся запусков
+	background sync.WaitGroup      // Запуски, начатые через start
+}
+
+// activeRun - каналы результатов и неудавшихся задач одного запуска и его ход
+type activeRun struct {
+	results  chan *models.ScrapingResult
+	failures chan hooks.TaskEvent
+	progress *runProgress
+
+	mu       sync.Mutex
+	attempts map[string][]models.AttemptError // Ошибки попыток по ExecID задачи
+}
+
+// addAttempt запоминает ошибку попытки задачи для очереди недоставленных задач
+func (run *activeRun) addAttempt(e hooks.TaskEvent) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	run.attempts[e.ExecID] = append(run.attempts[e.ExecID], models.AttemptError{
+		Attempt: e.Attempt,
+		Error:   e.Err.Error(),
+		Code:    string(errs.CodeOf(e.Err)),
+		At:      e.Start.Add(e.Duration),
+	})
+}
+
+// takeAttempts возвращает ошибки попыток задачи и забывает их
+func (run *activeRun) takeAttempts(execID string) []models.AttemptError {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	attempts := run.attempts[execID]
+	delete(run.attempts, execID)
+	return attempts
+}
+
+// runOptions - источник и расписание запуска
+type runOptions struct {
+	Trigger     string    // models.TriggerCLI, models.TriggerSchedule или models.TriggerAPI
+	TriggeredBy string    // Инициатор запуска для аудита, по умолчанию пользователь ОС
+	Schedule    string    // Cron-выражение для запусков по расписанию
+	PlannedAt   time.Time // Плановое время запуска по расписанию
+	// Active сообщает, осталась ли задача в конфигурации. Задачи, удаленные до начала
+	// попытки, пропускаются; nil - все задачи запуска актуальны
+	Active func(config.ScraperTask) bool
+}
+
+// newRunner создает runner и подписывает его на неудавшиеся задачи
+func newRunner(r *runner) *runner {
+	r.runs = make(map[string]*activeRun)
+	r.preparing = make(map[string]struct{})
+	r.projects = make(map[string][]string)
+	if r.sitemaps == nil {
+		r.sitemaps = discover.NewSitemaps()
+	}
+	if r.crawler == nil {
+		// Движок без сбора ссылок оставляет Links пустым, задачи с Crawl завершаются ErrCrawlUnsupported
+		links, _ := r.scraper.(discover.LinkSource)
+		r.crawler = &discover.Crawler{Links: links, Robots: r.robots}
+	}
+
+	// Неудавшиеся задачи не попадают в канал результатов, поэтому получаем их через хук
+	r.lifecycle.OnTaskFinish(func(ctx context.Context, e hooks.TaskEvent) {
+		if e.Err == nil {
+			return
+		}
+		run := r.active(e.RunID)
+		if run == nil {
+			return
+		}
+		run.addAttempt(e)
+		if !e.Retrying {
+			run.failures <- e
+		}
+	})
+	r.trackProgress()
+	return r
+}
+
+// dispatch передает результаты пула запускам, к которым они относятся
+func (r *runner) dispatch() {
+	for res := range r.pool.Results() {
+		run := r.active(res.Metadata.RunID)
+		if run == nil {
+			r.logger.Warn("Dropping result of finished run", "run_id", res.Metadata.RunID, "url", res.URL)
+			continue
+		}
+		run.results <- res
+	}
+}
+
+func (r *runner) active(runID string) *activeRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.runs[runID]
+}
+
+// running сообщает, выполняется ли запуск
+func (r *runner) running(runID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, preparing := r.preparing[runID]
+	return preparing || r.runs[runID] != nil
+}
+
+// register создает каналы запуска с запасом на все задачи, чтобы dispatch и хуки не блокировались
+func (r *runner) register(runID string, tasks []config.ScraperTask) *activeRun {
+	run := &activeRun{
+		results:  make(chan *models.ScrapingResult, len(tasks)),
+		failures: make(chan hooks.TaskEvent, len(tasks)),
+		progress: newRunProgress(runID, len(tasks)),
+		attempts: make(map[string][]models.AttemptError),
+	}
+
+	r.mu.Lock()
+	delete(r.preparing, runID)
+	r.runs[runID] = run
+	r.projects[runID] = taskProjects(tasks)
+	r.mu.Unlock()
+	return run
+}
+
+// runProjects возвращает проекты задач выполняющегося запуска
+func (r *runner) runProjects(runID string) ([]string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	projects, ok := r.projects[runID]
+	return projects, ok
+}
+
+// taskProjects возвращает проекты задач без повторов
+func taskProjects(tasks []config.ScraperTask) []string {
+	projects := make([]string, 0, 1)
+	for _, task := range tasks {
+		if !slices.Contains(projects, task.Project) {
+			projects = append(projects, task.Project)
+		}
+	}
+	return projects
+}
+
+// start выполняет задачи в фоне и сразу возвращает идентификатор запуска
+func (r *runner) start(ctx context.Context, tasks []config.ScraperTask, opts runOptions) string {
+	runID := primitive.NewObjectID().Hex()
+	r.mu.Lock()
+	r.preparing[runID] = struct{}{}
+	r.projects[runID] = taskProjects(tasks)
+	r.mu.Unlock()
+
+	r.background.Add(1)
+	go func() {
+		defer r.background.Done()
+		r.execute(ctx, runID, tasks, opts)
+	}()
+	return runID
+}
+
+// wait ожидает завершения запусков, начатых через start
+func (r *runner) wait() {
+	r.background.Wait()
+}
+
+// run выполняет задачи одним запуском: ставит их в пул, сохраняет результаты
+// и записывает аудит. Возвращает после обработки всех задач или отмены контекста
+func (r *runner) run(ctx context.Context, tasks []config.ScraperTask, opts runOptions) {
+	r.execute(ctx, primitive.NewObjectID().Hex(), tasks, opts)
+}
+
+// discoveryFailure - задача обнаружения страниц, которую не удалось развернуть
+type discoveryFailure struct {
+	task config.ScraperTask
+	err  error
+}
+
+// discover разворачивает задачи с Sitemap и Crawl в задачи найденных страниц.
+// origins - исходная задача по отпечатку задачи страницы
+func (r *runner) discover(ctx context.Context, logger *log.Logger, tasks []config.ScraperTask) (expanded []config.ScraperTask, origins map[string]config.ScraperTask, failed []discoveryFailure) {
+	origins = make(map[string]config.ScraperTask)
+	for _, task := range tasks {
+		var (
+			pages []config.ScraperTask
+			err   error
+			mode  string
+		)
+		switch {
+		case task.Sitemap != nil:
+			mode = "sitemap"
+			pages, err = r.sitemaps.Tasks(ctx, task)
+		case task.Crawl != nil:
+			mode = "crawl"
+			pages, err = r.crawler.Tasks(ctx, task)
+		default:
+			expanded = append(expanded, task)
+			continue
+		}
+
+		if err != nil {
+			logger.Error("Page discovery failed", "mode", mode, "url", task.URL, "error", err)
+			failed = append(failed, discoveryFailure{task: task, err: err})
+			continue
+		}
+		logger.Info("Discovered pages", "mode", mode, "url", task.URL, "pages", len(pages))
+		for _, page := range pages {
+			origins[page.Fingerprint()] = task
+		}
+		expanded = append(expanded, pages...)
+	}
+	return expanded, origins, failed
+}
+
+func (r *runner) execute(ctx context.Context, runID string, tasks []config.ScraperTask, opts runOptions) {
+	logger := r.logger.With("run_id", runID)
+
+	tasks = r.takeRequeued(ctx, logger, tasks)
+
+	// Страницы sitemap и обхода известны только после загрузки, до создания каналов запуска
+	tasks, origins, discoveryFailures := r.discover(ctx, logger, tasks)
+	run := r.register(runID, tasks)
+
+	logger.Info("Starting run", "trigger", opts.Trigger, "schedule", opts.Schedule, "tasks", len(tasks))
+
+	// Корневой спан запуска: спаны задач, их попыток в пуле и сохранения становятся дочерними
+	ctx, span := tracing.Start(ctx, "scrape.run",
+		tracing.String("run.id", runID),
+		tracing.String("run.trigger", opts.Trigger),
+		tracing.String("run.schedule", opts.Schedule),
+		tracing.Int("run.tasks", len(tasks)),
+	)
+	defer span.End()
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.runs, runID)
+		delete(r.projects, runID)
+		r.mu.Unlock()
+	}()
+
+	auditTasks := tasks
+	for _, failure := range discoveryFailures {
+		auditTasks = append(auditTasks, failure.task)
+	}
+	audit := newAuditEntry(runID, auditTasks, opts)
+	for _, failure := range discoveryFailures {
+		audit.SetError(failure.task.URL, failure.task.Type, models.OutcomeFailed, failure.err)
+	}
+	run.progress.update(func(p *models.RunProgress) {
+		p.Total += len(discoveryFailures)
+		p.Failed += len(discoveryFailures)
+	})
+	// Пропущенные и не поставленные в очередь задачи завершаются сразу
+	failTask := func() {
+		run.progress.update(func(p *models.RunProgress) { p.Failed++ })
+	}
+
+	// Запуск сохраняется до постановки задач, чтобы запись осталась и при падении процесса
+	scrapeRun := models.NewScrapeRun(audit, opts.Schedule)
+	if _, err := r.runRepo.SaveRun(ctx, scrapeRun); err != nil {
+		logger.Error("Failed to save run", "error", err)
+	}
+
+	defer func() {
+		canceled := ctx.Err() != nil
+		audit.Finish()
+		span.SetAttrs(
+			tracing.Int("run.succeeded", audit.Succeeded),
+			tracing.Int("run.failed", audit.Failed),
+			tracing.Int("run.skipped", audit.Skipped),
+		)
+
+		// Контекст запуска может быть уже отменен, поэтому сохраняем с отдельным таймаутом
+		auditCtx := tracing.ContextWithParent(context.Background(), span.TraceID(), span.SpanID())
+		auditCtx, auditCancel := context.WithTimeout(auditCtx, db.DefaultTimeout)
+		defer auditCancel()
+
+		if _, err := r.auditRepo.SaveAudit(auditCtx, audit); err != nil {
+			logger.Error("Failed to save audit entry", "error", err)
+		} else {
+			logger.Info("Audit entry saved")
+		}
+
+		scrapeRun.Finish(audit, canceled)
+		if _, err := r.runRepo.SaveRun(auditCtx, scrapeRun); err != nil {
+			logger.Error("Failed to save run", "error", err)
+		} else {
+			logger.Info("Run finished", "status", scrapeRun.Status)
+		}
+
+		r.lifecycle.RunCompleted(auditCtx, hooks.RunEvent{RunID: runID, Audit: audit})
+	}()
+
+	// Задачи с сопоставлением событий по URL и типу результата
+	eventTasks := make(map[string]config.ScraperTask)
+
+	// Добавляем задачи в пул
+	queued := 0
+	for _, task := range tasks {
+		if task.Event != nil {
+			eventTasks[task.URL+"\x00"+task.Type] = task
+		}
+		if err := r.robots.Check(ctx, task.URL); err != nil {
+			logger.Warn("Skipping task", "url", task.URL, "reason", err)
+			audit.SetError(task.URL, task.Type, models.OutcomeSkipped, err)
+			failTask()
+			continue
+		}
+
+		if _, ok := work.ParsePriority(task.Priority); !ok {
+			logger.Warn("Unknown task priority, using normal", "url", task.URL, "priority", task.Priority)
+		}
+
+		// Таймаут отсчитывается для каждой попытки в Execute, а не с момента постановки в очередь,
+		// иначе задачи, ожидающие в очереди или повтора, истекали бы до запуска
+		scraperTask := scraper.NewTaskToScrape(task, ctx, r.scraper, *r.scraperLogger)
+		scraperTask.RunID = runID
+		scraperTask.SlowThreshold = r.cfg.SlowTasks.For(task.Type)
+		scraperTask.Hooks = r.lifecycle
+		scraperTask.Retry = r.retry
+		scraperTask.Timeout = task.Timeouts(r.cfg.Timeouts).Scrape
+		scraperTask.Limiter = r.limiter
+		scraperTask.Images = r.images
+		scraperTask.Limits = r.cfg.ResultLimits
+		scraperTask.Schedule = opts.Schedule
+		scraperTask.PlannedAt = opts.PlannedAt
+		if opts.Active != nil {
+			// Страница sitemap актуальна, пока в конфигурации есть задача sitemap
+			source := task
+			if origin, ok := origins[task.Fingerprint()]; ok {
+				source = origin
+			}
+			scraperTask.Active = func() bool { return opts.Active(source) }
+		}
+
+		// Задача может начаться до возврата AddTask, поэтому в очередь она записывается заранее
+		run.progress.update(func(p *models.RunProgress) { p.Queued++ })
+		if err := r.pool.AddTask(scraperTask); err != nil {
+			logger.Error("Failed to add task", "url", task.URL, "error", err)
+			audit.SetError(task.URL, task.Type, models.OutcomeFailed, err)
+			run.progress.update(func(p *models.RunProgress) {
+				p.Queued--
+				p.Failed++
+			})
+			continue
+		}
+		queued++
+	}
+
+	if r.cfg.ProgressInterval > 0 {
+		progressCtx, stopProgress := context.WithCancel(ctx)
+		defer stopProgress()
+		go logProgress(progressCtx, logger, run.progress, r.cfg.ProgressInterval)
+	}
+
+	// Обрабатываем результаты
+	var saved []*models.ScrapingResult
+	resultsProcessed := 0
+
+results:
+	for resultsProcessed < queued {
+		select {
+		case scrapingResult := <-run.results:
+			logger.Info("Got result", "data", scrapingResult)
+
+			// Обогащение и сохранение попадают в трассу задачи как дочерние спаны
+			saveCtx := tracing.ContextWithParent(ctx, scrapingResult.Metadata.TraceID, scrapingResult.Metadata.SpanID)
+			saveCtx = applog.WithExecutionID(saveCtx, scrapingResult.Metadata.ExecutionID)
+
+			enrichCtx, enrichSpan := tracing.Start(saveCtx, "result.enrich")
+			if err := r.enrichers.Enrich(enrichCtx, scrapingResult); err != nil {
+				logger.Warn("Failed to enrich result", "url", scrapingResult.URL, "error", err)
+				enrichSpan.RecordError(err)
+			}
+			enrichSpan.End()
+
+			audit.SetTiming(scrapingResult.URL, scrapingResult.Type, scrapingResult.Metadata.Duration, scrapingResult.Metadata.Slow)
+
+			// По хэшу хранилище узнает результат без изменений и только отмечает время проверки
+			checkedAt := time.Now()
+			scrapingResult.Metadata.ContentHash = scrapingResult.ContentHash()
+			scrapingResult.Metadata.CheckedAt = &checkedAt
+
+			change, err := r.repository.UpsertResult(saveCtx, scrapingResult)
+			if err != nil {
+				err = errs.Wrap(errs.CodeStorage, "save", err)
+				logger.Error("Failed to save result", "error", err,
+					applog.ExecutionIDKey, scrapingResult.Metadata.ExecutionID)
+				audit.SetError(scrapingResult.URL, scrapingResult.Type, models.OutcomeSaveError, err)
+			} else if change.ChangeType == models.ChangeUnchanged {
+				// Подписчики, события и приемники получают только изменившиеся результаты
+				audit.SetOutcome(scrapingResult.URL, scrapingResult.Type, models.OutcomeSuccess, change.ResultID, "")
+				logger.Info("Result unchanged",
+					applog.ExecutionIDKey, scrapingResult.Metadata.ExecutionID,
+					"id", change.ResultID)
+				saved = append(saved, scrapingResult)
+			} else {
+				audit.SetOutcome(scrapingResult.URL, scrapingResult.Type, models.OutcomeSuccess, change.ResultID, "")
+				logger.Info("Result saved",
+					applog.ExecutionIDKey, scrapingResult.Metadata.ExecutionID,
+					"id", change.ResultID,
+					"change", change.ChangeType,
+					"changed_fields", change.ChangedFields)
+				saved = append(saved, scrapingResult)
+				if task, ok := eventTasks[scrapingResult.URL+"\x00"+scrapingResult.Type]; ok {
+					r.saveEvents(saveCtx, logger, task, scrapingResult, change.ResultID)
+				}
+				r.lifecycle.ResultSaved(saveCtx, hooks.ResultEvent{RunID: runID, Result: scrapingResult, Change: change})
+			}
+
+			resultsProcessed++
+
+		case e := <-run.failures:
+			outcome := models.OutcomeFailed
+			switch {
+			case errors.Is(e.Err, scraper.ErrTaskRetired):
+				logger.Info("Skipped task removed from configuration", "url", e.Task.URL, "type", e.Task.Type)
+				outcome = models.OutcomeSkipped
+			case errors.Is(e.Err, scraper.ErrNotModified):
+				logger.Info("Skipped unchanged page", "url", e.Task.URL, "type", e.Task.Type)
+				outcome = models.OutcomeSkipped
+			case errs.Is(e.Err, errs.CodeBudgetExhausted):
+				logger.Warn("Skipped task over request budget", "url", e.Task.URL, "type", e.Task.Type, "reason", e.Err)
+				outcome = models.OutcomeSkipped
+			default:
+				r.saveFailure(ctx, logger, runID, e)
+				r.saveDeadLetter(ctx, logger, runID, e, run.takeAttempts(e.ExecID))
+			}
+			audit.SetError(e.Task.URL, e.Task.Type, outcome, e.Err)
+			resultsProcessed++
+
+		case <-ctx.Done():
+			logger.Info("Context cancelled, stopping")
+			break results
+		}
+	}
+	if resultsProcessed >= queued {
+		logger.Info("All tasks completed", "count", resultsProcessed)
+	}
+
+	// Экспортируем сохраненные результаты запуска в JSON-LD
+	if r.cfg.JSONLDPath != "" {
+		if err := writeJSONLD(r.cfg.JSONLDPath, saved); err != nil {
+			logger.Error("Failed to export JSON-LD", "path", r.cfg.JSONLDPath, "error", err)
+		} else {
+			logger.Info("Exported JSON-LD", "path", r.cfg.JSONLDPath, "count", len(saved))
+		}
+	}
+}
+
+// saveFailure сохраняет неудачу задачи как результат со статусом error, чтобы ее можно
+// было найти в хранилище. Данные последнего успешного сбора при этом не затираются
+func (r *runner) saveFailure(ctx context.Context, logger *log.Logger, runID string, e hooks.TaskEvent) {
+	failed := models.NewFailedResult(e.Task.URL, e.Task.Type, e.Task.Name, e.Err, e.Attempt)
+	failed.Project = e.Task.Project
+	failed.Metadata.TaskFingerprint = e.Task.Fingerprint()
+	failed.Metadata.RunID = runID
+	failed.Metadata.ExecutionID = e.ExecID
+
+	if _, err := r.repository.SaveFailure(applog.WithExecutionID(ctx, e.ExecID), failed); err != nil {
+		logger.Warn("Failed to save task failure", "url", e.Task.URL, "type", e.Task.Type, "error", err,
+			applog.ExecutionIDKey, e.ExecID)
+	}
+}
+
+// saveDeadLetter добавляет задачу, не выполненную после всех попыток, в очередь
+// недоставленных задач. Задачи прерванного запуска в очередь не попадают
+func (r *runner) saveDeadLetter(ctx context.Context, logger *log.Logger, runID string, e hooks.TaskEvent, attempts []models.AttemptError) {
+	if r.deadLetters == nil || errors.Is(e.Err, context.Canceled) {
+		return
+	}
+
+	task, err := json.Marshal(e.Task)
+	if err != nil {
+		logger.Warn("Failed to encode dead-lettered task", "url", e.Task.URL, "error", err)
+		return
+	}
+	letter := &models.DeadLetter{
+		TaskFingerprint: e.Task.Fingerprint(),
+		URL:             e.Task.URL,
+		Type:            e.Task.Type,
+		Name:            e.Task.Name,
+		Project:         e.Task.Project,
+		Task:            task,
+		Error:           e.Err.Error(),
+		ErrorCode:       string(errs.CodeOf(e.Err)),
+		Attempts:        attempts,
+		RunID:           runID,
+		ExecutionID:     e.ExecID,
+		FailedAt:        time.Now(),
+	}
+	id, err := r.deadLetters.SaveDeadLetter(ctx, letter)
+	if err != nil {
+		logger.Warn("Failed to save dead letter", "url", e.Task.URL, "type", e.Task.Type, "error", err)
+		return
+	}
+	logger.Info("Task moved to dead-letter queue", "url", e.Task.URL, "type", e.Task.Type, "id", id, "attempts", len(attempts))
+}
+
+// takeRequeued добавляет к задачам запуска задачи, возвращенные из очереди недоставленных.
+// Задачи, уже входящие в запуск, не дублируются
+func (r *runner) takeRequeued(ctx context.Context, logger *log.Logger, tasks []config.ScraperTask) []config.ScraperTask {
+	if r.deadLetters == nil {
+		return tasks
+	}
+
+	letters, err := r.deadLetters.TakeRequeued(ctx)
+	if err != nil {
+		logger.Error("Failed to load requeued tasks", "error", err)
+	}
+	if len(letters) == 0 {
+		return tasks
+	}
+
+	seen := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		seen[task.Fingerprint()] = true
+	}
+	// Не изменяем срез вызывающего: демон выполняет его по расписанию
+	tasks = slices.Clip(tasks)
+	for _, letter := range letters {
+		var task config.ScraperTask
+		if err := json.Unmarshal(letter.Task, &task); err != nil {
+			logger.Warn("Dropping requeued task", "id", letter.ID.Hex(), "url", letter.URL, "error", err)
+			continue
+		}
+		if seen[task.Fingerprint()] {
+			continue
+		}
+		seen[task.Fingerprint()] = true
+		tasks = append(tasks, task)
+	}
+	logger.Info("Requeued tasks from dead-letter queue", "count", len(letters))
+	return tasks
+}
+
+// saveEvents сохраняет события, собранные из результата задачи. Ошибка не меняет
+// исход задачи: результат уже сохранен, события обновятся при следующем запуске
+func (r *runner) saveEvents(ctx context.Context, logger *log.Logger, task config.ScraperTask, result *models.ScrapingResult, resultID string) {
+	if r.eventRepo == nil {
+		return
+	}
+
+	events := enrich.MapEvents(result, enrich.EventFields(*task.Event), task.DateLayouts, time.Now().In(task.Location()))
+	if len(events) == 0 {
+		logger.Warn("No events mapped from result", "url", result.URL, "type", result.Type)
+		return
+	}
+	for _, event := range events {
+		event.ResultID = resultID
+	}
+
+	inserted, err := r.eventRepo.UpsertEvents(ctx, events)
+	if err != nil {
+		logger.Error("Failed to save events", "url", result.URL, "error", err)
+		return
+	}
+	logger.Info("Events saved", "url", result.URL, "count", len(events), "new", inserted)
+}
+
+// newAuditEntry создает запись аудита запуска
+func newAuditEntry(runID string, tasks []config.ScraperTask, opts runOptions) *models.AuditEntry {
+	triggeredBy := opts.TriggeredBy
+	if triggeredBy == "" {
+		triggeredBy = os.Getenv("USER")
+	}
+	if triggeredBy == "" {
+		triggeredBy = "unknown"
+	}
+	host, _ := os.Hostname()
+
+	audit := &models.AuditEntry{
+		RunID:             runID,
+		Trigger:           opts.Trigger,
+		TriggeredBy:       triggeredBy,
+		Host:              host,
+		ConfigFingerprint: config.TasksFingerprint(tasks),
+		StartedAt:         time.Now(),
+	}
+
+	for _, task := range tasks {
+		audit.Tasks = append(audit.Tasks, models.AuditTask{
+			Name:    task.Name,
+			URL:     task.URL,
+			Project: task.Project,
+			Type:    task.Type,
+			Outcome: models.OutcomePending,
+		})
+	}
+
+	return audit
+}
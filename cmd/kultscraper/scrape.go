@@ -0,0 +1,781 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/go-rod/rod"
+	"github.com/rx3lixir/kultscraper/hooks"
+	"github.com/rx3lixir/kultscraper/internal/api"
+	"github.com/rx3lixir/kultscraper/internal/browser"
+	"github.com/rx3lixir/kultscraper/internal/bus"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/db"
+	"github.com/rx3lixir/kultscraper/internal/discover"
+	"github.com/rx3lixir/kultscraper/internal/enrich"
+	"github.com/rx3lixir/kultscraper/internal/lib/auth"
+	applog "github.com/rx3lixir/kultscraper/internal/lib/logger"
+	"github.com/rx3lixir/kultscraper/internal/lib/metrics"
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+	"github.com/rx3lixir/kultscraper/internal/lib/work"
+	"github.com/rx3lixir/kultscraper/internal/media"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/rx3lixir/kultscraper/internal/notify"
+	pluginload "github.com/rx3lixir/kultscraper/internal/plugin"
+	"github.com/rx3lixir/kultscraper/internal/proxy"
+	"github.com/rx3lixir/kultscraper/internal/robots"
+	"github.com/rx3lixir/kultscraper/internal/rpc"
+	"github.com/rx3lixir/kultscraper/internal/scheduler"
+	"github.com/rx3lixir/kultscraper/internal/scraper"
+	"github.com/rx3lixir/kultscraper/internal/stream"
+	"github.com/rx3lixir/kultscraper/plugin"
+	"github.com/spf13/cobra"
+)
+
+// Режимы runScrape, совпадают с именами команд
+const (
+	modeRun         = "run"
+	modeServe       = "serve"
+	modeRetryFailed = "retry-failed"
+)
+
+// scrapeFlags - флаги команд run, serve и retry-failed
+type scrapeFlags struct {
+	*configFlags
+	mode        string
+	daemon      bool
+	apiAddr     string
+	grpcAddr    string
+	retrySource string
+	harDir      string
+}
+
+// newScrapeCmd создает команду run, serve или retry-failed
+func newScrapeCmd(mode, short string) *cobra.Command {
+	f := &scrapeFlags{mode: mode}
+	cmd := &cobra.Command{Use: mode, Short: short, Args: cobra.NoArgs}
+	fs := cmd.Flags()
+	f.configFlags = addConfigFlags(fs)
+	f.addStorage(fs)
+	f.addTaskFilter(fs)
+	fs.StringVar(&f.harDir, "har", "", "record network traffic of tasks with HAR into this directory, overrides HAR_DIR")
+	switch mode {
+	case modeServe:
+		fs.StringVar(&f.apiAddr, "addr", "", "REST API listen address, overrides API_ADDR")
+		fs.StringVar(&f.grpcAddr, "grpc-addr", "", "gRPC listen address, overrides GRPC_ADDR")
+	case modeRetryFailed:
+		fs.StringVar(&f.retrySource, "source", retryAll, "failed tasks to retry: dead-letters, errors or all")
+	default:
+		fs.BoolVar(&f.daemon, "daemon", false, "keep running: re-run tasks every DAEMON_INTERVAL and reload the tasks file on changes")
+	}
+	cmd.RunE = runCode(func() int { return runScrape(f) })
+	return cmd
+}
+
+// runScrape выполняет задачи из файла задач. В режиме демона (--daemon) и в serve
+// работает до сигнала завершения, serve дополнительно поднимает REST и gRPC API.
+// retry-failed выполняет одним запуском только задачи, не выполненные ранее.
+// Возвращает код выхода: ненулевой, если задачи не удалось запустить
+func runScrape(f *scrapeFlags) int {
+	if f.mode == modeRetryFailed && !validRetrySource(f.retrySource) {
+		applog.InitLogger("", "").Error("Unknown retry source", "source", f.retrySource)
+		return 2
+	}
+
+	// Загружаем конфигурацию
+	cfg, err := f.load()
+	if err != nil {
+		applog.InitLogger("", "").Error("Failed to load configuration", "error", err)
+		return 1
+	}
+	if f.apiAddr != "" {
+		cfg.APIAddr = f.apiAddr
+	}
+	if f.grpcAddr != "" {
+		cfg.GRPCAddr = f.grpcAddr
+	}
+	if f.harDir != "" {
+		cfg.HAR.Dir = f.harDir
+	}
+
+	logger := applog.InitLogger(cfg.Log.Level, cfg.Log.Format)
+	logger.Info("Starting Scrapper")
+
+	app := &scrapeApp{
+		mode:        f.mode,
+		keepRunning: f.daemon || f.mode == modeServe,
+		cfg:         cfg,
+		logger:      logger,
+	}
+	defer app.close()
+
+	if err := app.setup(); err != nil {
+		logger.Error("Failed to start", "error", err)
+		return 1
+	}
+
+	plan, err := app.plan(f.retrySource)
+	if err != nil {
+		logger.Error("Failed to load tasks", "error", err)
+		return 1
+	}
+	if plan == nil {
+		logger.Info("No failed tasks to retry")
+		return 0
+	}
+
+	if err := app.persist(); err != nil {
+		logger.Error("Failed to set up result processing", "error", err)
+		return 1
+	}
+
+	if err := app.scrape(plan); err != nil {
+		logger.Error("Failed to run tasks", "error", err)
+		return 1
+	}
+	return 0
+}
+
+// scrapeApp - компоненты команд run, serve и retry-failed. Создаются по шагам:
+// setup, persist и scrape, а закрываются в close в обратном порядке
+type scrapeApp struct {
+	mode        string
+	keepRunning bool // Демон и serve работают до сигнала завершения
+	cfg         *config.AppConfig
+	logger      *log.Logger
+
+	// ctx отменяется при завершении работы и прерывает идущие запуски,
+	// stopCtx - по первому сигналу и останавливает планирование новых
+	ctx     context.Context
+	stopCtx context.Context
+
+	storage   *db.Storage
+	enrichers enrich.Chain
+	lifecycle *hooks.Registry
+	broker    *stream.Broker          // Поток событий, nil - выключен
+	images    scraper.ImageDownloader // Скачивание изображений, nil - выключено
+
+	closers []func()
+}
+
+// taskPlan - задачи запуска: без расписания и сгруппированные по cron-выражению
+type taskPlan struct {
+	all       []config.ScraperTask
+	once      []config.ScraperTask
+	scheduled map[string][]config.ScraperTask
+	schedules []string
+}
+
+// onClose добавляет действие, которое close выполнит раньше добавленных до него
+func (a *scrapeApp) onClose(fn func()) {
+	a.closers = append(a.closers, fn)
+}
+
+// close освобождает компоненты в обратном порядке создания
+func (a *scrapeApp) close() {
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		a.closers[i]()
+	}
+}
+
+// setup настраивает трассировку, метрики и плагины, обработку сигналов и хранилище
+func (a *scrapeApp) setup() error {
+	cfg, logger := a.cfg, a.logger
+
+	// Настраиваем экспорт трассировок
+	if cfg.Tracing.Endpoint != "" {
+		exporter, err := tracing.NewOTLPExporter(context.Background(), cfg.Tracing.Endpoint, tracing.ParseHeaders(cfg.Tracing.Headers))
+		if err != nil {
+			return fmt.Errorf("create trace exporter: %w", err)
+		}
+		tracer := tracing.NewTracer(exporter, tracing.Options{
+			ServiceName: cfg.Tracing.ServiceName,
+			SampleRatio: cfg.Tracing.SampleRatio,
+		})
+		tracing.SetTracer(tracer)
+		a.onClose(func() {
+			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), gracefulShutdown)
+			defer shutdownCancel()
+			if err := tracer.Shutdown(shutdownCtx); err != nil {
+				logger.Error("Failed to shutdown tracer", "error", err)
+			}
+		})
+		logger.Info("Tracing enabled", "endpoint", cfg.Tracing.Endpoint, "sample_ratio", cfg.Tracing.SampleRatio)
+	}
+
+	// Запускаем эндпоинт метрик Prometheus
+	if cfg.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.DefaultRegistry.Handler())
+		go func() {
+			if err := http.ListenAndServe(cfg.MetricsAddr, mux); err != nil {
+				logger.Error("Metrics server stopped", "error", err)
+			}
+		}()
+		logger.Info("Metrics endpoint enabled", "addr", cfg.MetricsAddr)
+	}
+
+	// Загружаем плагины до создания компонентов, чтобы они успели зарегистрироваться
+	if len(cfg.Plugins.Paths) > 0 {
+		if err := pluginload.Load(cfg.Plugins.Paths); err != nil {
+			return fmt.Errorf("load plugins: %w", err)
+		}
+		logger.Info("Plugins loaded", "registered", plugin.Names())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopCtx, stop := context.WithCancel(ctx)
+	a.ctx, a.stopCtx = ctx, stopCtx
+	a.onClose(cancel)
+	a.onClose(stop)
+
+	// Обработка сигналов завершения. В режиме демона идущим запускам дается
+	// gracefulShutdown на завершение, повторный сигнал прерывает их сразу
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signalCh
+		logger.Info("Received signal", "signal", sig)
+		stop()
+		if !a.keepRunning {
+			cancel()
+			return
+		}
+
+		select {
+		case sig := <-signalCh:
+			logger.Info("Received second signal, cancelling running scrapes", "signal", sig)
+		case <-time.After(gracefulShutdown):
+			logger.Warn("Graceful shutdown timed out, cancelling running scrapes")
+		case <-ctx.Done():
+			return
+		}
+		cancel()
+	}()
+
+	// Инициализация хранилища (STORAGE_BACKEND)
+	storage, err := db.NewStorage(ctx, cfg, applog.NewAdapter(applog.ForModule(logger, cfg.Log.Modules, applog.ModuleDB)))
+	if err != nil {
+		return fmt.Errorf("initialize %s storage: %w", cfg.StorageBackend, err)
+	}
+	a.storage = storage
+	logger.Info("Storage initialized", "backend", cfg.StorageBackend)
+
+	// Гарантируем закрытие соединения с хранилищем
+	a.onClose(func() {
+		if err := storage.Close(); err != nil {
+			logger.Error("Failed to close storage", "error", err)
+		} else {
+			logger.Info("Storage closed successfully")
+		}
+	})
+	return nil
+}
+
+// plan загружает задачи и группирует их по расписанию. Для retry-failed запуск
+// состоит только из неудавшихся задач, nil означает, что повторять нечего
+func (a *scrapeApp) plan(retrySource string) (*taskPlan, error) {
+	serveMode, retryMode := a.mode == modeServe, a.mode == modeRetryFailed
+
+	// Для serve файл задач может появиться позже, через API,
+	// retry-failed может повторить задачи очереди недоставленных и без файла
+	tasks, err := loadTasks(a.ctx, a.cfg, a.logger)
+	if (serveMode || retryMode) && errors.Is(err, os.ErrNotExist) ||
+		retryMode && errors.Is(err, config.ErrNoMatchingTasks) {
+		tasks, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.logger.Info("Loaded tasks", "count", len(tasks))
+
+	// Задачи с одинаковым расписанием выполняются одним запуском
+	once, scheduled, schedules, err := groupTasks(tasks, "")
+	if err != nil {
+		return nil, err
+	}
+	plan := &taskPlan{all: tasks, once: once, scheduled: scheduled, schedules: schedules}
+
+	if retryMode {
+		failed, requeued, err := failedTasks(a.ctx, a.cfg, a.storage, tasks, retrySource, a.logger)
+		if err != nil {
+			return nil, fmt.Errorf("load failed tasks: %w", err)
+		}
+		if len(failed)+requeued == 0 {
+			return nil, nil
+		}
+		a.logger.Info("Retrying failed tasks", "errors", len(failed), "dead_letters", requeued)
+		plan.once, plan.scheduled, plan.schedules = failed, nil, nil
+	}
+	return plan, nil
+}
+
+// persist настраивает обработку результатов: обогащение, историю изменений,
+// поток событий, приемники из плагинов, шину сообщений, вебхуки и хранилище изображений
+func (a *scrapeApp) persist() error {
+	cfg, logger, storage := a.cfg, a.logger, a.storage
+
+	enrichers, err := newEnrichers(cfg, logger)
+	if err != nil {
+		return err
+	}
+	a.enrichers = enrichers
+
+	// Хуки жизненного цикла запуска
+	lifecycle := hooks.New()
+	a.lifecycle = lifecycle
+	lifecycle.OnRunComplete(func(ctx context.Context, e hooks.RunEvent) {
+		logger.Info("Run summary",
+			"run_id", e.RunID,
+			"succeeded", e.Audit.Succeeded,
+			"failed", e.Audit.Failed,
+			"skipped", e.Audit.Skipped,
+			"slow_tasks", e.Audit.SlowTasks,
+			"errors_by_code", e.Audit.ErrorsByCode)
+	})
+
+	// История изменений: старые и новые значения полей обновленных результатов
+	lifecycle.OnResultSaved(func(ctx context.Context, e hooks.ResultEvent) {
+		if e.Change == nil || e.Change.ChangeType != models.ChangeUpdated {
+			return
+		}
+		for _, d := range e.Change.Diffs {
+			logger.Info("Result field changed",
+				"id", e.Change.ResultID, "url", e.Change.URL, "field", d.Field, "old", d.Old, "new", d.New)
+		}
+		if _, err := storage.History.SaveHistory(ctx, models.NewHistoryEntry(e.RunID, e.Change)); err != nil {
+			logger.Warn("Failed to save result history", "id", e.Change.ResultID, "error", err)
+		}
+	})
+
+	// Поток событий запуска для дашборда и внешних потребителей
+	if cfg.StreamAddr != "" || (a.mode == modeServe && cfg.GRPCAddr != "") {
+		a.broker = stream.NewBroker()
+		stream.Attach(a.broker, lifecycle)
+	}
+	if cfg.StreamAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/ws", stream.WebSocketHandler(a.broker))
+		mux.Handle("/events/stream", stream.SSEHandler(a.broker))
+		var handler http.Handler = mux
+		if cfg.APIKeys.Enabled() {
+			// Поток требует тех же ключей, что и API, ключ проекта получает только события проекта
+			handler = auth.Require(cfg.APIKeys, mux)
+		}
+		go func() {
+			if err := http.ListenAndServe(cfg.StreamAddr, handler); err != nil {
+				logger.Error("Stream server stopped", "error", err)
+			}
+		}()
+		logger.Info("Event stream enabled", "addr", cfg.StreamAddr)
+	}
+
+	// Приемники результатов из плагинов
+	sinks, err := plugin.NewSinks(cfg.Plugins.Sinks, cfg)
+	if err != nil {
+		return fmt.Errorf("create plugin sinks: %w", err)
+	}
+	for _, sink := range sinks {
+		a.onClose(func() { sink.Close() })
+		lifecycle.OnResultSaved(func(ctx context.Context, e hooks.ResultEvent) {
+			if err := sink.Write(ctx, e.Result, e.Change); err != nil {
+				logger.Warn("Plugin sink failed", "url", e.Result.URL, "error", err)
+			}
+		})
+	}
+
+	// Публикация сохраненных результатов в Kafka или NATS
+	if cfg.Bus.Driver != "" {
+		publisher, err := bus.New(cfg.Bus)
+		if err != nil {
+			return fmt.Errorf("create message bus publisher: %w", err)
+		}
+		a.onClose(func() { publisher.Close() })
+
+		lifecycle.OnResultSaved(func(ctx context.Context, e hooks.ResultEvent) {
+			msg, err := bus.ResultMessage(e)
+			if err == nil {
+				err = publisher.Publish(ctx, msg)
+			}
+			if err != nil {
+				logger.Warn("Failed to publish result", "url", e.Result.URL, "driver", cfg.Bus.Driver, "error", err)
+			}
+		})
+		logger.Info("Publishing results to message bus", "driver", cfg.Bus.Driver, "topic", cfg.Bus.Topic)
+	}
+
+	// Уведомления на вебхуки о задачах и изменениях результатов
+	if cfg.Webhooks.Enabled() {
+		notifier := notify.NewWithLogger(cfg.Webhooks, applog.NewAdapter(applog.ForModule(logger, cfg.Log.Modules, applog.ModuleNotify)))
+		notify.Attach(notifier, lifecycle)
+		a.onClose(func() {
+			closeCtx, closeCancel := context.WithTimeout(context.Background(), gracefulShutdown)
+			defer closeCancel()
+			if err := notifier.Close(closeCtx); err != nil {
+				logger.Warn("Pending webhook notifications dropped", "error", err)
+			}
+		})
+		logger.Info("Webhook notifications enabled", "types", len(cfg.Webhooks.Targets))
+	}
+
+	// Изображения ключей с типом image сохраняются в IMAGE_STORE, чтобы не зависеть от ссылок на сайты площадок
+	imageStore, err := media.NewStore(a.ctx, cfg.Images, cfg.MongoDB)
+	if err != nil {
+		return fmt.Errorf("create %s image store: %w", cfg.Images.Backend, err)
+	}
+	if imageStore != nil {
+		if closer, ok := imageStore.(io.Closer); ok {
+			a.onClose(func() { closer.Close() })
+		}
+		a.images = media.NewDownloader(imageStore, cfg.Images.MaxBytes, cfg.Images.Timeout)
+		logger.Info("Image downloads enabled", "store", cfg.Images.Backend)
+	}
+	return nil
+}
+
+// newEnrichers создает цепочку обогащения результатов: перевод, теги по правилам,
+// срок актуальности и обогатители из плагинов
+func newEnrichers(cfg *config.AppConfig, logger *log.Logger) (enrich.Chain, error) {
+	var enrichers enrich.Chain
+	if cfg.Translate.Enabled() {
+		translator, err := enrich.NewTranslator(cfg.Translate.Provider, cfg.Translate.Endpoint, cfg.Translate.APIKey)
+		if err != nil {
+			return nil, fmt.Errorf("create translator: %w", err)
+		}
+		enrichers = append(enrichers, enrich.NewTranslationEnricher(
+			translator,
+			cfg.Translate.Fields,
+			cfg.Translate.SourceLang,
+			cfg.Translate.TargetLang,
+		))
+		logger.Info("Translation enabled", "provider", cfg.Translate.Provider, "fields", cfg.Translate.Fields)
+	}
+
+	if cfg.TagRules != "" {
+		rules, err := enrich.LoadTagRules(cfg.TagRules)
+		if err != nil {
+			return nil, fmt.Errorf("load tag rules %s: %w", cfg.TagRules, err)
+		}
+		tagger, err := enrich.NewTagEnricher(rules)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag rules: %w", err)
+		}
+		enrichers = append(enrichers, tagger)
+		logger.Info("Tag rules loaded", "count", len(rules))
+	}
+
+	if len(cfg.Expiry.Fields) > 0 {
+		loc := time.Local
+		if cfg.Expiry.Location != "" {
+			var err error
+			if loc, err = time.LoadLocation(cfg.Expiry.Location); err != nil {
+				return nil, fmt.Errorf("invalid expiry timezone %q: %w", cfg.Expiry.Location, err)
+			}
+		}
+		enrichers = append(enrichers, enrich.NewExpiryEnricher(cfg.Expiry.Fields, loc))
+	}
+
+	if len(cfg.Plugins.Enrichers) > 0 {
+		pluginEnrichers, err := plugin.NewEnrichers(cfg.Plugins.Enrichers, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("create plugin enrichers: %w", err)
+		}
+		enrichers = append(enrichers, pluginEnrichers...)
+	}
+	return enrichers, nil
+}
+
+// scrape запускает браузеры и пул работников и выполняет задачи плана: в режиме демона
+// и serve до сигнала завершения, иначе одним запуском и по расписанию задач
+func (a *scrapeApp) scrape(plan *taskPlan) error {
+	cfg, logger, ctx, stopCtx := a.cfg, a.logger, a.ctx, a.stopCtx
+	scraperLogger := applog.ForModule(logger, cfg.Log.Modules, applog.ModuleScraper)
+
+	// Дневные лимиты запросов к сайтам партнеров: учитывается каждый запрос движков и загрузка sitemap
+	budget := newRequestBudget(cfg, a.storage.Budget, logger)
+
+	taskScraper, err := a.newScraper(scraperLogger, budget)
+	if err != nil {
+		return err
+	}
+
+	// Создаем пул работников
+	// В режиме демона задачи могут добавиться после старта, поэтому очередь не меньше числа воркеров
+	poolLogger := applog.NewAdapter(applog.ForModule(logger, cfg.Log.Modules, applog.ModulePool))
+	pool, err := work.NewTypedPoolWithLogger[*models.ScrapingResult](numWorkers, max(len(plan.all), numWorkers), poolLogger)
+	if err != nil {
+		return fmt.Errorf("create worker pool: %w", err)
+	}
+
+	// Запускаем пул
+	if err := pool.Start(ctx); err != nil {
+		return fmt.Errorf("start worker pool: %w", err)
+	}
+
+	// Гарантируем остановку пула
+	a.onClose(func() {
+		// Создаем контекст с таймаутом для грейсфул шатдауна
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), gracefulShutdown)
+		defer shutdownCancel() // Гарантированный вызов функции отмены
+
+		// Запускаем горутину для остановки пула
+		done := make(chan struct{})
+		go func() {
+			pool.Stop()
+			close(done)
+		}()
+
+		// Ожидаем либо завершения остановки, либо таймаута
+		select {
+		case <-done:
+			logger.Info("Pool stopped gracefully")
+		case <-shutdownCtx.Done():
+			logger.Warn("Pool shutdown timed out")
+		}
+	})
+
+	// robots.txt соблюдается по ROBOTS_TXT: запрещенные адреса пропускаются, Crawl-delay задает интервал источника
+	limiter := scraper.NewSourceLimiter(cfg.SourceLimit)
+	limiter.CoolDown = cfg.SourceCoolDown
+	var robotsChecker *robots.Checker
+	if cfg.Robots.Enabled {
+		robotsChecker = robots.NewChecker(cfg.Robots.UserAgent, cfg.Robots.CacheTTL)
+		limiter.Delay = robotsChecker.CrawlDelay
+		logger.Info("robots.txt rules enabled", "user_agent", cfg.Robots.UserAgent)
+	}
+
+	sitemaps := discover.NewSitemaps()
+	sitemaps.Budget = budget
+
+	runs := newRunner(&runner{
+		cfg:           cfg,
+		logger:        logger,
+		scraperLogger: scraperLogger,
+		pool:          pool,
+		scraper:       taskScraper,
+		repository:    a.storage.Results,
+		auditRepo:     a.storage.Audit,
+		runRepo:       a.storage.Runs,
+		deadLetters:   a.storage.DeadLetters,
+		eventRepo:     a.storage.Events,
+		enrichers:     a.enrichers,
+		lifecycle:     a.lifecycle,
+		limiter:       limiter,
+		images:        a.images,
+		sitemaps:      sitemaps,
+		robots:        robotsChecker,
+		retry: work.RetryPolicy{
+			MaxAttempts:    cfg.Retry.MaxAttempts,
+			InitialBackoff: cfg.Retry.InitialBackoff,
+			MaxBackoff:     cfg.Retry.MaxBackoff,
+			Jitter:         cfg.Retry.Jitter,
+			Retryable:      scraper.RetryableError,
+		},
+	})
+	go runs.dispatch()
+
+	if a.keepRunning {
+		d := newDaemon(cfg, logger, runs, ctx)
+		if err := d.Load(plan.all); err != nil {
+			return fmt.Errorf("start daemon: %w", err)
+		}
+		if a.mode == modeServe {
+			a.serve(runs, d)
+		}
+
+		d.Run(stopCtx)
+		runs.wait()
+		return nil
+	}
+
+	// Задачи без расписания выполняются сразу одним запуском. Задачи, возвращенные
+	// из очереди недоставленных, runner добавляет в запуск сам
+	if len(plan.once) > 0 || a.mode == modeRetryFailed {
+		runs.run(ctx, plan.once, runOptions{Trigger: models.TriggerCLI})
+	}
+
+	// Задачи с расписанием перезапускаются по cron до сигнала завершения
+	if len(plan.schedules) > 0 {
+		sched := scheduler.NewWithLogger(applog.NewAdapter(applog.ForModule(logger, cfg.Log.Modules, applog.ModuleScheduler)))
+		for _, spec := range plan.schedules {
+			group := plan.scheduled[spec]
+			err := sched.Add(spec, spec, func(ctx context.Context, planned time.Time) {
+				runs.run(ctx, group, runOptions{Trigger: models.TriggerSchedule, TriggeredBy: spec, Schedule: spec, PlannedAt: planned})
+			})
+			if err != nil {
+				// Выражения проверены при загрузке задач
+				logger.Error("Failed to schedule tasks", "schedule", spec, "error", err)
+			}
+		}
+
+		logger.Info("Running scheduled tasks until stopped", "schedules", len(plan.schedules))
+		sched.Run(stopCtx)
+	}
+	return nil
+}
+
+// serve поднимает REST и gRPC API над задачами демона до первого сигнала завершения
+func (a *scrapeApp) serve(runs *runner, d *daemon) {
+	cfg, logger, ctx, stopCtx := a.cfg, a.logger, a.ctx, a.stopCtx
+
+	// Задачи удаленного источника управляются в самом источнике
+	var store api.TaskStore = api.NewSourceTaskStore(func() ([]config.ScraperTask, error) {
+		return loadTasks(ctx, cfg, logger)
+	})
+	if config.IsLocalTaskSource(cfg.ConfigPath) {
+		fileStore := api.NewFileTaskStore(cfg.ConfigPath)
+		fileStore.OnChange = d.reload
+		store = fileStore
+	}
+
+	starter := apiRunner{ctx: ctx, cfg: cfg, runs: runs}
+
+	server := api.NewServerWithLogger(store, a.storage.Results, a.storage.Audit, starter, applog.NewAdapter(logger))
+	server.History = a.storage.History
+	server.Runs = a.storage.Runs
+	server.Keys = cfg.APIKeys
+	server.DefaultProject = cfg.Project
+
+	go serveAPI(stopCtx, cfg.APIAddr, server, logger)
+
+	if cfg.GRPCAddr != "" {
+		grpcService := rpc.NewServerWithLogger(store, a.storage.Results, a.broker, starter, applog.NewAdapter(logger))
+		grpcService.Keys = cfg.APIKeys
+		go serveGRPC(stopCtx, cfg.GRPCAddr, grpcService.GRPCServer(stopCtx), logger)
+	}
+}
+
+// newScraper подключает браузеры и создает скрапер задач: встроенный rod и движки из плагинов
+func (a *scrapeApp) newScraper(scraperLogger *log.Logger, budget scraper.RequestBudget) (scraper.Scraper, error) {
+	cfg, logger, ctx := a.cfg, a.logger, a.ctx
+
+	// Инициализируем браузеры: удаленные по BROWSER_WS_URL, закрепленная ревизия Chromium
+	// или браузер, найденный на хосте. id - номер браузера в наборе BROWSER_INSTANCES
+	var connectBrowser func(id int) (*rod.Browser, error)
+	if cfg.BrowserRemote.URL != "" {
+		remoteConfig := browser.RemoteConfig(cfg.BrowserRemote)
+		connectBrowser = func(int) (*rod.Browser, error) {
+			return browser.Connect(ctx, remoteConfig, applog.NewAdapter(logger))
+		}
+		logger.Info("Using remote browser", "url", browser.RedactRemoteURL(remoteConfig.URL))
+	} else {
+		var bin string
+		binaryConfig := browser.BinaryConfig(cfg.BrowserBinary)
+		if binaryConfig.Managed() {
+			var err error
+			if bin, err = browser.EnsureBinary(ctx, binaryConfig, applog.NewAdapter(logger)); err != nil {
+				return nil, fmt.Errorf("prepare browser binary: %w", err)
+			}
+		}
+		connectBrowser = func(id int) (*rod.Browser, error) {
+			launchConfig := browser.LaunchConfig(cfg.BrowserLaunch)
+			// Chromium блокирует каталог профиля, поэтому у каждого браузера набора свой
+			if launchConfig.UserDataDir != "" && cfg.BrowserInstances > 1 {
+				launchConfig.UserDataDir = filepath.Join(launchConfig.UserDataDir, strconv.Itoa(id))
+			}
+			return browser.Launch(bin, launchConfig)
+		}
+	}
+
+	rodBrowsers := make([]*rod.Browser, cfg.BrowserInstances)
+	for id := range rodBrowsers {
+		b, err := connectBrowser(id)
+		if err != nil {
+			return nil, fmt.Errorf("connect to browser %d: %w", id, err)
+		}
+		rodBrowsers[id] = b
+		a.onClose(func() { b.Close() })
+	}
+	if len(rodBrowsers) > 1 {
+		logger.Info("Browsers started", "instances", len(rodBrowsers))
+	}
+
+	// Создаем скрапер: предел страниц задается на каждый браузер набора
+	rodScraper := scraper.NewRodScraperWithBrowsers(rodBrowsers, *scraperLogger, cfg.Pages.PerBrowser*len(rodBrowsers))
+	a.onClose(func() { rodScraper.Close() })
+	rodScraper.MaxPageUses = cfg.Pages.MaxUses
+	rodScraper.CaptureConsole = cfg.CaptureConsole
+	rodScraper.ArtifactDir = cfg.ArtifactDir
+	rodScraper.DebugDir = cfg.DebugDir
+	rodScraper.Block = cfg.BlockResources
+	rodScraper.Stealth = cfg.Stealth
+	rodScraper.ScreenshotDir = cfg.Screenshots.Dir
+	rodScraper.ScreenshotAll = cfg.Screenshots.All
+	rodScraper.SnapshotDir = cfg.Snapshots.Dir
+	rodScraper.SnapshotAll = cfg.Snapshots.All
+	rodScraper.HARDir = cfg.HAR.Dir
+	rodScraper.HARAll = cfg.HAR.All
+	rodScraper.PDFDir = cfg.PDFs.Dir
+	rodScraper.PDFAll = cfg.PDFs.All
+	rodScraper.Timeouts = cfg.Timeouts
+	rodScraper.Budget = budget
+	if cfg.Plugins.Captcha != "" {
+		solver, err := plugin.NewCaptchaSolver(cfg.Plugins.Captcha, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("create captcha solver %s: %w", cfg.Plugins.Captcha, err)
+		}
+		rodScraper.Captcha = solver
+		logger.Info("Using captcha solver", "solver", cfg.Plugins.Captcha)
+	}
+	proxies, err := proxy.NewRotator(cfg.Proxy.URLs, cfg.Proxy.Rotation)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy configuration: %w", err)
+	}
+	rodScraper.Proxies = proxies
+
+	// Мониторинг ресурсов браузера
+	if cfg.BrowserMonitor.Interval > 0 {
+		monitor := scraper.NewBrowserMonitor(rodScraper, cfg.BrowserMonitor.Interval, scraper.BrowserLimits{
+			MaxJSHeapBytes: cfg.BrowserMonitor.MaxJSHeapMB * 1024 * 1024,
+			MaxTargets:     cfg.BrowserMonitor.MaxPages,
+			MaxCPUPercent:  cfg.BrowserMonitor.MaxCPUPercent,
+		}, cfg.BrowserMonitor.Action)
+		monitor.Connect = connectBrowser
+		go monitor.Run(ctx)
+	}
+	// Переподключение при обрыве соединения с браузером
+	if cfg.BrowserKeepAlive > 0 {
+		keepAlive := &scraper.BrowserKeepAlive{Scraper: rodScraper, Interval: cfg.BrowserKeepAlive, Connect: connectBrowser}
+		go keepAlive.Run(ctx)
+	}
+
+	// Движок из плагина заменяет встроенный для задач без Engine. Задачи с Engine "http"
+	// и "auto" выполняет встроенный движок без браузера, если плагин "http" его не заменяет
+	httpScraper := scraper.NewHTTPScraper(*scraperLogger, proxies)
+	httpScraper.Timeouts = cfg.Timeouts
+	httpScraper.Budget = budget
+	a.onClose(func() { httpScraper.Close() })
+	engines := map[string]scraper.Scraper{scraper.EngineRod: rodScraper, scraper.EngineHTTP: httpScraper}
+	for _, name := range []string{cfg.Plugins.Engine, scraper.EngineHTTP} {
+		if name == "" || name == scraper.EngineRod {
+			continue
+		}
+		if name == scraper.EngineHTTP && !slices.Contains(plugin.Names()["engine"], name) {
+			continue
+		}
+		engine, err := plugin.NewEngine(name, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("create plugin engine %s: %w", name, err)
+		}
+		a.onClose(func() { engine.Close() })
+		engines[name] = engine
+		logger.Info("Using plugin engine", "engine", name)
+	}
+	dispatcher := scraper.NewDispatcher(cfg.Plugins.Engine, engines, *scraperLogger)
+	if cfg.HTTPCache {
+		dispatcher.Cache = scraper.NewHTTPCache(resultValidators{a.storage.Results})
+		logger.Info("HTTP cache enabled for http engine")
+	}
+	return dispatcher, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"google.golang.org/grpc"
+)
+
+// apiRunner запускает задачи по запросу REST API на общем runner
+type apiRunner struct {
+	ctx  context.Context // Контекст процесса: запуск не должен завершаться вместе с HTTP-запросом
+	cfg  *config.AppConfig
+	runs *runner
+}
+
+func (a apiRunner) Start(tasks []config.ScraperTask) (string, error) {
+	// Задачи из хранилища API и gRPC могут быть шаблонами
+	tasks, err := config.ExpandTasks(tasks, time.Now())
+	if err != nil {
+		return "", err
+	}
+	tasks = withDefaultProject(tasks, a.cfg.Project)
+	return a.runs.start(a.ctx, tasks, runOptions{Trigger: models.TriggerAPI, TriggeredBy: "api"}), nil
+}
+
+func (a apiRunner) Running(runID string) bool {
+	return a.runs.running(runID)
+}
+
+func (a apiRunner) Progress(runID string) (models.RunProgress, bool) {
+	return a.runs.progress(runID)
+}
+
+func (a apiRunner) Projects(runID string) ([]string, bool) {
+	return a.runs.runProjects(runID)
+}
+
+// serveAPI обслуживает REST API до отмены ctx, затем дает запросам gracefulShutdown на завершение
+func serveAPI(ctx context.Context, addr string, handler http.Handler, logger *log.Logger) {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	logger.Info("REST API enabled", "addr", addr)
+	serve(ctx, srv, "API", logger)
+}
+
+// serveGRPC обслуживает gRPC-сервис до отмены ctx, затем дает вызовам gracefulShutdown на завершение
+func serveGRPC(ctx context.Context, addr string, srv *grpc.Server, logger *log.Logger) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		logger.Error("Server stopped", "server", "gRPC", "error", err)
+		return
+	}
+
+	go func() {
+		<-ctx.Done()
+		stopped := make(chan struct{})
+		go func() {
+			srv.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(gracefulShutdown):
+			logger.Warn("Server shutdown timed out", "server", "gRPC")
+			srv.Stop()
+		}
+	}()
+
+	logger.Info("gRPC API enabled", "addr", addr)
+	if err := srv.Serve(lis); err != nil {
+		logger.Error("Server stopped", "server", "gRPC", "error", err)
+	}
+}
+
+// serve запускает srv до отмены ctx, затем дает запросам gracefulShutdown на завершение
+func serve(ctx context.Context, srv *http.Server, name string, logger *log.Logger) {
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), gracefulShutdown)
+		defer shutdownCancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Warn("Server shutdown timed out", "server", name, "error", err)
+		}
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("Server stopped", "server", name, "error", err)
+	}
+}
@@ -0,0 +1,174 @@
+// Package hooks - подписки на события жизненного цикла запуска: начало и завершение
+// задач, сохранение результатов, ход и завершение запуска. Пакет публичный, чтобы
+// код вне модуля, например тесты на scrapertest.Harness, мог подписываться на события
+package hooks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// TaskEvent описывает запуск или завершение задачи скраппинга
+type TaskEvent struct {
+	RunID    string
+	ExecID   string
+	Task     config.ScraperTask
+	Start    time.Time
+	Duration time.Duration // Заполняется только для OnTaskFinish
+	Result   *models.ScrapingResult
+	Err      error
+	Attempt  int  // Номер попытки, с 1
+	Retrying bool // Попытка неудачна, задача будет повторена
+}
+
+// ResultEvent описывает сохраненный результат
+type ResultEvent struct {
+	RunID  string
+	Result *models.ScrapingResult
+	Change *models.ResultChange
+}
+
+// RunEvent описывает завершенный запуск
+type RunEvent struct {
+	RunID string
+	Audit *models.AuditEntry
+}
+
+// ProgressEvent описывает изменение хода запуска: задача начата, завершена или ушла на повтор
+type ProgressEvent struct {
+	RunID    string
+	Progress models.RunProgress
+}
+
+type (
+	TaskHook     func(ctx context.Context, e TaskEvent)
+	ResultHook   func(ctx context.Context, e ResultEvent)
+	RunHook      func(ctx context.Context, e RunEvent)
+	ProgressHook func(ctx context.Context, e ProgressEvent)
+)
+
+// Registry хранит подписчиков на события жизненного цикла запуска.
+// Нулевое значение готово к использованию, методы nil-реестра ничего не делают
+type Registry struct {
+	mu          sync.RWMutex
+	taskStart   []TaskHook
+	taskFinish  []TaskHook
+	resultSaved []ResultHook
+	runComplete []RunHook
+	progress    []ProgressHook
+}
+
+// New создает пустой реестр хуков
+func New() *Registry {
+	return &Registry{}
+}
+
+// OnTaskStart подписывает хук на начало выполнения задачи
+func (r *Registry) OnTaskStart(h TaskHook) {
+	r.mu.Lock()
+	r.taskStart = append(r.taskStart, h)
+	r.mu.Unlock()
+}
+
+// OnTaskFinish подписывает хук на завершение задачи (успешное или с ошибкой)
+func (r *Registry) OnTaskFinish(h TaskHook) {
+	r.mu.Lock()
+	r.taskFinish = append(r.taskFinish, h)
+	r.mu.Unlock()
+}
+
+// OnResultSaved подписывает хук на сохранение результата в хранилище
+func (r *Registry) OnResultSaved(h ResultHook) {
+	r.mu.Lock()
+	r.resultSaved = append(r.resultSaved, h)
+	r.mu.Unlock()
+}
+
+// OnRunComplete подписывает хук на завершение запуска
+func (r *Registry) OnRunComplete(h RunHook) {
+	r.mu.Lock()
+	r.runComplete = append(r.runComplete, h)
+	r.mu.Unlock()
+}
+
+// OnProgress подписывает хук на изменение хода запуска. Хук вызывается из воркеров
+// пула и не должен блокироваться
+func (r *Registry) OnProgress(h ProgressHook) {
+	r.mu.Lock()
+	r.progress = append(r.progress, h)
+	r.mu.Unlock()
+}
+
+// TaskStarted вызывает подписчиков OnTaskStart
+func (r *Registry) TaskStarted(ctx context.Context, e TaskEvent) {
+	if r == nil {
+		return
+	}
+	r.mu.RLock()
+	hooks := r.taskStart
+	r.mu.RUnlock()
+
+	for _, h := range hooks {
+		h(ctx, e)
+	}
+}
+
+// TaskFinished вызывает подписчиков OnTaskFinish
+func (r *Registry) TaskFinished(ctx context.Context, e TaskEvent) {
+	if r == nil {
+		return
+	}
+	r.mu.RLock()
+	hooks := r.taskFinish
+	r.mu.RUnlock()
+
+	for _, h := range hooks {
+		h(ctx, e)
+	}
+}
+
+// ResultSaved вызывает подписчиков OnResultSaved
+func (r *Registry) ResultSaved(ctx context.Context, e ResultEvent) {
+	if r == nil {
+		return
+	}
+	r.mu.RLock()
+	hooks := r.resultSaved
+	r.mu.RUnlock()
+
+	for _, h := range hooks {
+		h(ctx, e)
+	}
+}
+
+// RunCompleted вызывает подписчиков OnRunComplete
+func (r *Registry) RunCompleted(ctx context.Context, e RunEvent) {
+	if r == nil {
+		return
+	}
+	r.mu.RLock()
+	hooks := r.runComplete
+	r.mu.RUnlock()
+
+	for _, h := range hooks {
+		h(ctx, e)
+	}
+}
+
+// ProgressUpdated вызывает подписчиков OnProgress
+func (r *Registry) ProgressUpdated(ctx context.Context, e ProgressEvent) {
+	if r == nil {
+		return
+	}
+	r.mu.RLock()
+	hooks := r.progress
+	r.mu.RUnlock()
+
+	for _, h := range hooks {
+		h(ctx, e)
+	}
+}
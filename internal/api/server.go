@@ -0,0 +1,654 @@
+package api
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/db"
+	"github.com/rx3lixir/kultscraper/internal/export"
+	"github.com/rx3lixir/kultscraper/internal/lib/auth"
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// Пределы выдачи результатов
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// maxBodySize - предел размера тела запроса
+const maxBodySize = 1 << 20
+
+// Runner запускает задачи вне расписания
+type Runner interface {
+	// Start запускает задачи в фоне и возвращает идентификатор запуска
+	Start(tasks []config.ScraperTask) (string, error)
+	// Running сообщает, выполняется ли запуск
+	Running(runID string) bool
+	// Progress возвращает ход выполняющегося запуска, false - запуск не выполняется
+	Progress(runID string) (models.RunProgress, bool)
+	// Projects возвращает проекты задач выполняющегося запуска, false - запуск не выполняется
+	Projects(runID string) ([]string, bool)
+}
+
+// Logger - интерфейс для логирования
+type Logger interface {
+	Info(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+	Debug(msg string, keyvals ...interface{})
+}
+
+// NoopLogger - реализация Logger, которая ничего не делает
+type NoopLogger struct{}
+
+func (n NoopLogger) Info(msg string, keyvals ...interface{})  {}
+func (n NoopLogger) Error(msg string, keyvals ...interface{}) {}
+func (n NoopLogger) Debug(msg string, keyvals ...interface{}) {}
+
+// Server - REST API для задач, запусков и сохраненных результатов:
+//
+//	GET    /tasks           список задач
+//	POST   /tasks           добавить задачу
+//	DELETE /tasks/{id}      удалить задачу
+//	POST   /runs            запустить все задачи или {"task_ids": [...]}
+//	GET    /runs            последние запуски
+//	GET    /runs/{id}       запуск по run_id
+//	GET    /runs/{id}/progress  ход выполняющегося запуска: задачи в очереди, выполняемые, завершенные, ETA
+//	GET    /results         результаты с фильтрами ?type=&tag=&project=&name=&updated_after=&include_expired=
+//	                        сортировкой ?sort=[-]created_at|updated_at|name|type|url и страницей ?limit=&offset=
+//	GET    /results/{id}    результат по ID
+//	GET    /results/{id}/history  история изменений полей результата ?limit=
+//	GET    /stats           сводка по типам и источникам, доля неудачных задач за ?window= (по умолчанию 168h)
+//	GET    /feeds/{type}    лента RSS последних результатов типа ?project=&limit=
+//
+// Если заданы Keys, запросы должны передавать ключ в заголовке
+// Authorization: Bearer <key> или X-API-Key. Программы чтения лент не умеют
+// передавать заголовки, поэтому для /feeds ключ принимается и в параметре ?key=.
+// Ключ проекта ограничивает задачи, запуски и результаты своим проектом, параметр
+// ?project= при этом не действует. Сводка /stats доступна только ключу администратора
+type Server struct {
+	Keys           config.APIKeys
+	DefaultProject string               // Проект задач без явного Project
+	History        db.HistoryRepository // Без истории /results/{id}/history отвечает 404
+	Runs           db.RunRepository     // Состояние выполняющихся запусков для /runs/{id}, nil - только статус running
+
+	tasks   TaskStore
+	results db.ScraperRepository
+	audits  db.AuditRepository
+	runner  Runner
+	logger  Logger
+	mux     *http.ServeMux
+}
+
+// NewServer создает API-сервер
+func NewServer(tasks TaskStore, results db.ScraperRepository, audits db.AuditRepository, runner Runner) *Server {
+	return NewServerWithLogger(tasks, results, audits, runner, NoopLogger{})
+}
+
+// NewServerWithLogger создает API-сервер с логгером
+func NewServerWithLogger(tasks TaskStore, results db.ScraperRepository, audits db.AuditRepository, runner Runner, logger Logger) *Server {
+	s := &Server{
+		tasks:   tasks,
+		results: results,
+		audits:  audits,
+		runner:  runner,
+		logger:  logger,
+		mux:     http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /tasks", s.listTasks)
+	s.mux.HandleFunc("POST /tasks", s.addTask)
+	s.mux.HandleFunc("DELETE /tasks/{id}", s.deleteTask)
+	s.mux.HandleFunc("POST /runs", s.startRun)
+	s.mux.HandleFunc("GET /runs", s.listRuns)
+	s.mux.HandleFunc("GET /runs/{id}", s.getRun)
+	s.mux.HandleFunc("GET /runs/{id}/progress", s.runProgress)
+	s.mux.HandleFunc("GET /results", s.listResults)
+	s.mux.HandleFunc("GET /results/{id}", s.getResult)
+	s.mux.HandleFunc("GET /results/{id}/history", s.resultHistory)
+	s.mux.HandleFunc("GET /stats", s.stats)
+	s.mux.HandleFunc("GET /feeds/{type}", s.feed)
+
+	return s
+}
+
+// ServeHTTP проверяет ключ API и передает запрос обработчику
+// с проектом ключа и спаном запроса в контексте
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Запрос продолжает трассу вызывающей стороны из заголовка traceparent
+	ctx, span := tracing.Start(tracing.Extract(r.Context(), r.Header), "api.request",
+		tracing.String("http.method", r.Method), tracing.String("http.path", r.URL.Path))
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	if s.Keys.Enabled() {
+		key := auth.RequestKey(r)
+		if key == "" && strings.HasPrefix(r.URL.Path, "/feeds/") {
+			key = r.URL.Query().Get("key")
+		}
+		project, all, ok := s.Keys.Scope(key)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid or missing API key"))
+			return
+		}
+		if !all {
+			r = r.WithContext(auth.WithProject(r.Context(), project))
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// projectScope возвращает проект, которым ограничен запрос: проект ключа или ?project=.
+// nil - все проекты
+func projectScope(r *http.Request) *string {
+	if project, ok := auth.ProjectFrom(r.Context()); ok {
+		return &project
+	}
+	if q := r.URL.Query(); q.Has("project") {
+		project := q.Get("project")
+		return &project
+	}
+	return nil
+}
+
+// allowed сообщает, доступен ли проект ключу запроса
+func allowed(r *http.Request, project string) bool {
+	scoped, ok := auth.ProjectFrom(r.Context())
+	return !ok || scoped == project
+}
+
+// taskProject возвращает проект задачи с учетом проекта по умолчанию
+func (s *Server) taskProject(t config.ScraperTask) string {
+	return cmp.Or(t.Project, s.DefaultProject)
+}
+
+// scopedTasks возвращает задачи, доступные ключу запроса
+func (s *Server) scopedTasks(r *http.Request) ([]config.ScraperTask, error) {
+	tasks, err := s.tasks.List()
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(tasks, func(t config.ScraperTask) bool {
+		return !allowed(r, s.taskProject(t))
+	}), nil
+}
+
+// taskView - задача с идентификатором в ответах API
+type taskView struct {
+	ID string `json:"ID"`
+	config.ScraperTask
+}
+
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	tasks, err := s.scopedTasks(r)
+	if err != nil {
+		s.internalError(w, "list tasks", err)
+		return
+	}
+
+	views := make([]taskView, 0, len(tasks))
+	for _, t := range tasks {
+		views = append(views, taskView{ID: t.Fingerprint(), ScraperTask: t})
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) addTask(w http.ResponseWriter, r *http.Request) {
+	var task config.ScraperTask
+	if err := decodeBody(w, r, &task); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := config.ValidateTask(task); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if project, ok := auth.ProjectFrom(r.Context()); ok {
+		if task.Project != "" && task.Project != project {
+			writeError(w, http.StatusForbidden, fmt.Errorf("task project %q is not available to this API key", task.Project))
+			return
+		}
+		task.Project = project
+	}
+
+	id, err := s.tasks.Add(task)
+	if errors.Is(err, ErrTaskExists) {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if errors.Is(err, ErrReadOnly) {
+		writeError(w, http.StatusMethodNotAllowed, err)
+		return
+	}
+	if err != nil {
+		s.internalError(w, "add task", err)
+		return
+	}
+
+	s.logger.Info("Task added via API", "id", id, "url", task.URL)
+	writeJSON(w, http.StatusCreated, taskView{ID: id, ScraperTask: task})
+}
+
+func (s *Server) deleteTask(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := auth.ProjectFrom(r.Context()); ok {
+		tasks, err := s.scopedTasks(r)
+		if err != nil {
+			s.internalError(w, "list tasks", err)
+			return
+		}
+		if !slices.ContainsFunc(tasks, func(t config.ScraperTask) bool { return t.Fingerprint() == id }) {
+			writeError(w, http.StatusNotFound, ErrTaskNotFound)
+			return
+		}
+	}
+
+	err := s.tasks.Delete(id)
+	if errors.Is(err, ErrTaskNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if errors.Is(err, ErrReadOnly) {
+		writeError(w, http.StatusMethodNotAllowed, err)
+		return
+	}
+	if err != nil {
+		s.internalError(w, "delete task", err)
+		return
+	}
+
+	s.logger.Info("Task deleted via API", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// runRequest - тело POST /runs, пустой список означает все задачи
+type runRequest struct {
+	TaskIDs []string `json:"task_ids"`
+}
+
+func (s *Server) startRun(w http.ResponseWriter, r *http.Request) {
+	var req runRequest
+	if r.ContentLength != 0 {
+		if err := decodeBody(w, r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	tasks, err := s.scopedTasks(r)
+	if err != nil {
+		s.internalError(w, "list tasks", err)
+		return
+	}
+
+	if len(req.TaskIDs) > 0 {
+		byID := make(map[string]config.ScraperTask, len(tasks))
+		for _, t := range tasks {
+			byID[t.Fingerprint()] = t
+		}
+
+		tasks = tasks[:0:0]
+		for _, id := range req.TaskIDs {
+			t, ok := byID[id]
+			if !ok {
+				writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", ErrTaskNotFound, id))
+				return
+			}
+			tasks = append(tasks, t)
+		}
+	}
+	if len(tasks) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("no tasks to run"))
+		return
+	}
+
+	runID, err := s.runner.Start(tasks)
+	if err != nil {
+		s.internalError(w, "start run", err)
+		return
+	}
+
+	s.logger.Info("Run started via API", "run_id", runID, "tasks", len(tasks))
+	writeJSON(w, http.StatusAccepted, map[string]any{"run_id": runID, "tasks": len(tasks)})
+}
+
+func (s *Server) listRuns(w http.ResponseWriter, r *http.Request) {
+	limit, _, err := pagination(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var audits []*models.AuditEntry
+	if project, ok := auth.ProjectFrom(r.Context()); ok {
+		audits, err = s.audits.GetProjectAudits(r.Context(), project, int64(limit))
+	} else {
+		audits, err = s.audits.GetAudits(r.Context(), int64(limit))
+	}
+	if err != nil {
+		s.internalError(w, "list runs", err)
+		return
+	}
+	if len(audits) == 0 {
+		audits = []*models.AuditEntry{}
+	}
+	writeJSON(w, http.StatusOK, audits)
+}
+
+func (s *Server) getRun(w http.ResponseWriter, r *http.Request) {
+	runID := r.PathValue("id")
+
+	// Запись аудита сохраняется по завершении запуска. Запуск без задач проекта ключа
+	// не находится и в аудите, пока выполняется
+	if visible, whole := s.runScope(r, runID); s.runner.Running(runID) && visible {
+		// Итоги запуска считаются по всем задачам, запуск с задачами других проектов отдается только статусом
+		if s.Runs != nil && whole {
+			if run, err := s.Runs.GetRun(r.Context(), runID); err == nil {
+				writeJSON(w, http.StatusOK, run)
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"run_id": runID, "status": models.RunRunning})
+		return
+	}
+
+	audit, err := s.audits.GetAuditByRunID(r.Context(), runID)
+	if errors.Is(err, db.ErrNotFound) {
+		writeError(w, http.StatusNotFound, errors.New("run not found"))
+		return
+	}
+	if err != nil {
+		s.internalError(w, "get run", err)
+		return
+	}
+	if project, ok := auth.ProjectFrom(r.Context()); ok {
+		if audit = audit.ForProject(project); audit == nil {
+			writeError(w, http.StatusNotFound, errors.New("run not found"))
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, audit)
+}
+
+func (s *Server) runProgress(w http.ResponseWriter, r *http.Request) {
+	runID := r.PathValue("id")
+	progress, ok := s.runner.Progress(runID)
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("run is not running"))
+		return
+	}
+	// Ход запуска считается по всем задачам, поэтому ключу проекта доступен только запуск своего проекта
+	if _, whole := s.runScope(r, runID); !whole {
+		writeError(w, http.StatusNotFound, errors.New("run is not running"))
+		return
+	}
+	writeJSON(w, http.StatusOK, progress)
+}
+
+// runScope проверяет доступ ключа запроса к выполняющемуся запуску. visible - в запуске
+// есть задачи проекта ключа, whole - других задач нет и итоги запуска можно отдать целиком
+func (s *Server) runScope(r *http.Request, runID string) (visible, whole bool) {
+	if _, ok := auth.ProjectFrom(r.Context()); !ok {
+		return true, true
+	}
+	projects, ok := s.runner.Projects(runID)
+	if !ok {
+		return false, false
+	}
+	whole = len(projects) > 0
+	for _, project := range projects {
+		if allowed(r, cmp.Or(project, s.DefaultProject)) {
+			visible = true
+		} else {
+			whole = false
+		}
+	}
+	return visible, whole
+}
+
+func (s *Server) listResults(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limit, offset, err := pagination(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var opts []db.QueryOption
+	if project := projectScope(r); project != nil {
+		opts = append(opts, db.InProject(*project))
+	}
+	if v := q.Get("include_expired"); v != "" {
+		include, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid include_expired: %q", v))
+			return
+		}
+		if include {
+			opts = append(opts, db.IncludeExpired())
+		}
+	}
+
+	if v := q.Get("type"); v != "" {
+		opts = append(opts, db.OfType(v))
+	}
+	if v := q.Get("tag"); v != "" {
+		opts = append(opts, db.Tagged(v))
+	}
+	if v := q.Get("name"); v != "" {
+		opts = append(opts, db.NameContains(v))
+	}
+	if v := q.Get("updated_after"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid updated_after: %q", v))
+			return
+		}
+		opts = append(opts, db.UpdatedAfter(t))
+	}
+	if v := q.Get("sort"); v != "" {
+		field, desc := strings.CutPrefix(v, "-")
+		if !db.ValidSortField(field) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid sort: %q, available: %s", v, strings.Join(db.SortFields, ", ")))
+			return
+		}
+		opts = append(opts, db.SortBy(field, desc))
+	}
+
+	total, err := s.results.CountResults(r.Context(), opts...)
+	if err != nil {
+		s.internalError(w, "count results", err)
+		return
+	}
+	results, err := s.results.GetAllResults(r.Context(), append(opts, db.Page(limit, offset))...)
+	if err != nil {
+		s.internalError(w, "list results", err)
+		return
+	}
+	if results == nil {
+		results = []*models.ScrapingResult{}
+	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (s *Server) getResult(w http.ResponseWriter, r *http.Request) {
+	if result, ok := s.scopedResult(w, r); ok {
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// scopedResult возвращает результат {id}, если он доступен ключу запроса.
+// Результат чужого проекта не отличается от отсутствующего
+func (s *Server) scopedResult(w http.ResponseWriter, r *http.Request) (*models.ScrapingResult, bool) {
+	result, err := s.results.GetResultByID(r.Context(), r.PathValue("id"))
+	switch {
+	case errors.Is(err, db.ErrNotFound), errors.Is(err, db.ErrInvalidID):
+		writeError(w, http.StatusNotFound, errors.New("result not found"))
+		return nil, false
+	case err != nil:
+		s.internalError(w, "get result", err)
+		return nil, false
+	case !allowed(r, result.Project):
+		writeError(w, http.StatusNotFound, errors.New("result not found"))
+		return nil, false
+	}
+	return result, true
+}
+
+func (s *Server) resultHistory(w http.ResponseWriter, r *http.Request) {
+	if s.History == nil {
+		writeError(w, http.StatusNotFound, errors.New("result history is not available"))
+		return
+	}
+
+	limit, _, err := pagination(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if _, ok := auth.ProjectFrom(r.Context()); ok {
+		if _, ok := s.scopedResult(w, r); !ok {
+			return
+		}
+	}
+
+	entries, err := s.History.GetHistory(r.Context(), r.PathValue("id"), int64(limit))
+	if err != nil {
+		s.internalError(w, "get result history", err)
+		return
+	}
+	if entries == nil {
+		entries = []*models.HistoryEntry{}
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *Server) stats(w http.ResponseWriter, r *http.Request) {
+	// Сводка считается по всем проектам
+	if _, ok := auth.ProjectFrom(r.Context()); ok {
+		writeError(w, http.StatusForbidden, errors.New("stats are available only to the admin API key"))
+		return
+	}
+
+	window := db.DefaultStatsWindow
+	if v := r.URL.Query().Get("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid window: %q", v))
+			return
+		}
+		window = d
+	}
+
+	stats, err := db.CollectStats(r.Context(), s.results, s.audits, time.Now().Add(-window))
+	if err != nil {
+		s.internalError(w, "get stats", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (s *Server) feed(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	scraperType := r.PathValue("type")
+
+	size := export.DefaultFeedSize
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %q", v))
+			return
+		}
+		size = min(n, MaxLimit)
+	}
+
+	var opts []db.QueryOption
+	if project := projectScope(r); project != nil {
+		opts = append(opts, db.InProject(*project))
+	}
+
+	results, err := s.results.GetResultsByType(r.Context(), scraperType, opts...)
+	if err != nil {
+		s.internalError(w, "feed", err)
+		return
+	}
+
+	// Ключ доступа не попадает в ссылку на ленту
+	link := *r.URL
+	link.Scheme, link.Host = "http", r.Host
+	if r.TLS != nil {
+		link.Scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		link.Scheme = proto
+	}
+	query := link.Query()
+	query.Del("key")
+	link.RawQuery = query.Encode()
+
+	feed := export.Feed{
+		Title:       "kultscraper: " + scraperType,
+		Link:        link.String(),
+		Description: "Latest scraped results of type " + scraperType,
+		Size:        size,
+	}
+
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	if err := export.WriteRSS(w, feed, results); err != nil {
+		s.logger.Error("Failed to write feed", "type", scraperType, "error", err)
+	}
+}
+
+func (s *Server) internalError(w http.ResponseWriter, op string, err error) {
+	s.logger.Error("API request failed", "op", op, "error", err)
+	writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+}
+
+// pagination читает ?limit= и ?offset=
+func pagination(q url.Values) (limit, offset int, err error) {
+	limit = DefaultLimit
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			return 0, 0, fmt.Errorf("invalid limit: %q", v)
+		}
+		limit = min(limit, MaxLimit)
+	}
+	if v := q.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset: %q", v)
+		}
+	}
+	return limit, offset, nil
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+)
+
+var (
+	ErrTaskNotFound = errors.New("task not found")
+	ErrTaskExists   = errors.New("task already exists")
+	ErrReadOnly     = errors.New("tasks are managed by a remote source")
+)
+
+// TaskStore - хранилище задач, изменяемых через API.
+// Идентификатор задачи - ее отпечаток config.ScraperTask.Fingerprint
+type TaskStore interface {
+	List() ([]config.ScraperTask, error)
+	Add(task config.ScraperTask) (string, error)
+	Delete(id string) error
+}
+
+// FileTaskStore хранит задачи в JSON-файле задач (CONFIG_PATH).
+// OnChange вызывается после каждого изменения файла
+type FileTaskStore struct {
+	mu       sync.Mutex
+	path     string
+	OnChange func()
+}
+
+// NewFileTaskStore создает хранилище задач в файле path
+func NewFileTaskStore(path string) *FileTaskStore {
+	return &FileTaskStore{path: path}
+}
+
+// List возвращает задачи из файла, отсутствующий файл означает пустой список
+func (s *FileTaskStore) List() ([]config.ScraperTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Add добавляет задачу и возвращает ее идентификатор
+func (s *FileTaskStore) Add(task config.ScraperTask) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks, err := s.load()
+	if err != nil {
+		return "", err
+	}
+
+	id := task.Fingerprint()
+	for _, t := range tasks {
+		if t.Fingerprint() == id {
+			return id, ErrTaskExists
+		}
+	}
+
+	if err := s.save(append(tasks, task)); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Delete удаляет задачу по идентификатору
+func (s *FileTaskStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	for i, t := range tasks {
+		if t.Fingerprint() == id {
+			return s.save(append(tasks[:i], tasks[i+1:]...))
+		}
+	}
+	return ErrTaskNotFound
+}
+
+func (s *FileTaskStore) load() ([]config.ScraperTask, error) {
+	tasks, err := config.LoadTaskDefinitions(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return tasks, err
+}
+
+// save атомарно перезаписывает файл задач, чтобы наблюдатель демона не прочитал его частично
+func (s *FileTaskStore) save(tasks []config.ScraperTask) error {
+	if tasks == nil {
+		tasks = []config.ScraperTask{}
+	}
+	data, err := json.MarshalIndent(tasks, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+
+	if s.OnChange != nil {
+		s.OnChange()
+	}
+	return nil
+}
+
+// SourceTaskStore отдает задачи удаленного источника (CONFIG_PATH с http(s):// или mongodb://).
+// Такие задачи изменяются в самом источнике, Add и Delete возвращают ErrReadOnly
+type SourceTaskStore struct {
+	load func() ([]config.ScraperTask, error)
+}
+
+// NewSourceTaskStore создает хранилище, читающее задачи через load
+func NewSourceTaskStore(load func() ([]config.ScraperTask, error)) *SourceTaskStore {
+	return &SourceTaskStore{load: load}
+}
+
+func (s *SourceTaskStore) List() ([]config.ScraperTask, error) { return s.load() }
+
+func (s *SourceTaskStore) Add(config.ScraperTask) (string, error) { return "", ErrReadOnly }
+
+func (s *SourceTaskStore) Delete(string) error { return ErrReadOnly }
@@ -0,0 +1,261 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/rx3lixir/kultscraper/hooks"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/lib/work"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/rx3lixir/kultscraper/internal/scraper"
+)
+
+// sampleInterval - период замера потребления ресурсов во время прогона
+const sampleInterval = 500 * time.Millisecond
+
+// Config - параметры нагрузочного прогона
+type Config struct {
+	Tasks       int // Число синтетических задач
+	Workers     int // Число воркеров пула
+	TaskTimeout time.Duration
+	// BrowserUsage, если задана, замеряет ресурсы браузера во время прогона
+	BrowserUsage func(ctx context.Context) (*scraper.BrowserUsage, error)
+}
+
+// Report - итоги прогона
+type Report struct {
+	Tasks      int
+	Succeeded  int
+	Failed     int
+	Workers    int
+	Duration   time.Duration
+	Throughput float64 // Задач в секунду
+
+	P50, P90, P99, Max time.Duration
+
+	PeakGoHeapBytes uint64
+	PeakGoroutines  int
+	PeakJSHeapBytes float64
+	PeakBrowserCPU  float64
+	PeakActivePages int
+	Errors          map[string]int // Число ошибок по тексту
+}
+
+// NewServer запускает локальный сервер с синтетическими страницами событий.
+// Любой путь вида /event/<n> отдает страницу с заголовком, датой и ценой
+func NewServer(delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/event/")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `<!doctype html><html><head><title>Event %[1]s</title></head><body>
+<h1 class="title">Событие %[1]s</h1>
+<div class="date">12 марта 19:00</div>
+<div class="price">от 500 ₽</div>
+<ul class="tags"><li>концерт</li><li>бенчмарк</li></ul>
+</body></html>`, id)
+	}))
+}
+
+// Tasks генерирует синтетические задачи для страниц сервера
+func Tasks(baseURL string, n int) []config.ScraperTask {
+	tasks := make([]config.ScraperTask, n)
+	for i := range tasks {
+		tasks[i] = config.ScraperTask{
+			URL:  fmt.Sprintf("%s/event/%d", baseURL, i),
+			Type: "bench",
+			Name: fmt.Sprintf("bench-%d", i),
+			Selectors: map[string]string{
+				"Title": ".title",
+				"Date":  ".date",
+				"Price": ".price",
+				"Tags":  ".tags li",
+			},
+		}
+	}
+	return tasks
+}
+
+// Run выполняет задачи через пул воркеров и собирает статистику
+func Run(ctx context.Context, cfg Config, s scraper.Scraper, tasks []config.ScraperTask) (*Report, error) {
+	workers := max(cfg.Workers, 1)
+	timeout := cfg.TaskTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	report := &Report{Tasks: len(tasks), Workers: workers, Errors: make(map[string]int)}
+	if len(tasks) == 0 {
+		return report, nil
+	}
+
+	pool, err := work.NewTypedPool[*models.ScrapingResult](workers, len(tasks))
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Start(ctx); err != nil {
+		return nil, err
+	}
+	defer pool.Stop()
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+	)
+	finished := make(chan struct{}, len(tasks))
+	lifecycle := hooks.New()
+	lifecycle.OnTaskFinish(func(ctx context.Context, e hooks.TaskEvent) {
+		mu.Lock()
+		latencies = append(latencies, e.Duration)
+		if e.Err != nil {
+			report.Failed++
+			report.Errors[e.Err.Error()]++
+		} else {
+			report.Succeeded++
+		}
+		mu.Unlock()
+		finished <- struct{}{}
+	})
+
+	sampleCtx, stopSampling := context.WithCancel(ctx)
+	defer stopSampling()
+	samplingDone := make(chan struct{})
+	go func() {
+		defer close(samplingDone)
+		sample(sampleCtx, cfg, report)
+	}()
+
+	logger := log.New(io.Discard)
+	start := time.Now()
+
+	for _, task := range tasks {
+		taskCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		t := scraper.NewTaskToScrape(task, taskCtx, s, *logger)
+		t.RunID = "bench"
+		t.Hooks = lifecycle
+		if err := pool.AddTask(t); err != nil {
+			return nil, err
+		}
+	}
+
+	// Результаты не сохраняются, канал только освобождается
+	go func() {
+		for range pool.Results() {
+		}
+	}()
+
+	for done := 0; done < len(tasks); done++ {
+		select {
+		case <-finished:
+		case <-ctx.Done():
+			stopSampling()
+			<-samplingDone
+			return report, ctx.Err()
+		}
+	}
+
+	report.Duration = time.Since(start)
+	stopSampling()
+	<-samplingDone
+
+	report.Throughput = float64(len(tasks)) / report.Duration.Seconds()
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	report.P50 = percentile(latencies, 0.50)
+	report.P90 = percentile(latencies, 0.90)
+	report.P99 = percentile(latencies, 0.99)
+	report.Max = latencies[len(latencies)-1]
+
+	return report, nil
+}
+
+// sample периодически замеряет потребление ресурсов процессом и браузером, сохраняя пики
+func sample(ctx context.Context, cfg Config, report *Report) {
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+
+	for {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		report.PeakGoHeapBytes = max(report.PeakGoHeapBytes, mem.HeapAlloc)
+		report.PeakGoroutines = max(report.PeakGoroutines, runtime.NumGoroutine())
+
+		if cfg.BrowserUsage != nil {
+			if usage, err := cfg.BrowserUsage(ctx); err == nil {
+				report.PeakJSHeapBytes = max(report.PeakJSHeapBytes, usage.JSHeapBytes)
+				report.PeakBrowserCPU = max(report.PeakBrowserCPU, usage.CPUPercent)
+				report.PeakActivePages = max(report.PeakActivePages, usage.ActivePages)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// percentile возвращает перцентиль p отсортированного набора длительностей
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * p)
+	return sorted[idx]
+}
+
+// Write выводит отчет в читаемом виде
+func (r *Report) Write(w io.Writer) error {
+	_, err := fmt.Fprintf(w, `Benchmark report
+  tasks:        %d (ok %d, failed %d)
+  workers:      %d
+  duration:     %s
+  throughput:   %.2f tasks/s
+  latency:      p50 %s  p90 %s  p99 %s  max %s
+  go heap peak: %.1f MB
+  goroutines:   %d
+  js heap peak: %.1f MB
+  browser cpu:  %.1f%%
+  active pages: %d
+`,
+		r.Tasks, r.Succeeded, r.Failed,
+		r.Workers,
+		r.Duration.Round(time.Millisecond),
+		r.Throughput,
+		r.P50.Round(time.Millisecond), r.P90.Round(time.Millisecond), r.P99.Round(time.Millisecond), r.Max.Round(time.Millisecond),
+		float64(r.PeakGoHeapBytes)/1024/1024,
+		r.PeakGoroutines,
+		r.PeakJSHeapBytes/1024/1024,
+		r.PeakBrowserCPU,
+		r.PeakActivePages,
+	)
+	if err != nil {
+		return err
+	}
+
+	for msg, n := range r.Errors {
+		if _, err := fmt.Fprintf(w, "  error x%d: %s\n", n, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
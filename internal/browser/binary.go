@@ -0,0 +1,104 @@
+package browser
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-rod/rod/lib/launcher"
+)
+
+var (
+	ErrBinaryMissing    = errors.New("browser binary not found in offline mode")
+	ErrChecksumMismatch = errors.New("browser binary checksum mismatch")
+)
+
+// Logger - интерфейс для логирования
+type Logger interface {
+	Info(msg string, keyvals ...interface{})
+}
+
+// BinaryConfig - настройки закрепленной версии Chromium
+type BinaryConfig struct {
+	Bin      string // Явный путь к исполняемому файлу, отключает скачивание
+	Revision int    // Ревизия Chromium, 0 - ревизия по умолчанию для rod
+	Dir      string // Каталог кеша скачанных браузеров
+	SHA256   string // Ожидаемая контрольная сумма исполняемого файла
+	Offline  bool   // Не скачивать браузер, использовать только кеш
+}
+
+// Managed сообщает, нужно ли управлять бинарником браузера,
+// иначе rod ищет браузер на хосте самостоятельно
+func (c BinaryConfig) Managed() bool {
+	return c.Bin != "" || c.Revision > 0 || c.Offline || c.SHA256 != ""
+}
+
+// EnsureBinary возвращает путь к исполняемому файлу браузера: скачивает закрепленную
+// ревизию в кеш при необходимости и проверяет контрольную сумму
+func EnsureBinary(ctx context.Context, cfg BinaryConfig, logger Logger) (string, error) {
+	path := cfg.Bin
+	if path == "" {
+		lc := launcher.NewBrowser()
+		lc.Context = ctx
+		lc.Logger = launcherLogger{logger}
+		if cfg.Revision > 0 {
+			lc.Revision = cfg.Revision
+		}
+		if cfg.Dir != "" {
+			lc.RootDir = cfg.Dir
+		}
+
+		if cfg.Offline {
+			if err := lc.Validate(); err != nil {
+				return "", fmt.Errorf("%w: %s: %v", ErrBinaryMissing, lc.BinPath(), err)
+			}
+			path = lc.BinPath()
+		} else {
+			var err error
+			if path, err = lc.Get(); err != nil {
+				return "", fmt.Errorf("download browser revision %d: %w", lc.Revision, err)
+			}
+		}
+		logger.Info("Using browser binary", "path", path, "revision", lc.Revision)
+	}
+
+	if cfg.SHA256 != "" {
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return "", err
+		}
+		if !strings.EqualFold(sum, cfg.SHA256) {
+			return "", fmt.Errorf("%w: %s: expected %s, got %s", ErrChecksumMismatch, path, cfg.SHA256, sum)
+		}
+	}
+
+	return path, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// launcherLogger перенаправляет прогресс скачивания в логгер приложения
+type launcherLogger struct {
+	logger Logger
+}
+
+func (l launcherLogger) Println(args ...interface{}) {
+	l.logger.Info(strings.TrimSpace(fmt.Sprintln(args...)))
+}
@@ -0,0 +1,66 @@
+package browser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/launcher/flags"
+)
+
+// ErrNoBrowser - Chromium не найден на хосте и не задан явно
+var ErrNoBrowser = errors.New("no Chromium found: install Chromium or Chrome, set BROWSER_BIN to its path or BROWSER_REVISION to download a pinned build")
+
+// LaunchConfig - параметры запуска локального Chromium
+type LaunchConfig struct {
+	Headless    bool
+	NoSandbox   bool     // --no-sandbox, нужен при запуске от root в контейнере
+	UserDataDir string   // Каталог профиля, пусто - временный каталог
+	Proxy       string   // Прокси всего браузера (--proxy-server), host:port
+	Flags       []string // Дополнительные флаги: "--name" или "--name=value"
+}
+
+// Launch запускает Chromium с параметрами cfg и подключается к нему.
+// Пустой bin - браузер, найденный на хосте
+func Launch(bin string, cfg LaunchConfig) (*rod.Browser, error) {
+	if bin == "" {
+		found, ok := launcher.LookPath()
+		if !ok {
+			return nil, ErrNoBrowser
+		}
+		bin = found
+	}
+
+	l := launcher.New().Bin(bin).Headless(cfg.Headless).NoSandbox(cfg.NoSandbox)
+	if cfg.UserDataDir != "" {
+		l = l.UserDataDir(cfg.UserDataDir)
+	}
+	if cfg.Proxy != "" {
+		l = l.Proxy(cfg.Proxy)
+	}
+	for _, flag := range cfg.Flags {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(flag, "-"), "=")
+		if name == "" {
+			continue
+		}
+		if hasValue {
+			l = l.Set(flags.Flag(name), value)
+		} else {
+			l = l.Set(flags.Flag(name))
+		}
+	}
+
+	u, err := l.Launch()
+	if err != nil {
+		return nil, fmt.Errorf("launch browser %s: %w", bin, err)
+	}
+
+	b := rod.New().ControlURL(u)
+	if err := b.Connect(); err != nil {
+		l.Kill()
+		return nil, fmt.Errorf("connect to browser %s: %w", bin, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,142 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/cdp"
+)
+
+// ErrInvalidRemoteURL - адрес удаленного браузера не ws(s) и не http(s)
+var ErrInvalidRemoteURL = errors.New("invalid remote browser URL")
+
+// RemoteConfig - подключение к удаленному браузеру по DevTools (browserless, отдельный пул браузеров)
+type RemoteConfig struct {
+	URL      string        // ws(s):// - адрес DevTools, http(s):// - адрес ищется через /json/version
+	Token    string        // Токен доступа, передается параметром token и заголовком Authorization
+	Attempts int           // Попыток подключения, 0 - одна
+	Backoff  time.Duration // Пауза перед повторной попыткой, удваивается с каждой попыткой
+}
+
+// Connect подключается к удаленному браузеру, повторяя попытки при ошибках
+func Connect(ctx context.Context, cfg RemoteConfig, logger Logger) (*rod.Browser, error) {
+	attempts := max(cfg.Attempts, 1)
+	backoff := cfg.Backoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var b *rod.Browser
+		if b, err = connectRemote(ctx, cfg); err == nil {
+			return b, nil
+		}
+		if errors.Is(err, ErrInvalidRemoteURL) || attempt >= attempts {
+			break
+		}
+		logger.Info("Remote browser unavailable, retrying", "url", RedactRemoteURL(cfg.URL), "attempt", attempt, "error", err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+	return nil, fmt.Errorf("connect to remote browser %s: %w", RedactRemoteURL(cfg.URL), err)
+}
+
+func connectRemote(ctx context.Context, cfg RemoteConfig) (*rod.Browser, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRemoteURL, RedactRemoteURL(cfg.URL))
+	}
+	if cfg.Token != "" {
+		query := u.Query()
+		query.Set("token", cfg.Token)
+		u.RawQuery = query.Encode()
+	}
+	header := http.Header{}
+	if cfg.Token != "" {
+		header.Set("Authorization", "Bearer "+cfg.Token)
+	}
+
+	switch u.Scheme {
+	case "ws", "wss":
+	case "http", "https":
+		if u, err = resolveDevTools(ctx, u, header); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: scheme %q", ErrInvalidRemoteURL, u.Scheme)
+	}
+
+	client, err := cdp.StartWithURL(ctx, u.String(), header)
+	if err != nil {
+		return nil, err
+	}
+	b := rod.New().Client(client)
+	if err := b.Connect(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// resolveDevTools получает адрес DevTools браузера через /json/version.
+// Хост и параметры исходного адреса сохраняются: браузер за прокси сообщает свой внутренний адрес
+func resolveDevTools(ctx context.Context, u *url.URL, header http.Header) (*url.URL, error) {
+	version := *u
+	version.Path = strings.TrimSuffix(version.Path, "/") + "/json/version"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, version.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header.Clone()
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("devtools version: unexpected status %s", resp.Status)
+	}
+
+	var info struct {
+		WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("devtools version: %w", err)
+	}
+	ws, err := url.Parse(info.WebSocketDebuggerURL)
+	if err != nil || ws.Path == "" {
+		return nil, fmt.Errorf("devtools version: invalid webSocketDebuggerUrl %q", info.WebSocketDebuggerURL)
+	}
+
+	ws.Scheme = "ws"
+	if u.Scheme == "https" {
+		ws.Scheme = "wss"
+	}
+	ws.Host = u.Host
+	ws.RawQuery = u.RawQuery
+	return ws, nil
+}
+
+// RedactRemoteURL скрывает пароль и параметры адреса, в которых передаются токены
+func RedactRemoteURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "<invalid>"
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = "redacted"
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,130 @@
+package bus
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/hooks"
+	"github.com/rx3lixir/kultscraper/internal/config"
+)
+
+const (
+	DriverKafka = "kafka"
+	DriverNATS  = "nats"
+)
+
+var (
+	ErrUnknownDriver = errors.New("unknown message bus driver")
+	ErrNoAddrs       = errors.New("message bus addresses are not configured")
+	ErrUnknownSASL   = errors.New("unknown SASL mechanism")
+)
+
+// Заголовки сообщения с результатом
+const (
+	HeaderChangeType = "change-type"
+	HeaderRunID      = "run-id"
+	HeaderType       = "type"
+)
+
+// Message - сообщение для публикации
+type Message struct {
+	Key     string            // Ключ партиционирования Kafka, в NATS не используется
+	Value   []byte            // Тело сообщения
+	Headers map[string]string // Заголовки сообщения
+}
+
+// Publisher публикует сообщения в топик Kafka или subject NATS
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// New создает издателя по настройкам шины
+func New(cfg config.BusConfig) (Publisher, error) {
+	if len(cfg.Addrs) == 0 {
+		return nil, ErrNoAddrs
+	}
+
+	switch strings.ToLower(cfg.Driver) {
+	case DriverKafka:
+		return NewKafka(cfg)
+	case DriverNATS:
+		return NewNATS(cfg)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDriver, cfg.Driver)
+	}
+}
+
+// ResultMessage создает сообщение с сохраненным результатом: JSON результата,
+// ключ - идентификатор результата, в заголовках - тип изменения, запуск и тип задачи
+func ResultMessage(e hooks.ResultEvent) (Message, error) {
+	value, err := json.Marshal(e.Result)
+	if err != nil {
+		return Message{}, err
+	}
+
+	msg := Message{
+		Key:   e.Result.ID.Hex(),
+		Value: value,
+		Headers: map[string]string{
+			HeaderRunID: e.RunID,
+			HeaderType:  e.Result.Type,
+		},
+	}
+	if e.Change != nil {
+		msg.Key = e.Change.ResultID
+		msg.Headers[HeaderChangeType] = string(e.Change.ChangeType)
+	}
+	return msg, nil
+}
+
+// tlsConfig возвращает настройки TLS подключения к шине, nil - без TLS
+func tlsConfig(cfg config.BusConfig) (*tls.Config, error) {
+	if !cfg.TLS && cfg.TLSCA == "" && cfg.TLSCert == "" {
+		return nil, nil
+	}
+
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSCA != "" {
+		pem, err := os.ReadFile(cfg.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("read bus CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("bus CA %s contains no certificates", cfg.TLSCA)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if cfg.TLSCert != "" || cfg.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("load bus client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
+
+// sortedKeys возвращает имена заголовков по алфавиту, чтобы порядок заголовков не менялся
+func sortedKeys(headers map[string]string) []string {
+	return slices.Sorted(maps.Keys(headers))
+}
+
+// timeoutOf возвращает срок операции: дедлайн ctx или now+timeout, если он раньше
+func timeoutOf(ctx context.Context, timeout time.Duration) time.Time {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return d
+	}
+	return deadline
+}
@@ -0,0 +1,105 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+// Механизмы SASL Kafka
+const (
+	SASLPlain       = "plain"
+	SASLScramSHA256 = "scram-sha-256"
+	SASLScramSHA512 = "scram-sha-512"
+)
+
+const kafkaClientID = "kultscraper"
+
+// Kafka публикует сообщения в топик Kafka клиентом franz-go: подтверждение всех
+// синхронизированных реплик, идемпотентная запись, переключение между брокерами
+// и обновление метаданных при смене лидера партиции. Партиция выбирается по хешу ключа
+type Kafka struct {
+	client  *kgo.Client
+	timeout time.Duration
+}
+
+// NewKafka создает издателя Kafka по настройкам шины. Подключение к брокерам
+// устанавливается при первой публикации
+func NewKafka(cfg config.BusConfig) (*Kafka, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(cfg.Addrs...),
+		kgo.DefaultProduceTopic(cfg.Topic),
+		kgo.ClientID(kafkaClientID),
+		kgo.RecordDeliveryTimeout(cfg.Timeout),
+		kgo.ProducerLinger(0),
+	}
+
+	tlsCfg, err := tlsConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		opts = append(opts, kgo.DialTLSConfig(tlsCfg))
+	}
+
+	if cfg.Username != "" {
+		mechanism, err := saslMechanism(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, kgo.SASL(mechanism))
+	}
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create Kafka client: %w", err)
+	}
+	return &Kafka{client: client, timeout: cfg.Timeout}, nil
+}
+
+// saslMechanism возвращает механизм SASL для логина и пароля шины
+func saslMechanism(cfg config.BusConfig) (sasl.Mechanism, error) {
+	switch strings.ToLower(cfg.SASL) {
+	case "", SASLPlain:
+		return plain.Auth{User: cfg.Username, Pass: cfg.Password}.AsMechanism(), nil
+	case SASLScramSHA256:
+		return scram.Auth{User: cfg.Username, Pass: cfg.Password}.AsSha256Mechanism(), nil
+	case SASLScramSHA512:
+		return scram.Auth{User: cfg.Username, Pass: cfg.Password}.AsSha512Mechanism(), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSASL, cfg.SASL)
+	}
+}
+
+// Publish публикует сообщение и ожидает подтверждения брокеров
+func (k *Kafka) Publish(ctx context.Context, msg Message) (err error) {
+	defer func(start time.Time) { observe(DriverKafka, start, err) }(time.Now())
+
+	ctx, cancel := context.WithDeadline(ctx, timeoutOf(ctx, k.timeout))
+	defer cancel()
+
+	record := &kgo.Record{Value: msg.Value}
+	if msg.Key != "" {
+		record.Key = []byte(msg.Key)
+	}
+	for _, name := range sortedKeys(msg.Headers) {
+		record.Headers = append(record.Headers, kgo.RecordHeader{Key: name, Value: []byte(msg.Headers[name])})
+	}
+	return k.client.ProduceSync(ctx, record).FirstErr()
+}
+
+// Close дожидается отправки буферизованных сообщений и закрывает соединения с брокерами
+func (k *Kafka) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), k.timeout)
+	defer cancel()
+	err := k.client.Flush(ctx)
+	k.client.Close()
+	return err
+}
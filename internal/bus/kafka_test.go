@@ -0,0 +1,283 @@
+package bus_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/bus"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+const testTopic = "results"
+
+var testMessage = bus.Message{
+	Key:   "result-1",
+	Value: []byte(`{"id":"result-1"}`),
+	Headers: map[string]string{
+		bus.HeaderType:  "Кино",
+		bus.HeaderRunID: "run-1",
+	},
+}
+
+func newKafkaCluster(t *testing.T, opts ...kfake.Opt) *kfake.Cluster {
+	t.Helper()
+	c, err := kfake.NewCluster(append([]kfake.Opt{kfake.SeedTopics(1, testTopic)}, opts...)...)
+	if err != nil {
+		t.Fatalf("kfake.NewCluster() error = %v", err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func newPublisher(t *testing.T, cfg config.BusConfig) bus.Publisher {
+	t.Helper()
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	p, err := bus.New(cfg)
+	if err != nil {
+		t.Fatalf("bus.New() error = %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+// consumeKafka читает n записей топика с начала
+func consumeKafka(t *testing.T, addrs []string, n int, opts ...kgo.Opt) []*kgo.Record {
+	t.Helper()
+	client, err := kgo.NewClient(append([]kgo.Opt{kgo.SeedBrokers(addrs...), kgo.ConsumeTopics(testTopic)}, opts...)...)
+	if err != nil {
+		t.Fatalf("kgo.NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var records []*kgo.Record
+	for len(records) < n {
+		fetches := client.PollFetches(ctx)
+		if err := ctx.Err(); err != nil {
+			t.Fatalf("consumed %d records, want %d: %v", len(records), n, err)
+		}
+		records = append(records, fetches.Records()...)
+	}
+	return records
+}
+
+func checkRecord(t *testing.T, r *kgo.Record, msg bus.Message) {
+	t.Helper()
+	if string(r.Key) != msg.Key {
+		t.Errorf("record key = %q, want %q", r.Key, msg.Key)
+	}
+	if string(r.Value) != string(msg.Value) {
+		t.Errorf("record value = %q, want %q", r.Value, msg.Value)
+	}
+	// Заголовки публикуются в порядке имен
+	want := []kgo.RecordHeader{
+		{Key: bus.HeaderRunID, Value: []byte(msg.Headers[bus.HeaderRunID])},
+		{Key: bus.HeaderType, Value: []byte(msg.Headers[bus.HeaderType])},
+	}
+	if len(r.Headers) != len(want) {
+		t.Fatalf("record headers = %v, want %v", r.Headers, want)
+	}
+	for i, h := range r.Headers {
+		if h.Key != want[i].Key || string(h.Value) != string(want[i].Value) {
+			t.Errorf("record header %d = %s: %s, want %s: %s", i, h.Key, h.Value, want[i].Key, want[i].Value)
+		}
+	}
+}
+
+// selfSignedTLS создает самоподписанный сертификат для 127.0.0.1 и возвращает
+// настройки TLS сервера и путь к PEM-файлу сертификата для проверки клиентом
+func selfSignedTLS(t *testing.T) (*tls.Config, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kultscraper-test"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, caFile
+}
+
+func TestKafkaPublish(t *testing.T) {
+	serverTLS, caFile := selfSignedTLS(t)
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	clientTLS := &tls.Config{RootCAs: x509.NewCertPool(), MinVersion: tls.VersionTLS12}
+	clientTLS.RootCAs.AppendCertsFromPEM(caPEM)
+
+	tests := []struct {
+		name     string
+		cluster  []kfake.Opt
+		cfg      config.BusConfig
+		consumer []kgo.Opt
+	}{
+		{
+			name: "no auth",
+		},
+		{
+			name:     "sasl plain by default",
+			cluster:  []kfake.Opt{kfake.EnableSASL(), kfake.Superuser("PLAIN", "scraper", "secret")},
+			cfg:      config.BusConfig{Username: "scraper", Password: "secret"},
+			consumer: []kgo.Opt{kgo.SASL(plain.Auth{User: "scraper", Pass: "secret"}.AsMechanism())},
+		},
+		{
+			name:     "sasl scram-sha-256",
+			cluster:  []kfake.Opt{kfake.EnableSASL(), kfake.Superuser("SCRAM-SHA-256", "scraper", "secret")},
+			cfg:      config.BusConfig{Username: "scraper", Password: "secret", SASL: "SCRAM-SHA-256"},
+			consumer: []kgo.Opt{kgo.SASL(scram.Auth{User: "scraper", Pass: "secret"}.AsSha256Mechanism())},
+		},
+		{
+			name:     "sasl scram-sha-512",
+			cluster:  []kfake.Opt{kfake.EnableSASL(), kfake.Superuser("SCRAM-SHA-512", "scraper", "secret")},
+			cfg:      config.BusConfig{Username: "scraper", Password: "secret", SASL: bus.SASLScramSHA512},
+			consumer: []kgo.Opt{kgo.SASL(scram.Auth{User: "scraper", Pass: "secret"}.AsSha512Mechanism())},
+		},
+		{
+			name:     "tls with custom CA",
+			cluster:  []kfake.Opt{kfake.TLS(serverTLS)},
+			cfg:      config.BusConfig{TLSCA: caFile},
+			consumer: []kgo.Opt{kgo.DialTLSConfig(clientTLS)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newKafkaCluster(t, tt.cluster...)
+			cfg := tt.cfg
+			cfg.Driver, cfg.Addrs, cfg.Topic = bus.DriverKafka, c.ListenAddrs(), testTopic
+
+			if err := newPublisher(t, cfg).Publish(context.Background(), testMessage); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+			records := consumeKafka(t, c.ListenAddrs(), 1, tt.consumer...)
+			checkRecord(t, records[0], testMessage)
+		})
+	}
+}
+
+func TestKafkaPublishAuthFailure(t *testing.T) {
+	c := newKafkaCluster(t, kfake.EnableSASL(), kfake.Superuser("SCRAM-SHA-256", "scraper", "secret"))
+	p := newPublisher(t, config.BusConfig{
+		Driver:   bus.DriverKafka,
+		Addrs:    c.ListenAddrs(),
+		Topic:    testTopic,
+		Timeout:  2 * time.Second,
+		Username: "scraper",
+		Password: "wrong",
+		SASL:     bus.SASLScramSHA256,
+	})
+
+	if err := p.Publish(context.Background(), testMessage); err == nil {
+		t.Fatal("Publish() with wrong password error = nil, want error")
+	}
+}
+
+func TestKafkaFailover(t *testing.T) {
+	c := newKafkaCluster(t, kfake.NumBrokers(3))
+
+	// Первый адрес недоступен: клиент берет метаданные у следующего брокера
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	deadAddr := dead.Addr().String()
+	dead.Close()
+
+	p := newPublisher(t, config.BusConfig{
+		Driver: bus.DriverKafka,
+		Addrs:  append([]string{deadAddr}, c.ListenAddrs()...),
+		Topic:  testTopic,
+	})
+	if err := p.Publish(context.Background(), testMessage); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	// Лидер партиции выходит из кластера: клиент обновляет метаданные и пишет новому лидеру
+	leader := c.LeaderFor(testTopic, 0)
+	if err := c.RemoveNode(leader); err != nil {
+		t.Fatalf("RemoveNode(%d) error = %v", leader, err)
+	}
+	if got := c.LeaderFor(testTopic, 0); got == leader {
+		t.Fatalf("partition leader = %d after removing it", got)
+	}
+	if err := p.Publish(context.Background(), testMessage); err != nil {
+		t.Fatalf("Publish() after leader removal error = %v", err)
+	}
+
+	for _, r := range consumeKafka(t, c.ListenAddrs(), 2) {
+		checkRecord(t, r, testMessage)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.BusConfig
+		want error
+	}{
+		{"no addrs", config.BusConfig{Driver: bus.DriverKafka}, bus.ErrNoAddrs},
+		{"unknown driver", config.BusConfig{Driver: "amqp", Addrs: []string{"localhost:5672"}}, bus.ErrUnknownDriver},
+		{
+			name: "unknown sasl mechanism",
+			cfg:  config.BusConfig{Driver: bus.DriverKafka, Addrs: []string{"localhost:9092"}, Username: "u", SASL: "gssapi"},
+			want: bus.ErrUnknownSASL,
+		},
+		{
+			name: "missing CA file",
+			cfg:  config.BusConfig{Driver: bus.DriverKafka, Addrs: []string{"localhost:9092"}, TLSCA: "/nonexistent/ca.pem"},
+			want: os.ErrNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := bus.New(tt.cfg)
+			if err == nil {
+				p.Close()
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
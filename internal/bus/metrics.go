@@ -0,0 +1,32 @@
+package bus
+
+import (
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/lib/metrics"
+)
+
+// Метрики публикации регистрируются в общем реестре при загрузке пакета
+var (
+	publishDuration = metrics.DefaultRegistry.NewHistogramVec(
+		"kultscraper_bus_publish_duration_seconds",
+		"Duration of message bus publishes.",
+		[]float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		"driver",
+	)
+	published = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_bus_published_total",
+		"Number of published messages by driver and status (ok, error).",
+		"driver", "status",
+	)
+)
+
+// observe записывает длительность и результат публикации
+func observe(driver string, start time.Time, err error) {
+	publishDuration.With(driver).Observe(time.Since(start).Seconds())
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	published.With(driver, status).Inc()
+}
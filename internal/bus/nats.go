@@ -0,0 +1,81 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rx3lixir/kultscraper/internal/config"
+)
+
+const natsClientName = "kultscraper"
+
+// NATS публикует сообщения в subject NATS клиентом nats.go. Клиент переподключается
+// к любому серверу кластера, а публикация подтверждается ответом сервера на flush,
+// поэтому ошибки доступа к subject возвращаются из Publish
+type NATS struct {
+	conn    *nats.Conn
+	subject string
+	timeout time.Duration
+}
+
+// NewNATS создает издателя NATS по настройкам шины. Адреса серверов:
+// nats://[user:pass@|token@]host:port, tls://host:port или host:port.
+// Если серверы недоступны при старте, клиент подключается в фоне
+func NewNATS(cfg config.BusConfig) (*NATS, error) {
+	opts := []nats.Option{
+		nats.Name(natsClientName),
+		nats.Timeout(cfg.Timeout),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	}
+
+	tlsCfg, err := tlsConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		opts = append(opts, nats.Secure(tlsCfg))
+	}
+	if cfg.Username != "" {
+		opts = append(opts, nats.UserInfo(cfg.Username, cfg.Password))
+	}
+	if cfg.Creds != "" {
+		opts = append(opts, nats.UserCredentials(cfg.Creds))
+	}
+
+	conn, err := nats.Connect(strings.Join(cfg.Addrs, ","), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connect to NATS: %w", err)
+	}
+	return &NATS{conn: conn, subject: cfg.Topic, timeout: cfg.Timeout}, nil
+}
+
+// Publish публикует сообщение и ожидает подтверждения сервера
+func (n *NATS) Publish(ctx context.Context, msg Message) (err error) {
+	defer func(start time.Time) { observe(DriverNATS, start, err) }(time.Now())
+
+	ctx, cancel := context.WithDeadline(ctx, timeoutOf(ctx, n.timeout))
+	defer cancel()
+
+	m := nats.NewMsg(n.subject)
+	m.Data = msg.Value
+	for _, name := range sortedKeys(msg.Headers) {
+		m.Header.Set(name, msg.Headers[name])
+	}
+	if err := n.conn.PublishMsg(m); err != nil {
+		return err
+	}
+	return n.conn.FlushWithContext(ctx)
+}
+
+// Close отправляет буферизованные сообщения и закрывает соединение
+func (n *NATS) Close() error {
+	defer n.conn.Close()
+	if !n.conn.IsConnected() {
+		return nil
+	}
+	return n.conn.FlushTimeout(n.timeout)
+}
@@ -0,0 +1,277 @@
+package bus_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/bus"
+	"github.com/rx3lixir/kultscraper/internal/config"
+)
+
+// natsMsg - сообщение, полученное тестовым сервером NATS
+type natsMsg struct {
+	subject string
+	headers map[string]string
+	data    string
+}
+
+// natsServer - минимальный сервер протокола NATS: проверяет логин и пароль из CONNECT,
+// отвечает на PING и складывает опубликованные сообщения в msgs
+type natsServer struct {
+	ln         net.Listener
+	user, pass string
+	msgs       chan natsMsg
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func startNATS(t *testing.T, user, pass string) *natsServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	s := &natsServer{ln: ln, user: user, pass: pass, msgs: make(chan natsMsg, 16)}
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *natsServer) Addr() string { return "nats://" + s.ln.Addr().String() }
+
+// Close останавливает сервер и разрывает соединения клиентов
+func (s *natsServer) Close() {
+	s.ln.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *natsServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *natsServer) handle(conn net.Conn) {
+	defer conn.Close()
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"test\",\"version\":\"2.11.0\",\"proto\":1,\"headers\":true,\"max_payload\":1048576,\"auth_required\":%t}\r\n", s.user != "")
+
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		op, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch strings.ToUpper(op) {
+		case "CONNECT":
+			var opts struct {
+				User string `json:"user"`
+				Pass string `json:"pass"`
+			}
+			json.Unmarshal([]byte(args), &opts)
+			if opts.User != s.user || opts.Pass != s.pass {
+				io.WriteString(conn, "-ERR 'Authorization Violation'\r\n")
+				return
+			}
+		case "PING":
+			io.WriteString(conn, "PONG\r\n")
+		case "PUB", "HPUB":
+			// PUB <subject> [reply] <size>, HPUB <subject> [reply] <header size> <size>
+			fields := strings.Fields(args)
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			headerSize := 0
+			if op == "HPUB" {
+				headerSize, _ = strconv.Atoi(fields[len(fields)-2])
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			s.msgs <- natsMsg{
+				subject: fields[0],
+				headers: parseNATSHeaders(string(payload[:headerSize])),
+				data:    string(payload[headerSize:size]),
+			}
+		}
+	}
+}
+
+// parseNATSHeaders разбирает блок заголовков NATS/1.0
+func parseNATSHeaders(block string) map[string]string {
+	headers := make(map[string]string)
+	lines := strings.Split(block, "\r\n")
+	if len(lines) == 0 {
+		return headers
+	}
+	for _, line := range lines[1:] {
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			headers[key] = strings.TrimSpace(value)
+		}
+	}
+	return headers
+}
+
+// receive ждет сообщение на сервере
+func (s *natsServer) receive(t *testing.T) natsMsg {
+	t.Helper()
+	select {
+	case msg := <-s.msgs:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+		return natsMsg{}
+	}
+}
+
+func checkNATSMsg(t *testing.T, got natsMsg, msg bus.Message) {
+	t.Helper()
+	if got.subject != testTopic {
+		t.Errorf("subject = %q, want %q", got.subject, testTopic)
+	}
+	if got.data != string(msg.Value) {
+		t.Errorf("data = %q, want %q", got.data, msg.Value)
+	}
+	for name, value := range msg.Headers {
+		if got.headers[name] != value {
+			t.Errorf("header %s = %q, want %q", name, got.headers[name], value)
+		}
+	}
+}
+
+func TestNATSPublish(t *testing.T) {
+	tests := []struct {
+		name       string
+		user, pass string
+		cfg        func(s *natsServer) config.BusConfig
+	}{
+		{
+			name: "no auth",
+			cfg: func(s *natsServer) config.BusConfig {
+				return config.BusConfig{Addrs: []string{s.Addr()}}
+			},
+		},
+		{
+			name: "user and password",
+			user: "scraper",
+			pass: "secret",
+			cfg: func(s *natsServer) config.BusConfig {
+				return config.BusConfig{Addrs: []string{s.Addr()}, Username: "scraper", Password: "secret"}
+			},
+		},
+		{
+			name: "credentials in address",
+			user: "scraper",
+			pass: "secret",
+			cfg: func(s *natsServer) config.BusConfig {
+				return config.BusConfig{Addrs: []string{strings.Replace(s.Addr(), "nats://", "nats://scraper:secret@", 1)}}
+			},
+		},
+		{
+			name: "address without scheme",
+			cfg: func(s *natsServer) config.BusConfig {
+				return config.BusConfig{Addrs: []string{strings.TrimPrefix(s.Addr(), "nats://")}}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := startNATS(t, tt.user, tt.pass)
+			cfg := tt.cfg(s)
+			cfg.Driver, cfg.Topic = bus.DriverNATS, testTopic
+
+			if err := newPublisher(t, cfg).Publish(context.Background(), testMessage); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+			checkNATSMsg(t, s.receive(t), testMessage)
+		})
+	}
+}
+
+func TestNATSPublishAuthFailure(t *testing.T) {
+	s := startNATS(t, "scraper", "secret")
+	p := newPublisher(t, config.BusConfig{
+		Driver:   bus.DriverNATS,
+		Addrs:    []string{s.Addr()},
+		Topic:    testTopic,
+		Timeout:  time.Second,
+		Username: "scraper",
+		Password: "wrong",
+	})
+
+	if err := p.Publish(context.Background(), testMessage); err == nil {
+		t.Fatal("Publish() with wrong password error = nil, want error")
+	}
+	select {
+	case <-s.msgs:
+		t.Error("server received a message from an unauthorized client")
+	default:
+	}
+}
+
+func TestNATSFailover(t *testing.T) {
+	// Первый адрес недоступен: клиент подключается к следующему серверу
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	deadAddr := "nats://" + dead.Addr().String()
+	dead.Close()
+
+	a, b := startNATS(t, "", ""), startNATS(t, "", "")
+	p := newPublisher(t, config.BusConfig{
+		Driver: bus.DriverNATS,
+		Addrs:  []string{deadAddr, a.Addr(), b.Addr()},
+		Topic:  testTopic,
+	})
+	if err := p.Publish(context.Background(), testMessage); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	// Сервер, получивший сообщение, останавливается: клиент переподключается к другому
+	current, other := a, b
+	select {
+	case msg := <-a.msgs:
+		checkNATSMsg(t, msg, testMessage)
+	case msg := <-b.msgs:
+		checkNATSMsg(t, msg, testMessage)
+		current, other = b, a
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	current.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := p.Publish(context.Background(), testMessage)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Publish() after server shutdown error = %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	checkNATSMsg(t, other.receive(t), testMessage)
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+)
+
+// Способы авторизации на сайте задачи
+const (
+	AuthBasic  = "basic"   // Authorization: Basic из Username и Password
+	AuthBearer = "bearer"  // Authorization: Bearer из Token
+	AuthAPIKey = "api_key" // Token в заголовке Header
+)
+
+// DefaultAPIKeyHeader - заголовок ключа API, если Header не задан
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// Префиксы ссылок на секреты
+const (
+	SecretEnvPrefix  = "env:"  // env:NAME - переменная окружения
+	SecretFilePrefix = "file:" // file:/run/secrets/name - файл хранилища секретов (Docker, Kubernetes)
+)
+
+// ErrSecretNotSet - секрет по ссылке пуст или не найден
+var ErrSecretNotSet = errors.New("secret is not set")
+
+// AuthConfig - авторизация запросов к сайту задачи: партнерские API и закрытые стенды.
+// Username, Password и Token - ссылки на секреты, а не сами значения: в файле задач,
+// отпечатке задачи и очереди недоставленных задач секреты не появляются
+type AuthConfig struct {
+	Type     string `json:"Type"`               // basic, bearer или api_key
+	Username string `json:"Username,omitempty"` // Ссылка на логин для basic
+	Password string `json:"Password,omitempty"` // Ссылка на пароль для basic
+	Token    string `json:"Token,omitempty"`    // Ссылка на токен для bearer или ключ для api_key
+	Header   string `json:"Header,omitempty"`   // Заголовок ключа для api_key, по умолчанию DefaultAPIKeyHeader
+}
+
+// Headers возвращает заголовки авторизации с секретами, прочитанными по ссылкам
+func (a AuthConfig) Headers() (map[string]string, error) {
+	switch strings.ToLower(a.Type) {
+	case AuthBasic:
+		username, err := ResolveSecret(a.Username)
+		if err != nil {
+			return nil, fmt.Errorf("auth username: %w", err)
+		}
+		password, err := ResolveSecret(a.Password)
+		if err != nil {
+			return nil, fmt.Errorf("auth password: %w", err)
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		return map[string]string{"Authorization": "Basic " + credentials}, nil
+	case AuthBearer:
+		token, err := ResolveSecret(a.Token)
+		if err != nil {
+			return nil, fmt.Errorf("auth token: %w", err)
+		}
+		return map[string]string{"Authorization": "Bearer " + token}, nil
+	case AuthAPIKey:
+		key, err := ResolveSecret(a.Token)
+		if err != nil {
+			return nil, fmt.Errorf("auth token: %w", err)
+		}
+		header := a.Header
+		if header == "" {
+			header = DefaultAPIKeyHeader
+		}
+		return map[string]string{header: key}, nil
+	default:
+		return nil, fmt.Errorf("unknown auth type %q", a.Type)
+	}
+}
+
+// ResolveSecret возвращает значение секрета по ссылке: env:NAME, file:/path
+// или имя переменной окружения без префикса
+func ResolveSecret(ref string) (string, error) {
+	var value string
+	switch {
+	case ref == "":
+		return "", ErrSecretNotSet
+	case strings.HasPrefix(ref, SecretFilePrefix):
+		data, err := os.ReadFile(strings.TrimPrefix(ref, SecretFilePrefix))
+		if err != nil {
+			return "", fmt.Errorf("read secret %s: %w", ref, err)
+		}
+		value = strings.TrimRight(string(data), "\r\n")
+	default:
+		value = os.Getenv(strings.TrimPrefix(ref, SecretEnvPrefix))
+	}
+	if value == "" {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotSet, ref)
+	}
+	return value, nil
+}
+
+// RequestHeaders возвращает заголовки запросов к сайту задачи: Headers и заголовки Auth.
+// Заголовки авторизации заменяют одноименные из Headers
+func (t ScraperTask) RequestHeaders() (map[string]string, error) {
+	if t.Auth == nil {
+		return t.Headers, nil
+	}
+	auth, err := t.Auth.Headers()
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string, len(t.Headers)+len(auth))
+	for name, value := range t.Headers {
+		if !hasHeaderFold(auth, name) {
+			headers[name] = value
+		}
+	}
+	maps.Copy(headers, auth)
+	return headers, nil
+}
+
+// hasHeaderFold сообщает, есть ли заголовок name в headers без учета регистра
+func hasHeaderFold(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
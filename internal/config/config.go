@@ -1,75 +1,1286 @@
 package config
 
 import (
+	"cmp"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
 	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type AppConfig struct {
-	Timeout    string
-	ConfigPath string
-	OutputPath string
-	MongoDB    MongoDBConfig
+	Timeouts       TaskTimeouts  // Таймауты задач без собственных значений
+	SourceLimit    int           // Одновременных задач на один источник (хост), 0 - без ограничения
+	SourceCoolDown time.Duration // Пауза задач источника после блокировки сайтом (403, 429, страница блокировки), 0 - без паузы
+	Robots         RobotsConfig
+	ConfigPath     string // Файл задач или ссылка на удаленный источник (http(s)://, mongodb://)
+	TasksCacheDir  string // Каталог копий задач удаленных источников на случай их недоступности
+	OutputPath     string
+	OutputFormat   string // Формат файлового хранилища: ndjson или json
+	CSVColumns     string // Сопоставление колонок CSV-выгрузки, "Название=title,Дата=date"
+	JSONLDPath     string
+	MetricsAddr    string
+	StreamAddr     string
+	APIAddr        string  // Адрес REST API команды serve
+	APIKeys        APIKeys // Ключи доступа к API: администратора и проектов, без ключей - без проверки
+	GRPCAddr       string  // Адрес gRPC-сервиса команды serve, пустой - выключен
+	StorageBackend string
+	Project        string // Проект по умолчанию для задач без явного проекта
+	SlowTasks      SlowTaskThresholds
+	ResultLimits   ResultLimits
+	Retry          RetryConfig
+	Daemon         DaemonConfig
+	TaskFilter     TaskFilter // Отбор задач из файла, задается флагами команды
+	RequestBudgets RequestBudgets
+	BudgetOnError  string // Запросы при недоступном счетчике бюджета: allow (по умолчанию) или deny
+	Webhooks       WebhookConfig
+	Bus            BusConfig
+	CaptureConsole bool
+	ArtifactDir    string
+	DebugDir       string // Артефакты страниц без данных или с ошибкой навигации по имени задачи и времени
+	Screenshots    CaptureConfig
+	Snapshots      CaptureConfig
+	HAR            CaptureConfig // Запись сетевых запросов в HAR для отладки, по умолчанию выключена
+	PDFs           CaptureConfig // PDF-копии страниц для архива анонсов
+	Images         ImageStoreConfig
+	BrowserMonitor BrowserMonitorConfig
+	BrowserBinary  BrowserBinaryConfig
+	BrowserLaunch  BrowserLaunchConfig
+	BrowserRemote  BrowserRemoteConfig
+	// Число браузеров, между которыми распределяются страницы задач
+	BrowserInstances int
+	Pages            PagePoolConfig
+	// Интервал записи хода запуска в лог, 0 - выключена
+	ProgressInterval time.Duration
+	// Интервал проверки соединения с браузером и переподключения при обрыве, 0 - выключена
+	BrowserKeepAlive time.Duration
+	Proxy            ProxyConfig
+	BlockResources   []string // Блокируемые запросы для задач без поля Block
+	Stealth          bool     // Скрипт go-rod/stealth для задач без Stealth.Enabled
+	HTTPCache        bool     // Условные запросы ETag/Last-Modified для движка http, неизмененные страницы пропускаются
+	TagRules         string
+	Expiry           ExpiryConfig
+	Log              LogConfig
+	Plugins          PluginConfig
+	Tracing          TracingConfig
+	MongoDB          MongoDBConfig
+	Postgres         PostgresConfig
+	SQLite           SQLiteConfig
+	Translate        TranslateConfig
 }
 
 type MongoDBConfig struct {
-	URI            string
-	Database       string
-	Collection     string
-	Username       string
-	Password       string
-	ConnectTimeout time.Duration
+	URI               string
+	Database          string
+	Collection        string
+	AuditCollection   string
+	BudgetCollection  string
+	HistoryCollection string
+	EventsCollection  string
+	RunsCollection    string
+	DLQCollection     string // Очередь задач, не выполненных после всех попыток
+	Username          string
+	Password          string
+	ConnectTimeout    time.Duration
 }
 
+// PostgresConfig - настройки хранилища PostgreSQL
+type PostgresConfig struct {
+	DSN    string
+	Driver string // Имя драйвера database/sql, драйвер должен быть подключен в сборке
+	Table  string
+}
+
+// SQLiteConfig - настройки локального хранилища SQLite
+type SQLiteConfig struct {
+	Path   string
+	Driver string // Имя драйвера database/sql, драйвер должен быть подключен в сборке
+	Table  string
+}
+
+// SlowTaskThresholds - пороги длительности задач по типу, ключ "*" задает порог по умолчанию
+type SlowTaskThresholds map[string]time.Duration
+
+// For возвращает порог для типа задачи или 0, если порог не задан
+func (t SlowTaskThresholds) For(taskType string) time.Duration {
+	if d, ok := t[taskType]; ok {
+		return d
+	}
+	return t["*"]
+}
+
+// parseThresholds разбирает пороги в формате "Кино=20s,*=25s"
+func parseThresholds(s string) (SlowTaskThresholds, error) {
+	thresholds := make(SlowTaskThresholds)
+	for _, pair := range splitList(s) {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid slow task threshold %q: expected type=duration", pair)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid slow task threshold %q: %w", pair, err)
+		}
+		thresholds[strings.TrimSpace(key)] = d
+	}
+	return thresholds, nil
+}
+
+// RequestBudgets - дневные лимиты запросов по доменам, ключ "*" задает лимит по умолчанию
+type RequestBudgets map[string]int
+
+// Поведение при недоступном счетчике бюджета запросов (REQUEST_BUDGET_ON_ERROR)
+const (
+	BudgetAllow = "allow" // Запрос выполняется без учета
+	BudgetDeny  = "deny"  // Запрос не выполняется, задача завершается ошибкой
+)
+
+// For возвращает лимит для домена (с учетом поддоменов) или 0, если лимит не задан
+func (b RequestBudgets) For(domain string) int {
+	for d := domain; d != ""; {
+		if limit, ok := b[d]; ok {
+			return limit
+		}
+		_, parent, ok := strings.Cut(d, ".")
+		if !ok {
+			break
+		}
+		d = parent
+	}
+	return b["*"]
+}
+
+// parseBudgets разбирает лимиты в формате "kassir.ru=500,*=1000"
+func parseBudgets(s string) (RequestBudgets, error) {
+	budgets := make(RequestBudgets)
+	for _, pair := range splitList(s) {
+		domain, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid request budget %q: expected domain=limit", pair)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid request budget %q: limit must be a non-negative integer", pair)
+		}
+		budgets[strings.ToLower(strings.TrimSpace(domain))] = limit
+	}
+	return budgets, nil
+}
+
+// События вебхуков
+const (
+	WebhookTaskFinished  = "task_finished"  // Задача выполнена успешно
+	WebhookTaskFailed    = "task_failed"    // Задача завершилась ошибкой после всех попыток
+	WebhookTaskSkipped   = "task_skipped"   // Задача пропущена: страница не изменилась с прошлого сбора
+	WebhookResultChanged = "result_changed" // Сохраненный результат создан или его данные изменились
+)
+
+// WebhookConfig - уведомления о задачах и изменениях результатов
+type WebhookConfig struct {
+	Targets WebhookTargets // Адреса по типу задачи
+	Events  WebhookEvents  // События по типу задачи
+	Secret  string         // Ключ подписи тела запроса HMAC-SHA256, пустое значение - без подписи
+	Timeout time.Duration
+}
+
+// Enabled сообщает, заданы ли адреса вебхуков
+func (w WebhookConfig) Enabled() bool {
+	return len(w.Targets) > 0
+}
+
+// WebhookTargets - адреса вебхуков по типу задачи, ключ "*" задает адреса для всех типов
+type WebhookTargets map[string][]string
+
+// For возвращает адреса для типа задачи: собственные и общие
+func (t WebhookTargets) For(taskType string) []string {
+	if taskType == "*" {
+		return t["*"]
+	}
+	return append(append([]string(nil), t[taskType]...), t["*"]...)
+}
+
+// WebhookEvents - события вебхуков по типу задачи, ключ "*" задает события по умолчанию
+type WebhookEvents map[string][]string
+
+// Has сообщает, отправляется ли событие для типа задачи. Без настроек отправляются все события
+func (e WebhookEvents) Has(taskType, event string) bool {
+	events, ok := e[taskType]
+	if !ok {
+		if events, ok = e["*"]; !ok {
+			return true
+		}
+	}
+	return slices.Contains(events, event)
+}
+
+// parseWebhookTargets разбирает адреса в формате "Кино=https://a/hook,*=https://b/hook".
+// Тип может повторяться, чтобы задать несколько адресов
+func parseWebhookTargets(s string) (WebhookTargets, error) {
+	targets := make(WebhookTargets)
+	for _, pair := range splitList(s) {
+		taskType, target, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid webhook %q: expected type=url", pair)
+		}
+		target = strings.TrimSpace(target)
+		if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid webhook %q: expected http(s) URL", pair)
+		}
+		taskType = strings.TrimSpace(taskType)
+		targets[taskType] = append(targets[taskType], target)
+	}
+	return targets, nil
+}
+
+// parseWebhookEvents разбирает события в формате "Кино=result_changed,*=task_failed"
+func parseWebhookEvents(s string) (WebhookEvents, error) {
+	events := make(WebhookEvents)
+	for _, pair := range splitList(s) {
+		taskType, event, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid webhook event %q: expected type=event", pair)
+		}
+		event = strings.TrimSpace(event)
+		switch event {
+		case WebhookTaskFinished, WebhookTaskFailed, WebhookTaskSkipped, WebhookResultChanged:
+		default:
+			return nil, fmt.Errorf("invalid webhook event %q: unknown event %q", pair, event)
+		}
+		taskType = strings.TrimSpace(taskType)
+		events[taskType] = append(events[taskType], event)
+	}
+	return events, nil
+}
+
+// APIKeys - ключи доступа к REST и gRPC API и потоку событий. Ключ администратора
+// открывает все проекты, ключ проекта - только задачи, результаты и события своего проекта
+type APIKeys struct {
+	Admin    string            // Ключ администратора, пустой - нет
+	Projects map[string]string // Ключ -> проект, пустой проект - проект по умолчанию
+}
+
+// Enabled сообщает, требуется ли ключ для доступа к API
+func (k APIKeys) Enabled() bool {
+	return k.Admin != "" || len(k.Projects) > 0
+}
+
+// Scope возвращает проект ключа. all - ключ администратора с доступом ко всем проектам,
+// ok - ключ известен. Ключи сравниваются за постоянное время
+func (k APIKeys) Scope(key string) (project string, all, ok bool) {
+	if key == "" {
+		return "", false, false
+	}
+	if k.Admin != "" && subtle.ConstantTimeCompare([]byte(key), []byte(k.Admin)) == 1 {
+		all, ok = true, true
+	}
+	for candidate, p := range k.Projects {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(candidate)) == 1 {
+			project, ok = p, true
+		}
+	}
+	return project, all, ok
+}
+
+// parseProjectKeys разбирает ключи проектов в формате "Кино=key1,Театр=key2". Проект
+// может повторяться, чтобы задать несколько ключей, но один ключ не может относиться к разным проектам
+func parseProjectKeys(s string) (map[string]string, error) {
+	keys := make(map[string]string)
+	for _, pair := range splitList(s) {
+		project, key, ok := strings.Cut(pair, "=")
+		project, key = strings.TrimSpace(project), strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid API key of project %q: expected project=key", project)
+		}
+		if existing, ok := keys[key]; ok && existing != project {
+			return nil, fmt.Errorf("invalid API key of project %q: key is already used by project %q", project, existing)
+		}
+		keys[key] = project
+	}
+	return keys, nil
+}
+
+// BusConfig - публикация сохраненных результатов в шину сообщений
+type BusConfig struct {
+	Driver  string   // kafka или nats, пустое значение отключает публикацию
+	Addrs   []string // Брокеры Kafka (host:port) или серверы NATS (nats://[user:pass@]host:port)
+	Topic   string   // Топик Kafka или subject NATS
+	Timeout time.Duration
+
+	TLS     bool   // Подключение по TLS, включается и заданием TLSCA или TLSCert
+	TLSCA   string // PEM-файл корневых сертификатов, по умолчанию системные
+	TLSCert string // PEM-файлы клиентского сертификата и ключа для взаимной аутентификации
+	TLSKey  string
+
+	Username string // Логин SASL для Kafka или пользователь NATS
+	Password string
+	SASL     string // Механизм SASL Kafka: plain, scram-sha-256 или scram-sha-512, по умолчанию plain
+	Creds    string // Файл учетных данных NATS (JWT и NKey)
+}
+
+// BrowserBinaryConfig - настройки закрепленной версии Chromium
+type BrowserBinaryConfig struct {
+	Bin      string
+	Revision int
+	Dir      string
+	SHA256   string
+	Offline  bool
+}
+
+// BrowserLaunchConfig - параметры запуска локального Chromium
+type BrowserLaunchConfig struct {
+	Headless    bool
+	NoSandbox   bool
+	UserDataDir string
+	Proxy       string
+	Flags       []string // Дополнительные флаги Chromium через пробел: "--disable-gpu --lang=ru"
+}
+
+// BrowserRemoteConfig - подключение к удаленному браузеру вместо запуска локального
+type BrowserRemoteConfig struct {
+	URL      string // Адрес DevTools: ws(s)://... или http(s)://host:9222, пусто - локальный Chromium
+	Token    string
+	Attempts int
+	Backoff  time.Duration
+}
+
+// PagePoolConfig - пул страниц браузеров
+type PagePoolConfig struct {
+	PerBrowser int // Одновременных страниц на браузер; задачи сверх предела ждут свободной страницы
+	MaxUses    int // Задач на одной странице до ее закрытия, 0 - без ограничения
+}
+
+// ResultLimits - пределы размера значений результата. Длинные значения обрезаются
+// с пометкой TruncatedMarker, 0 - без предела
+type ResultLimits struct {
+	MaxFieldBytes int // Предел одного значения Data или записи Items
+	MaxDataBytes  int // Предел суммы значений Data и Items
+}
+
+// ImageStoreConfig - скачивание изображений ключей с типом image
+type ImageStoreConfig struct {
+	Backend  string        // dir, gridfs или s3, пусто - изображения не скачиваются
+	Dir      string        // Каталог для dir
+	BaseURL  string        // Адрес раздачи сохраненных файлов, пусто - в результат пишется ссылка хранилища
+	MaxBytes int64         // Предел размера файла
+	Timeout  time.Duration // Таймаут скачивания одного файла
+	Bucket   string        // Бакет GridFS или S3
+	S3       S3Config
+}
+
+// S3Config - S3-совместимое хранилище (AWS, MinIO, Yandex Object Storage)
+type S3Config struct {
+	Endpoint  string // Адрес API, по умолчанию https://s3.<region>.amazonaws.com
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// CaptureConfig - сохранение снимков страниц на диск
+type CaptureConfig struct {
+	Dir string // Каталог файлов, пустое значение отключает сохранение
+	All bool   // Сохранять для всех задач, иначе только для задач с включенным флагом
+}
+
+// RetryConfig - повторы задач, завершившихся временной ошибкой
+type RetryConfig struct {
+	MaxAttempts    int // Всего попыток, включая первую
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Jitter         float64
+}
+
+// DaemonConfig - настройки режима --daemon
+type DaemonConfig struct {
+	Interval      time.Duration // Период повторного запуска задач без расписания
+	WatchInterval time.Duration // Период проверки файла задач на изменения
+}
+
+// TaskTimeouts - таймауты выполнения задачи
+type TaskTimeouts struct {
+	Scrape     time.Duration // Одна попытка задачи целиком
+	Navigation time.Duration // Загрузка страницы, в том числе следующей страницы списка
+	Selector   time.Duration // Поиск элементов по селектору
+}
+
+// DefaultTaskTimeouts - таймауты, если они не заданы ни в задаче, ни в окружении
+var DefaultTaskTimeouts = TaskTimeouts{
+	Scrape:     30 * time.Second,
+	Navigation: 15 * time.Second,
+	Selector:   5 * time.Second,
+}
+
+// TaskFilter отбирает задачи по имени и части URL, пустые поля не ограничивают
+type TaskFilter struct {
+	Name     string // Имя задачи, без учета регистра
+	URLMatch string // Подстрока URL задачи
+}
+
+// ErrNoMatchingTasks - фильтр не оставил ни одной задачи
+var ErrNoMatchingTasks = errors.New("no tasks match filter")
+
+// Empty сообщает, что фильтр не задан
+func (f TaskFilter) Empty() bool {
+	return f.Name == "" && f.URLMatch == ""
+}
+
+// Match сообщает, проходит ли задача фильтр
+func (f TaskFilter) Match(t ScraperTask) bool {
+	if f.Name != "" && !strings.EqualFold(t.Name, f.Name) {
+		return false
+	}
+	return f.URLMatch == "" || strings.Contains(t.URL, f.URLMatch)
+}
+
+// Apply возвращает задачи, прошедшие фильтр.
+// Если задан фильтр и не подошла ни одна задача, возвращается ErrNoMatchingTasks
+func (f TaskFilter) Apply(tasks []ScraperTask) ([]ScraperTask, error) {
+	if f.Empty() {
+		return tasks, nil
+	}
+	var matched []ScraperTask
+	for _, t := range tasks {
+		if f.Match(t) {
+			matched = append(matched, t)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("%w: name %q, url %q", ErrNoMatchingTasks, f.Name, f.URLMatch)
+	}
+	return matched, nil
+}
+
+// RobotsConfig - соблюдение robots.txt: запрещенные адреса пропускаются, Crawl-delay выдерживается
+type RobotsConfig struct {
+	Enabled   bool
+	UserAgent string        // Агент, группы правил которого применяются, иначе группы "*"
+	CacheTTL  time.Duration // Время жизни правил хоста в кэше
+}
+
+// ProxyConfig - общий список прокси для браузера и HTTP-клиентов
+type ProxyConfig struct {
+	URLs     []string
+	Rotation string // round_robin или random
+}
+
+// BrowserMonitorConfig - настройки мониторинга ресурсов браузера
+type BrowserMonitorConfig struct {
+	Interval      time.Duration
+	MaxJSHeapMB   float64
+	MaxPages      int
+	MaxCPUPercent float64
+	Action        string
+}
+
+// PluginConfig - настройки подключаемых компонентов
+type PluginConfig struct {
+	Paths     []string // Разделяемые библиотеки плагинов (.so)
+	Engine    string   // Движок скраппинга из плагина, пустое значение - встроенный rod
+	Enrichers []string
+	Sinks     []string
+	Captcha   string // Решатель капчи из плагина, пустое значение - задачи с капчей завершаются ошибкой
+}
+
+// LogConfig - настройки логирования
+type LogConfig struct {
+	Level   string
+	Format  string
+	Modules map[string]string // Уровни логирования подсистем (scraper, db, pool), переопределяют Level
+}
+
+// parseLogLevels разбирает уровни подсистем в формате "scraper=debug,db=warn,pool=info"
+func parseLogLevels(s string) (map[string]string, error) {
+	levels := make(map[string]string)
+	for _, pair := range splitList(s) {
+		module, level, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid module log level %q: expected module=level", pair)
+		}
+		levels[strings.ToLower(strings.TrimSpace(module))] = strings.TrimSpace(level)
+	}
+	return levels, nil
+}
+
+// TracingConfig - настройки экспорта трассировок OpenTelemetry (OTLP/HTTP)
+type TracingConfig struct {
+	Endpoint    string
+	Headers     string
+	ServiceName string
+	SampleRatio float64 // Доля записываемых трасс от 0 до 1, дочерние спаны следуют решению родителя
+}
+
+// ExpiryConfig - настройки вычисления срока актуальности событий
+type ExpiryConfig struct {
+	Fields   []string
+	Location string
+}
+
+// TranslateConfig - настройки машинного перевода полей
+type TranslateConfig struct {
+	Provider   string
+	Endpoint   string
+	APIKey     string
+	SourceLang string
+	TargetLang string
+	Fields     []string
+}
+
+// Enabled сообщает, включен ли перевод
+func (t TranslateConfig) Enabled() bool {
+	return t.Provider != "" && len(t.Fields) > 0
+}
+
+// DefaultConfigPath - файл задач, если CONFIG_PATH не задан
+const DefaultConfigPath = "scraperConfig.json"
+
+// DefaultEnvFile - файл переменных окружения, читаемый при наличии
+const DefaultEnvFile = ".env"
+
+// LoadConfig загружает конфигурацию из окружения и файла ENV_FILE (по умолчанию .env).
+// Значения слоями: значения по умолчанию < .env < переменные окружения, флаги
+// команд применяются поверх. Отсутствие .env по умолчанию не считается ошибкой
 func LoadConfig() (*AppConfig, error) {
-	if err := godotenv.Load(); err != nil {
+	return LoadConfigFrom(os.Getenv("ENV_FILE"))
+}
+
+// LoadConfigFrom загружает конфигурацию, читая переменные из envFile.
+// Пустой envFile означает необязательный DefaultEnvFile, явно заданный файл должен существовать
+func LoadConfigFrom(envFile string) (*AppConfig, error) {
+	if envFile != "" {
+		if err := godotenv.Load(envFile); err != nil {
+			return nil, fmt.Errorf("env file: %w", err)
+		}
+	} else if err := godotenv.Load(DefaultEnvFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("env file: %w", err)
+	}
+
+	// Ошибки разбора чисел, флагов и длительностей возвращаются вместе после чтения всех переменных
+	env := &envReader{}
+
+	slowTasks, err := parseThresholds(os.Getenv("SLOW_TASK_THRESHOLDS"))
+	if err != nil {
 		return nil, err
 	}
 
-	// Таймаут подключения к MongoDB
-	connectTimeout := 10 * time.Second
-	if timeout := os.Getenv("MONGODB_CONNECT_TIMEOUT"); timeout != "" {
-		if parsed, err := time.ParseDuration(timeout); err == nil {
-			connectTimeout = parsed
-		}
+	budgets, err := parseBudgets(os.Getenv("REQUEST_BUDGETS"))
+	if err != nil {
+		return nil, err
+	}
+
+	moduleLevels, err := parseLogLevels(os.Getenv("LOG_LEVELS"))
+	if err != nil {
+		return nil, err
+	}
+
+	webhookTargets, err := parseWebhookTargets(os.Getenv("WEBHOOKS"))
+	if err != nil {
+		return nil, err
+	}
+
+	webhookEvents, err := parseWebhookEvents(os.Getenv("WEBHOOK_EVENTS"))
+	if err != nil {
+		return nil, err
+	}
+
+	projectKeys, err := parseProjectKeys(os.Getenv("API_KEYS"))
+	if err != nil {
+		return nil, err
 	}
 
-	return &AppConfig{
-		Timeout:    os.Getenv("SCRAPER_TIMEOUT"),
-		ConfigPath: os.Getenv("CONFIG_PATH"),
-		OutputPath: os.Getenv("OUTPUT_PATH"),
+	cfg := &AppConfig{
+		Timeouts: TaskTimeouts{
+			Scrape:     env.getEnvDuration("SCRAPER_TIMEOUT", DefaultTaskTimeouts.Scrape),
+			Navigation: env.getEnvDuration("NAVIGATION_TIMEOUT", DefaultTaskTimeouts.Navigation),
+			Selector:   env.getEnvDuration("SELECTOR_TIMEOUT", DefaultTaskTimeouts.Selector),
+		},
+		SourceLimit:    env.getEnvInt("SOURCE_CONCURRENCY", 0),
+		SourceCoolDown: env.getEnvDuration("SOURCE_COOLDOWN", 5*time.Minute),
+		ConfigPath:     getEnvDefault("CONFIG_PATH", DefaultConfigPath),
+		TasksCacheDir:  getEnvDefault("TASKS_CACHE_DIR", "tasks-cache"),
+		OutputPath:     os.Getenv("OUTPUT_PATH"),
+		OutputFormat:   getEnvDefault("OUTPUT_FORMAT", "ndjson"),
+		CSVColumns:     os.Getenv("CSV_COLUMNS"),
+		JSONLDPath:     os.Getenv("JSONLD_OUTPUT_PATH"),
+		MetricsAddr:    os.Getenv("METRICS_ADDR"),
+		StreamAddr:     os.Getenv("STREAM_ADDR"),
+		APIAddr:        getEnvDefault("API_ADDR", ":8080"),
+		APIKeys:        APIKeys{Admin: os.Getenv("API_KEY"), Projects: projectKeys},
+		GRPCAddr:       os.Getenv("GRPC_ADDR"),
+		StorageBackend: getEnvDefault("STORAGE_BACKEND", "mongo"),
+		Project:        os.Getenv("DEFAULT_PROJECT"),
+		SlowTasks:      slowTasks,
+		ResultLimits: ResultLimits{
+			MaxFieldBytes: env.getEnvInt("RESULT_MAX_FIELD_BYTES", 64<<10),
+			MaxDataBytes:  env.getEnvInt("RESULT_MAX_DATA_BYTES", 1<<20),
+		},
+		RequestBudgets: budgets,
+		BudgetOnError:  getEnvDefault("REQUEST_BUDGET_ON_ERROR", BudgetAllow),
+		Webhooks: WebhookConfig{
+			Targets: webhookTargets,
+			Events:  webhookEvents,
+			Secret:  os.Getenv("WEBHOOK_SECRET"),
+			Timeout: env.getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		},
+		Bus: BusConfig{
+			Driver:   os.Getenv("BUS_DRIVER"),
+			Addrs:    splitList(os.Getenv("BUS_ADDRS")),
+			Topic:    getEnvDefault("BUS_TOPIC", "kultscraper.results"),
+			Timeout:  env.getEnvDuration("BUS_TIMEOUT", 10*time.Second),
+			TLS:      env.getEnvBool("BUS_TLS", false),
+			TLSCA:    os.Getenv("BUS_TLS_CA"),
+			TLSCert:  os.Getenv("BUS_TLS_CERT"),
+			TLSKey:   os.Getenv("BUS_TLS_KEY"),
+			Username: os.Getenv("BUS_USERNAME"),
+			Password: os.Getenv("BUS_PASSWORD"),
+			SASL:     os.Getenv("BUS_SASL_MECHANISM"),
+			Creds:    os.Getenv("BUS_NATS_CREDS"),
+		},
+		CaptureConsole: env.getEnvBool("CAPTURE_CONSOLE", true),
+		BrowserBinary: BrowserBinaryConfig{
+			Bin:      os.Getenv("BROWSER_BIN"),
+			Revision: env.getEnvInt("BROWSER_REVISION", 0),
+			Dir:      os.Getenv("BROWSER_DIR"),
+			SHA256:   os.Getenv("BROWSER_SHA256"),
+			Offline:  env.getEnvBool("BROWSER_OFFLINE", false),
+		},
+		BrowserLaunch: BrowserLaunchConfig{
+			Headless:    env.getEnvBool("BROWSER_HEADLESS", true),
+			NoSandbox:   env.getEnvBool("BROWSER_NO_SANDBOX", false),
+			UserDataDir: os.Getenv("BROWSER_USER_DATA_DIR"),
+			Proxy:       os.Getenv("BROWSER_PROXY"),
+			Flags:       strings.Fields(os.Getenv("BROWSER_FLAGS")),
+		},
+		BrowserRemote: BrowserRemoteConfig{
+			URL:      os.Getenv("BROWSER_WS_URL"),
+			Token:    os.Getenv("BROWSER_WS_TOKEN"),
+			Attempts: env.getEnvInt("BROWSER_CONNECT_ATTEMPTS", 5),
+			Backoff:  env.getEnvDuration("BROWSER_CONNECT_BACKOFF", 2*time.Second),
+		},
+		BrowserInstances: env.getEnvInt("BROWSER_INSTANCES", 1),
+		Pages: PagePoolConfig{
+			PerBrowser: env.getEnvInt("PAGES_PER_BROWSER", 10),
+			MaxUses:    env.getEnvInt("PAGE_MAX_USES", 50),
+		},
+		BrowserKeepAlive: env.getEnvDuration("BROWSER_KEEPALIVE_INTERVAL", 30*time.Second),
+		ProgressInterval: env.getEnvDuration("PROGRESS_LOG_INTERVAL", 30*time.Second),
+		ArtifactDir:      os.Getenv("FAILURE_ARTIFACTS_DIR"),
+		DebugDir:         os.Getenv("DEBUG_ARTIFACTS_DIR"),
+		Images: ImageStoreConfig{
+			Backend:  strings.ToLower(os.Getenv("IMAGE_STORE")),
+			Dir:      os.Getenv("IMAGE_DIR"),
+			BaseURL:  os.Getenv("IMAGE_BASE_URL"),
+			MaxBytes: int64(env.getEnvInt("IMAGE_MAX_BYTES", 10<<20)),
+			Timeout:  env.getEnvDuration("IMAGE_TIMEOUT", 30*time.Second),
+			Bucket:   getEnvDefault("IMAGE_BUCKET", "images"),
+			S3: S3Config{
+				Endpoint:  os.Getenv("S3_ENDPOINT"),
+				Region:    getEnvDefault("S3_REGION", "us-east-1"),
+				AccessKey: os.Getenv("S3_ACCESS_KEY_ID"),
+				SecretKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			},
+		},
+		BrowserMonitor: BrowserMonitorConfig{
+			Interval:      env.getEnvDuration("BROWSER_MONITOR_INTERVAL", 0),
+			MaxJSHeapMB:   env.getEnvFloat("BROWSER_MAX_JS_HEAP_MB", 0),
+			MaxPages:      env.getEnvInt("BROWSER_MAX_PAGES", 0),
+			MaxCPUPercent: env.getEnvFloat("BROWSER_MAX_CPU_PERCENT", 0),
+			Action:        getEnvDefault("BROWSER_LIMIT_ACTION", "log"),
+		},
+		Proxy: ProxyConfig{
+			URLs:     splitList(os.Getenv("PROXY_URLS")),
+			Rotation: getEnvDefault("PROXY_ROTATION", "round_robin"),
+		},
+		BlockResources: splitList(os.Getenv("BLOCK_RESOURCES")),
+		Stealth:        env.getEnvBool("STEALTH", true),
+		HTTPCache:      env.getEnvBool("HTTP_CACHE", false),
+		Robots: RobotsConfig{
+			Enabled:   env.getEnvBool("ROBOTS_TXT", false),
+			UserAgent: getEnvDefault("ROBOTS_USER_AGENT", "kultscraper"),
+			CacheTTL:  env.getEnvDuration("ROBOTS_CACHE_TTL", 24*time.Hour),
+		},
+		Retry: RetryConfig{
+			MaxAttempts:    env.getEnvInt("RETRY_MAX_ATTEMPTS", 3),
+			InitialBackoff: env.getEnvDuration("RETRY_BACKOFF", 2*time.Second),
+			MaxBackoff:     env.getEnvDuration("RETRY_MAX_BACKOFF", 30*time.Second),
+			Jitter:         env.getEnvFloat("RETRY_JITTER", 0.2),
+		},
+		Daemon: DaemonConfig{
+			Interval:      env.getEnvDuration("DAEMON_INTERVAL", time.Hour),
+			WatchInterval: env.getEnvDuration("DAEMON_WATCH_INTERVAL", 30*time.Second),
+		},
+		Screenshots: CaptureConfig{
+			Dir: getEnvDefault("SCREENSHOT_DIR", "screenshots"),
+			All: env.getEnvBool("SCREENSHOT_ALL", false),
+		},
+		Snapshots: CaptureConfig{
+			Dir: getEnvDefault("SNAPSHOT_DIR", "snapshots"),
+			All: env.getEnvBool("SNAPSHOT_ALL", false),
+		},
+		HAR: CaptureConfig{
+			Dir: os.Getenv("HAR_DIR"),
+			All: env.getEnvBool("HAR_ALL", false),
+		},
+		PDFs: CaptureConfig{
+			Dir: getEnvDefault("PDF_DIR", "pdfs"),
+			All: env.getEnvBool("PDF_ALL", false),
+		},
+		TagRules: os.Getenv("TAG_RULES_PATH"),
 		MongoDB: MongoDBConfig{
-			URI:            os.Getenv("MONGO_URI"),
-			Database:       os.Getenv("MONGODB_DATABASE"),
-			Collection:     os.Getenv("MONGODB_COLLECTION"),
-			Username:       os.Getenv("MONGODB_USERNAME"),
-			Password:       os.Getenv("MONGODB_PASSWORD"),
-			ConnectTimeout: connectTimeout,
+			URI:               os.Getenv("MONGO_URI"),
+			Database:          os.Getenv("MONGODB_DATABASE"),
+			Collection:        os.Getenv("MONGODB_COLLECTION"),
+			AuditCollection:   os.Getenv("MONGODB_AUDIT_COLLECTION"),
+			BudgetCollection:  os.Getenv("MONGODB_BUDGET_COLLECTION"),
+			HistoryCollection: os.Getenv("MONGODB_HISTORY_COLLECTION"),
+			EventsCollection:  os.Getenv("MONGODB_EVENTS_COLLECTION"),
+			RunsCollection:    os.Getenv("MONGODB_RUNS_COLLECTION"),
+			DLQCollection:     os.Getenv("MONGODB_DLQ_COLLECTION"),
+			Username:          os.Getenv("MONGODB_USERNAME"),
+			Password:          os.Getenv("MONGODB_PASSWORD"),
+			ConnectTimeout:    env.getEnvDuration("MONGODB_CONNECT_TIMEOUT", 10*time.Second),
+		},
+		Postgres: PostgresConfig{
+			DSN:    os.Getenv("POSTGRES_DSN"),
+			Driver: getEnvDefault("POSTGRES_DRIVER", "pgx"),
+			Table:  getEnvDefault("POSTGRES_TABLE", "scraping_results"),
+		},
+		SQLite: SQLiteConfig{
+			Path:   getEnvDefault("SQLITE_PATH", "kultscraper.db"),
+			Driver: getEnvDefault("SQLITE_DRIVER", "sqlite"),
+			Table:  getEnvDefault("SQLITE_TABLE", "scraping_results"),
 		},
-	}, nil
+		Plugins: PluginConfig{
+			Paths:     splitList(os.Getenv("PLUGIN_PATHS")),
+			Engine:    os.Getenv("PLUGIN_ENGINE"),
+			Enrichers: splitList(os.Getenv("PLUGIN_ENRICHERS")),
+			Sinks:     splitList(os.Getenv("PLUGIN_SINKS")),
+			Captcha:   os.Getenv("PLUGIN_CAPTCHA_SOLVER"),
+		},
+		Log: LogConfig{
+			Level:   os.Getenv("LOG_LEVEL"),
+			Format:  os.Getenv("LOG_FORMAT"),
+			Modules: moduleLevels,
+		},
+		Tracing: TracingConfig{
+			Endpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+			Headers:     os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"),
+			ServiceName: getEnvDefault("OTEL_SERVICE_NAME", "kultscraper"),
+			SampleRatio: env.getEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1),
+		},
+		Expiry: ExpiryConfig{
+			Fields:   splitList(getEnvDefault("EXPIRY_FIELDS", "Date,EndDate")),
+			Location: os.Getenv("EXPIRY_TIMEZONE"),
+		},
+		Translate: TranslateConfig{
+			Provider:   os.Getenv("TRANSLATE_PROVIDER"),
+			Endpoint:   os.Getenv("TRANSLATE_ENDPOINT"),
+			APIKey:     os.Getenv("TRANSLATE_API_KEY"),
+			SourceLang: os.Getenv("TRANSLATE_SOURCE_LANG"),
+			TargetLang: getEnvDefault("TRANSLATE_TARGET_LANG", "en"),
+			Fields:     splitList(os.Getenv("TRANSLATE_FIELDS")),
+		},
+	}
+	if len(env.errs) > 0 {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(env.errs...))
+	}
+	return cfg, nil
+}
+
+// getEnvDefault возвращает значение переменной окружения или значение по умолчанию
+func getEnvDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
 }
 
+// envReader читает типизированные переменные окружения и накапливает ошибки разбора,
+// чтобы неверное значение не подменялось молча значением по умолчанию
+type envReader struct {
+	errs []error
+}
+
+// lookup возвращает непустое значение переменной окружения
+func (e *envReader) lookup(key string) (string, bool) {
+	v := strings.TrimSpace(os.Getenv(key))
+	return v, v != ""
+}
+
+// getEnvBool возвращает булево значение переменной окружения или значение по умолчанию
+func (e *envReader) getEnvBool(key string, def bool) bool {
+	v, ok := e.lookup(key)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("invalid %s %q, expected true or false", key, v))
+		return def
+	}
+	return b
+}
+
+// getEnvDuration возвращает длительность из переменной окружения или значение по умолчанию
+func (e *envReader) getEnvDuration(key string, def time.Duration) time.Duration {
+	v, ok := e.lookup(key)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("invalid %s %q, expected duration like 30s", key, v))
+		return def
+	}
+	return d
+}
+
+// getEnvFloat возвращает число из переменной окружения или значение по умолчанию
+func (e *envReader) getEnvFloat(key string, def float64) float64 {
+	v, ok := e.lookup(key)
+	if !ok {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("invalid %s %q, expected number", key, v))
+		return def
+	}
+	return f
+}
+
+// getEnvInt возвращает целое число из переменной окружения или значение по умолчанию
+func (e *envReader) getEnvInt(key string, def int) int {
+	v, ok := e.lookup(key)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("invalid %s %q, expected integer", key, v))
+		return def
+	}
+	return n
+}
+
+// splitList разбивает строку со списком через запятую
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// LoadTasks читает и проверяет файл задач. Ошибки всех задач возвращаются вместе
+// как *ValidationError с номерами строк
 func LoadTasks(filePath string) ([]ScraperTask, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
+	return parseTasks(filePath, data)
+}
 
-	var configs []ScraperTask
+// parseTasks разбирает и проверяет JSON-массив задач и разворачивает шаблоны,
+// filePath используется в ошибках
+func parseTasks(filePath string, data []byte) ([]ScraperTask, error) {
+	_, tasks, err := parseTaskDefinitions(filePath, data, time.Now())
+	return tasks, err
+}
 
-	if err := json.Unmarshal(data, &configs); err != nil {
+// LoadTaskDefinitions читает файл задач без развертывания шаблонов, например
+// для изменения файла через API. Шаблоны проверяются развертыванием
+func LoadTaskDefinitions(filePath string) ([]ScraperTask, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
 		return nil, err
 	}
+	defs, _, err := parseTaskDefinitions(filePath, data, time.Now())
+	return defs, err
+}
+
+// parseTaskDefinitions возвращает описания задач из файла и задачи после развертывания шаблонов
+func parseTaskDefinitions(filePath string, data []byte, now time.Time) (defs, tasks []ScraperTask, err error) {
+	defs, lines, invalid, err := decodeTasks(filePath, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// origins - номер описания в файле для каждой развернутой задачи
+	var origins []int
+	for i, def := range defs {
+		expanded, err := ExpandTask(def, now)
+		if err != nil {
+			invalid = append(invalid, &TaskError{Index: i, Line: lines[i], Name: def.Name, Err: err})
+			continue
+		}
+		tasks = append(tasks, expanded...)
+		for range expanded {
+			origins = append(origins, i)
+		}
+	}
 
-	return configs, nil
+	if errs := validateTasks(tasks, origins, lines); len(errs) > 0 {
+		invalid = append(invalid, errs...)
+	}
+	if len(invalid) > 0 {
+		sort.SliceStable(invalid, func(i, j int) bool { return invalid[i].Index < invalid[j].Index })
+		return nil, nil, &ValidationError{Path: filePath, Errors: invalid}
+	}
+
+	return defs, tasks, nil
 }
 
 type ScraperTask struct {
-	URL       string            `json:"URL"`
-	Type      string            `json:"Type"`
-	Name      string            `json:"Name"`
-	Selectors map[string]string `json:"Selectors"`
+	URL               string                      `json:"URL"`
+	Project           string                      `json:"Project,omitempty"` // Проект (тенант), к которому относятся задача и ее результаты
+	Type              string                      `json:"Type"`
+	Name              string                      `json:"Name"`
+	Mode              string                      `json:"Mode,omitempty"`         // fields (по умолчанию) или items
+	ItemSelector      string                      `json:"ItemSelector,omitempty"` // Контейнер записи в режиме items, Selectors ищутся внутри него
+	Selectors         map[string]string           `json:"Selectors"`
+	SelectorType      string                      `json:"SelectorType,omitempty"`     // css (по умолчанию) или xpath, селектор можно переопределить префиксом "xpath:"/"css:"
+	Extract           map[string]string           `json:"Extract,omitempty"`          // Режим извлечения по ключу: text (по умолчанию), html или attr:<имя>
+	NextPageSelector  string                      `json:"NextPageSelector,omitempty"` // Ссылка или кнопка перехода на следующую страницу списка
+	MaxPages          int                         `json:"MaxPages,omitempty"`         // Предел страниц при пагинации, по умолчанию DefaultMaxPages
+	Actions           []TaskAction                `json:"Actions,omitempty"`          // Действия на странице перед извлечением
+	Login             *LoginConfig                `json:"Login,omitempty"`            // Вход на сайт, сессия переиспользуется задачами того же домена
+	Auth              *AuthConfig                 `json:"Auth,omitempty"`             // Авторизация запросов к сайту: basic, bearer или ключ API
+	Proxy             string                      `json:"Proxy,omitempty"`            // Прокси задачи: пусто - общий список, "direct" - без прокси, иначе адрес прокси
+	Block             []string                    `json:"Block,omitempty"`            // Блокируемые запросы: image, media, font, stylesheet, third_party; пустой список отключает общий
+	Headers           map[string]string           `json:"Headers,omitempty"`          // Дополнительные заголовки запросов к сайту задачи: Referer, Accept-Language, X-Requested-With
+	Stealth           *StealthConfig              `json:"Stealth,omitempty"`          // Маскировка браузера для задачи, по умолчанию STEALTH
+	Wait              *WaitConfig                 `json:"Wait,omitempty"`             // Ожидание отрисовки после загрузки каждой страницы
+	Waits             map[string]WaitConfig       `json:"Waits,omitempty"`            // Ожидание перед извлечением ключа, пустой Selector - селектор самого ключа
+	Screenshot        bool                        `json:"Screenshot,omitempty"`       // Сохранять снимок всей страницы после загрузки
+	Snapshot          bool                        `json:"Snapshot,omitempty"`         // Сохранять отрисованный HTML страницы
+	HAR               bool                        `json:"HAR,omitempty"`              // Записывать сетевые запросы страницы в HAR, если задан HAR_DIR или флаг -har
+	PDF               bool                        `json:"PDF,omitempty"`              // Сохранять PDF-копию страницы для архива
+	Priority          string                      `json:"Priority,omitempty"`         // Приоритет в очереди: low, normal (по умолчанию) или high
+	Schedule          string                      `json:"Schedule,omitempty"`         // Cron-выражение повторного запуска ("0 */6 * * *"), пусто - однократный запуск
+	Tags              []string                    `json:"Tags,omitempty"`
+	Transforms        map[string][]FieldTransform `json:"Transforms,omitempty"` // Обработка значений ключа после извлечения, шаги выполняются по порядку
+	Derived           map[string]string           `json:"Derived,omitempty"`
+	Types             map[string]string           `json:"Types,omitempty"`             // Тип значения ключа или вычисляемого поля: date, price, number или image
+	DateLayouts       []string                    `json:"DateLayouts,omitempty"`       // Форматы дат Go ("02.01.2006 15:04"), проверяются до встроенного распознавания
+	Timezone          string                      `json:"Timezone,omitempty"`          // Часовой пояс IANA дат задачи, по умолчанию локальный
+	Script            string                      `json:"Script,omitempty"`            // Тело JS-функции для нестандартного извлечения, выполняется над HTML страницы без браузера
+	Engine            string                      `json:"Engine,omitempty"`            // Движок: rod, http или auto (http, при пустом результате - rod), по умолчанию PLUGIN_ENGINE или rod
+	Sitemap           *SitemapConfig              `json:"Sitemap,omitempty"`           // URL задачи - sitemap.xml, селекторы применяются к каждой найденной странице
+	Crawl             *CrawlConfig                `json:"Crawl,omitempty"`             // Обход ссылок со страницы задачи, селекторы применяются к подходящим страницам
+	Vars              map[string]string           `json:"Vars,omitempty"`              // Переменные шаблона: строковые поля задачи могут содержать {{.name}}
+	Matrix            map[string][]string         `json:"Matrix,omitempty"`            // Значения переменных, задача разворачивается в каждое их сочетание
+	Timeout           string                      `json:"Timeout,omitempty"`           // Таймаут попытки ("90s"), по умолчанию SCRAPER_TIMEOUT
+	NavigationTimeout string                      `json:"NavigationTimeout,omitempty"` // Таймаут загрузки страницы, по умолчанию NAVIGATION_TIMEOUT
+	SelectorTimeout   string                      `json:"SelectorTimeout,omitempty"`   // Таймаут поиска элементов, по умолчанию SELECTOR_TIMEOUT
+	Event             *EventMapping               `json:"Event,omitempty"`             // Сохранять события из результата в коллекцию событий
+	MaxConcurrency    int                         `json:"MaxConcurrency,omitempty"`    // Одновременных задач на хост задачи, по умолчанию SOURCE_CONCURRENCY
+	Quality           *QualityRules               `json:"Quality,omitempty"`           // Проверка результата перед сохранением
+}
+
+// Синтаксис селекторов задачи
+const (
+	SelectorCSS   = "css"
+	SelectorXPath = "xpath"
+)
+
+// Режимы извлечения значения из элемента
+const (
+	ExtractText       = "text"
+	ExtractHTML       = "html"
+	ExtractAttrPrefix = "attr:"
+)
+
+// SitemapConfig - отбор страниц sitemap для задачи
+type SitemapConfig struct {
+	Match   string `json:"Match,omitempty"`   // Регулярное выражение адресов страниц, пусто - все страницы
+	MaxURLs int    `json:"MaxURLs,omitempty"` // Предел страниц, по умолчанию DefaultSitemapMaxURLs
+}
+
+// DefaultSitemapMaxURLs - предел страниц sitemap, если MaxURLs не задан
+const DefaultSitemapMaxURLs = 500
+
+// URLLimit возвращает предел страниц sitemap
+func (s SitemapConfig) URLLimit() int {
+	if s.MaxURLs > 0 {
+		return s.MaxURLs
+	}
+	return DefaultSitemapMaxURLs
+}
+
+// StealthConfig - профиль маскировки браузера для задачи. Незаданные поля не меняют страницу
+type StealthConfig struct {
+	Enabled       *bool  `json:"Enabled,omitempty"`       // Скрипт go-rod/stealth, по умолчанию STEALTH
+	HideWebdriver bool   `json:"HideWebdriver,omitempty"` // Скрывать navigator.webdriver без остального скрипта stealth
+	Noise         bool   `json:"Noise,omitempty"`         // Шум в данных canvas и WebGL против отпечатков
+	Timezone      string `json:"Timezone,omitempty"`      // Часовой пояс IANA, например "Europe/Moscow"
+	Locale        string `json:"Locale,omitempty"`        // Локаль и Accept-Language, например "ru-RU"
+	Viewport      string `json:"Viewport,omitempty"`      // Размер окна "1366x768"
+}
+
+// StealthEnabled сообщает, применяется ли скрипт stealth к задаче с профилем s
+func (s *StealthConfig) StealthEnabled(def bool) bool {
+	if s == nil || s.Enabled == nil {
+		return def
+	}
+	return *s.Enabled
+}
+
+// ViewportSize разбирает Viewport, ok - размер задан и корректен
+func (s *StealthConfig) ViewportSize() (width, height int, ok bool) {
+	if s == nil || s.Viewport == "" {
+		return 0, 0, false
+	}
+	w, h, found := strings.Cut(strings.ToLower(s.Viewport), "x")
+	if !found {
+		return 0, 0, false
+	}
+	width, errW := strconv.Atoi(strings.TrimSpace(w))
+	height, errH := strconv.Atoi(strings.TrimSpace(h))
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// WaitConfig - ожидание перед извлечением для страниц, которые отрисовываются после загрузки.
+// Заданные условия проверяются по порядку: элемент, тишина в сети, пауза
+type WaitConfig struct {
+	Selector    string `json:"Selector,omitempty"`    // Элемент, появления которого ждать
+	NetworkIdle bool   `json:"NetworkIdle,omitempty"` // Ждать, пока запросы не прекратятся на DefaultNetworkIdle
+	Delay       string `json:"Delay,omitempty"`       // Фиксированная пауза ("2s")
+	Timeout     string `json:"Timeout,omitempty"`     // Предел ожидания элемента и сети, по умолчанию таймаут селекторов
+}
+
+// DefaultNetworkIdle - длительность без сетевых запросов, после которой страница считается загруженной
+const DefaultNetworkIdle = 500 * time.Millisecond
+
+// DelayDuration возвращает паузу ожидания. Неверные значения отсекаются при загрузке задач
+func (w WaitConfig) DelayDuration() time.Duration {
+	d, _ := time.ParseDuration(w.Delay)
+	return max(d, 0)
+}
+
+// TimeoutOr возвращает предел ожидания или def, если он не задан
+func (w WaitConfig) TimeoutOr(def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(w.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
+
+// CrawlConfig - обход ссылок начиная со страницы задачи
+type CrawlConfig struct {
+	Follow   string `json:"Follow,omitempty"`   // Регулярное выражение ссылок для перехода, пусто - все ссылки
+	Match    string `json:"Match,omitempty"`    // Регулярное выражение страниц для селекторов, пусто - все посещенные страницы
+	MaxDepth int    `json:"MaxDepth,omitempty"` // Глубина переходов от страницы задачи, по умолчанию 1
+	MaxPages int    `json:"MaxPages,omitempty"` // Предел посещенных страниц, по умолчанию DefaultCrawlMaxPages
+	External bool   `json:"External,omitempty"` // Переходить по ссылкам на другие хосты
+}
+
+// DefaultCrawlMaxPages - предел посещенных страниц обхода, если MaxPages не задан
+const DefaultCrawlMaxPages = 100
+
+// Depth возвращает глубину обхода
+func (c CrawlConfig) Depth() int {
+	if c.MaxDepth > 0 {
+		return c.MaxDepth
+	}
+	return 1
+}
+
+// PageLimit возвращает предел посещенных страниц
+func (c CrawlConfig) PageLimit() int {
+	if c.MaxPages > 0 {
+		return c.MaxPages
+	}
+	return DefaultCrawlMaxPages
+}
+
+// EventMapping - ключи результата для полей события. Незаданное поле берется из
+// одноименного ключа в нижнем регистре (title, start, venue...). В режиме items
+// событие создается из каждой записи
+type EventMapping struct {
+	Title       string `json:"Title,omitempty"`
+	Description string `json:"Description,omitempty"`
+	Start       string `json:"Start,omitempty"`
+	End         string `json:"End,omitempty"`
+	Venue       string `json:"Venue,omitempty"`
+	Address     string `json:"Address,omitempty"`
+	Price       string `json:"Price,omitempty"`
+	Link        string `json:"Link,omitempty"`
+	Image       string `json:"Image,omitempty"`
+	Category    string `json:"Category,omitempty"`
+	// DefaultCategory - категория событий задачи без ключа категории ("cinema", "theatre")
+	DefaultCategory string `json:"DefaultCategory,omitempty"`
+}
+
+// QualityRules - проверка заполненности результата. Защищает от сохранения пустых
+// значений, когда сайт меняет разметку и селекторы перестают находить элементы
+type QualityRules struct {
+	Required    []string           `json:"Required,omitempty"`    // Ключи, которые не могут быть пустыми, в режиме items - ни в одной записи
+	MinFillRate float64            `json:"MinFillRate,omitempty"` // Доля непустых значений Selectors от 0 до 1, в режиме items - по всем записям
+	FillRates   map[string]float64 `json:"FillRates,omitempty"`   // Доля записей с непустым значением ключа от 0 до 1
+	MinItems    int                `json:"MinItems,omitempty"`    // Наименьшее число записей в режиме items
+	OnFail      string             `json:"OnFail,omitempty"`      // flag (по умолчанию) - сохранить с отметкой invalid, reject - не сохранять
+}
+
+// Действия при непрошедшей проверке результата
+const (
+	QualityFlag   = "flag"
+	QualityReject = "reject"
+)
+
+// Rejects сообщает, отклоняется ли результат, не прошедший проверку
+func (q *QualityRules) Rejects() bool {
+	return q != nil && strings.ToLower(q.OnFail) == QualityReject
+}
+
+// TaskAction - действие на странице перед запуском селекторов
+type TaskAction struct {
+	Type     string `json:"Type"`               // click, hover, wait, press или select
+	Selector string `json:"Selector,omitempty"` // Элемент действия, для wait - элемент, появления которого ждать
+	Value    string `json:"Value,omitempty"`    // Клавиша для press, значение опции для select
+	Ms       int    `json:"Ms,omitempty"`       // Пауза для wait или таймаут ожидания элемента
+	Optional bool   `json:"Optional,omitempty"` // Не считать ошибкой отсутствие элемента
+}
+
+// LoginConfig - вход через форму на сайте. Учетные данные берутся из переменных окружения
+type LoginConfig struct {
+	URL              string `json:"URL"`                       // Страница с формой входа
+	UsernameSelector string `json:"UsernameSelector"`          // Поле логина
+	PasswordSelector string `json:"PasswordSelector"`          // Поле пароля
+	SubmitSelector   string `json:"SubmitSelector,omitempty"`  // Кнопка отправки, без нее форма отправляется клавишей Enter
+	SuccessSelector  string `json:"SuccessSelector,omitempty"` // Элемент, появляющийся после успешного входа
+	UsernameEnv      string `json:"UsernameEnv"`               // Переменная окружения с логином
+	PasswordEnv      string `json:"PasswordEnv"`               // Переменная окружения с паролем
+}
+
+// Credentials возвращает логин и пароль из переменных окружения
+func (l LoginConfig) Credentials() (string, string, error) {
+	username, password := os.Getenv(l.UsernameEnv), os.Getenv(l.PasswordEnv)
+	if username == "" || password == "" {
+		return "", "", fmt.Errorf("login credentials are not set: %s, %s", l.UsernameEnv, l.PasswordEnv)
+	}
+	return username, password, nil
+}
+
+// Типы действий задачи
+const (
+	ActionClick  = "click"
+	ActionHover  = "hover"
+	ActionWait   = "wait"
+	ActionPress  = "press"
+	ActionSelect = "select"
+)
+
+// FieldTransform - шаг обработки извлеченного значения. Несколько совпадений
+// ключа хранятся через перевод строки, построчные шаги обрабатывают каждое отдельно
+type FieldTransform struct {
+	Type    string `json:"Type"`              // trim, lower, upper, strip_html, regex_extract, regex_replace, split или join
+	Pattern string `json:"Pattern,omitempty"` // Регулярное выражение для regex_extract и regex_replace
+	Value   string `json:"Value,omitempty"`   // Замена для regex_replace, разделитель для split и join
+}
+
+// Шаги обработки значений
+const (
+	TransformTrim         = "trim"          // Обрезать пробелы в каждой строке и убрать пустые строки
+	TransformLower        = "lower"         // Нижний регистр
+	TransformUpper        = "upper"         // Верхний регистр
+	TransformStripHTML    = "strip_html"    // Удалить теги и раскрыть HTML-сущности
+	TransformRegexExtract = "regex_extract" // Оставить первую группу совпадения (или все совпадение) в каждой строке
+	TransformRegexReplace = "regex_replace" // Заменить совпадения на Value, допускаются ссылки $1
+	TransformSplit        = "split"         // Разбить значение по Value на отдельные строки
+	TransformJoin         = "join"          // Объединить строки через Value, по умолчанию ", "
+)
+
+// Типы значений ключей
+const (
+	TypeDate   = "date"   // Дата и время, сохраняются в Metadata.Dates результата
+	TypePrice  = "price"  // Цена с валютой ("от 500 ₽"), сохраняется в Metadata.Prices
+	TypeNumber = "number" // Число, сохраняется в Metadata.Numbers
+	TypeImage  = "image"  // Ссылка на изображение, файл скачивается в IMAGE_STORE, ссылка на копию - в Metadata.Images
+)
+
+// Режимы извлечения задачи
+const (
+	ModeFields = "fields" // Одно значение на ключ, совпадения объединяются переводом строки
+	ModeItems  = "items"  // Запись на каждый контейнер ItemSelector
+)
+
+// DefaultMaxPages - предел страниц пагинации, если MaxPages не задан
+const DefaultMaxPages = 10
+
+// ItemsMode сообщает, извлекает ли задача список записей
+func (t ScraperTask) ItemsMode() bool {
+	return strings.EqualFold(t.Mode, ModeItems)
+}
+
+// PageLimit возвращает число страниц, которые нужно обойти
+func (t ScraperTask) PageLimit() int {
+	if t.NextPageSelector == "" {
+		return 1
+	}
+	if t.MaxPages > 0 {
+		return t.MaxPages
+	}
+	return DefaultMaxPages
+}
+
+// Timeouts возвращает таймауты задачи, незаданные берутся из defaults.
+// Неверные значения отсекаются при загрузке задач
+func (t ScraperTask) Timeouts(defaults TaskTimeouts) TaskTimeouts {
+	override := func(value string, def time.Duration) time.Duration {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+		return def
+	}
+	return TaskTimeouts{
+		Scrape:     override(t.Timeout, cmp.Or(defaults.Scrape, DefaultTaskTimeouts.Scrape)),
+		Navigation: override(t.NavigationTimeout, cmp.Or(defaults.Navigation, DefaultTaskTimeouts.Navigation)),
+		Selector:   override(t.SelectorTimeout, cmp.Or(defaults.Selector, DefaultTaskTimeouts.Selector)),
+	}
+}
+
+// Location возвращает часовой пояс дат задачи.
+// Неверные значения отсекаются при загрузке задач
+func (t ScraperTask) Location() *time.Location {
+	if t.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// Fingerprint возвращает хеш конфигурации задачи для отслеживания изменений
+func (t ScraperTask) Fingerprint() string {
+	data, _ := json.Marshal(t)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// TasksFingerprint возвращает общий хеш конфигурации набора задач
+func TasksFingerprint(tasks []ScraperTask) string {
+	h := sha256.New()
+	for _, t := range tasks {
+		h.Write([]byte(t.Fingerprint()))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
 }
@@ -0,0 +1,216 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TaskSource - источник описаний задач. Fetch возвращает JSON-массив задач
+// в формате файла задач, разбор и проверка общие для всех источников
+type TaskSource interface {
+	Fetch(ctx context.Context) ([]byte, error)
+	String() string
+}
+
+// TaskSourceFactory создает источник по ссылке CONFIG_PATH
+type TaskSourceFactory func(ref string) (TaskSource, error)
+
+// ErrUnknownTaskSource - схема ссылки на задачи не зарегистрирована
+var ErrUnknownTaskSource = errors.New("unknown task source")
+
+// MaxTaskSourceSize - предел размера задач, получаемых из удаленного источника
+const MaxTaskSourceSize = 32 << 20
+
+var (
+	taskSourcesMu sync.RWMutex
+	taskSources   = map[string]TaskSourceFactory{
+		"file":  func(ref string) (TaskSource, error) { return FileSource{Path: strings.TrimPrefix(ref, "file://")}, nil },
+		"http":  newHTTPSource,
+		"https": newHTTPSource,
+	}
+)
+
+// RegisterTaskSource регистрирует источник задач для схемы ссылки (например "mongodb")
+func RegisterTaskSource(scheme string, factory TaskSourceFactory) {
+	taskSourcesMu.Lock()
+	defer taskSourcesMu.Unlock()
+	taskSources[strings.ToLower(scheme)] = factory
+}
+
+// NewTaskSource создает источник задач по ссылке: путь к файлу, http(s)-адрес
+// или ссылка со схемой, зарегистрированной через RegisterTaskSource
+func NewTaskSource(ref string) (TaskSource, error) {
+	scheme := sourceScheme(ref)
+	if scheme == "" {
+		return FileSource{Path: ref}, nil
+	}
+
+	taskSourcesMu.RLock()
+	factory, ok := taskSources[scheme]
+	taskSourcesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTaskSource, scheme)
+	}
+	return factory(ref)
+}
+
+// IsLocalTaskSource сообщает, что ссылка указывает на локальный файл задач
+func IsLocalTaskSource(ref string) bool {
+	scheme := sourceScheme(ref)
+	return scheme == "" || scheme == "file"
+}
+
+// sourceScheme возвращает схему ссылки, пустую для путей к файлам (в том числе "C:\tasks.json")
+func sourceScheme(ref string) string {
+	scheme, _, ok := strings.Cut(ref, "://")
+	if !ok || strings.ContainsAny(scheme, `/\`) {
+		return ""
+	}
+	return strings.ToLower(scheme)
+}
+
+// FileSource читает задачи из локального файла
+type FileSource struct {
+	Path string
+}
+
+func (s FileSource) Fetch(ctx context.Context) ([]byte, error) {
+	return os.ReadFile(s.Path)
+}
+
+func (s FileSource) String() string { return s.Path }
+
+// HTTPSource загружает задачи по http(s)
+type HTTPSource struct {
+	URL    string
+	Client *http.Client
+}
+
+func newHTTPSource(ref string) (TaskSource, error) {
+	u, err := url.Parse(ref)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid task source URL %q", ref)
+	}
+	return HTTPSource{URL: ref, Client: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+func (s HTTPSource) Fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %s", s, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxTaskSourceSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxTaskSourceSize {
+		return nil, fmt.Errorf("%s: tasks exceed %d bytes", s, MaxTaskSourceSize)
+	}
+	return data, nil
+}
+
+// String возвращает адрес без пароля
+func (s HTTPSource) String() string { return RedactURL(s.URL) }
+
+// CachedSource сохраняет полученные задачи в локальный файл и читает их из него,
+// если удаленный источник недоступен
+type CachedSource struct {
+	Source       TaskSource
+	Path         string
+	OnFallback   func(err error) // Источник недоступен, задачи прочитаны из кэша
+	OnCacheError func(err error) // Не удалось обновить кэш, задачи источника возвращены
+}
+
+// NewCachedSource кэширует задачи источника в каталоге dir
+func NewCachedSource(source TaskSource, dir string) *CachedSource {
+	sum := sha256.Sum256([]byte(source.String()))
+	return &CachedSource{Source: source, Path: filepath.Join(dir, hex.EncodeToString(sum[:8])+".json")}
+}
+
+func (s *CachedSource) Fetch(ctx context.Context) ([]byte, error) {
+	data, err := s.Source.Fetch(ctx)
+	if err == nil {
+		if cacheErr := writeFileAtomic(s.Path, data); cacheErr != nil && s.OnCacheError != nil {
+			s.OnCacheError(cacheErr)
+		}
+		return data, nil
+	}
+
+	cached, cacheErr := os.ReadFile(s.Path)
+	if cacheErr != nil {
+		return nil, err
+	}
+	if s.OnFallback != nil {
+		s.OnFallback(err)
+	}
+	return cached, nil
+}
+
+func (s *CachedSource) String() string { return s.Source.String() }
+
+// LoadTasksFrom загружает и проверяет задачи из источника. Ошибки всех задач
+// возвращаются вместе как *ValidationError
+func LoadTasksFrom(ctx context.Context, source TaskSource) ([]ScraperTask, error) {
+	data, err := source.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseTasks(source.String(), data)
+}
+
+// writeFileAtomic записывает файл через временный, чтобы читатели не видели его частично
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// RedactURL скрывает пароль в ссылке для логов и сообщений об ошибках.
+// Пути к файлам возвращаются без изменений
+func RedactURL(ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.Redacted()
+}
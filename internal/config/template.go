@@ -0,0 +1,220 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Поля задачи, которые не разворачиваются: Derived - шаблоны, вычисляемые после
+// скрапинга, Script - JavaScript и может содержать "{{", Vars и Matrix
+// разворачиваются отдельно
+var templateSkipFields = []string{"Derived", "Script", "Vars", "Matrix"}
+
+// IsTemplate сообщает, что задача содержит переменные или матрицу и перед запуском
+// должна быть развернута через ExpandTask
+func (t ScraperTask) IsTemplate() bool {
+	if len(t.Vars) > 0 || len(t.Matrix) > 0 {
+		return true
+	}
+	found := false
+	_, _ = mapTaskStrings(t, func(s string) (string, error) {
+		found = found || strings.Contains(s, "{{")
+		return s, nil
+	})
+	return found
+}
+
+// ExpandTask разворачивает шаблон задачи: строковые поля выполняются как text/template
+// с переменными Vars, встроенными .date, .month, .year и значениями Matrix.
+// Matrix порождает задачу на каждое сочетание значений. Задача без шаблонов возвращается как есть
+func ExpandTask(t ScraperTask, now time.Time) ([]ScraperTask, error) {
+	if !t.IsTemplate() {
+		return []ScraperTask{t}, nil
+	}
+
+	funcs := templateFuncs(now)
+	builtins := map[string]string{
+		"date":  now.Format(time.DateOnly),
+		"month": now.Format("2006-01"),
+		"year":  strconv.Itoa(now.Year()),
+	}
+
+	// Переменные могут ссылаться на встроенные значения и окружение
+	vars := maps.Clone(builtins)
+	for _, name := range slices.Sorted(maps.Keys(t.Vars)) {
+		value, err := renderTemplate("Vars."+name, t.Vars[name], funcs, vars)
+		if err != nil {
+			return nil, err
+		}
+		vars[name] = value
+	}
+
+	combos, err := matrixCombos(t.Matrix, funcs, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]ScraperTask, 0, len(combos))
+	for _, combo := range combos {
+		data := maps.Clone(vars)
+		maps.Copy(data, combo)
+
+		task, err := renderTask(t, funcs, data)
+		if err != nil {
+			if len(combo) > 0 {
+				return nil, fmt.Errorf("matrix %v: %w", combo, err)
+			}
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// ExpandTasks разворачивает все шаблоны списка задач
+func ExpandTasks(tasks []ScraperTask, now time.Time) ([]ScraperTask, error) {
+	var expanded []ScraperTask
+	for _, t := range tasks {
+		concrete, err := ExpandTask(t, now)
+		if err != nil {
+			return nil, fmt.Errorf("task %s: %w", t.Name, err)
+		}
+		expanded = append(expanded, concrete...)
+	}
+	return expanded, nil
+}
+
+// templateFuncs - функции шаблонов задач:
+// env "NAME", query и path экранируют значение для адреса,
+// day N - дата через N дней (2006-01-02), month N - месяц через N месяцев (2006-01)
+func templateFuncs(now time.Time) template.FuncMap {
+	return template.FuncMap{
+		"env":   os.Getenv,
+		"query": url.QueryEscape,
+		"path":  url.PathEscape,
+		"day": func(offset int) string {
+			return now.AddDate(0, 0, offset).Format(time.DateOnly)
+		},
+		"month": func(offset int) string {
+			first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+			return first.AddDate(0, offset, 0).Format("2006-01")
+		},
+	}
+}
+
+// matrixCombos возвращает все сочетания значений матрицы в порядке имен переменных
+func matrixCombos(matrix map[string][]string, funcs template.FuncMap, vars map[string]string) ([]map[string]string, error) {
+	combos := []map[string]string{{}}
+	for _, name := range slices.Sorted(maps.Keys(matrix)) {
+		values := matrix[name]
+		if len(values) == 0 {
+			return nil, fmt.Errorf("Matrix[%q] has no values", name)
+		}
+
+		next := make([]map[string]string, 0, len(combos)*len(values))
+		for _, combo := range combos {
+			for i, raw := range values {
+				value, err := renderTemplate(fmt.Sprintf("Matrix.%s[%d]", name, i), raw, funcs, vars)
+				if err != nil {
+					return nil, err
+				}
+				c := maps.Clone(combo)
+				c[name] = value
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+	return combos, nil
+}
+
+// renderTask выполняет шаблоны во всех строковых полях задачи, кроме templateSkipFields
+func renderTask(t ScraperTask, funcs template.FuncMap, data map[string]string) (ScraperTask, error) {
+	rendered, err := mapTaskStrings(t, func(s string) (string, error) {
+		if !strings.Contains(s, "{{") {
+			return s, nil
+		}
+		return renderTemplate(s, s, funcs, data)
+	})
+	if err != nil {
+		return ScraperTask{}, err
+	}
+
+	rendered.Derived = t.Derived
+	rendered.Script = t.Script
+	rendered.Vars = nil
+	rendered.Matrix = nil
+	return rendered, nil
+}
+
+// mapTaskStrings возвращает копию задачи, в которой к строковым значениям
+// применена fn. Поля templateSkipFields в копии пусты
+func mapTaskStrings(t ScraperTask, fn func(string) (string, error)) (ScraperTask, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return ScraperTask{}, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return ScraperTask{}, err
+	}
+	for _, name := range templateSkipFields {
+		delete(fields, name)
+	}
+
+	walked, err := walkStrings(fields, fn)
+	if err != nil {
+		return ScraperTask{}, err
+	}
+	if data, err = json.Marshal(walked); err != nil {
+		return ScraperTask{}, err
+	}
+	var mapped ScraperTask
+	err = json.Unmarshal(data, &mapped)
+	return mapped, err
+}
+
+func walkStrings(v any, fn func(string) (string, error)) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return fn(v)
+	case map[string]any:
+		for key, item := range v {
+			walked, err := walkStrings(item, fn)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = walked
+		}
+	case []any:
+		for i, item := range v {
+			walked, err := walkStrings(item, fn)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = walked
+		}
+	}
+	return v, nil
+}
+
+// renderTemplate выполняет шаблон, неизвестная переменная считается ошибкой
+func renderTemplate(name, text string, funcs template.FuncMap, data map[string]string) (string, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
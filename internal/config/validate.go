@@ -0,0 +1,584 @@
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/lib/work"
+	"github.com/rx3lixir/kultscraper/internal/scheduler"
+)
+
+// TaskError - ошибка в описании одной задачи
+type TaskError struct {
+	Index int    // Номер задачи в файле, с 0
+	Line  int    // Строка начала задачи в файле, 0 - неизвестна
+	Name  string // Имя задачи, если удалось прочитать
+	Err   error
+}
+
+func (e *TaskError) Error() string {
+	var b strings.Builder
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", e.Line)
+	}
+	fmt.Fprintf(&b, "task %d", e.Index)
+	if e.Name != "" {
+		fmt.Fprintf(&b, " (%s)", e.Name)
+	}
+	// Ошибки одной задачи выводятся в одну строку
+	fmt.Fprintf(&b, ": %s", strings.ReplaceAll(e.Err.Error(), "\n", "; "))
+	return b.String()
+}
+
+func (e *TaskError) Unwrap() error { return e.Err }
+
+// ValidationError собирает все ошибки файла задач, чтобы их можно было исправить за один проход
+type ValidationError struct {
+	Path   string
+	Errors []*TaskError
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Errors)+1)
+	lines = append(lines, fmt.Sprintf("%s: %d invalid task(s)", e.Path, len(e.Errors)))
+	for _, err := range e.Errors {
+		lines = append(lines, "  "+e.Path+": "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// ErrDuplicateTask - задача с тем же проектом, URL и типом уже описана
+var ErrDuplicateTask = errors.New("duplicate task")
+
+// taskEngines - допустимые значения Engine задачи
+var taskEngines = []string{"rod", "http", "auto"}
+
+// valueTypes - допустимые значения Types задачи
+var valueTypes = []string{TypeDate, TypePrice, TypeNumber, TypeImage}
+
+// decodeTasks разбирает массив задач и возвращает номера строк, с которых начинаются задачи.
+// Неизвестные поля и неверные типы значений возвращаются как ошибки задач, остальные
+// поля таких задач заполняются, чтобы проверить их вместе с остальными
+func decodeTasks(path string, data []byte) ([]ScraperTask, []int, []*TaskError, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: %w", path, syntaxPosition(data, err))
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, nil, nil, fmt.Errorf("%s: tasks file must contain a JSON array", path)
+	}
+
+	var (
+		tasks   []ScraperTask
+		lines   []int
+		invalid []*TaskError
+	)
+	for dec.More() {
+		line := lineAt(data, int(dec.InputOffset()))
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: %w", path, syntaxPosition(data, err))
+		}
+
+		var task ScraperTask
+		strict := json.NewDecoder(bytes.NewReader(raw))
+		strict.DisallowUnknownFields()
+		if err := strict.Decode(&task); err != nil {
+			invalid = append(invalid, &TaskError{Index: len(tasks), Line: line, Name: task.Name, Err: err})
+		}
+		tasks = append(tasks, task)
+		lines = append(lines, line)
+	}
+
+	return tasks, lines, invalid, nil
+}
+
+// lineAt возвращает строку, на которой начинается значение после offset
+// (пропуская пробелы и запятую)
+func lineAt(data []byte, offset int) int {
+	for offset < len(data) && strings.IndexByte(" \t\r\n,", data[offset]) >= 0 {
+		offset++
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// syntaxPosition дополняет синтаксическую ошибку JSON номером строки
+func syntaxPosition(data []byte, err error) error {
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		return fmt.Errorf("line %d: %w", lineAt(data, int(syntax.Offset)-1), err)
+	}
+	return err
+}
+
+// ValidateTasks проверяет каждую задачу и повторы (проект, URL, тип).
+// lines - строки начала задач в файле path, может быть nil
+func ValidateTasks(path string, tasks []ScraperTask, lines []int) error {
+	origins := make([]int, len(tasks))
+	for i := range origins {
+		origins[i] = i
+	}
+	if invalid := validateTasks(tasks, origins, lines); len(invalid) > 0 {
+		return &ValidationError{Path: path, Errors: invalid}
+	}
+	return nil
+}
+
+// validateTasks проверяет развернутые задачи. origins - номер описания в файле
+// для каждой задачи, ошибки относятся к описаниям
+func validateTasks(tasks []ScraperTask, origins, lines []int) []*TaskError {
+	lineOf := func(i int) int {
+		if i < len(lines) {
+			return lines[i]
+		}
+		return 0
+	}
+
+	var invalid []*TaskError
+	seen := make(map[[3]string]int, len(tasks))
+	for i, t := range tasks {
+		origin := origins[i]
+		if err := validateTask(t); err != nil {
+			invalid = append(invalid, &TaskError{Index: origin, Line: lineOf(origin), Name: t.Name, Err: err})
+		}
+
+		key := [3]string{t.Project, t.URL, t.Type}
+		if first, ok := seen[key]; ok && t.URL != "" {
+			err := fmt.Errorf("%w: same URL and Type as task %d", ErrDuplicateTask, first)
+			if line := lineOf(first); line > 0 {
+				err = fmt.Errorf("%w: same URL and Type as task %d at line %d", ErrDuplicateTask, first, line)
+			}
+			invalid = append(invalid, &TaskError{Index: origin, Line: lineOf(origin), Name: t.Name, Err: err})
+			continue
+		}
+		seen[key] = origin
+	}
+	return invalid
+}
+
+// ValidateTask проверяет описание задачи и возвращает все найденные ошибки.
+// Шаблон проверяется по каждой задаче, в которую он разворачивается
+func ValidateTask(t ScraperTask) error {
+	if !t.IsTemplate() {
+		return validateTask(t)
+	}
+	tasks, err := ExpandTask(t, time.Now())
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, task := range tasks {
+		if err := validateTask(task); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateTask(t ScraperTask) error {
+	var errs []error
+
+	if t.URL == "" {
+		errs = append(errs, errors.New("URL is required"))
+	} else if u, err := url.Parse(t.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("invalid URL %q: expected absolute http(s) URL", t.URL))
+	}
+	if t.Type == "" {
+		errs = append(errs, errors.New("Type is required"))
+	}
+
+	if len(t.Selectors) == 0 && t.Script == "" {
+		errs = append(errs, errors.New("Selectors or Script is required"))
+	}
+	for _, key := range slices.Sorted(maps.Keys(t.Selectors)) {
+		if strings.TrimSpace(t.Selectors[key]) == "" {
+			errs = append(errs, fmt.Errorf("Selectors[%q] is empty", key))
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(t.Extract)) {
+		mode := t.Extract[key]
+		if _, ok := t.Selectors[key]; !ok {
+			errs = append(errs, fmt.Errorf("Extract[%q] has no matching selector", key))
+		}
+		if mode != ExtractText && mode != ExtractHTML && !(strings.HasPrefix(mode, ExtractAttrPrefix) && len(mode) > len(ExtractAttrPrefix)) {
+			errs = append(errs, fmt.Errorf("Extract[%q]: unknown mode %q, expected text, html or attr:<name>", key, mode))
+		}
+	}
+
+	switch strings.ToLower(t.Mode) {
+	case "", ModeFields:
+	case ModeItems:
+		if t.ItemSelector == "" {
+			errs = append(errs, errors.New("ItemSelector is required in items mode"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown Mode %q, expected fields or items", t.Mode))
+	}
+	switch strings.ToLower(t.SelectorType) {
+	case "", SelectorCSS, SelectorXPath:
+	default:
+		errs = append(errs, fmt.Errorf("unknown SelectorType %q, expected css or xpath", t.SelectorType))
+	}
+	if t.MaxPages < 0 {
+		errs = append(errs, fmt.Errorf("invalid MaxPages: %d", t.MaxPages))
+	}
+
+	for i, action := range t.Actions {
+		switch strings.ToLower(action.Type) {
+		case ActionWait, ActionPress:
+		case ActionClick, ActionHover, ActionSelect:
+			if action.Selector == "" {
+				errs = append(errs, fmt.Errorf("Actions[%d]: %s requires Selector", i, action.Type))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("Actions[%d]: unknown Type %q", i, action.Type))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(t.Headers)) {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			errs = append(errs, fmt.Errorf("invalid Headers name %q", name))
+		}
+		if strings.ContainsAny(t.Headers[name], "\r\n") {
+			errs = append(errs, fmt.Errorf("Headers[%q]: value must be a single line", name))
+		}
+	}
+
+	if t.Auth != nil {
+		switch strings.ToLower(t.Auth.Type) {
+		case AuthBasic:
+			if t.Auth.Username == "" || t.Auth.Password == "" {
+				errs = append(errs, errors.New("Auth: basic requires Username and Password"))
+			}
+		case AuthBearer, AuthAPIKey:
+			if t.Auth.Token == "" {
+				errs = append(errs, fmt.Errorf("Auth: %s requires Token", t.Auth.Type))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("Auth: unknown Type %q, expected basic, bearer or api_key", t.Auth.Type))
+		}
+		if strings.ContainsAny(t.Auth.Header, " \t\r\n:") {
+			errs = append(errs, fmt.Errorf("invalid Auth.Header %q", t.Auth.Header))
+		}
+	}
+
+	if t.Schedule != "" {
+		if _, err := scheduler.Validate(t.Schedule); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, timeout := range [][2]string{
+		{"Timeout", t.Timeout},
+		{"NavigationTimeout", t.NavigationTimeout},
+		{"SelectorTimeout", t.SelectorTimeout},
+	} {
+		if timeout[1] == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(timeout[1]); err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("invalid %s %q, expected positive duration like 45s", timeout[0], timeout[1]))
+		}
+	}
+	if t.Sitemap != nil {
+		if _, err := regexp.Compile(t.Sitemap.Match); err != nil {
+			errs = append(errs, fmt.Errorf("invalid Sitemap.Match: %w", err))
+		}
+		if t.Sitemap.MaxURLs < 0 {
+			errs = append(errs, fmt.Errorf("invalid Sitemap.MaxURLs: %d", t.Sitemap.MaxURLs))
+		}
+	}
+	if t.Stealth != nil {
+		if _, _, ok := t.Stealth.ViewportSize(); t.Stealth.Viewport != "" && !ok {
+			errs = append(errs, fmt.Errorf("invalid Stealth.Viewport %q, expected WIDTHxHEIGHT", t.Stealth.Viewport))
+		}
+		if t.Stealth.Timezone != "" && strings.ContainsAny(t.Stealth.Timezone, " \t") {
+			errs = append(errs, fmt.Errorf("invalid Stealth.Timezone %q", t.Stealth.Timezone))
+		}
+	}
+	for key, steps := range t.Transforms {
+		if _, ok := t.Selectors[key]; !ok {
+			errs = append(errs, fmt.Errorf("Transforms: unknown selector key %q", key))
+		}
+		for i, step := range steps {
+			if err := validateTransform(step); err != nil {
+				errs = append(errs, fmt.Errorf("Transforms[%s][%d]: %w", key, i, err))
+			}
+		}
+	}
+	for key, typ := range t.Types {
+		_, selector := t.Selectors[key]
+		_, derived := t.Derived[key]
+		if !selector && !derived {
+			errs = append(errs, fmt.Errorf("Types: unknown key %q", key))
+		}
+		if !slices.Contains(valueTypes, strings.ToLower(typ)) {
+			errs = append(errs, fmt.Errorf("Types[%s]: unknown type %q, expected one of %v", key, typ, valueTypes))
+		}
+	}
+	if t.Timezone != "" {
+		if _, err := time.LoadLocation(t.Timezone); err != nil {
+			errs = append(errs, fmt.Errorf("invalid Timezone: %w", err))
+		}
+	}
+	if t.Wait != nil {
+		errs = append(errs, validateWait("Wait", *t.Wait)...)
+	}
+	for key, wait := range t.Waits {
+		if _, ok := t.Selectors[key]; !ok {
+			errs = append(errs, fmt.Errorf("Waits: unknown selector key %q", key))
+		}
+		errs = append(errs, validateWait(fmt.Sprintf("Waits[%s]", key), wait)...)
+	}
+	if t.Engine != "" && !slices.Contains(taskEngines, t.Engine) {
+		errs = append(errs, fmt.Errorf("unknown Engine %q, expected one of %v", t.Engine, taskEngines))
+	}
+	if t.Crawl != nil {
+		if t.Sitemap != nil {
+			errs = append(errs, errors.New("Crawl and Sitemap cannot be used together"))
+		}
+		for _, re := range [][2]string{{"Follow", t.Crawl.Follow}, {"Match", t.Crawl.Match}} {
+			if _, err := regexp.Compile(re[1]); err != nil {
+				errs = append(errs, fmt.Errorf("invalid Crawl.%s: %w", re[0], err))
+			}
+		}
+		if t.Crawl.MaxDepth < 0 || t.Crawl.MaxPages < 0 {
+			errs = append(errs, fmt.Errorf("invalid Crawl limits: MaxDepth %d, MaxPages %d", t.Crawl.MaxDepth, t.Crawl.MaxPages))
+		}
+	}
+	if t.MaxConcurrency < 0 {
+		errs = append(errs, fmt.Errorf("invalid MaxConcurrency: %d", t.MaxConcurrency))
+	}
+	if _, ok := work.ParsePriority(t.Priority); !ok {
+		errs = append(errs, fmt.Errorf("invalid Priority %q, expected low, normal or high", t.Priority))
+	}
+	if t.Quality != nil {
+		errs = append(errs, validateQuality(t)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateTransform проверяет тип шага обработки и его регулярное выражение
+func validateTransform(step FieldTransform) error {
+	switch strings.ToLower(step.Type) {
+	case TransformTrim, TransformLower, TransformUpper, TransformStripHTML, TransformJoin:
+	case TransformSplit:
+		if step.Value == "" {
+			return errors.New("split requires Value")
+		}
+	case TransformRegexExtract, TransformRegexReplace:
+		if step.Pattern == "" {
+			return fmt.Errorf("%s requires Pattern", step.Type)
+		}
+		if _, err := regexp.Compile(step.Pattern); err != nil {
+			return fmt.Errorf("invalid Pattern: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown Type %q", step.Type)
+	}
+	return nil
+}
+
+// validateQuality проверяет ключи и пороги правил Quality
+func validateQuality(t ScraperTask) []error {
+	var errs []error
+	q := t.Quality
+
+	known := func(key string) bool {
+		_, selector := t.Selectors[key]
+		_, derived := t.Derived[key]
+		return selector || derived
+	}
+	for _, key := range q.Required {
+		if !known(key) {
+			errs = append(errs, fmt.Errorf("Quality.Required: unknown key %q", key))
+		}
+	}
+	if q.MinFillRate < 0 || q.MinFillRate > 1 {
+		errs = append(errs, fmt.Errorf("invalid Quality.MinFillRate %v, expected value from 0 to 1", q.MinFillRate))
+	}
+	for _, key := range slices.Sorted(maps.Keys(q.FillRates)) {
+		if !known(key) {
+			errs = append(errs, fmt.Errorf("Quality.FillRates: unknown key %q", key))
+		}
+		if rate := q.FillRates[key]; rate < 0 || rate > 1 {
+			errs = append(errs, fmt.Errorf("invalid Quality.FillRates[%s] %v, expected value from 0 to 1", key, rate))
+		}
+	}
+	if q.MinItems < 0 {
+		errs = append(errs, fmt.Errorf("invalid Quality.MinItems: %d", q.MinItems))
+	} else if q.MinItems > 0 && !t.ItemsMode() {
+		errs = append(errs, errors.New("Quality.MinItems requires items mode"))
+	}
+	switch strings.ToLower(q.OnFail) {
+	case "", QualityFlag, QualityReject:
+	default:
+		errs = append(errs, fmt.Errorf("unknown Quality.OnFail %q, expected flag or reject", q.OnFail))
+	}
+	return errs
+}
+
+// validateWait проверяет длительности ожидания
+func validateWait(name string, w WaitConfig) []error {
+	var errs []error
+	for _, field := range [][2]string{{"Delay", w.Delay}, {"Timeout", w.Timeout}} {
+		if field[1] == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(field[1]); err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("invalid %s.%s %q, expected positive duration like 2s", name, field[0], field[1]))
+		}
+	}
+	return errs
+}
+
+// ErrInvalidConfig - конфигурация приложения содержит неверные или пропущенные значения
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// Validate проверяет настройки, общие для всех команд, и возвращает все найденные ошибки
+func (c *AppConfig) Validate() error {
+	var errs []error
+
+	if c.ConfigPath == "" {
+		errs = append(errs, errors.New("CONFIG_PATH is required"))
+	} else if _, err := NewTaskSource(c.ConfigPath); err != nil {
+		errs = append(errs, fmt.Errorf("CONFIG_PATH: %w", err))
+	}
+	switch c.OutputFormat {
+	case "", "ndjson", "json":
+	default:
+		errs = append(errs, fmt.Errorf("unknown OUTPUT_FORMAT %q, expected ndjson or json", c.OutputFormat))
+	}
+	switch strings.ToLower(c.Log.Format) {
+	case "", "text", "json", "logfmt":
+	default:
+		errs = append(errs, fmt.Errorf("unknown LOG_FORMAT %q, expected text, json or logfmt", c.Log.Format))
+	}
+	switch c.BudgetOnError {
+	case "", BudgetAllow, BudgetDeny:
+	default:
+		errs = append(errs, fmt.Errorf("unknown REQUEST_BUDGET_ON_ERROR %q, expected %s or %s", c.BudgetOnError, BudgetAllow, BudgetDeny))
+	}
+	switch c.Proxy.Rotation {
+	case "", "round_robin", "random":
+	default:
+		errs = append(errs, fmt.Errorf("unknown PROXY_ROTATION %q, expected round_robin or random", c.Proxy.Rotation))
+	}
+
+	for _, timeout := range []struct {
+		env   string
+		value time.Duration
+	}{
+		{"SCRAPER_TIMEOUT", c.Timeouts.Scrape},
+		{"NAVIGATION_TIMEOUT", c.Timeouts.Navigation},
+		{"SELECTOR_TIMEOUT", c.Timeouts.Selector},
+		{"DAEMON_INTERVAL", c.Daemon.Interval},
+		{"DAEMON_WATCH_INTERVAL", c.Daemon.WatchInterval},
+	} {
+		if timeout.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %s", timeout.env, timeout.value))
+		}
+	}
+	if c.SourceLimit < 0 {
+		errs = append(errs, fmt.Errorf("SOURCE_CONCURRENCY must not be negative, got %d", c.SourceLimit))
+	}
+	if c.Retry.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("RETRY_MAX_ATTEMPTS must be at least 1, got %d", c.Retry.MaxAttempts))
+	}
+	if c.Retry.Jitter < 0 || c.Retry.Jitter > 1 {
+		errs = append(errs, fmt.Errorf("RETRY_JITTER must be between 0 and 1, got %g", c.Retry.Jitter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("OTEL_TRACES_SAMPLER_ARG must be between 0 and 1, got %g", c.Tracing.SampleRatio))
+	}
+	if c.BrowserInstances < 1 {
+		errs = append(errs, fmt.Errorf("BROWSER_INSTANCES must be at least 1, got %d", c.BrowserInstances))
+	}
+	if c.Pages.PerBrowser < 1 {
+		errs = append(errs, fmt.Errorf("PAGES_PER_BROWSER must be at least 1, got %d", c.Pages.PerBrowser))
+	}
+	if c.Pages.MaxUses < 0 {
+		errs = append(errs, fmt.Errorf("PAGE_MAX_USES must not be negative, got %d", c.Pages.MaxUses))
+	}
+	switch c.Images.Backend {
+	case "":
+	case "dir":
+		if c.Images.Dir == "" {
+			errs = append(errs, errors.New("IMAGE_DIR is required for image store dir"))
+		}
+	case "gridfs":
+		if c.MongoDB.URI == "" || c.MongoDB.Database == "" {
+			errs = append(errs, errors.New("MONGO_URI and MONGODB_DATABASE are required for image store gridfs"))
+		}
+	case "s3":
+		if c.Images.S3.AccessKey == "" || c.Images.S3.SecretKey == "" {
+			errs = append(errs, errors.New("S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required for image store s3"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown IMAGE_STORE %q, expected dir, gridfs or s3", c.Images.Backend))
+	}
+	if c.BrowserRemote.URL != "" {
+		u, err := url.Parse(c.BrowserRemote.URL)
+		switch {
+		case err != nil || u.Host == "":
+			errs = append(errs, errors.New("BROWSER_WS_URL must be an absolute URL"))
+		case !slices.Contains([]string{"ws", "wss", "http", "https"}, u.Scheme):
+			errs = append(errs, fmt.Errorf("BROWSER_WS_URL scheme %q, expected ws, wss, http or https", u.Scheme))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+	}
+	return nil
+}
+
+// ValidateStorage проверяет обязательные настройки встроенного хранилища STORAGE_BACKEND.
+// Хранилища, зарегистрированные вне пакета db, проверяют настройки сами
+func (c *AppConfig) ValidateStorage() error {
+	var required [][2]string
+	switch strings.ToLower(c.StorageBackend) {
+	case "", "mongo":
+		required = [][2]string{
+			{"MONGO_URI", c.MongoDB.URI},
+			{"MONGODB_DATABASE", c.MongoDB.Database},
+			{"MONGODB_COLLECTION", c.MongoDB.Collection},
+		}
+	case "postgres":
+		required = [][2]string{{"POSTGRES_DSN", c.Postgres.DSN}}
+	case "sqlite":
+		required = [][2]string{{"SQLITE_PATH", c.SQLite.Path}}
+	case "file":
+		required = [][2]string{{"OUTPUT_PATH", c.OutputPath}}
+	}
+
+	var errs []error
+	for _, field := range required {
+		if field[1] == "" {
+			errs = append(errs, fmt.Errorf("%s is required for storage backend %s", field[0], cmp.Or(c.StorageBackend, "mongo")))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+	}
+	return nil
+}
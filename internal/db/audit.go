@@ -0,0 +1,315 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultAuditCollection - коллекция журнала аудита по умолчанию
+const DefaultAuditCollection = "scrape_audit"
+
+// AuditRepository определяет интерфейс для журнала аудита запусков
+type AuditRepository interface {
+	SaveAudit(ctx context.Context, entry *models.AuditEntry) (string, error)
+	GetAudits(ctx context.Context, limit int64) ([]*models.AuditEntry, error)
+	GetProjectAudits(ctx context.Context, project string, limit int64) ([]*models.AuditEntry, error)
+	GetAuditByRunID(ctx context.Context, runID string) (*models.AuditEntry, error)
+	GetFailureStats(ctx context.Context, since time.Time) ([]models.FailureStats, error)
+}
+
+// MongoAuditRepo имплементирует интерфейс AuditRepository
+type MongoAuditRepo struct {
+	collection *mongo.Collection
+}
+
+// NewMongoAuditRepo создает репозиторий журнала аудита
+func NewMongoAuditRepo(client *mongo.Client, dbname, collectionName string) (*MongoAuditRepo, error) {
+	if client == nil {
+		return nil, errors.New("Mongo client is nil")
+	}
+
+	if collectionName == "" {
+		collectionName = DefaultAuditCollection
+	}
+
+	collection := client.Database(dbname).Collection(collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	// Создаем уникальный индекс по run_id и индекс по времени запуска
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "run_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "started_at", Value: -1}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoAuditRepo{collection: collection}, nil
+}
+
+// SaveAudit сохраняет запись аудита, заменяя запись с тем же run_id
+func (r *MongoAuditRepo) SaveAudit(ctx context.Context, entry *models.AuditEntry) (string, error) {
+	if r.collection == nil {
+		return "", ErrNilCollection
+	}
+
+	ctx, span := tracing.Start(ctx, "db.save_audit", tracing.String("run.id", entry.RunID))
+	defer span.End()
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.ReplaceOne(timeout,
+		bson.M{"run_id": entry.RunID},
+		entry,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+
+	return entry.ID.Hex(), nil
+}
+
+// GetAudits возвращает последние записи аудита, новые первыми
+func (r *MongoAuditRepo) GetAudits(ctx context.Context, limit int64) ([]*models.AuditEntry, error) {
+	if r.collection == nil {
+		return nil, ErrNilCollection
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	cursor, err := r.collection.Find(timeout, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var entries []*models.AuditEntry
+	if err := cursor.All(timeout, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetProjectAudits возвращает последние запуски с задачами проекта, новые первыми.
+// Записи содержат только задачи проекта и итоги по ним
+func (r *MongoAuditRepo) GetProjectAudits(ctx context.Context, project string, limit int64) ([]*models.AuditEntry, error) {
+	if r.collection == nil {
+		return nil, ErrNilCollection
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	cursor, err := r.collection.Find(timeout, bson.M{"tasks.project": project}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var entries []*models.AuditEntry
+	if err := cursor.All(timeout, &entries); err != nil {
+		return nil, err
+	}
+
+	scoped := entries[:0]
+	for _, entry := range entries {
+		if entry = entry.ForProject(project); entry != nil {
+			scoped = append(scoped, entry)
+		}
+	}
+	return scoped, nil
+}
+
+// GetAuditByRunID возвращает запись аудита по идентификатору запуска
+func (r *MongoAuditRepo) GetAuditByRunID(ctx context.Context, runID string) (*models.AuditEntry, error) {
+	if r.collection == nil {
+		return nil, ErrNilCollection
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	var entry models.AuditEntry
+	err := r.collection.FindOne(timeout, bson.M{"run_id": runID}).Decode(&entry)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// GetFailureStats считает выполненные и неудачные задачи по типам в запусках с момента since.
+// Пропущенные задачи не учитываются
+func (r *MongoAuditRepo) GetFailureStats(ctx context.Context, since time.Time) ([]models.FailureStats, error) {
+	if r.collection == nil {
+		return nil, ErrNilCollection
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	failed := make(bson.A, 0, len(failedOutcomes))
+	for _, o := range failedOutcomes {
+		failed = append(failed, o)
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"started_at": bson.M{"$gte": since}}}},
+		{{Key: "$unwind", Value: "$tasks"}},
+		{{Key: "$match", Value: bson.M{"tasks.outcome": bson.M{"$ne": models.OutcomeSkipped}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$tasks.type",
+			"tasks": bson.M{"$sum": 1},
+			"failed": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$in": bson.A{"$tasks.outcome", failed}}, 1, 0,
+			}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(timeout, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var failures []models.FailureStats
+	if err := cursor.All(timeout, &failures); err != nil {
+		return nil, err
+	}
+	return failures, nil
+}
+
+// MemoryAuditRepo хранит записи аудита в памяти процесса
+type MemoryAuditRepo struct {
+	mu      sync.RWMutex
+	entries []*models.AuditEntry
+}
+
+// NewMemoryAuditRepo создает журнал аудита в памяти
+func NewMemoryAuditRepo() *MemoryAuditRepo {
+	return &MemoryAuditRepo{}
+}
+
+// SaveAudit сохраняет запись аудита, заменяя запись с тем же run_id
+func (r *MemoryAuditRepo) SaveAudit(ctx context.Context, entry *models.AuditEntry) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+
+	saved := *entry
+	for i, e := range r.entries {
+		if e.RunID == entry.RunID {
+			r.entries[i] = &saved
+			return entry.ID.Hex(), nil
+		}
+	}
+	r.entries = append(r.entries, &saved)
+
+	return entry.ID.Hex(), nil
+}
+
+// GetAudits возвращает последние записи аудита, новые первыми
+func (r *MemoryAuditRepo) GetAudits(ctx context.Context, limit int64) ([]*models.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]*models.AuditEntry, 0, len(r.entries))
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if limit > 0 && int64(len(entries)) >= limit {
+			break
+		}
+		entry := *r.entries[i]
+		entries = append(entries, &entry)
+	}
+
+	return entries, nil
+}
+
+// GetProjectAudits возвращает последние запуски с задачами проекта, новые первыми.
+// Записи содержат только задачи проекта и итоги по ним
+func (r *MemoryAuditRepo) GetProjectAudits(ctx context.Context, project string, limit int64) ([]*models.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []*models.AuditEntry
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if limit > 0 && int64(len(entries)) >= limit {
+			break
+		}
+		if entry := r.entries[i].ForProject(project); entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// GetAuditByRunID возвращает запись аудита по идентификатору запуска
+func (r *MemoryAuditRepo) GetAuditByRunID(ctx context.Context, runID string) (*models.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, e := range r.entries {
+		if e.RunID == runID {
+			entry := *e
+			return &entry, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// GetFailureStats считает выполненные и неудачные задачи по типам в запусках с момента since
+func (r *MemoryAuditRepo) GetFailureStats(ctx context.Context, since time.Time) ([]models.FailureStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	acc := make(failureAccumulator)
+	for _, e := range r.entries {
+		if !e.StartedAt.Before(since) {
+			acc.add(e)
+		}
+	}
+	return acc.result(), nil
+}
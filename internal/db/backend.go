@@ -0,0 +1,235 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+)
+
+// Встроенные хранилища
+const (
+	BackendMongo    = "mongo"
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+	BackendSQLite   = "sqlite"
+	BackendFile     = "file"
+)
+
+// Storage - набор репозиториев выбранного хранилища
+type Storage struct {
+	Results ScraperRepository
+	Audit   AuditRepository
+	Budget  BudgetRepository
+	History HistoryRepository
+	Events  EventRepository
+	Runs    RunRepository
+	// DeadLetters - задачи, не выполненные после всех попыток
+	DeadLetters DeadLetterRepository
+}
+
+// Close закрывает соединение хранилища
+func (s *Storage) Close() error {
+	return s.Results.Close()
+}
+
+// BackendFactory создает репозитории хранилища по конфигурации приложения.
+// Audit, Budget, History, Events, Runs и DeadLetters могут быть nil, тогда используются реализации в памяти
+type BackendFactory func(ctx context.Context, cfg *config.AppConfig, logger Logger) (*Storage, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]BackendFactory{
+		BackendMongo:    newMongoStorage,
+		BackendMemory:   newMemoryStorage,
+		BackendPostgres: newPostgresStorage,
+		BackendSQLite:   newSQLiteStorage,
+		BackendFile:     newFileStorage,
+	}
+)
+
+// RegisterBackend регистрирует хранилище под именем для STORAGE_BACKEND.
+// Повторная регистрация заменяет фабрику
+func RegisterBackend(name string, factory BackendFactory) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	backends[strings.ToLower(name)] = factory
+}
+
+// Backends возвращает имена зарегистрированных хранилищ
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewStorage создает хранилище, выбранное в cfg.StorageBackend (по умолчанию MongoDB)
+func NewStorage(ctx context.Context, cfg *config.AppConfig, logger Logger) (*Storage, error) {
+	name := strings.ToLower(cfg.StorageBackend)
+	if name == "" {
+		name = BackendMongo
+	}
+
+	backendsMu.RLock()
+	factory, ok := backends[name]
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown storage backend %q, available: %s", name, strings.Join(Backends(), ", "))
+	}
+
+	storage, err := factory(ctx, cfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("storage backend %s: %w", name, err)
+	}
+
+	if storage.Audit == nil {
+		storage.Audit = NewMemoryAuditRepo()
+	}
+	if storage.Budget == nil {
+		storage.Budget = NewMemoryBudgetRepo()
+	}
+	if storage.History == nil {
+		storage.History = NewMemoryHistoryRepo()
+	}
+	if storage.Events == nil {
+		storage.Events = NewMemoryEventRepo()
+	}
+	if storage.Runs == nil {
+		storage.Runs = NewMemoryRunRepo()
+	}
+	if storage.DeadLetters == nil {
+		storage.DeadLetters = NewMemoryDeadLetterRepo()
+	}
+
+	return storage, nil
+}
+
+// NewRepository создает репозиторий результатов выбранного хранилища
+func NewRepository(ctx context.Context, cfg *config.AppConfig, logger Logger) (ScraperRepository, error) {
+	storage, err := NewStorage(ctx, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	return storage.Results, nil
+}
+
+// newMongoStorage подключается к MongoDB и создает репозитории в ее коллекциях
+func newMongoStorage(ctx context.Context, cfg *config.AppConfig, logger Logger) (*Storage, error) {
+	mongoConfig := NewDefaultConfig(cfg.MongoDB.URI, cfg.MongoDB.Database, cfg.MongoDB.Collection)
+	mongoConfig.Username = cfg.MongoDB.Username
+	mongoConfig.Password = cfg.MongoDB.Password
+	mongoConfig.Timeout = cfg.MongoDB.ConnectTimeout
+
+	client, err := ConnectMongo(ctx, mongoConfig)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Successfully connected to MongoDB")
+
+	results, err := NewMongoScraperRepoWithLogger(client, mongoConfig.Database, mongoConfig.CollectionName, logger)
+	if err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+
+	audit, err := NewMongoAuditRepo(client, mongoConfig.Database, cfg.MongoDB.AuditCollection)
+	if err != nil {
+		results.Close()
+		return nil, err
+	}
+
+	budget, err := NewMongoBudgetRepo(client, mongoConfig.Database, cfg.MongoDB.BudgetCollection)
+	if err != nil {
+		results.Close()
+		return nil, err
+	}
+
+	history, err := NewMongoHistoryRepo(client, mongoConfig.Database, cfg.MongoDB.HistoryCollection)
+	if err != nil {
+		results.Close()
+		return nil, err
+	}
+
+	events, err := NewMongoEventRepo(client, mongoConfig.Database, cfg.MongoDB.EventsCollection)
+	if err != nil {
+		results.Close()
+		return nil, err
+	}
+
+	runs, err := NewMongoRunRepo(client, mongoConfig.Database, cfg.MongoDB.RunsCollection)
+	if err != nil {
+		results.Close()
+		return nil, err
+	}
+
+	deadLetters, err := NewMongoDeadLetterRepo(client, mongoConfig.Database, cfg.MongoDB.DLQCollection)
+	if err != nil {
+		results.Close()
+		return nil, err
+	}
+
+	return &Storage{
+		Results:     results,
+		Audit:       audit,
+		Budget:      budget,
+		History:     history,
+		Events:      events,
+		Runs:        runs,
+		DeadLetters: deadLetters,
+	}, nil
+}
+
+// newPostgresStorage подключается к PostgreSQL. Аудит, бюджеты, история, события, запуски и недоставленные задачи хранятся в памяти
+func newPostgresStorage(ctx context.Context, cfg *config.AppConfig, logger Logger) (*Storage, error) {
+	if cfg.Postgres.DSN == "" {
+		return nil, fmt.Errorf("POSTGRES_DSN is required")
+	}
+
+	results, err := NewPostgresScraperRepo(ctx, cfg.Postgres.Driver, cfg.Postgres.DSN, cfg.Postgres.Table, logger)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Successfully connected to PostgreSQL")
+
+	return &Storage{Results: results}, nil
+}
+
+// newSQLiteStorage открывает локальную базу SQLite. Аудит, бюджеты, история, события, запуски и недоставленные задачи хранятся в памяти
+func newSQLiteStorage(ctx context.Context, cfg *config.AppConfig, logger Logger) (*Storage, error) {
+	results, err := NewSQLiteScraperRepo(ctx, cfg.SQLite.Driver, cfg.SQLite.Path, cfg.SQLite.Table, logger)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Opened SQLite database", "path", cfg.SQLite.Path)
+
+	return &Storage{Results: results}, nil
+}
+
+// newFileStorage пишет результаты в файлы в каталоге OUTPUT_PATH
+func newFileStorage(ctx context.Context, cfg *config.AppConfig, logger Logger) (*Storage, error) {
+	if cfg.OutputPath == "" {
+		return nil, fmt.Errorf("OUTPUT_PATH is required")
+	}
+
+	results, err := NewFileScraperRepo(cfg.OutputPath, cfg.OutputFormat, logger)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Writing results to files", "dir", cfg.OutputPath, "format", cfg.OutputFormat)
+
+	return &Storage{Results: results}, nil
+}
+
+// newMemoryStorage создает хранилище в памяти процесса
+func newMemoryStorage(ctx context.Context, cfg *config.AppConfig, logger Logger) (*Storage, error) {
+	return &Storage{Results: NewMemoryScraperRepo()}, nil
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultBudgetCollection - коллекция счетчиков бюджета запросов по умолчанию
+const DefaultBudgetCollection = "request_budget"
+
+// BudgetRepository хранит дневные счетчики запросов по доменам
+type BudgetRepository interface {
+	// Consume увеличивает счетчик домена за день и сообщает, уложился ли запрос в лимит
+	Consume(ctx context.Context, domain string, day time.Time, limit int) (allowed bool, used int, err error)
+}
+
+// budgetDay возвращает ключ дня в UTC
+func budgetDay(day time.Time) string {
+	return day.UTC().Format(time.DateOnly)
+}
+
+// MongoBudgetRepo имплементирует интерфейс BudgetRepository
+type MongoBudgetRepo struct {
+	collection *mongo.Collection
+}
+
+// NewMongoBudgetRepo создает репозиторий бюджета запросов
+func NewMongoBudgetRepo(client *mongo.Client, dbname, collectionName string) (*MongoBudgetRepo, error) {
+	if client == nil {
+		return nil, errors.New("Mongo client is nil")
+	}
+
+	if collectionName == "" {
+		collectionName = DefaultBudgetCollection
+	}
+
+	collection := client.Database(dbname).Collection(collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	// Старые счетчики удаляются автоматически через неделю
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "updated_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(7 * 24 * 60 * 60),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoBudgetRepo{collection: collection}, nil
+}
+
+// Consume атомарно увеличивает счетчик домена за день.
+// Запросы сверх лимита тоже учитываются, чтобы в отчете было видно превышение
+func (r *MongoBudgetRepo) Consume(ctx context.Context, domain string, day time.Time, limit int) (bool, int, error) {
+	if r.collection == nil {
+		return false, 0, ErrNilCollection
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	key := budgetDay(day)
+
+	var counter struct {
+		Count int `bson:"count"`
+	}
+	err := r.collection.FindOneAndUpdate(timeout,
+		bson.M{"_id": domain + "/" + key},
+		bson.M{
+			"$inc": bson.M{"count": 1},
+			"$set": bson.M{"domain": domain, "day": key, "updated_at": time.Now()},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return false, 0, err
+	}
+
+	return counter.Count <= limit, counter.Count, nil
+}
+
+// MemoryBudgetRepo хранит счетчики бюджета в памяти процесса
+type MemoryBudgetRepo struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewMemoryBudgetRepo создает репозиторий бюджета в памяти
+func NewMemoryBudgetRepo() *MemoryBudgetRepo {
+	return &MemoryBudgetRepo{counts: make(map[string]int)}
+}
+
+// Consume увеличивает счетчик домена за день
+func (r *MemoryBudgetRepo) Consume(ctx context.Context, domain string, day time.Time, limit int) (bool, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := domain + "/" + budgetDay(day)
+	r.counts[key]++
+	return r.counts[key] <= limit, r.counts[key], nil
+}
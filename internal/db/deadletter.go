@@ -0,0 +1,379 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultDeadLetterCollection - коллекция недоставленных задач по умолчанию
+const DefaultDeadLetterCollection = "dead_letters"
+
+// DeadLetterRepository определяет интерфейс очереди задач, не выполненных после всех попыток
+type DeadLetterRepository interface {
+	// SaveDeadLetter добавляет задачу в очередь или обновляет запись с тем же отпечатком задачи
+	SaveDeadLetter(ctx context.Context, letter *models.DeadLetter) (string, error)
+	GetDeadLetters(ctx context.Context, status string, limit int64) ([]*models.DeadLetter, error)
+	GetDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error)
+	// Requeue отмечает запись для выполнения со следующим запуском
+	Requeue(ctx context.Context, id string) error
+	// TakeRequeued удаляет из очереди отмеченные Requeue записи и возвращает их
+	TakeRequeued(ctx context.Context) ([]*models.DeadLetter, error)
+	DeleteDeadLetter(ctx context.Context, id string) error
+}
+
+// MongoDeadLetterRepo имплементирует интерфейс DeadLetterRepository
+type MongoDeadLetterRepo struct {
+	collection *mongo.Collection
+}
+
+// NewMongoDeadLetterRepo создает репозиторий недоставленных задач
+func NewMongoDeadLetterRepo(client *mongo.Client, dbname, collectionName string) (*MongoDeadLetterRepo, error) {
+	if client == nil {
+		return nil, errors.New("Mongo client is nil")
+	}
+
+	if collectionName == "" {
+		collectionName = DefaultDeadLetterCollection
+	}
+
+	collection := client.Database(dbname).Collection(collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	// Одна запись на задачу и выборка последних неудач по статусу
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "task_fingerprint", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "failed_at", Value: -1}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoDeadLetterRepo{collection: collection}, nil
+}
+
+// SaveDeadLetter добавляет задачу в очередь или обновляет запись с тем же отпечатком задачи.
+// Обновленная запись снова ожидает разбора, Failures увеличивается
+func (r *MongoDeadLetterRepo) SaveDeadLetter(ctx context.Context, letter *models.DeadLetter) (string, error) {
+	if r.collection == nil {
+		return "", ErrNilCollection
+	}
+
+	ctx, span := tracing.Start(ctx, "db.save_dead_letter", tracing.String("url", letter.URL), tracing.String("type", letter.Type))
+	defer span.End()
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"url":          letter.URL,
+			"type":         letter.Type,
+			"name":         letter.Name,
+			"project":      letter.Project,
+			"task":         letter.Task,
+			"status":       models.DeadLetterPending,
+			"error":        letter.Error,
+			"error_code":   letter.ErrorCode,
+			"attempts":     letter.Attempts,
+			"run_id":       letter.RunID,
+			"execution_id": letter.ExecutionID,
+			"failed_at":    letter.FailedAt,
+		},
+		"$unset":       bson.M{"requeued_at": ""},
+		"$inc":         bson.M{"failures": 1},
+		"$setOnInsert": bson.M{"first_failed_at": letter.FailedAt},
+	}
+
+	err := r.collection.FindOneAndUpdate(timeout,
+		bson.M{"task_fingerprint": letter.TaskFingerprint},
+		update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(letter)
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+
+	return letter.ID.Hex(), nil
+}
+
+// GetDeadLetters возвращает записи очереди, последние неудачи первыми. Пустой status - записи в любом статусе
+func (r *MongoDeadLetterRepo) GetDeadLetters(ctx context.Context, status string, limit int64) ([]*models.DeadLetter, error) {
+	if r.collection == nil {
+		return nil, ErrNilCollection
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "failed_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	cursor, err := r.collection.Find(timeout, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var letters []*models.DeadLetter
+	if err := cursor.All(timeout, &letters); err != nil {
+		return nil, err
+	}
+
+	return letters, nil
+}
+
+// GetDeadLetter возвращает запись очереди по идентификатору
+func (r *MongoDeadLetterRepo) GetDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error) {
+	if r.collection == nil {
+		return nil, ErrNilCollection
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	var letter models.DeadLetter
+	err = r.collection.FindOne(timeout, bson.M{"_id": objID}).Decode(&letter)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &letter, nil
+}
+
+// Requeue отмечает запись для выполнения со следующим запуском
+func (r *MongoDeadLetterRepo) Requeue(ctx context.Context, id string) error {
+	if r.collection == nil {
+		return ErrNilCollection
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	res, err := r.collection.UpdateByID(timeout, objID, bson.M{
+		"$set": bson.M{"status": models.DeadLetterRequeued, "requeued_at": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// TakeRequeued удаляет из очереди отмеченные Requeue записи и возвращает их.
+// Запись удаляется по одной, поэтому одновременные запуски не получат одну задачу дважды
+func (r *MongoDeadLetterRepo) TakeRequeued(ctx context.Context) ([]*models.DeadLetter, error) {
+	if r.collection == nil {
+		return nil, ErrNilCollection
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	var letters []*models.DeadLetter
+	for {
+		var letter models.DeadLetter
+		err := r.collection.FindOneAndDelete(timeout, bson.M{"status": models.DeadLetterRequeued}).Decode(&letter)
+		if err == mongo.ErrNoDocuments {
+			return letters, nil
+		}
+		if err != nil {
+			return letters, err
+		}
+		letters = append(letters, &letter)
+	}
+}
+
+// DeleteDeadLetter удаляет запись очереди
+func (r *MongoDeadLetterRepo) DeleteDeadLetter(ctx context.Context, id string) error {
+	if r.collection == nil {
+		return ErrNilCollection
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	res, err := r.collection.DeleteOne(timeout, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MemoryDeadLetterRepo хранит очередь недоставленных задач в памяти процесса
+type MemoryDeadLetterRepo struct {
+	mu      sync.RWMutex
+	letters []*models.DeadLetter
+}
+
+// NewMemoryDeadLetterRepo создает очередь недоставленных задач в памяти
+func NewMemoryDeadLetterRepo() *MemoryDeadLetterRepo {
+	return &MemoryDeadLetterRepo{}
+}
+
+// SaveDeadLetter добавляет задачу в очередь или обновляет запись с тем же отпечатком задачи
+func (r *MemoryDeadLetterRepo) SaveDeadLetter(ctx context.Context, letter *models.DeadLetter) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	letter.Status = models.DeadLetterPending
+	letter.RequeuedAt = nil
+	for i, existing := range r.letters {
+		if existing.TaskFingerprint == letter.TaskFingerprint {
+			letter.ID = existing.ID
+			letter.FirstFailedAt = existing.FirstFailedAt
+			letter.Failures = existing.Failures + 1
+			saved := *letter
+			r.letters[i] = &saved
+			return letter.ID.Hex(), nil
+		}
+	}
+
+	letter.ID = primitive.NewObjectID()
+	letter.FirstFailedAt = letter.FailedAt
+	letter.Failures = 1
+	saved := *letter
+	r.letters = append(r.letters, &saved)
+
+	return letter.ID.Hex(), nil
+}
+
+// GetDeadLetters возвращает записи очереди, последние неудачи первыми. Пустой status - записи в любом статусе
+func (r *MemoryDeadLetterRepo) GetDeadLetters(ctx context.Context, status string, limit int64) ([]*models.DeadLetter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	letters := make([]*models.DeadLetter, 0, len(r.letters))
+	for _, existing := range r.letters {
+		if status != "" && existing.Status != status {
+			continue
+		}
+		letter := *existing
+		letters = append(letters, &letter)
+	}
+	slices.SortStableFunc(letters, func(a, b *models.DeadLetter) int {
+		return b.FailedAt.Compare(a.FailedAt)
+	})
+	if limit > 0 && int64(len(letters)) > limit {
+		letters = letters[:limit]
+	}
+
+	return letters, nil
+}
+
+// GetDeadLetter возвращает запись очереди по идентификатору
+func (r *MemoryDeadLetterRepo) GetDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i, err := r.find(id)
+	if err != nil {
+		return nil, err
+	}
+	letter := *r.letters[i]
+	return &letter, nil
+}
+
+// Requeue отмечает запись для выполнения со следующим запуском
+func (r *MemoryDeadLetterRepo) Requeue(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, err := r.find(id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	r.letters[i].Status = models.DeadLetterRequeued
+	r.letters[i].RequeuedAt = &now
+	return nil
+}
+
+// TakeRequeued удаляет из очереди отмеченные Requeue записи и возвращает их
+func (r *MemoryDeadLetterRepo) TakeRequeued(ctx context.Context) ([]*models.DeadLetter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var taken []*models.DeadLetter
+	r.letters = slices.DeleteFunc(r.letters, func(letter *models.DeadLetter) bool {
+		if letter.Status != models.DeadLetterRequeued {
+			return false
+		}
+		taken = append(taken, letter)
+		return true
+	})
+	return taken, nil
+}
+
+// DeleteDeadLetter удаляет запись очереди
+func (r *MemoryDeadLetterRepo) DeleteDeadLetter(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, err := r.find(id)
+	if err != nil {
+		return err
+	}
+	r.letters = slices.Delete(r.letters, i, i+1)
+	return nil
+}
+
+// find возвращает индекс записи по идентификатору. Вызывается под mu
+func (r *MemoryDeadLetterRepo) find(id string) (int, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, ErrInvalidID
+	}
+	for i, letter := range r.letters {
+		if letter.ID == objID {
+			return i, nil
+		}
+	}
+	return 0, ErrNotFound
+}
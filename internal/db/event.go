@@ -0,0 +1,236 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultEventsCollection - коллекция событий по умолчанию
+const DefaultEventsCollection = "events"
+
+// EventRepository определяет интерфейс для хранилища событий
+type EventRepository interface {
+	// UpsertEvents сохраняет события с обновлением по Key и возвращает число новых
+	UpsertEvents(ctx context.Context, events []*models.Event) (int, error)
+	// FindEvents возвращает события по фильтру, ближайшие первыми
+	FindEvents(ctx context.Context, filter EventFilter) ([]*models.Event, error)
+}
+
+// EventFilter - отбор событий, пустые поля не ограничивают
+type EventFilter struct {
+	Project  string
+	Category string
+	Source   string
+	From     time.Time // Начало не раньше
+	To       time.Time // Начало раньше
+	Limit    int64
+}
+
+// match проверяет событие на соответствие фильтру
+func (f EventFilter) match(e *models.Event) bool {
+	switch {
+	case f.Project != "" && e.Project != f.Project,
+		f.Category != "" && e.Category != f.Category,
+		f.Source != "" && e.Source != f.Source,
+		!f.From.IsZero() && e.Start.Before(f.From),
+		!f.To.IsZero() && !e.Start.Before(f.To):
+		return false
+	}
+	return true
+}
+
+// MongoEventRepo имплементирует интерфейс EventRepository
+type MongoEventRepo struct {
+	collection *mongo.Collection
+}
+
+// NewMongoEventRepo создает репозиторий событий
+func NewMongoEventRepo(client *mongo.Client, dbname, collectionName string) (*MongoEventRepo, error) {
+	if client == nil {
+		return nil, errors.New("Mongo client is nil")
+	}
+
+	if collectionName == "" {
+		collectionName = DefaultEventsCollection
+	}
+
+	collection := client.Database(dbname).Collection(collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	// Уникальный индекс по ключу события и индексы для выборок по времени начала
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "start", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "category", Value: 1}, {Key: "start", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoEventRepo{collection: collection}, nil
+}
+
+// UpsertEvents сохраняет события одним BulkWrite с upsert по key
+func (r *MongoEventRepo) UpsertEvents(ctx context.Context, events []*models.Event) (int, error) {
+	if r.collection == nil {
+		return 0, ErrNilCollection
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	ctx, span := tracing.Start(ctx, "db.upsert_events", tracing.Int("count", len(events)))
+	defer span.End()
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	now := time.Now()
+	writes := make([]mongo.WriteModel, 0, len(events))
+	for _, event := range events {
+		event.UpdatedAt = now
+		set := bson.M{
+			"project":     event.Project,
+			"source":      event.Source,
+			"source_url":  event.SourceURL,
+			"result_id":   event.ResultID,
+			"title":       event.Title,
+			"description": event.Description,
+			"start":       event.Start,
+			"end":         event.End,
+			"venue":       event.Venue,
+			"address":     event.Address,
+			"price":       event.Price,
+			"link":        event.Link,
+			"image":       event.Image,
+			"category":    event.Category,
+			"updated_at":  event.UpdatedAt,
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"key": event.Key}).
+			SetUpdate(bson.M{"$set": set, "$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": now}}).
+			SetUpsert(true))
+	}
+
+	res, err := r.collection.BulkWrite(timeout, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	return int(res.UpsertedCount), nil
+}
+
+// FindEvents возвращает события по фильтру, ближайшие первыми
+func (r *MongoEventRepo) FindEvents(ctx context.Context, filter EventFilter) ([]*models.Event, error) {
+	if r.collection == nil {
+		return nil, ErrNilCollection
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	query := bson.M{}
+	for field, value := range map[string]string{"project": filter.Project, "category": filter.Category, "source": filter.Source} {
+		if value != "" {
+			query[field] = value
+		}
+	}
+	start := bson.M{}
+	if !filter.From.IsZero() {
+		start["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		start["$lt"] = filter.To
+	}
+	if len(start) > 0 {
+		query["start"] = start
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "start", Value: 1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+
+	cursor, err := r.collection.Find(timeout, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var events []*models.Event
+	if err := cursor.All(timeout, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// MemoryEventRepo хранит события в памяти процесса
+type MemoryEventRepo struct {
+	mu     sync.RWMutex
+	events map[string]*models.Event
+}
+
+// NewMemoryEventRepo создает хранилище событий в памяти
+func NewMemoryEventRepo() *MemoryEventRepo {
+	return &MemoryEventRepo{events: make(map[string]*models.Event)}
+}
+
+// UpsertEvents сохраняет события с обновлением по Key
+func (r *MemoryEventRepo) UpsertEvents(ctx context.Context, events []*models.Event) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	inserted := 0
+	for _, event := range events {
+		saved := *event
+		saved.UpdatedAt = now
+		if existing, ok := r.events[event.Key]; ok {
+			saved.ID, saved.CreatedAt = existing.ID, existing.CreatedAt
+		} else {
+			saved.ID, saved.CreatedAt = primitive.NewObjectID(), now
+			inserted++
+		}
+		r.events[event.Key] = &saved
+		event.ID, event.CreatedAt, event.UpdatedAt = saved.ID, saved.CreatedAt, saved.UpdatedAt
+	}
+	return inserted, nil
+}
+
+// FindEvents возвращает события по фильтру, ближайшие первыми
+func (r *MemoryEventRepo) FindEvents(ctx context.Context, filter EventFilter) ([]*models.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []*models.Event
+	for _, event := range r.events {
+		if filter.match(event) {
+			e := *event
+			events = append(events, &e)
+		}
+	}
+	slices.SortFunc(events, func(a, b *models.Event) int { return a.Start.Compare(b.Start) })
+	if filter.Limit > 0 && int64(len(events)) > filter.Limit {
+		events = events[:filter.Limit]
+	}
+	return events, nil
+}
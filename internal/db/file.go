@@ -0,0 +1,346 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// Форматы файлового хранилища
+const (
+	FileFormatNDJSON = "ndjson" // Файл <type>.ndjson на каждый тип, по строке на результат
+	FileFormatJSON   = "json"   // Один файл results.json с массивом результатов
+)
+
+// jsonResultsFile - имя файла для формата FileFormatJSON
+const jsonResultsFile = "results.json"
+
+// FileScraperRepo хранит результаты в локальных файлах в каталоге dir.
+// Выборки и upsert выполняются по копии в памяти, загруженной при открытии.
+// В формате NDJSON новые версии результатов дописываются в конец файла типа,
+// при загрузке последняя строка с тем же ID побеждает
+type FileScraperRepo struct {
+	*MemoryScraperRepo
+
+	mu     sync.Mutex
+	dir    string
+	format string
+	logger Logger
+}
+
+// NewFileScraperRepo открывает каталог результатов и загружает сохраненные ранее данные
+func NewFileScraperRepo(dir, format string, logger Logger) (*FileScraperRepo, error) {
+	if dir == "" {
+		return nil, errors.New("output directory is required")
+	}
+	format = strings.ToLower(format)
+	if format == "" {
+		format = FileFormatNDJSON
+	}
+	if format != FileFormatNDJSON && format != FileFormatJSON {
+		return nil, fmt.Errorf("unknown file format %q, expected %s or %s", format, FileFormatNDJSON, FileFormatJSON)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	repo := &FileScraperRepo{
+		MemoryScraperRepo: NewMemoryScraperRepo(),
+		dir:               dir,
+		format:            format,
+		logger:            logger,
+	}
+	if err := repo.load(); err != nil {
+		return nil, err
+	}
+
+	logger.Debug("File storage opened", "dir", dir, "format", format)
+	return repo, nil
+}
+
+// SaveResult сохраняет один результат скраппинга
+func (r *FileScraperRepo) SaveResult(ctx context.Context, result *models.ScrapingResult) (string, error) {
+	change, err := r.UpsertResult(ctx, result)
+	if err != nil {
+		return "", err
+	}
+	return change.ResultID, nil
+}
+
+// UpsertResult сохраняет результат по project, type и url и записывает его в файл
+func (r *FileScraperRepo) UpsertResult(ctx context.Context, result *models.ScrapingResult) (_ *models.ResultChange, err error) {
+	defer func(start time.Time) { observe("upsert", start, err) }(time.Now())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	change, err := r.MemoryScraperRepo.UpsertResult(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	if change.ChangeType == models.ChangeUnchanged {
+		// В памяти обновлено только время проверки. Файл не перезаписывается, иначе NDJSON
+		// рос бы на строку с каждой проверкой: после перезапуска время проверки
+		// берется из последней записи с изменением
+		upserts.With("unchanged").Inc()
+		return change, nil
+	}
+	if err := r.persist(result); err != nil {
+		r.logger.Error("Failed to write result", withContext(ctx, "url", result.URL, "error", err)...)
+		return nil, err
+	}
+
+	if change.ChangeType == models.ChangeCreated {
+		upserts.With("insert").Inc()
+	} else {
+		upserts.With("update").Inc()
+	}
+	return change, nil
+}
+
+// SaveFailure отмечает результат неудачей failed или создает его и записывает в файл
+func (r *FileScraperRepo) SaveFailure(ctx context.Context, failed *models.ScrapingResult) (_ string, err error) {
+	defer func(start time.Time) { observe("save_failure", start, err) }(time.Now())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, err := r.MemoryScraperRepo.SaveFailure(ctx, failed)
+	if err != nil {
+		return "", err
+	}
+	saved, err := r.MemoryScraperRepo.GetResultByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if err := r.persist(saved); err != nil {
+		r.logger.Error("Failed to write failure", withContext(ctx, "url", failed.URL, "error", err)...)
+		return "", err
+	}
+	return id, nil
+}
+
+// SaveResults сохраняет несколько результатов скраппинга
+func (r *FileScraperRepo) SaveResults(ctx context.Context, results []*models.ScrapingResult) ([]string, error) {
+	ids := []string{}
+	for _, result := range results {
+		id, err := r.SaveResult(ctx, result)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// UpdateResult заменяет сохраненный результат с тем же ID
+func (r *FileScraperRepo) UpdateResult(ctx context.Context, result *models.ScrapingResult) (err error) {
+	defer func(start time.Time) { observe("update", start, err) }(time.Now())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.MemoryScraperRepo.UpdateResult(ctx, result); err != nil {
+		return err
+	}
+	return r.persist(result)
+}
+
+// DeleteResult удаляет результат по ID и перезаписывает файл его типа
+func (r *FileScraperRepo) DeleteResult(ctx context.Context, id string) (err error) {
+	defer func(start time.Time) { observe("delete", start, err) }(time.Now())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, err := r.MemoryScraperRepo.GetResultByID(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := r.MemoryScraperRepo.DeleteResult(ctx, id); err != nil {
+		return err
+	}
+
+	if r.format == FileFormatJSON {
+		return r.writeJSON()
+	}
+	return r.rewriteNDJSON(existing.Type)
+}
+
+// persist записывает результат в файл выбранного формата
+func (r *FileScraperRepo) persist(result *models.ScrapingResult) error {
+	if r.format == FileFormatJSON {
+		return r.writeJSON()
+	}
+
+	f, err := os.OpenFile(r.ndjsonPath(result.Type), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(result)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// all возвращает все результаты, включая истекшие
+func (r *FileScraperRepo) all() []*models.ScrapingResult {
+	return r.MemoryScraperRepo.find([]QueryOption{IncludeExpired()}, func(*models.ScrapingResult) bool { return true })
+}
+
+// writeJSON перезаписывает results.json массивом всех результатов
+func (r *FileScraperRepo) writeJSON() error {
+	results := r.all()
+	if results == nil {
+		results = []*models.ScrapingResult{}
+	}
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(r.dir, jsonResultsFile), data)
+}
+
+// rewriteNDJSON перезаписывает файл типа текущими результатами, убирая старые версии
+func (r *FileScraperRepo) rewriteNDJSON(scraperType string) error {
+	var buf strings.Builder
+	for _, result := range r.all() {
+		if result.Type != scraperType {
+			continue
+		}
+		line, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return writeFileAtomic(r.ndjsonPath(scraperType), []byte(buf.String()))
+}
+
+// load загружает результаты, сохраненные предыдущими запусками
+func (r *FileScraperRepo) load() error {
+	if r.format == FileFormatJSON {
+		data, err := os.ReadFile(filepath.Join(r.dir, jsonResultsFile))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var results []*models.ScrapingResult
+		if err := json.Unmarshal(data, &results); err != nil {
+			return fmt.Errorf("%s: %w", jsonResultsFile, err)
+		}
+		for _, result := range results {
+			r.restore(result)
+		}
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(r.dir, "*.ndjson"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := r.loadNDJSON(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *FileScraperRepo) loadNDJSON(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var result models.ScrapingResult
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			return fmt.Errorf("%s:%d: %w", filepath.Base(path), n, err)
+		}
+		r.restore(&result)
+	}
+	return scanner.Err()
+}
+
+// restore кладет загруженный результат в память, сохраняя его ID и время создания
+func (r *FileScraperRepo) restore(result *models.ScrapingResult) {
+	m := r.MemoryScraperRepo
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.results[result.ID]; !ok {
+		m.order = append(m.order, result.ID)
+	}
+	m.results[result.ID] = result
+}
+
+// ndjsonPath возвращает путь к файлу типа
+func (r *FileScraperRepo) ndjsonPath(scraperType string) string {
+	return filepath.Join(r.dir, fileNameFor(scraperType)+".ndjson")
+}
+
+// fileNameFor превращает тип скраппера в безопасное имя файла
+func fileNameFor(scraperType string) string {
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', 0:
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(scraperType))
+	if name == "" || name == "." || name == ".." {
+		return "untyped"
+	}
+	return name
+}
+
+// writeFileAtomic записывает файл через временный файл и переименование
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
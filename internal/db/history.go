@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultHistoryCollection - коллекция истории изменений результатов по умолчанию
+const DefaultHistoryCollection = "result_history"
+
+// HistoryRepository определяет интерфейс для истории изменений результатов
+type HistoryRepository interface {
+	SaveHistory(ctx context.Context, entry *models.HistoryEntry) (string, error)
+	GetHistory(ctx context.Context, resultID string, limit int64) ([]*models.HistoryEntry, error)
+}
+
+// MongoHistoryRepo имплементирует интерфейс HistoryRepository
+type MongoHistoryRepo struct {
+	collection *mongo.Collection
+}
+
+// NewMongoHistoryRepo создает репозиторий истории изменений
+func NewMongoHistoryRepo(client *mongo.Client, dbname, collectionName string) (*MongoHistoryRepo, error) {
+	if client == nil {
+		return nil, errors.New("Mongo client is nil")
+	}
+
+	if collectionName == "" {
+		collectionName = DefaultHistoryCollection
+	}
+
+	collection := client.Database(dbname).Collection(collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	// История читается по результату, новые записи первыми
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "result_id", Value: 1}, {Key: "changed_at", Value: -1}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoHistoryRepo{collection: collection}, nil
+}
+
+// SaveHistory добавляет запись в историю изменений
+func (r *MongoHistoryRepo) SaveHistory(ctx context.Context, entry *models.HistoryEntry) (string, error) {
+	if r.collection == nil {
+		return "", ErrNilCollection
+	}
+
+	ctx, span := tracing.Start(ctx, "db.save_history", tracing.String("result.id", entry.ResultID))
+	defer span.End()
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+
+	if _, err := r.collection.InsertOne(timeout, entry); err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+
+	return entry.ID.Hex(), nil
+}
+
+// GetHistory возвращает последние изменения результата, новые первыми
+func (r *MongoHistoryRepo) GetHistory(ctx context.Context, resultID string, limit int64) ([]*models.HistoryEntry, error) {
+	if r.collection == nil {
+		return nil, ErrNilCollection
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "changed_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	cursor, err := r.collection.Find(timeout, bson.M{"result_id": resultID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var entries []*models.HistoryEntry
+	if err := cursor.All(timeout, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// MemoryHistoryRepo хранит историю изменений в памяти процесса
+type MemoryHistoryRepo struct {
+	mu      sync.RWMutex
+	entries []*models.HistoryEntry
+}
+
+// NewMemoryHistoryRepo создает историю изменений в памяти
+func NewMemoryHistoryRepo() *MemoryHistoryRepo {
+	return &MemoryHistoryRepo{}
+}
+
+// SaveHistory добавляет запись в историю изменений
+func (r *MemoryHistoryRepo) SaveHistory(ctx context.Context, entry *models.HistoryEntry) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+
+	saved := *entry
+	r.entries = append(r.entries, &saved)
+
+	return entry.ID.Hex(), nil
+}
+
+// GetHistory возвращает последние изменения результата, новые первыми
+func (r *MemoryHistoryRepo) GetHistory(ctx context.Context, resultID string, limit int64) ([]*models.HistoryEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []*models.HistoryEntry
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if limit > 0 && int64(len(entries)) >= limit {
+			break
+		}
+		if r.entries[i].ResultID != resultID {
+			continue
+		}
+		entry := *r.entries[i]
+		entries = append(entries, &entry)
+	}
+
+	return entries, nil
+}
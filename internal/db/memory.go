@@ -0,0 +1,298 @@
+package db
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryScraperRepo хранит результаты в памяти процесса.
+// Подходит для тестов и запусков без MongoDB, данные теряются при завершении
+type MemoryScraperRepo struct {
+	mu      sync.RWMutex
+	results map[primitive.ObjectID]*models.ScrapingResult
+	order   []primitive.ObjectID
+}
+
+// NewMemoryScraperRepo создает пустой репозиторий в памяти
+func NewMemoryScraperRepo() *MemoryScraperRepo {
+	return &MemoryScraperRepo{results: make(map[primitive.ObjectID]*models.ScrapingResult)}
+}
+
+// GetAllResults возвращает результаты с учетом фильтров, сортировки и страницы из opts
+func (r *MemoryScraperRepo) GetAllResults(ctx context.Context, opts ...QueryOption) ([]*models.ScrapingResult, error) {
+	return r.find(opts, func(*models.ScrapingResult) bool { return true }), nil
+}
+
+// CountResults возвращает число результатов, подходящих под фильтры opts, без учета страницы
+func (r *MemoryScraperRepo) CountResults(ctx context.Context, opts ...QueryOption) (int64, error) {
+	return int64(len(r.filter(applyQueryOptions(opts), func(*models.ScrapingResult) bool { return true }))), nil
+}
+
+// GetStats считает сводку по типам и источникам, включая результаты с истекшим сроком
+func (r *MemoryScraperRepo) GetStats(ctx context.Context) (*models.Stats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	acc := newStatsAccumulator()
+	for _, id := range r.order {
+		res := r.results[id]
+		acc.add(res.Type, res.Name, res.UpdatedAt, res.Completeness())
+	}
+	return acc.result(), nil
+}
+
+// GetResultByID возвращает результат по ID
+func (r *MemoryScraperRepo) GetResultByID(ctx context.Context, id string) (*models.ScrapingResult, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result, ok := r.results[objID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneResult(result), nil
+}
+
+// GetResultsByType возвращает результаты заданного типа
+func (r *MemoryScraperRepo) GetResultsByType(ctx context.Context, scraperType string, opts ...QueryOption) ([]*models.ScrapingResult, error) {
+	return r.find(opts, func(res *models.ScrapingResult) bool { return res.Type == scraperType }), nil
+}
+
+// GetResultsByTag возвращает результаты с заданной меткой
+func (r *MemoryScraperRepo) GetResultsByTag(ctx context.Context, tag string, opts ...QueryOption) ([]*models.ScrapingResult, error) {
+	return r.find(opts, func(res *models.ScrapingResult) bool { return res.HasTag(tag) }), nil
+}
+
+// SaveResult сохраняет один результат скраппинга
+func (r *MemoryScraperRepo) SaveResult(ctx context.Context, result *models.ScrapingResult) (string, error) {
+	change, err := r.UpsertResult(ctx, result)
+	if err != nil {
+		return "", err
+	}
+	return change.ResultID, nil
+}
+
+// UpsertResult сохраняет результат по URL и типу и возвращает описание изменения
+func (r *MemoryScraperRepo) UpsertResult(ctx context.Context, result *models.ScrapingResult) (*models.ResultChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range r.order {
+		existing := r.results[id]
+		if existing.URL != result.URL || existing.Type != result.Type || existing.Project != result.Project {
+			continue
+		}
+
+		result.ID = existing.ID
+		result.CreatedAt = existing.CreatedAt
+		result.UpdatedAt = time.Now()
+
+		previous := existing.Data
+		if previous == nil {
+			previous = map[string]string{}
+		}
+		if result.SameContent(existing) {
+			// Содержимое не изменилось: UpdatedAt остается временем последнего изменения
+			result.UpdatedAt = existing.UpdatedAt
+			checked := cloneResult(existing)
+			checked.Metadata.SetChecked(result.Metadata)
+			r.results[id] = checked
+			return models.NewResultChange(id.Hex(), previous, result), nil
+		}
+		r.results[id] = cloneResult(result)
+
+		return models.NewResultChange(id.Hex(), previous, result), nil
+	}
+
+	result.ID = primitive.NewObjectID()
+	result.CreatedAt = time.Now()
+	result.UpdatedAt = result.CreatedAt
+
+	r.results[result.ID] = cloneResult(result)
+	r.order = append(r.order, result.ID)
+
+	return models.NewResultChange(result.ID.Hex(), nil, result), nil
+}
+
+// SaveFailure отмечает результат с тем же (project, type, url) неудачей failed или создает его
+func (r *MemoryScraperRepo) SaveFailure(ctx context.Context, failed *models.ScrapingResult) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range r.order {
+		existing := r.results[id]
+		if existing.URL != failed.URL || existing.Type != failed.Type || existing.Project != failed.Project {
+			continue
+		}
+
+		updated := cloneResult(existing)
+		updated.Metadata.SetFailure(failed.Metadata)
+		r.results[id] = updated
+		return id.Hex(), nil
+	}
+
+	failed.ID = primitive.NewObjectID()
+	failed.CreatedAt = time.Now()
+	failed.UpdatedAt = failed.CreatedAt
+
+	r.results[failed.ID] = cloneResult(failed)
+	r.order = append(r.order, failed.ID)
+
+	return failed.ID.Hex(), nil
+}
+
+// SaveResults сохраняет несколько результатов скраппинга
+func (r *MemoryScraperRepo) SaveResults(ctx context.Context, results []*models.ScrapingResult) ([]string, error) {
+	ids := []string{}
+	for _, result := range results {
+		id, err := r.SaveResult(ctx, result)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// UpdateResult заменяет сохраненный результат с тем же ID
+func (r *MemoryScraperRepo) UpdateResult(ctx context.Context, result *models.ScrapingResult) error {
+	if result.ID.IsZero() {
+		return ErrInvalidID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.results[result.ID]; !ok {
+		return ErrNotFound
+	}
+	r.results[result.ID] = cloneResult(result)
+	return nil
+}
+
+// DeleteResult удаляет результат по ID
+func (r *MemoryScraperRepo) DeleteResult(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.results, objID)
+	r.order = slices.DeleteFunc(r.order, func(o primitive.ObjectID) bool { return o == objID })
+	return nil
+}
+
+// Close ничего не делает
+func (r *MemoryScraperRepo) Close() error {
+	return nil
+}
+
+// find возвращает копии результатов, подходящих под условие и параметры выборки,
+// в порядке сортировки и с учетом страницы
+func (r *MemoryScraperRepo) find(opts []QueryOption, match func(*models.ScrapingResult) bool) []*models.ScrapingResult {
+	o := applyQueryOptions(opts)
+
+	results := r.filter(o, match)
+	sortResults(results, o.Sort, o.Desc)
+
+	results = results[min(o.Offset, len(results)):]
+	if o.Limit > 0 {
+		results = results[:min(o.Limit, len(results))]
+	}
+	return results
+}
+
+// filter возвращает копии результатов, подходящих под условие и фильтры выборки, в порядке добавления
+func (r *MemoryScraperRepo) filter(o QueryOptions, match func(*models.ScrapingResult) bool) []*models.ScrapingResult {
+	now := time.Now()
+	name := strings.ToLower(o.NameContains)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []*models.ScrapingResult
+	for _, id := range r.order {
+		res := r.results[id]
+		if !o.IncludeExpired && res.Expired(now) {
+			continue
+		}
+		if o.Project != nil && res.Project != *o.Project {
+			continue
+		}
+		if o.Type != "" && res.Type != o.Type {
+			continue
+		}
+		if o.URL != "" && res.URL != o.URL {
+			continue
+		}
+		if o.Tag != "" && !res.HasTag(o.Tag) {
+			continue
+		}
+		if o.Status != "" && res.Metadata.Status != o.Status {
+			continue
+		}
+		if name != "" && !strings.Contains(strings.ToLower(res.Name), name) {
+			continue
+		}
+		if !o.UpdatedAfter.IsZero() && !res.UpdatedAt.After(o.UpdatedAfter) {
+			continue
+		}
+		if match(res) {
+			results = append(results, cloneResult(res))
+		}
+	}
+	return results
+}
+
+// sortResults сортирует результаты по полю из SortFields, сохраняя порядок равных
+func sortResults(results []*models.ScrapingResult, field string, desc bool) {
+	slices.SortStableFunc(results, func(a, b *models.ScrapingResult) int {
+		var c int
+		switch field {
+		case SortUpdated:
+			c = a.UpdatedAt.Compare(b.UpdatedAt)
+		case SortName:
+			c = strings.Compare(a.Name, b.Name)
+		case SortType:
+			c = strings.Compare(a.Type, b.Type)
+		case SortURL:
+			c = strings.Compare(a.URL, b.URL)
+		default:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		if desc {
+			return -c
+		}
+		return c
+	})
+}
+
+// cloneResult копирует результат, чтобы вызывающий код не менял хранимые данные
+func cloneResult(r *models.ScrapingResult) *models.ScrapingResult {
+	c := *r
+	c.Data = maps.Clone(r.Data)
+	if r.Items != nil {
+		c.Items = make([]map[string]string, len(r.Items))
+		for i, item := range r.Items {
+			c.Items[i] = maps.Clone(item)
+		}
+	}
+	c.Tags = slices.Clone(r.Tags)
+	c.Confidence = maps.Clone(r.Confidence)
+	return &c
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/lib/metrics"
+)
+
+// Метрики репозитория регистрируются в общем реестре при загрузке пакета
+var (
+	opDuration = metrics.DefaultRegistry.NewHistogramVec(
+		"kultscraper_db_operation_duration_seconds",
+		"Duration of repository operations.",
+		[]float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		"op",
+	)
+	upserts = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_db_upserts_total",
+		"Number of saved results by write kind.",
+		"kind",
+	)
+	opErrors = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_db_errors_total",
+		"Number of failed repository operations.",
+		"op",
+	)
+)
+
+// observe записывает длительность и ошибку операции репозитория
+func observe(op string, start time.Time, err error) {
+	opDuration.With(op).Observe(time.Since(start).Seconds())
+	if err != nil {
+		opErrors.With(op).Inc()
+	}
+}
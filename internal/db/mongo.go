@@ -5,6 +5,8 @@ import (
 	"errors"
 	"time"
 
+	applog "github.com/rx3lixir/kultscraper/internal/lib/logger"
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
 	"github.com/rx3lixir/kultscraper/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -24,11 +26,18 @@ var (
 
 // ScraperRepository определяет интерйес для работы с данными скраппинга
 type ScraperRepository interface {
-	GetAllResults(ctx context.Context) ([]*models.ScrapingResult, error)
+	GetAllResults(ctx context.Context, opts ...QueryOption) ([]*models.ScrapingResult, error)
+	CountResults(ctx context.Context, opts ...QueryOption) (int64, error)
+	GetStats(ctx context.Context) (*models.Stats, error)
 	GetResultByID(ctx context.Context, id string) (*models.ScrapingResult, error)
-	GetResultByType(ctx context.Context, scraperType string) (*models.ScrapingResult, error)
+	GetResultsByType(ctx context.Context, scraperType string, opts ...QueryOption) ([]*models.ScrapingResult, error)
+	GetResultsByTag(ctx context.Context, tag string, opts ...QueryOption) ([]*models.ScrapingResult, error)
 
 	SaveResult(ctx context.Context, result *models.ScrapingResult) (string, error)
+	UpsertResult(ctx context.Context, result *models.ScrapingResult) (*models.ResultChange, error)
+	// SaveFailure записывает неудачу задачи в ее результат, не затирая данные последнего
+	// успешного сбора. Если результата еще нет, сохраняет failed как новый
+	SaveFailure(ctx context.Context, failed *models.ScrapingResult) (string, error)
 	SaveResults(ctx context.Context, result []*models.ScrapingResult) ([]string, error)
 
 	UpdateResult(ctx context.Context, result *models.ScrapingResult) error
@@ -37,14 +46,47 @@ type ScraperRepository interface {
 	Close() error
 }
 
+// Logger - интерфейс для логирования
+type Logger interface {
+	Info(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+	Debug(msg string, keyvals ...interface{})
+}
+
+// NoopLogger - реализация Logger, которая ничего не делает
+type NoopLogger struct{}
+
+func (n NoopLogger) Info(msg string, keyvals ...interface{})  {}
+func (n NoopLogger) Error(msg string, keyvals ...interface{}) {}
+func (n NoopLogger) Debug(msg string, keyvals ...interface{}) {}
+
+// withContext дополняет пары ключ-значение для логов данными из контекста (exec_id)
+func withContext(ctx context.Context, keyvals ...interface{}) []interface{} {
+	return append(keyvals, applog.ContextKeyvals(ctx)...)
+}
+
+// Проверяем соответствие реализаций интерфейсу на этапе компиляции
+var (
+	_ ScraperRepository = (*MongoScraperRepo)(nil)
+	_ ScraperRepository = (*MemoryScraperRepo)(nil)
+	_ ScraperRepository = (*SQLScraperRepo)(nil)
+	_ ScraperRepository = (*FileScraperRepo)(nil)
+)
+
 // MongoScraperRepo имплементирует интерфейс ScraperRepository
 type MongoScraperRepo struct {
 	client     *mongo.Client
 	collection *mongo.Collection
+	logger     Logger
 }
 
 // NewMongoScraperRepo создает новый репозиторий скраппинга
 func NewMongoScraperRepo(client *mongo.Client, dbname, collectionName string) (*MongoScraperRepo, error) {
+	return NewMongoScraperRepoWithLogger(client, dbname, collectionName, NoopLogger{})
+}
+
+// NewMongoScraperRepoWithLogger создает новый репозиторий скраппинга с логгером
+func NewMongoScraperRepoWithLogger(client *mongo.Client, dbname, collectionName string, logger Logger) (*MongoScraperRepo, error) {
 	if client == nil {
 		return nil, errors.New("Mongo client is nil")
 	}
@@ -57,6 +99,7 @@ func NewMongoScraperRepo(client *mongo.Client, dbname, collectionName string) (*
 	repo := &MongoScraperRepo{
 		client:     client,
 		collection: collection,
+		logger:     logger,
 	}
 
 	// Создаем индексы для более быстрого поиска
@@ -73,9 +116,14 @@ func NewMongoScraperRepo(client *mongo.Client, dbname, collectionName string) (*
 		return nil, err
 	}
 
-	// Создаем составной индекс по type и url
+	// Уникальность по type и url действует в пределах проекта, поэтому старый
+	// глобальный индекс удаляем (ошибка означает, что его уже нет)
+	_, _ = collection.Indexes().DropOne(ctx, "type_1_url_1")
+
+	// Создаем составной индекс по project, type и url
 	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.D{
+			{Key: "project", Value: 1},
 			{Key: "type", Value: 1},
 			{Key: "url", Value: 1},
 		},
@@ -85,11 +133,45 @@ func NewMongoScraperRepo(client *mongo.Client, dbname, collectionName string) (*
 		return nil, err
 	}
 
+	// Создаем индекс по меткам
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "tags", Value: 1},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Создаем индекс по времени обновления для фильтра UpdatedAfter и сортировки
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "updated_at", Value: 1},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Создаем индекс по сроку актуальности
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "expires_at", Value: 1},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug("MongoDB indexes ensured", "database", dbname, "collection", collectionName)
+
 	return repo, nil
 }
 
-// GetAllResults возвращает все результаты скраппинга
-func (r *MongoScraperRepo) GetAllResults(ctx context.Context) ([]*models.ScrapingResult, error) {
+// GetAllResults возвращает результаты скраппинга с учетом фильтров, сортировки и страницы из opts
+func (r *MongoScraperRepo) GetAllResults(ctx context.Context, opts ...QueryOption) (results []*models.ScrapingResult, err error) {
+	defer func(start time.Time) { observe("get_all", start, err) }(time.Now())
+
 	if r.collection == nil {
 		return nil, ErrNilCollection
 	}
@@ -97,14 +179,12 @@ func (r *MongoScraperRepo) GetAllResults(ctx context.Context) ([]*models.Scrapin
 	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
 	defer cancel()
 
-	cursor, err := r.collection.Find(timeout, bson.M{})
+	cursor, err := r.collection.Find(timeout, withQueryOptions(bson.M{}, opts), findOptions(opts))
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(context.Background())
 
-	var results []*models.ScrapingResult
-
 	if err := cursor.All(timeout, &results); err != nil {
 		return nil, err
 	}
@@ -112,8 +192,112 @@ func (r *MongoScraperRepo) GetAllResults(ctx context.Context) ([]*models.Scrapin
 	return results, nil
 }
 
+// CountResults возвращает число результатов, подходящих под фильтры opts, без учета страницы
+func (r *MongoScraperRepo) CountResults(ctx context.Context, opts ...QueryOption) (_ int64, err error) {
+	defer func(start time.Time) { observe("count", start, err) }(time.Now())
+
+	if r.collection == nil {
+		return 0, ErrNilCollection
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	return r.collection.CountDocuments(timeout, withQueryOptions(bson.M{}, opts))
+}
+
+// GetStats считает сводку по типам и источникам одним конвейером агрегации,
+// включая результаты с истекшим сроком актуальности
+func (r *MongoScraperRepo) GetStats(ctx context.Context) (_ *models.Stats, err error) {
+	defer func(start time.Time) { observe("stats", start, err) }(time.Now())
+
+	if r.collection == nil {
+		return nil, ErrNilCollection
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	// Полнота - доля непустых значений в data, для пустого data - 0
+	fields := bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$data", bson.M{}}}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$project", Value: bson.M{
+			"type":       1,
+			"name":       1,
+			"updated_at": 1,
+			"fields":     fields,
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"type":       1,
+			"name":       1,
+			"updated_at": 1,
+			"completeness": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{bson.M{"$size": "$fields"}, 0}},
+				0,
+				bson.M{"$divide": bson.A{
+					bson.M{"$size": bson.M{"$filter": bson.M{
+						"input": "$fields",
+						"cond":  bson.M{"$ne": bson.A{"$$this.v", ""}},
+					}}},
+					bson.M{"$size": "$fields"},
+				}},
+			}},
+		}}},
+		{{Key: "$facet", Value: bson.M{
+			"total": bson.A{bson.M{"$count": "n"}},
+			"types": bson.A{bson.M{"$group": bson.M{
+				"_id":          "$type",
+				"count":        bson.M{"$sum": 1},
+				"completeness": bson.M{"$avg": "$completeness"},
+				"last_scraped": bson.M{"$max": "$updated_at"},
+			}}},
+			"sources": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":          bson.M{"type": "$type", "name": "$name"},
+					"count":        bson.M{"$sum": 1},
+					"last_scraped": bson.M{"$max": "$updated_at"},
+				}},
+				bson.M{"$project": bson.M{
+					"_id":          0,
+					"type":         "$_id.type",
+					"name":         "$_id.name",
+					"count":        1,
+					"last_scraped": 1,
+				}},
+			},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(timeout, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var facets []struct {
+		Total   []struct{ N int64 }  `bson:"total"`
+		Types   []models.TypeStats   `bson:"types"`
+		Sources []models.SourceStats `bson:"sources"`
+	}
+	if err := cursor.All(timeout, &facets); err != nil {
+		return nil, err
+	}
+
+	stats := &models.Stats{GeneratedAt: time.Now()}
+	if len(facets) > 0 {
+		if len(facets[0].Total) > 0 {
+			stats.Total = facets[0].Total[0].N
+		}
+		stats.Types, stats.Sources = facets[0].Types, facets[0].Sources
+	}
+	sortStats(stats)
+	return stats, nil
+}
+
 // GetResultByID возвращает результат скраппинга по ID
-func (r *MongoScraperRepo) GetResultByID(ctx context.Context, id string) (*models.ScrapingResult, error) {
+func (r *MongoScraperRepo) GetResultByID(ctx context.Context, id string) (_ *models.ScrapingResult, err error) {
+	defer func(start time.Time) { observe("get_by_id", start, err) }(time.Now())
+
 	if r.collection == nil {
 		return nil, ErrNilCollection
 	}
@@ -139,7 +323,34 @@ func (r *MongoScraperRepo) GetResultByID(ctx context.Context, id string) (*model
 	return &result, nil
 }
 
-func (r *MongoScraperRepo) GetResultsByType(ctx context.Context, scraperType string) ([]*models.ScrapingResult, error) {
+// GetResultsByType возвращает результаты скраппинга заданного типа
+func (r *MongoScraperRepo) GetResultsByType(ctx context.Context, scraperType string, opts ...QueryOption) (results []*models.ScrapingResult, err error) {
+	defer func(start time.Time) { observe("get_by_type", start, err) }(time.Now())
+
+	if r.collection == nil {
+		return nil, ErrNilCollection
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(timeout, withQueryOptions(bson.M{"type": scraperType}, opts), findOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	if err := cursor.All(timeout, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetResultsByTag возвращает результаты скраппинга с заданной меткой
+func (r *MongoScraperRepo) GetResultsByTag(ctx context.Context, tag string, opts ...QueryOption) (results []*models.ScrapingResult, err error) {
+	defer func(start time.Time) { observe("get_by_tag", start, err) }(time.Now())
+
 	if r.collection == nil {
 		return nil, ErrNilCollection
 	}
@@ -147,13 +358,12 @@ func (r *MongoScraperRepo) GetResultsByType(ctx context.Context, scraperType str
 	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
 	defer cancel()
 
-	cursor, err := r.collection.Find(timeout, bson.M{"type": scraperType})
+	cursor, err := r.collection.Find(timeout, withQueryOptions(bson.M{"tags": tag}, opts), findOptions(opts))
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(context.Background())
 
-	var results []*models.ScrapingResult
 	if err := cursor.All(timeout, &results); err != nil {
 		return nil, err
 	}
@@ -163,41 +373,76 @@ func (r *MongoScraperRepo) GetResultsByType(ctx context.Context, scraperType str
 
 // SaveResult сохраняет один результат скраппинга
 func (r *MongoScraperRepo) SaveResult(ctx context.Context, result *models.ScrapingResult) (string, error) {
+	change, err := r.UpsertResult(ctx, result)
+	if err != nil {
+		return "", err
+	}
+	return change.ResultID, nil
+}
+
+// UpsertResult сохраняет результат скраппинга и возвращает описание изменения
+func (r *MongoScraperRepo) UpsertResult(ctx context.Context, result *models.ScrapingResult) (_ *models.ResultChange, err error) {
+	defer func(start time.Time) { observe("upsert", start, err) }(time.Now())
+
 	if r.collection == nil {
-		return "", ErrNilCollection
+		return nil, ErrNilCollection
 	}
 
+	ctx, span := tracing.Start(ctx, "db.upsert",
+		tracing.String("url", result.URL),
+		tracing.String("type", result.Type),
+	)
+	defer span.End()
+
 	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
 	defer cancel()
 
-	// Проверяем существует ли уже документ с таким URL и типом
-	filter := bson.M{"url": result.URL, "type": result.Type}
+	// Проверяем существует ли уже документ с таким URL и типом в проекте
+	filter := resultKey(result)
 
 	var existing models.ScrapingResult
 
-	err := r.collection.FindOne(timeout, filter).Decode(&existing)
+	err = r.collection.FindOne(timeout, filter).Decode(&existing)
 
 	if err == nil {
-		// Документ существует, обновляем его
 		result.ID = existing.ID
 		result.CreatedAt = existing.CreatedAt
-		result.UpdatedAt = time.Now()
 
-		update := bson.M{
-			"$set": bson.M{
-				"name":       result.Name,
-				"data":       result.Data,
-				"updated_at": result.UpdatedAt,
-				"metadata":   result.Metadata,
-			},
+		previous := existing.Data
+		if previous == nil {
+			previous = map[string]string{}
 		}
 
+		if result.SameContent(&existing) {
+			// Содержимое не изменилось: отмечаем только проверку, UpdatedAt остается временем последнего изменения
+			result.UpdatedAt = existing.UpdatedAt
+			_, err = r.collection.UpdateOne(timeout, bson.M{"_id": existing.ID}, bson.M{"$set": checkedFields(result)})
+			if err != nil {
+				r.logger.Error("Failed to mark result checked", withContext(ctx, "url", result.URL, "error", err)...)
+				span.RecordError(err)
+				return nil, err
+			}
+			r.logger.Debug("Result unchanged", withContext(ctx, "id", existing.ID.Hex(), "url", result.URL)...)
+			upserts.With("unchanged").Inc()
+
+			return models.NewResultChange(existing.ID.Hex(), previous, result), nil
+		}
+
+		// Документ существует, обновляем его
+		result.UpdatedAt = time.Now()
+
+		update := bson.M{"$set": updatableFields(result)}
+
 		_, err = r.collection.UpdateOne(timeout, bson.M{"_id": existing.ID}, update)
 		if err != nil {
-			return "", err
+			r.logger.Error("Failed to update result", withContext(ctx, "url", result.URL, "error", err)...)
+			span.RecordError(err)
+			return nil, err
 		}
+		r.logger.Debug("Updated existing result", withContext(ctx, "id", existing.ID.Hex(), "url", result.URL)...)
+		upserts.With("update").Inc()
 
-		return existing.ID.Hex(), nil
+		return models.NewResultChange(existing.ID.Hex(), previous, result), nil
 	} else if err == mongo.ErrNoDocuments {
 		// Документ не существует, создаем новый
 		result.ID = primitive.NewObjectID()
@@ -206,40 +451,280 @@ func (r *MongoScraperRepo) SaveResult(ctx context.Context, result *models.Scrapi
 
 		_, err = r.collection.InsertOne(timeout, result)
 		if err != nil {
-			return "", err
+			r.logger.Error("Failed to insert result", withContext(ctx, "url", result.URL, "error", err)...)
+			span.RecordError(err)
+			return nil, err
 		}
+		r.logger.Debug("Inserted new result", withContext(ctx, "id", result.ID.Hex(), "url", result.URL)...)
+		upserts.With("insert").Inc()
 
-		return result.ID.Hex(), nil
+		return models.NewResultChange(result.ID.Hex(), nil, result), nil
 	}
 
 	// Какая-то другая непредвиденная ошибка
-	return "", err
+	span.RecordError(err)
+	return nil, err
+}
+
+// SaveFailure отмечает результат с тем же (project, type, url) неудачей failed или создает его
+func (r *MongoScraperRepo) SaveFailure(ctx context.Context, failed *models.ScrapingResult) (_ string, err error) {
+	defer func(start time.Time) { observe("save_failure", start, err) }(time.Now())
+
+	if r.collection == nil {
+		return "", ErrNilCollection
+	}
+
+	ctx, span := tracing.Start(ctx, "db.save_failure",
+		tracing.String("url", failed.URL),
+		tracing.String("type", failed.Type),
+	)
+	defer span.End()
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	meta := failed.Metadata
+	now := time.Now()
+	onInsert := bson.M{
+		"name":       failed.Name,
+		"data":       bson.M{},
+		"created_at": now,
+		"updated_at": now,
+	}
+	// Для проекта по умолчанию фильтр не задает значение project, оно добавляется явно
+	if failed.Project == "" {
+		onInsert["project"] = ""
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"metadata.status":           meta.Status,
+			"metadata.error":            meta.Error,
+			"metadata.error_code":       meta.ErrorCode,
+			"metadata.attempt":          meta.Attempt,
+			"metadata.task_fingerprint": meta.TaskFingerprint,
+			"metadata.run_id":           meta.RunID,
+			"metadata.exec_id":          meta.ExecutionID,
+		},
+		"$setOnInsert": onInsert,
+	}
+
+	var saved struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"_id": 1})
+	if err := r.collection.FindOneAndUpdate(timeout, resultKey(failed), update, opts).Decode(&saved); err != nil {
+		r.logger.Error("Failed to save failure", withContext(ctx, "url", failed.URL, "error", err)...)
+		span.RecordError(err)
+		return "", err
+	}
+
+	return saved.ID.Hex(), nil
 }
 
-// SaveResults сохраняет несколько результатов скраппинга
+// SaveResults сохраняет несколько результатов одним BulkWrite, см. UpsertResults.
+// Возвращает ID в порядке results
 func (r *MongoScraperRepo) SaveResults(ctx context.Context, results []*models.ScrapingResult) ([]string, error) {
+	changes, err := r.UpsertResults(ctx, results)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(changes))
+	for i, change := range changes {
+		ids[i] = change.ResultID
+	}
+	return ids, nil
+}
+
+// UpsertResults сохраняет несколько результатов одним BulkWrite по (project, type, url)
+// и возвращает изменения в порядке results. Существующие документы читаются одной выборкой
+// и сравниваются с результатами так же, как в UpsertResult: при неизменном содержимом
+// записываются только поля проверки, а UpdatedAt остается временем последнего изменения.
+// Хуки сохранения и историю по возвращенным изменениям ведет вызывающий код
+func (r *MongoScraperRepo) UpsertResults(ctx context.Context, results []*models.ScrapingResult) (_ []*models.ResultChange, err error) {
+	defer func(start time.Time) { observe("bulk_upsert", start, err) }(time.Now())
+
 	if r.collection == nil {
 		return nil, ErrNilCollection
 	}
 
 	if len(results) == 0 {
-		return []string{}, nil
+		return []*models.ResultChange{}, nil
 	}
 
-	var ids []string
-	for _, result := range results {
-		id, err := r.SaveResult(ctx, result)
+	ctx, span := tracing.Start(ctx, "db.bulk_upsert", tracing.Int("count", len(results)))
+	defer span.End()
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	keys := make([]bson.M, len(results))
+	for i, result := range results {
+		keys[i] = resultKey(result)
+	}
+	existing, err := r.findExisting(timeout, keys)
+	if err != nil {
+		r.logger.Error("Failed to load existing results", withContext(ctx, "count", len(results), "error", err)...)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	now := time.Now()
+	changes := make([]*models.ResultChange, len(results))
+	writes := make([]mongo.WriteModel, 0, len(results))
+	inserted := make(map[[3]string]bool) // Ключи документов, создаваемых этим пакетом
+	var fresh []int                      // Результаты, создающие документ
+	updated, unchanged := 0, 0
+	for i, result := range results {
+		key := [3]string{result.Project, result.Type, result.URL}
+		previous, found := existing[key]
+		if !found {
+			result.ID = primitive.NewObjectID()
+			result.CreatedAt = now
+			result.UpdatedAt = now
+			writes = append(writes, upsertModel(result))
+			changes[i] = models.NewResultChange(result.ID.Hex(), nil, result)
+			existing[key] = *result
+			inserted[key] = true
+			fresh = append(fresh, i)
+			continue
+		}
+
+		result.ID, result.CreatedAt = previous.ID, previous.CreatedAt
+		data := previous.Data
+		if data == nil {
+			data = map[string]string{}
+		}
+		switch {
+		case inserted[key]:
+			// Повтор ключа в пакете: документ еще может быть не создан, поэтому тоже upsert
+			result.UpdatedAt = now
+			writes = append(writes, upsertModel(result))
+			updated++
+		case result.SameContent(&previous):
+			result.UpdatedAt = previous.UpdatedAt
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": previous.ID}).
+				SetUpdate(bson.M{"$set": checkedFields(result)}))
+			unchanged++
+		default:
+			result.UpdatedAt = now
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": previous.ID}).
+				SetUpdate(bson.M{"$set": updatableFields(result)}))
+			updated++
+		}
+		changes[i] = models.NewResultChange(result.ID.Hex(), data, result)
+		existing[key] = *result
+	}
+
+	res, err := r.collection.BulkWrite(timeout, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		r.logger.Error("Failed to bulk save results", withContext(ctx, "count", len(results), "error", err)...)
+		span.RecordError(err)
+		return nil, err
+	}
+	upserts.With("insert").Add(float64(res.UpsertedCount))
+	upserts.With("update").Add(float64(updated))
+	upserts.With("unchanged").Add(float64(unchanged))
+
+	// Документ мог создать другой процесс после выборки: такой результат обновил его,
+	// а ID и время создания берутся из существующего документа
+	var raced []bson.M
+	for _, i := range fresh {
+		if _, ok := res.UpsertedIDs[int64(i)]; !ok {
+			raced = append(raced, keys[i])
+		}
+	}
+	if len(raced) > 0 {
+		found, err := r.findExisting(timeout, raced)
 		if err != nil {
-			return ids, err
+			span.RecordError(err)
+			return nil, err
+		}
+		for i, result := range results {
+			if e, ok := found[[3]string{result.Project, result.Type, result.URL}]; ok {
+				result.ID, result.CreatedAt = e.ID, e.CreatedAt
+				changes[i].ResultID = e.ID.Hex()
+			}
 		}
-		ids = append(ids, id)
 	}
 
-	return ids, nil
+	r.logger.Debug("Bulk saved results", withContext(ctx,
+		"count", len(results), "inserted", res.UpsertedCount, "updated", updated, "unchanged", unchanged)...)
+
+	return changes, nil
+}
+
+// findExisting возвращает существующие документы по ключам keys, индексированные по (project, type, url)
+func (r *MongoScraperRepo) findExisting(ctx context.Context, keys []bson.M) (map[[3]string]models.ScrapingResult, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"$or": keys})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var existing []models.ScrapingResult
+	if err := cursor.All(ctx, &existing); err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[[3]string]models.ScrapingResult, len(existing))
+	for _, e := range existing {
+		byKey[[3]string{e.Project, e.Type, e.URL}] = e
+	}
+	return byKey, nil
+}
+
+// upsertModel возвращает upsert результата по (project, type, url) с заранее созданным ID
+func upsertModel(result *models.ScrapingResult) mongo.WriteModel {
+	// Поля ключа с равенством копируются в новый документ из фильтра,
+	// project для проекта по умолчанию фильтруется через $in и не копируется
+	onInsert := bson.M{"_id": result.ID, "created_at": result.CreatedAt}
+	if result.Project != "" {
+		onInsert["project"] = result.Project
+	}
+	return mongo.NewUpdateOneModel().
+		SetFilter(resultKey(result)).
+		SetUpdate(bson.M{"$set": updatableFields(result), "$setOnInsert": onInsert}).
+		SetUpsert(true)
+}
+
+// resultKey возвращает фильтр документа результата по проекту, типу и URL
+func resultKey(result *models.ScrapingResult) bson.M {
+	return bson.M{"url": result.URL, "type": result.Type, "project": projectFilter(result.Project)}
+}
+
+// checkedFields возвращает поля проверки, которые записываются, когда содержимое результата не изменилось
+func checkedFields(result *models.ScrapingResult) bson.M {
+	return bson.M{
+		"metadata.checked_at":    result.Metadata.CheckedAt,
+		"metadata.etag":          result.Metadata.ETag,
+		"metadata.last_modified": result.Metadata.LastModified,
+	}
+}
+
+// updatableFields возвращает поля, которые перезаписываются при повторном сохранении результата
+func updatableFields(result *models.ScrapingResult) bson.M {
+	return bson.M{
+		"name":       result.Name,
+		"data":       result.Data,
+		"items":      result.Items,
+		"tags":       result.Tags,
+		"confidence": result.Confidence,
+		"updated_at": result.UpdatedAt,
+		"expires_at": result.ExpiresAt,
+		"metadata":   result.Metadata,
+		"debug":      result.Debug,
+	}
 }
 
 // UpdateResult обновляет результат скраппинга
-func (r *MongoScraperRepo) UpdateResult(ctx context.Context, result *models.ScrapingResult) error {
+func (r *MongoScraperRepo) UpdateResult(ctx context.Context, result *models.ScrapingResult) (err error) {
+	defer func(start time.Time) { observe("update", start, err) }(time.Now())
+
 	if r.collection == nil {
 		return ErrNilCollection
 	}
@@ -251,21 +736,16 @@ func (r *MongoScraperRepo) UpdateResult(ctx context.Context, result *models.Scra
 	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
 	defer cancel()
 
-	update := bson.M{
-		"$set": bson.M{
-			"name":       result.Name,
-			"data":       result.Data,
-			"updated_at": result.UpdatedAt,
-			"metadata":   result.Metadata,
-		},
-	}
+	update := bson.M{"$set": updatableFields(result)}
 
-	_, err := r.collection.UpdateOne(timeout, bson.M{"_id": result.ID}, update)
+	_, err = r.collection.UpdateOne(timeout, bson.M{"_id": result.ID}, update)
 	return err
 }
 
 // DeleteResult удаляет результат скраппинга по ID
-func (r *MongoScraperRepo) DeleteResult(ctx context.Context, id string) error {
+func (r *MongoScraperRepo) DeleteResult(ctx context.Context, id string) (err error) {
+	defer func(start time.Time) { observe("delete", start, err) }(time.Now())
+
 	if r.collection == nil {
 		return ErrNilCollection
 	}
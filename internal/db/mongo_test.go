@@ -0,0 +1,120 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/db"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// mongoIndexes - число команд работы с индексами в NewMongoScraperRepo
+const mongoIndexes = 6
+
+// storedResult возвращает документ результата в том виде, в каком его отдает MongoDB
+func storedResult(t *testing.T, r *models.ScrapingResult) bson.D {
+	t.Helper()
+	raw, err := bson.Marshal(r)
+	if err != nil {
+		t.Fatalf("bson.Marshal() error = %v", err)
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("bson.Unmarshal() error = %v", err)
+	}
+	return doc
+}
+
+func TestUpsertResultsSkipsUnchanged(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("unchanged result in batch", func(mt *mtest.T) {
+		for range mongoIndexes {
+			mt.AddMockResponses(mtest.CreateSuccessResponse())
+		}
+		repo, err := db.NewMongoScraperRepo(mt.Client, "kultscraper", "results")
+		if err != nil {
+			t.Fatalf("NewMongoScraperRepo() error = %v", err)
+		}
+
+		lastChange := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		previous := &models.ScrapingResult{
+			ID:        primitive.NewObjectID(),
+			URL:       "https://example.com/a",
+			Type:      "Кино",
+			Data:      map[string]string{"title": "A"},
+			CreatedAt: lastChange,
+			UpdatedAt: lastChange,
+			Metadata:  models.ScrapeMeta{ContentHash: "hash-a"},
+		}
+
+		checkedAt := time.Now()
+		unchanged := models.NewScrapingResult(previous.URL, previous.Type, "", map[string]string{"title": "A"})
+		unchanged.Metadata.ContentHash = "hash-a"
+		unchanged.Metadata.CheckedAt = &checkedAt
+		created := models.NewScrapingResult("https://example.com/b", "Кино", "", map[string]string{"title": "B"})
+		created.Metadata.ContentHash = "hash-b"
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "kultscraper.results", mtest.FirstBatch, storedResult(t, previous)),
+			mtest.CreateSuccessResponse(
+				bson.E{Key: "n", Value: 2},
+				bson.E{Key: "nModified", Value: 1},
+				bson.E{Key: "upserted", Value: bson.A{bson.D{{Key: "index", Value: 1}, {Key: "_id", Value: primitive.NewObjectID()}}}},
+			),
+		)
+
+		changes, err := repo.UpsertResults(context.Background(), []*models.ScrapingResult{unchanged, created})
+		if err != nil {
+			t.Fatalf("UpsertResults() error = %v", err)
+		}
+
+		if got := changes[0].ChangeType; got != models.ChangeUnchanged {
+			t.Errorf("changes[0].ChangeType = %v, want %v", got, models.ChangeUnchanged)
+		}
+		if got := changes[0].ResultID; got != previous.ID.Hex() {
+			t.Errorf("changes[0].ResultID = %v, want %v", got, previous.ID.Hex())
+		}
+		if !unchanged.UpdatedAt.Equal(lastChange) {
+			t.Errorf("unchanged.UpdatedAt = %v, want %v", unchanged.UpdatedAt, lastChange)
+		}
+		if got := changes[1].ChangeType; got != models.ChangeCreated {
+			t.Errorf("changes[1].ChangeType = %v, want %v", got, models.ChangeCreated)
+		}
+
+		// Неизмененный результат записывает только поля проверки, без данных и updated_at
+		var updates bson.Raw
+		for _, e := range mt.GetAllStartedEvents() {
+			if e.CommandName == "update" {
+				updates = e.Command
+			}
+		}
+		if updates == nil {
+			t.Fatal("no update command sent")
+		}
+		docs, err := updates.Lookup("updates").Array().Values()
+		if err != nil {
+			t.Fatalf("updates: %v", err)
+		}
+		if len(docs) != 2 {
+			t.Fatalf("len(updates) = %d, want 2", len(docs))
+		}
+		set, err := docs[0].Document().Lookup("u", "$set").Document().Elements()
+		if err != nil {
+			t.Fatalf("updates[0].u.$set: %v", err)
+		}
+		want := map[string]bool{"metadata.checked_at": true, "metadata.etag": true, "metadata.last_modified": true}
+		for _, field := range set {
+			if !want[field.Key()] {
+				t.Errorf("unchanged result sets %q, want only check fields", field.Key())
+			}
+		}
+		if got := docs[0].Document().Lookup("upsert"); got.Validate() == nil && got.Boolean() {
+			t.Errorf("unchanged result is written as upsert")
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultPostgresDriver - имя драйвера database/sql для PostgreSQL.
+// Драйвер github.com/jackc/pgx/v5/stdlib импортируется в cmd/kultscraper
+const DefaultPostgresDriver = "pgx"
+
+// postgresDialect хранит вложенные структуры в JSONB, метки ищутся через GIN-индекс
+var postgresDialect = sqlDialect{
+	name: "postgres",
+	schema: []string{
+		`CREATE TABLE IF NOT EXISTS %[1]s (
+	id         TEXT PRIMARY KEY,
+	project    TEXT NOT NULL DEFAULT '',
+	url        TEXT NOT NULL,
+	type       TEXT NOT NULL,
+	name       TEXT NOT NULL DEFAULT '',
+	data       JSONB NOT NULL DEFAULT '{}',
+	items      JSONB,
+	tags       JSONB NOT NULL DEFAULT '[]',
+	confidence JSONB,
+	metadata   JSONB,
+	debug      JSONB,
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	expires_at TIMESTAMPTZ,
+	UNIQUE (project, type, url)
+)`,
+		`CREATE INDEX IF NOT EXISTS %[1]s_type_idx ON %[1]s (type)`,
+		`CREATE INDEX IF NOT EXISTS %[1]s_tags_idx ON %[1]s USING GIN (tags)`,
+		`CREATE INDEX IF NOT EXISTS %[1]s_expires_at_idx ON %[1]s (expires_at)`,
+	},
+	placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+	tagMatch:    func(ph string) string { return fmt.Sprintf("tags @> jsonb_build_array(%s::text)", ph) },
+	statusMatch: func(ph string) string { return fmt.Sprintf("metadata->>'status' = %s", ph) },
+	lockClause:  " FOR UPDATE",
+	encodeTime:  func(t time.Time) any { return t },
+	decodeTime: func(v any) (time.Time, error) {
+		t, ok := v.(time.Time)
+		if !ok {
+			return time.Time{}, fmt.Errorf("unexpected timestamp type %T", v)
+		}
+		return t, nil
+	},
+}
+
+// NewPostgresScraperRepo подключается к PostgreSQL и создает таблицу результатов, если ее нет
+func NewPostgresScraperRepo(ctx context.Context, driver, dsn, table string, logger Logger) (*SQLScraperRepo, error) {
+	if driver == "" {
+		driver = DefaultPostgresDriver
+	}
+	return openSQLRepo(ctx, postgresDialect, driver, dsn, table, logger)
+}
@@ -0,0 +1,199 @@
+package db
+
+import (
+	"regexp"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Поля сортировки результатов
+const (
+	SortCreated = "created_at"
+	SortUpdated = "updated_at"
+	SortName    = "name"
+	SortType    = "type"
+	SortURL     = "url"
+)
+
+// SortFields - поля, по которым допускается сортировка
+var SortFields = []string{SortCreated, SortUpdated, SortName, SortType, SortURL}
+
+// ValidSortField сообщает, допустима ли сортировка по полю
+func ValidSortField(field string) bool {
+	return slices.Contains(SortFields, field)
+}
+
+// QueryOptions - параметры выборки результатов
+type QueryOptions struct {
+	IncludeExpired bool
+	Project        *string // nil - результаты всех проектов
+	Type           string
+	URL            string
+	Tag            string
+	Status         string    // Статус в метаданных результата
+	NameContains   string    // Подстрока имени без учета регистра
+	UpdatedAfter   time.Time // Нулевое значение - без ограничения
+	Limit          int       // 0 - без ограничения
+	Offset         int
+	Sort           string // Одно из SortFields, по умолчанию SortCreated
+	Desc           bool
+}
+
+// QueryOption изменяет параметры выборки
+type QueryOption func(*QueryOptions)
+
+// IncludeExpired включает в выборку события с истекшим сроком актуальности
+func IncludeExpired() QueryOption {
+	return func(o *QueryOptions) {
+		o.IncludeExpired = true
+	}
+}
+
+// InProject ограничивает выборку результатами проекта. Пустая строка - проект по умолчанию
+func InProject(project string) QueryOption {
+	return func(o *QueryOptions) {
+		o.Project = &project
+	}
+}
+
+// OfType ограничивает выборку результатами типа
+func OfType(scraperType string) QueryOption {
+	return func(o *QueryOptions) {
+		o.Type = scraperType
+	}
+}
+
+// AtURL ограничивает выборку результатами страницы url
+func AtURL(url string) QueryOption {
+	return func(o *QueryOptions) {
+		o.URL = url
+	}
+}
+
+// Tagged ограничивает выборку результатами с меткой
+func Tagged(tag string) QueryOption {
+	return func(o *QueryOptions) {
+		o.Tag = tag
+	}
+}
+
+// WithStatus ограничивает выборку результатами со статусом в метаданных, например models.StatusError
+func WithStatus(status string) QueryOption {
+	return func(o *QueryOptions) {
+		o.Status = status
+	}
+}
+
+// NameContains ограничивает выборку результатами, имя которых содержит подстроку
+func NameContains(substr string) QueryOption {
+	return func(o *QueryOptions) {
+		o.NameContains = substr
+	}
+}
+
+// UpdatedAfter ограничивает выборку результатами, обновленными позже t
+func UpdatedAfter(t time.Time) QueryOption {
+	return func(o *QueryOptions) {
+		o.UpdatedAfter = t
+	}
+}
+
+// Page пропускает offset результатов и возвращает не больше limit. limit 0 - без ограничения
+func Page(limit, offset int) QueryOption {
+	return func(o *QueryOptions) {
+		o.Limit, o.Offset = max(limit, 0), max(offset, 0)
+	}
+}
+
+// SortBy задает поле и направление сортировки. Недопустимое поле заменяется на SortCreated
+func SortBy(field string, desc bool) QueryOption {
+	return func(o *QueryOptions) {
+		o.Sort, o.Desc = field, desc
+	}
+}
+
+// projectFilter возвращает условие на поле project.
+// Документы без поля project относятся к проекту по умолчанию
+func projectFilter(project string) any {
+	if project == "" {
+		return bson.M{"$in": bson.A{nil, ""}}
+	}
+	return project
+}
+
+// applyQueryOptions собирает параметры выборки из опций
+func applyQueryOptions(opts []QueryOption) QueryOptions {
+	var o QueryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if !ValidSortField(o.Sort) {
+		o.Sort = SortCreated
+	}
+	return o
+}
+
+// withQueryOptions дополняет фильтр условиями из параметров выборки
+func withQueryOptions(filter bson.M, opts []QueryOption) bson.M {
+	o := applyQueryOptions(opts)
+
+	if o.Project != nil {
+		filter["project"] = projectFilter(*o.Project)
+	}
+
+	if !o.IncludeExpired {
+		// Документы без expires_at считаются бессрочными
+		filter["$or"] = bson.A{
+			bson.M{"expires_at": bson.M{"$exists": false}},
+			bson.M{"expires_at": nil},
+			bson.M{"expires_at": bson.M{"$gt": time.Now()}},
+		}
+	}
+
+	var and bson.A
+	if o.Type != "" {
+		and = append(and, bson.M{"type": o.Type})
+	}
+	if o.URL != "" {
+		and = append(and, bson.M{"url": o.URL})
+	}
+	if o.Tag != "" {
+		and = append(and, bson.M{"tags": o.Tag})
+	}
+	if o.Status != "" {
+		and = append(and, bson.M{"metadata.status": o.Status})
+	}
+	if o.NameContains != "" {
+		and = append(and, bson.M{"name": bson.M{"$regex": regexp.QuoteMeta(o.NameContains), "$options": "i"}})
+	}
+	if !o.UpdatedAfter.IsZero() {
+		and = append(and, bson.M{"updated_at": bson.M{"$gt": o.UpdatedAfter}})
+	}
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+
+	return filter
+}
+
+// findOptions возвращает сортировку и страницу выборки. Сортировка дополняется _id,
+// чтобы страницы при равных значениях поля не пересекались
+func findOptions(opts []QueryOption) *options.FindOptions {
+	o := applyQueryOptions(opts)
+
+	dir := 1
+	if o.Desc {
+		dir = -1
+	}
+	find := options.Find().SetSort(bson.D{{Key: o.Sort, Value: dir}, {Key: "_id", Value: dir}})
+	if o.Limit > 0 {
+		find.SetLimit(int64(o.Limit))
+	}
+	if o.Offset > 0 {
+		find.SetSkip(int64(o.Offset))
+	}
+	return find
+}
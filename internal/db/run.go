@@ -0,0 +1,209 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultRunsCollection - коллекция запусков скраппинга по умолчанию
+const DefaultRunsCollection = "scrape_runs"
+
+// RunRepository определяет интерфейс для запусков скраппинга и их статусов
+type RunRepository interface {
+	SaveRun(ctx context.Context, run *models.ScrapeRun) (string, error)
+	GetRuns(ctx context.Context, status string, limit int64) ([]*models.ScrapeRun, error)
+	GetRun(ctx context.Context, runID string) (*models.ScrapeRun, error)
+}
+
+// MongoRunRepo имплементирует интерфейс RunRepository
+type MongoRunRepo struct {
+	collection *mongo.Collection
+}
+
+// NewMongoRunRepo создает репозиторий запусков
+func NewMongoRunRepo(client *mongo.Client, dbname, collectionName string) (*MongoRunRepo, error) {
+	if client == nil {
+		return nil, errors.New("Mongo client is nil")
+	}
+
+	if collectionName == "" {
+		collectionName = DefaultRunsCollection
+	}
+
+	collection := client.Database(dbname).Collection(collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	// Уникальный индекс по run_id и выборка последних запусков по статусу
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "run_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "started_at", Value: -1}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoRunRepo{collection: collection}, nil
+}
+
+// SaveRun сохраняет запуск, заменяя запуск с тем же run_id
+func (r *MongoRunRepo) SaveRun(ctx context.Context, run *models.ScrapeRun) (string, error) {
+	if r.collection == nil {
+		return "", ErrNilCollection
+	}
+
+	ctx, span := tracing.Start(ctx, "db.save_run", tracing.String("run.id", run.RunID), tracing.String("run.status", run.Status))
+	defer span.End()
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	if run.ID.IsZero() {
+		run.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.ReplaceOne(timeout,
+		bson.M{"run_id": run.RunID},
+		run,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+
+	return run.ID.Hex(), nil
+}
+
+// GetRuns возвращает последние запуски, новые первыми. Пустой status - запуски в любом статусе
+func (r *MongoRunRepo) GetRuns(ctx context.Context, status string, limit int64) ([]*models.ScrapeRun, error) {
+	if r.collection == nil {
+		return nil, ErrNilCollection
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	cursor, err := r.collection.Find(timeout, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var runs []*models.ScrapeRun
+	if err := cursor.All(timeout, &runs); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// GetRun возвращает запуск по идентификатору
+func (r *MongoRunRepo) GetRun(ctx context.Context, runID string) (*models.ScrapeRun, error) {
+	if r.collection == nil {
+		return nil, ErrNilCollection
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	var run models.ScrapeRun
+	err := r.collection.FindOne(timeout, bson.M{"run_id": runID}).Decode(&run)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+// MemoryRunRepo хранит запуски в памяти процесса
+type MemoryRunRepo struct {
+	mu   sync.RWMutex
+	runs []*models.ScrapeRun
+}
+
+// NewMemoryRunRepo создает репозиторий запусков в памяти
+func NewMemoryRunRepo() *MemoryRunRepo {
+	return &MemoryRunRepo{}
+}
+
+// SaveRun сохраняет запуск, заменяя запуск с тем же run_id
+func (r *MemoryRunRepo) SaveRun(ctx context.Context, run *models.ScrapeRun) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if run.ID.IsZero() {
+		run.ID = primitive.NewObjectID()
+	}
+
+	saved := *run
+	for i, existing := range r.runs {
+		if existing.RunID == run.RunID {
+			r.runs[i] = &saved
+			return run.ID.Hex(), nil
+		}
+	}
+	r.runs = append(r.runs, &saved)
+
+	return run.ID.Hex(), nil
+}
+
+// GetRuns возвращает последние запуски, новые первыми. Пустой status - запуски в любом статусе
+func (r *MemoryRunRepo) GetRuns(ctx context.Context, status string, limit int64) ([]*models.ScrapeRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	runs := make([]*models.ScrapeRun, 0, len(r.runs))
+	for i := len(r.runs) - 1; i >= 0; i-- {
+		if limit > 0 && int64(len(runs)) >= limit {
+			break
+		}
+		if status != "" && r.runs[i].Status != status {
+			continue
+		}
+		run := *r.runs[i]
+		runs = append(runs, &run)
+	}
+
+	return runs, nil
+}
+
+// GetRun возвращает запуск по идентификатору
+func (r *MemoryRunRepo) GetRun(ctx context.Context, runID string) (*models.ScrapeRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, existing := range r.runs {
+		if existing.RunID == runID {
+			run := *existing
+			return &run, nil
+		}
+	}
+	return nil, ErrNotFound
+}
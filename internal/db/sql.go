@@ -0,0 +1,651 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultSQLTable - таблица результатов по умолчанию для SQL-хранилищ
+const DefaultSQLTable = "scraping_results"
+
+var tableNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// sqlColumns - порядок колонок для resultArgs и scanResult
+const sqlColumns = "id, project, url, type, name, data, items, tags, confidence, metadata, debug, created_at, updated_at, expires_at"
+
+// sqlDialect описывает различия SQL-движков
+type sqlDialect struct {
+	name string
+	// schema - выражения создания таблицы и индексов, %[1]s заменяется именем таблицы
+	schema []string
+	// placeholder возвращает n-й параметр запроса (с 1)
+	placeholder func(n int) string
+	// tagMatch возвращает условие "tags содержит метку" для параметра ph
+	tagMatch func(ph string) string
+	// statusMatch возвращает условие "статус в metadata равен параметру ph"
+	statusMatch func(ph string) string
+	// lockClause дописывается к выборке существующей записи при upsert
+	lockClause string
+	// encodeTime и decodeTime преобразуют время для хранения в колонке
+	encodeTime func(t time.Time) any
+	decodeTime func(v any) (time.Time, error)
+}
+
+// SQLScraperRepo имплементирует интерфейс ScraperRepository поверх database/sql.
+// Data, Metadata и остальные вложенные структуры хранятся как JSON
+type SQLScraperRepo struct {
+	db      *sql.DB
+	table   string
+	dialect sqlDialect
+	logger  Logger
+}
+
+// openSQLRepo открывает соединение, проверяет его и создает схему
+func openSQLRepo(ctx context.Context, dialect sqlDialect, driver, dsn, table string, logger Logger) (*SQLScraperRepo, error) {
+	if table == "" {
+		table = DefaultSQLTable
+	}
+	if !tableNameRe.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+
+	conn, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	if err := conn.PingContext(pingCtx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	repo := &SQLScraperRepo{db: conn, table: table, dialect: dialect, logger: logger}
+	if err := repo.migrate(pingCtx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	logger.Debug("SQL schema ensured", "dialect", dialect.name, "table", table)
+	return repo, nil
+}
+
+// migrate создает таблицу и индексы
+func (r *SQLScraperRepo) migrate(ctx context.Context) error {
+	for _, stmt := range r.dialect.schema {
+		if _, err := r.db.ExecContext(ctx, fmt.Sprintf(stmt, r.table)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ph возвращает n-й параметр запроса в синтаксисе диалекта
+func (r *SQLScraperRepo) ph(n int) string {
+	return r.dialect.placeholder(n)
+}
+
+// GetAllResults возвращает результаты скраппинга с учетом фильтров, сортировки и страницы из opts
+func (r *SQLScraperRepo) GetAllResults(ctx context.Context, opts ...QueryOption) (results []*models.ScrapingResult, err error) {
+	defer func(start time.Time) { observe("get_all", start, err) }(time.Now())
+	return r.query(ctx, nil, nil, opts)
+}
+
+// CountResults возвращает число результатов, подходящих под фильтры opts, без учета страницы
+func (r *SQLScraperRepo) CountResults(ctx context.Context, opts ...QueryOption) (count int64, err error) {
+	defer func(start time.Time) { observe("count", start, err) }(time.Now())
+
+	where, args := r.filter(applyQueryOptions(opts), nil, nil)
+	q := "SELECT COUNT(*) FROM " + r.table
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	err = r.db.QueryRowContext(timeout, q, args...).Scan(&count)
+	return count, err
+}
+
+// GetStats считает сводку по типам и источникам, включая результаты с истекшим сроком.
+// Полнота данных считается по JSON колонки data на стороне приложения
+func (r *SQLScraperRepo) GetStats(ctx context.Context) (_ *models.Stats, err error) {
+	defer func(start time.Time) { observe("stats", start, err) }(time.Now())
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(timeout, fmt.Sprintf("SELECT type, name, data, updated_at FROM %s", r.table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	acc := newStatsAccumulator()
+	for rows.Next() {
+		var (
+			res       models.ScrapingResult
+			data      []byte
+			updatedAt any
+		)
+		if err := rows.Scan(&res.Type, &res.Name, &data, &updatedAt); err != nil {
+			return nil, err
+		}
+		if res.UpdatedAt, err = r.dialect.decodeTime(updatedAt); err != nil {
+			return nil, err
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &res.Data); err != nil {
+				return nil, err
+			}
+		}
+		acc.add(res.Type, res.Name, res.UpdatedAt, res.Completeness())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return acc.result(), nil
+}
+
+// GetResultByID возвращает результат скраппинга по ID
+func (r *SQLScraperRepo) GetResultByID(ctx context.Context, id string) (_ *models.ScrapingResult, err error) {
+	defer func(start time.Time) { observe("get_by_id", start, err) }(time.Now())
+
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	results, err := r.query(ctx, []string{"id = " + r.ph(1)}, []any{id}, []QueryOption{IncludeExpired()})
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrNotFound
+	}
+	return results[0], nil
+}
+
+// GetResultsByType возвращает результаты скраппинга заданного типа
+func (r *SQLScraperRepo) GetResultsByType(ctx context.Context, scraperType string, opts ...QueryOption) (results []*models.ScrapingResult, err error) {
+	defer func(start time.Time) { observe("get_by_type", start, err) }(time.Now())
+	return r.query(ctx, []string{"type = " + r.ph(1)}, []any{scraperType}, opts)
+}
+
+// GetResultsByTag возвращает результаты скраппинга с заданной меткой
+func (r *SQLScraperRepo) GetResultsByTag(ctx context.Context, tag string, opts ...QueryOption) (results []*models.ScrapingResult, err error) {
+	defer func(start time.Time) { observe("get_by_tag", start, err) }(time.Now())
+	return r.query(ctx, []string{r.dialect.tagMatch(r.ph(1))}, []any{tag}, opts)
+}
+
+// SaveResult сохраняет один результат скраппинга
+func (r *SQLScraperRepo) SaveResult(ctx context.Context, result *models.ScrapingResult) (string, error) {
+	change, err := r.UpsertResult(ctx, result)
+	if err != nil {
+		return "", err
+	}
+	return change.ResultID, nil
+}
+
+// UpsertResult сохраняет результат по project, type и url и возвращает описание изменения
+func (r *SQLScraperRepo) UpsertResult(ctx context.Context, result *models.ScrapingResult) (_ *models.ResultChange, err error) {
+	defer func(start time.Time) { observe("upsert", start, err) }(time.Now())
+
+	ctx, span := tracing.Start(ctx, "db.upsert",
+		tracing.String("url", result.URL),
+		tracing.String("type", result.Type),
+	)
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(timeout, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var (
+		existingID string
+		createdAt  any
+		updatedAt  any
+		prevData   []byte
+		prevMeta   []byte
+	)
+	err = tx.QueryRowContext(timeout, fmt.Sprintf(
+		`SELECT id, created_at, updated_at, data, metadata FROM %s WHERE project = %s AND type = %s AND url = %s%s`,
+		r.table, r.ph(1), r.ph(2), r.ph(3), r.dialect.lockClause),
+		result.Project, result.Type, result.URL,
+	).Scan(&existingID, &createdAt, &updatedAt, &prevData, &prevMeta)
+
+	var previous map[string]string
+	switch {
+	case err == nil:
+		if result.ID, err = primitive.ObjectIDFromHex(existingID); err != nil {
+			return nil, err
+		}
+		if result.CreatedAt, err = r.dialect.decodeTime(createdAt); err != nil {
+			return nil, err
+		}
+		result.UpdatedAt = time.Now()
+		previous = map[string]string{}
+		if err := json.Unmarshal(prevData, &previous); err != nil {
+			return nil, err
+		}
+
+		var existing models.ScrapingResult
+		if prevMeta != nil {
+			if err := json.Unmarshal(prevMeta, &existing.Metadata); err != nil {
+				return nil, err
+			}
+		}
+		if result.SameContent(&existing) {
+			// Содержимое не изменилось: отмечаем только проверку, UpdatedAt остается временем последнего изменения
+			if result.UpdatedAt, err = r.dialect.decodeTime(updatedAt); err != nil {
+				return nil, err
+			}
+			existing.Metadata.SetChecked(result.Metadata)
+			metaJSON, err := json.Marshal(existing.Metadata)
+			if err != nil {
+				return nil, err
+			}
+			_, err = tx.ExecContext(timeout, fmt.Sprintf(`UPDATE %s SET metadata = %s WHERE id = %s`, r.table, r.ph(1), r.ph(2)),
+				string(metaJSON), existingID)
+			if err != nil {
+				return nil, err
+			}
+			if err := tx.Commit(); err != nil {
+				return nil, err
+			}
+			upserts.With("unchanged").Inc()
+			return models.NewResultChange(existingID, previous, result), nil
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		result.ID = primitive.NewObjectID()
+		result.CreatedAt = time.Now()
+		result.UpdatedAt = result.CreatedAt
+	default:
+		return nil, err
+	}
+
+	args, err := r.resultArgs(result)
+	if err != nil {
+		return nil, err
+	}
+
+	placeholders := make([]string, len(args))
+	for i := range args {
+		placeholders[i] = r.ph(i + 1)
+	}
+
+	_, err = tx.ExecContext(timeout, fmt.Sprintf(`
+INSERT INTO %s (%s) VALUES (%s)
+ON CONFLICT (id) DO UPDATE SET
+	name = EXCLUDED.name, data = EXCLUDED.data, items = EXCLUDED.items, tags = EXCLUDED.tags,
+	confidence = EXCLUDED.confidence, metadata = EXCLUDED.metadata, debug = EXCLUDED.debug,
+	updated_at = EXCLUDED.updated_at, expires_at = EXCLUDED.expires_at`,
+		r.table, sqlColumns, strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		r.logger.Error("Failed to upsert result", withContext(ctx, "url", result.URL, "error", err)...)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if previous == nil {
+		upserts.With("insert").Inc()
+		r.logger.Debug("Inserted new result", withContext(ctx, "id", result.ID.Hex(), "url", result.URL)...)
+	} else {
+		upserts.With("update").Inc()
+		r.logger.Debug("Updated existing result", withContext(ctx, "id", result.ID.Hex(), "url", result.URL)...)
+	}
+
+	return models.NewResultChange(result.ID.Hex(), previous, result), nil
+}
+
+// SaveFailure отмечает результат с тем же (project, type, url) неудачей failed или создает его.
+// Metadata хранится одним JSON, поэтому сведения о неудаче переносятся на стороне приложения
+func (r *SQLScraperRepo) SaveFailure(ctx context.Context, failed *models.ScrapingResult) (_ string, err error) {
+	defer func(start time.Time) { observe("save_failure", start, err) }(time.Now())
+
+	ctx, span := tracing.Start(ctx, "db.save_failure",
+		tracing.String("url", failed.URL),
+		tracing.String("type", failed.Type),
+	)
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(timeout, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var (
+		existingID string
+		metaJSON   []byte
+	)
+	err = tx.QueryRowContext(timeout, fmt.Sprintf(
+		`SELECT id, metadata FROM %s WHERE project = %s AND type = %s AND url = %s%s`,
+		r.table, r.ph(1), r.ph(2), r.ph(3), r.dialect.lockClause),
+		failed.Project, failed.Type, failed.URL,
+	).Scan(&existingID, &metaJSON)
+
+	switch {
+	case err == nil:
+		var meta models.ScrapeMeta
+		if err := json.Unmarshal(metaJSON, &meta); err != nil {
+			return "", err
+		}
+		meta.SetFailure(failed.Metadata)
+		if metaJSON, err = json.Marshal(meta); err != nil {
+			return "", err
+		}
+		_, err = tx.ExecContext(timeout, fmt.Sprintf(`UPDATE %s SET metadata = %s WHERE id = %s`, r.table, r.ph(1), r.ph(2)),
+			string(metaJSON), existingID)
+		if err != nil {
+			return "", err
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		failed.ID = primitive.NewObjectID()
+		failed.CreatedAt = time.Now()
+		failed.UpdatedAt = failed.CreatedAt
+		existingID = failed.ID.Hex()
+
+		args, err := r.resultArgs(failed)
+		if err != nil {
+			return "", err
+		}
+		placeholders := make([]string, len(args))
+		for i := range args {
+			placeholders[i] = r.ph(i + 1)
+		}
+		_, err = tx.ExecContext(timeout, fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)`,
+			r.table, sqlColumns, strings.Join(placeholders, ", ")), args...)
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return existingID, nil
+}
+
+// SaveResults сохраняет несколько результатов скраппинга
+func (r *SQLScraperRepo) SaveResults(ctx context.Context, results []*models.ScrapingResult) ([]string, error) {
+	ids := []string{}
+	for _, result := range results {
+		id, err := r.SaveResult(ctx, result)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// UpdateResult обновляет результат скраппинга
+func (r *SQLScraperRepo) UpdateResult(ctx context.Context, result *models.ScrapingResult) (err error) {
+	defer func(start time.Time) { observe("update", start, err) }(time.Now())
+
+	if result.ID.IsZero() {
+		return ErrInvalidID
+	}
+
+	result.UpdatedAt = time.Now()
+	args, err := r.resultArgs(result)
+	if err != nil {
+		return err
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(timeout, fmt.Sprintf(`
+UPDATE %s SET name = %s, data = %s, items = %s, tags = %s, confidence = %s, metadata = %s, debug = %s,
+	updated_at = %s, expires_at = %s
+WHERE id = %s`, r.table, r.ph(1), r.ph(2), r.ph(3), r.ph(4), r.ph(5), r.ph(6), r.ph(7), r.ph(8), r.ph(9), r.ph(10)),
+		args[4], args[5], args[6], args[7], args[8], args[9], args[10], args[12], args[13], args[0])
+	return err
+}
+
+// DeleteResult удаляет результат скраппинга по ID
+func (r *SQLScraperRepo) DeleteResult(ctx context.Context, id string) (err error) {
+	defer func(start time.Time) { observe("delete", start, err) }(time.Now())
+
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return ErrInvalidID
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(timeout, fmt.Sprintf(`DELETE FROM %s WHERE id = %s`, r.table, r.ph(1)), id)
+	return err
+}
+
+// Close закрывает соединение с базой данных
+func (r *SQLScraperRepo) Close() error {
+	return r.db.Close()
+}
+
+// query выбирает результаты по условиям where с учетом параметров выборки
+func (r *SQLScraperRepo) query(ctx context.Context, where []string, args []any, opts []QueryOption) ([]*models.ScrapingResult, error) {
+	o := applyQueryOptions(opts)
+	where, args = r.filter(o, where, args)
+
+	q := fmt.Sprintf("SELECT %s FROM %s", sqlColumns, r.table)
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	// Поле сортировки проверено applyQueryOptions, id разделяет равные значения
+	dir := "ASC"
+	if o.Desc {
+		dir = "DESC"
+	}
+	q += fmt.Sprintf(" ORDER BY %s %s, id %s", o.Sort, dir, dir)
+
+	if o.Limit > 0 || o.Offset > 0 {
+		// OFFSET без LIMIT не поддерживается SQLite
+		limit := int64(o.Limit)
+		if limit == 0 {
+			limit = math.MaxInt64
+		}
+		args = append(args, limit, o.Offset)
+		q += fmt.Sprintf(" LIMIT %s OFFSET %s", r.ph(len(args)-1), r.ph(len(args)))
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(timeout, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*models.ScrapingResult
+	for rows.Next() {
+		result, err := r.scanResult(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// filter дополняет условия where фильтрами из параметров выборки
+func (r *SQLScraperRepo) filter(o QueryOptions, where []string, args []any) ([]string, []any) {
+	if o.Project != nil {
+		args = append(args, *o.Project)
+		where = append(where, "project = "+r.ph(len(args)))
+	}
+	if !o.IncludeExpired {
+		args = append(args, r.dialect.encodeTime(time.Now()))
+		where = append(where, fmt.Sprintf("(expires_at IS NULL OR expires_at > %s)", r.ph(len(args))))
+	}
+	if o.Type != "" {
+		args = append(args, o.Type)
+		where = append(where, "type = "+r.ph(len(args)))
+	}
+	if o.URL != "" {
+		args = append(args, o.URL)
+		where = append(where, "url = "+r.ph(len(args)))
+	}
+	if o.Tag != "" {
+		args = append(args, o.Tag)
+		where = append(where, r.dialect.tagMatch(r.ph(len(args))))
+	}
+	if o.Status != "" {
+		args = append(args, o.Status)
+		where = append(where, r.dialect.statusMatch(r.ph(len(args))))
+	}
+	if o.NameContains != "" {
+		// LOWER в SQLite приводит к нижнему регистру только латиницу
+		args = append(args, "%"+likeEscaper.Replace(strings.ToLower(o.NameContains))+"%")
+		where = append(where, fmt.Sprintf(`LOWER(name) LIKE %s ESCAPE '\'`, r.ph(len(args))))
+	}
+	if !o.UpdatedAfter.IsZero() {
+		args = append(args, r.dialect.encodeTime(o.UpdatedAfter))
+		where = append(where, "updated_at > "+r.ph(len(args)))
+	}
+	return where, args
+}
+
+// likeEscaper экранирует спецсимволы шаблона LIKE
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// resultArgs возвращает значения колонок в порядке sqlColumns
+func (r *SQLScraperRepo) resultArgs(res *models.ScrapingResult) ([]any, error) {
+	data, err := json.Marshal(res.Data)
+	if err != nil {
+		return nil, err
+	}
+	items, err := nullableJSON(res.Items, res.Items == nil)
+	if err != nil {
+		return nil, err
+	}
+	tags := res.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return nil, err
+	}
+	confidence, err := nullableJSON(res.Confidence, res.Confidence == nil)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := json.Marshal(res.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	debug, err := nullableJSON(res.Debug, res.Debug == nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt any
+	if res.ExpiresAt != nil {
+		expiresAt = r.dialect.encodeTime(*res.ExpiresAt)
+	}
+
+	return []any{
+		res.ID.Hex(), res.Project, res.URL, res.Type, res.Name,
+		string(data), items, string(tagsJSON), confidence, string(metadata), debug,
+		r.dialect.encodeTime(res.CreatedAt), r.dialect.encodeTime(res.UpdatedAt), expiresAt,
+	}, nil
+}
+
+func nullableJSON(v any, isNil bool) (any, error) {
+	if isNil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// scanResult читает строку в порядке sqlColumns
+func (r *SQLScraperRepo) scanResult(rows *sql.Rows) (*models.ScrapingResult, error) {
+	var (
+		result                                     models.ScrapingResult
+		id                                         string
+		data, items, tags, confidence, meta, debug []byte
+		createdAt, updatedAt, expiresAt            any
+	)
+
+	err := rows.Scan(&id, &result.Project, &result.URL, &result.Type, &result.Name,
+		&data, &items, &tags, &confidence, &meta, &debug,
+		&createdAt, &updatedAt, &expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if result.CreatedAt, err = r.dialect.decodeTime(createdAt); err != nil {
+		return nil, err
+	}
+	if result.UpdatedAt, err = r.dialect.decodeTime(updatedAt); err != nil {
+		return nil, err
+	}
+	if expiresAt != nil {
+		t, err := r.dialect.decodeTime(expiresAt)
+		if err != nil {
+			return nil, err
+		}
+		result.ExpiresAt = &t
+	}
+
+	for _, f := range []struct {
+		raw []byte
+		dst any
+	}{
+		{data, &result.Data},
+		{items, &result.Items},
+		{tags, &result.Tags},
+		{confidence, &result.Confidence},
+		{meta, &result.Metadata},
+		{debug, &result.Debug},
+	} {
+		if len(f.raw) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(f.raw, f.dst); err != nil {
+			return nil, err
+		}
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultSQLiteDriver - имя драйвера database/sql для SQLite.
+// Драйвер modernc.org/sqlite (без cgo) регистрируется импортом в cmd/kultscraper
+const DefaultSQLiteDriver = "sqlite"
+
+// sqliteTimeLayout - время в UTC фиксированной ширины, чтобы строки сравнивались как время
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// sqliteDialect хранит вложенные структуры и время в TEXT, метки ищутся через json_each
+var sqliteDialect = sqlDialect{
+	name: "sqlite",
+	schema: []string{
+		`CREATE TABLE IF NOT EXISTS %[1]s (
+	id         TEXT PRIMARY KEY,
+	project    TEXT NOT NULL DEFAULT '',
+	url        TEXT NOT NULL,
+	type       TEXT NOT NULL,
+	name       TEXT NOT NULL DEFAULT '',
+	data       TEXT NOT NULL DEFAULT '{}',
+	items      TEXT,
+	tags       TEXT NOT NULL DEFAULT '[]',
+	confidence TEXT,
+	metadata   TEXT,
+	debug      TEXT,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	expires_at TEXT,
+	UNIQUE (project, type, url)
+)`,
+		`CREATE INDEX IF NOT EXISTS %[1]s_type_idx ON %[1]s (type)`,
+		`CREATE INDEX IF NOT EXISTS %[1]s_expires_at_idx ON %[1]s (expires_at)`,
+	},
+	placeholder: func(n int) string { return fmt.Sprintf("?%d", n) },
+	tagMatch: func(ph string) string {
+		return fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(tags) WHERE json_each.value = %s)", ph)
+	},
+	statusMatch: func(ph string) string {
+		return fmt.Sprintf("json_extract(metadata, '$.status') = %s", ph)
+	},
+	encodeTime: func(t time.Time) any { return t.UTC().Format(sqliteTimeLayout) },
+	decodeTime: func(v any) (time.Time, error) {
+		switch t := v.(type) {
+		case string:
+			return time.Parse(sqliteTimeLayout, t)
+		case []byte:
+			return time.Parse(sqliteTimeLayout, string(t))
+		case time.Time:
+			return t, nil
+		}
+		return time.Time{}, fmt.Errorf("unexpected timestamp type %T", v)
+	},
+}
+
+// NewSQLiteScraperRepo открывает файл базы SQLite и создает таблицу результатов, если ее нет.
+// Запись в SQLite однопоточная, поэтому используется одно соединение
+func NewSQLiteScraperRepo(ctx context.Context, driver, path, table string, logger Logger) (*SQLScraperRepo, error) {
+	if driver == "" {
+		driver = DefaultSQLiteDriver
+	}
+
+	repo, err := openSQLRepo(ctx, sqliteDialect, driver, path, table, logger)
+	if err != nil {
+		return nil, err
+	}
+	repo.db.SetMaxOpenConns(1)
+	return repo, nil
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// DefaultStatsWindow - период журнала аудита, по которому считается доля неудачных задач
+const DefaultStatsWindow = 7 * 24 * time.Hour
+
+// CollectStats собирает сводку по результатам и дополняет типы долей неудачных задач
+// из журнала аудита с момента since. Типы, по которым нет результатов, но есть задачи,
+// тоже попадают в сводку
+func CollectStats(ctx context.Context, results ScraperRepository, audits AuditRepository, since time.Time) (*models.Stats, error) {
+	stats, err := results.GetStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	failures, err := audits.GetFailureStats(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(stats.Types))
+	for i, t := range stats.Types {
+		index[t.Type] = i
+	}
+	for _, f := range failures {
+		i, ok := index[f.Type]
+		if !ok {
+			stats.Types = append(stats.Types, models.TypeStats{Type: f.Type})
+			i = len(stats.Types) - 1
+		}
+		t := &stats.Types[i]
+		t.Tasks, t.Failed = f.Tasks, f.Failed
+		if f.Tasks > 0 {
+			t.FailureRate = float64(f.Failed) / float64(f.Tasks)
+		}
+	}
+
+	sortStats(stats)
+	stats.FailuresSince = since
+	return stats, nil
+}
+
+// statsAccumulator считает сводку по результатам для хранилищ без агрегации на стороне базы
+type statsAccumulator struct {
+	stats   models.Stats
+	types   map[string]*models.TypeStats
+	sources map[[2]string]*models.SourceStats
+}
+
+func newStatsAccumulator() *statsAccumulator {
+	return &statsAccumulator{
+		types:   make(map[string]*models.TypeStats),
+		sources: make(map[[2]string]*models.SourceStats),
+	}
+}
+
+func (a *statsAccumulator) add(scraperType, name string, updatedAt time.Time, completeness float64) {
+	a.stats.Total++
+
+	t, ok := a.types[scraperType]
+	if !ok {
+		t = &models.TypeStats{Type: scraperType}
+		a.types[scraperType] = t
+	}
+	// До вызова result Completeness хранит сумму по результатам типа
+	t.Count++
+	t.Completeness += completeness
+	if updatedAt.After(t.LastScraped) {
+		t.LastScraped = updatedAt
+	}
+
+	key := [2]string{scraperType, name}
+	s, ok := a.sources[key]
+	if !ok {
+		s = &models.SourceStats{Type: scraperType, Name: name}
+		a.sources[key] = s
+	}
+	s.Count++
+	if updatedAt.After(s.LastScraped) {
+		s.LastScraped = updatedAt
+	}
+}
+
+func (a *statsAccumulator) result() *models.Stats {
+	stats := a.stats
+	stats.Types = make([]models.TypeStats, 0, len(a.types))
+	for _, t := range a.types {
+		t.Completeness /= float64(t.Count)
+		stats.Types = append(stats.Types, *t)
+	}
+	stats.Sources = make([]models.SourceStats, 0, len(a.sources))
+	for _, s := range a.sources {
+		stats.Sources = append(stats.Sources, *s)
+	}
+	sortStats(&stats)
+	stats.GeneratedAt = time.Now()
+	return &stats
+}
+
+// sortStats упорядочивает типы по имени, источники - по типу и имени
+func sortStats(stats *models.Stats) {
+	sort.Slice(stats.Types, func(i, j int) bool { return stats.Types[i].Type < stats.Types[j].Type })
+	sort.Slice(stats.Sources, func(i, j int) bool {
+		a, b := stats.Sources[i], stats.Sources[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Name < b.Name
+	})
+}
+
+// failureAccumulator считает неудачные задачи по записям аудита
+type failureAccumulator map[string]*models.FailureStats
+
+func (a failureAccumulator) add(entry *models.AuditEntry) {
+	for _, task := range entry.Tasks {
+		if task.Outcome == models.OutcomeSkipped {
+			continue
+		}
+		f, ok := a[task.Type]
+		if !ok {
+			f = &models.FailureStats{Type: task.Type}
+			a[task.Type] = f
+		}
+		f.Tasks++
+		if failedOutcome(task.Outcome) {
+			f.Failed++
+		}
+	}
+}
+
+func (a failureAccumulator) result() []models.FailureStats {
+	failures := make([]models.FailureStats, 0, len(a))
+	for _, f := range a {
+		failures = append(failures, *f)
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Type < failures[j].Type })
+	return failures
+}
+
+// failedOutcomes - исходы задач, которые считаются неудачными
+var failedOutcomes = []string{models.OutcomeFailed, models.OutcomeSaveError}
+
+func failedOutcome(outcome string) bool {
+	for _, o := range failedOutcomes {
+		if o == outcome {
+			return true
+		}
+	}
+	return false
+}
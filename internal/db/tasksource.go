@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultTaskCollection - коллекция задач, если в ссылке не задан параметр collection
+const DefaultTaskCollection = "tasks"
+
+func init() {
+	config.RegisterTaskSource("mongodb", NewMongoTaskSource)
+	config.RegisterTaskSource("mongodb+srv", NewMongoTaskSource)
+}
+
+// MongoTaskSource читает задачи из коллекции MongoDB. Документы коллекции
+// имеют те же поля, что и задачи в файле задач
+type MongoTaskSource struct {
+	uri        string
+	database   string
+	collection string
+}
+
+// NewMongoTaskSource создает источник по ссылке mongodb://host/database?collection=tasks
+func NewMongoTaskSource(ref string) (config.TaskSource, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid task source: %w", err)
+	}
+
+	database := u.Path
+	if len(database) > 0 && database[0] == '/' {
+		database = database[1:]
+	}
+	if database == "" {
+		return nil, fmt.Errorf("task source %s: database is required", u.Redacted())
+	}
+
+	query := u.Query()
+	collection := query.Get("collection")
+	if collection == "" {
+		collection = DefaultTaskCollection
+	}
+	// Параметр collection не относится к драйверу
+	query.Del("collection")
+	u.RawQuery = query.Encode()
+
+	return &MongoTaskSource{uri: u.String(), database: database, collection: collection}, nil
+}
+
+// Fetch возвращает задачи коллекции в порядке добавления
+func (s *MongoTaskSource) Fetch(ctx context.Context) ([]byte, error) {
+	client, err := ConnectMongo(ctx, NewDefaultConfig(s.uri, s.database, s.collection))
+	if err != nil {
+		return nil, err
+	}
+	defer client.Disconnect(context.Background())
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	cursor, err := client.Database(s.database).Collection(s.collection).
+		Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []bson.M
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	tasks := make([]bson.M, 0, len(docs))
+	for _, doc := range docs {
+		delete(doc, "_id")
+		tasks = append(tasks, doc)
+	}
+	// Отступы сохраняют номера строк в ошибках проверки задач
+	return json.MarshalIndent(tasks, "", "  ")
+}
+
+func (s *MongoTaskSource) String() string {
+	u, err := url.Parse(s.uri)
+	if err != nil {
+		return s.database + "." + s.collection
+	}
+	return u.Redacted() + "#" + s.collection
+}
@@ -0,0 +1,164 @@
+package discover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/robots"
+)
+
+// ErrCrawlUnsupported - движок скрапинга не умеет собирать ссылки страниц
+var ErrCrawlUnsupported = errors.New("scraper engine does not support crawling")
+
+// LinkSource открывает страницу задачи и возвращает адреса ссылок на ней.
+// Реализуется движками скрапинга, например scraper.RodScraper
+type LinkSource interface {
+	Links(ctx context.Context, task config.ScraperTask) ([]string, error)
+}
+
+// Crawler обходит ссылки в ширину от страницы задачи
+type Crawler struct {
+	Links LinkSource
+	// Robots исключает из обхода страницы, запрещенные robots.txt, и задает паузу
+	// между их загрузками по Crawl-delay. Сами страницы для селекторов не отбрасываются:
+	// их пропускает и записывает в журнал запуск. nil - без проверки
+	Robots *robots.Checker
+}
+
+// Tasks разворачивает задачу с Crawl в задачи страниц, подходящих под Crawl.Match.
+// Со страниц, прошедших Crawl.Follow, собираются ссылки до глубины Crawl.MaxDepth.
+// Каждый адрес учитывается один раз
+func (c *Crawler) Tasks(ctx context.Context, task config.ScraperTask) ([]config.ScraperTask, error) {
+	if task.Crawl == nil {
+		return []config.ScraperTask{task}, nil
+	}
+	if c.Links == nil {
+		return nil, ErrCrawlUnsupported
+	}
+
+	follow, err := compileOptional(task.Crawl.Follow)
+	if err != nil {
+		return nil, fmt.Errorf("crawl follow: %w", err)
+	}
+	match, err := compileOptional(task.Crawl.Match)
+	if err != nil {
+		return nil, fmt.Errorf("crawl match: %w", err)
+	}
+	start, err := url.Parse(task.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		depth   = task.Crawl.Depth()
+		limit   = task.Crawl.PageLimit()
+		visited = map[string]bool{normalize(start): true}
+		level   = []string{start.String()}
+		pages   []config.ScraperTask
+	)
+
+	addPage := func(u string) {
+		if match == nil || match.MatchString(u) {
+			page := task
+			page.URL = u
+			page.Crawl = nil
+			pages = append(pages, page)
+		}
+	}
+	addPage(start.String())
+
+	fetched := 0
+	for d := 0; d < depth && len(level) > 0; d++ {
+		var next []string
+		for _, pageURL := range level {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if c.Robots.Check(ctx, pageURL) != nil {
+				continue
+			}
+			if fetched > 0 {
+				if err := sleep(ctx, c.Robots.CrawlDelay(ctx, pageURL)); err != nil {
+					return nil, err
+				}
+			}
+			fetched++
+
+			source := task
+			source.URL = pageURL
+			links, err := c.Links.Links(ctx, source)
+			if err != nil {
+				// Недоступная стартовая страница - ошибка задачи, остальные пропускаются
+				if pageURL == start.String() {
+					return nil, err
+				}
+				continue
+			}
+
+			for _, link := range links {
+				u, err := url.Parse(strings.TrimSpace(link))
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+					continue
+				}
+				if !task.Crawl.External && !strings.EqualFold(u.Hostname(), start.Hostname()) {
+					continue
+				}
+				key := normalize(u)
+				if visited[key] {
+					continue
+				}
+				// Страницы для селекторов собираются, даже если по их ссылкам не переходим
+				followed := follow == nil || follow.MatchString(key)
+				if !followed && (match == nil || !match.MatchString(key)) {
+					continue
+				}
+				if len(visited) >= limit {
+					return pages, nil
+				}
+				visited[key] = true
+				if followed {
+					next = append(next, key)
+				}
+				addPage(key)
+			}
+		}
+		level = next
+	}
+	return pages, nil
+}
+
+// sleep ждет d или отмены контекста
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// normalize убирает фрагмент: ссылки на якоря одной страницы считаются одним адресом
+func normalize(u *url.URL) string {
+	clean := *u
+	clean.Fragment = ""
+	clean.RawFragment = ""
+	return clean.String()
+}
+
+func compileOptional(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	return regexp.Compile(expr)
+}
@@ -0,0 +1,206 @@
+// Package discover находит страницы для скрапинга: по sitemap.xml сайта и обходом ссылок
+package discover
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+)
+
+const (
+	// maxSitemapDepth - вложенность индексов sitemap
+	maxSitemapDepth = 3
+	// maxSitemapSize - предел размера одного файла sitemap после распаковки (по протоколу 50 МБ)
+	maxSitemapSize = 50 << 20
+)
+
+// ErrNotSitemap - документ не является urlset или sitemapindex
+var ErrNotSitemap = errors.New("not a sitemap")
+
+// Sitemaps загружает sitemap.xml и индексы sitemap
+type Sitemaps struct {
+	Client *http.Client
+	Budget Budget // Дневные лимиты запросов к сайтам, nil - без лимитов
+}
+
+// Budget учитывает загрузку файла sitemap в дневном лимите запросов к его домену,
+// ошибка отменяет загрузку
+type Budget interface {
+	Consume(ctx context.Context, rawURL string) error
+}
+
+// NewSitemaps создает загрузчик с HTTP-клиентом по умолчанию
+func NewSitemaps() *Sitemaps {
+	return &Sitemaps{Client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// sitemapDoc - urlset или sitemapindex, различаются корневым элементом
+type sitemapDoc struct {
+	XMLName  xml.Name
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// Tasks разворачивает задачу с Sitemap в задачи по каждой подходящей странице.
+// Задачи страниц получают адрес страницы и набор селекторов исходной задачи
+func (s *Sitemaps) Tasks(ctx context.Context, task config.ScraperTask) ([]config.ScraperTask, error) {
+	if task.Sitemap == nil {
+		return []config.ScraperTask{task}, nil
+	}
+
+	var match *regexp.Regexp
+	if task.Sitemap.Match != "" {
+		var err error
+		if match, err = regexp.Compile(task.Sitemap.Match); err != nil {
+			return nil, fmt.Errorf("sitemap match: %w", err)
+		}
+	}
+	limit := task.Sitemap.URLLimit()
+
+	urls, err := s.URLs(ctx, task.URL, func(loc string) bool {
+		return match == nil || match.MatchString(loc)
+	}, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]config.ScraperTask, 0, len(urls))
+	for _, u := range urls {
+		page := task
+		page.URL = u
+		page.Sitemap = nil
+		tasks = append(tasks, page)
+	}
+	return tasks, nil
+}
+
+// URLs возвращает адреса страниц sitemap, прошедшие keep, без повторов и не больше limit.
+// Индексы sitemap обходятся рекурсивно
+func (s *Sitemaps) URLs(ctx context.Context, sitemapURL string, keep func(string) bool, limit int) ([]string, error) {
+	var (
+		urls    []string
+		seen    = make(map[string]bool)
+		visited = make(map[string]bool)
+	)
+
+	var walk func(loc string, depth int) error
+	walk = func(loc string, depth int) error {
+		if visited[loc] || len(urls) >= limit {
+			return nil
+		}
+		visited[loc] = true
+
+		doc, err := s.fetch(ctx, loc)
+		if err != nil {
+			return fmt.Errorf("sitemap %s: %w", loc, err)
+		}
+
+		for _, u := range doc.URLs {
+			page := strings.TrimSpace(u.Loc)
+			if page == "" || seen[page] || !keep(page) {
+				continue
+			}
+			seen[page] = true
+			urls = append(urls, page)
+			if len(urls) >= limit {
+				return nil
+			}
+		}
+
+		if len(doc.Sitemaps) > 0 && depth >= maxSitemapDepth {
+			return fmt.Errorf("sitemap %s: index nesting exceeds %d", loc, maxSitemapDepth)
+		}
+		for _, child := range doc.Sitemaps {
+			next, err := resolve(loc, strings.TrimSpace(child.Loc))
+			if err != nil {
+				return err
+			}
+			if err := walk(next, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(sitemapURL, 0); err != nil {
+		return nil, err
+	}
+	return urls, nil
+}
+
+// fetch загружает и разбирает один файл sitemap, в том числе сжатый gzip
+func (s *Sitemaps) fetch(ctx context.Context, loc string) (*sitemapDoc, error) {
+	if s.Budget != nil {
+		if err := s.Budget.Consume(ctx, loc); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loc, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/xml, text/xml")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	// sitemap.xml.gz узнается по сигнатуре gzip, а не по имени или заголовкам
+	var body io.Reader = bufio.NewReader(resp.Body)
+	if magic, _ := body.(*bufio.Reader).Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	var doc sitemapDoc
+	if err := xml.NewDecoder(io.LimitReader(body, maxSitemapSize)).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc.XMLName.Local != "urlset" && doc.XMLName.Local != "sitemapindex" {
+		return nil, fmt.Errorf("%w: root element %q", ErrNotSitemap, doc.XMLName.Local)
+	}
+	return &doc, nil
+}
+
+// resolve разрешает адрес вложенного sitemap относительно родительского
+func resolve(base, ref string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	u, err := b.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("sitemap %s: invalid loc %q: %w", base, ref, err)
+	}
+	return u.String(), nil
+}
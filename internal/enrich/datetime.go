@@ -0,0 +1,56 @@
+package enrich
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// relativeDays - смещение в днях для относительных дат
+var relativeDays = map[string]int{
+	"позавчера":   -2,
+	"вчера":       -1,
+	"сегодня":     0,
+	"завтра":      1,
+	"послезавтра": 2,
+}
+
+var (
+	relativeDayRe = regexp.MustCompile(`(?i)(позавчера|вчера|сегодня|послезавтра|завтра)`)
+	clockRe       = regexp.MustCompile(`(?:^|\D)([01]?\d|2[0-3]):([0-5]\d)\b`)
+)
+
+// ParseDateTime распознает дату и время начала в тексте. Сначала текст целиком
+// проверяется по layouts и RFC 3339, затем ищутся даты ParseDates и относительные даты
+// ("сегодня", "завтра"). Время "19:30" в тексте добавляется к найденной дате
+func ParseDateTime(text string, layouts []string, now time.Time) (time.Time, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, false
+	}
+
+	for _, layout := range slices.Concat(layouts, []string{time.RFC3339}) {
+		if t, err := time.ParseInLocation(layout, text, now.Location()); err == nil {
+			return t, true
+		}
+	}
+
+	var date time.Time
+	if dates := ParseDates(text, now); len(dates) > 0 {
+		date = dates[0].Time
+	} else if m := relativeDayRe.FindStringSubmatch(text); m != nil {
+		y, mo, d := now.Date()
+		date = time.Date(y, mo, d+relativeDays[strings.ToLower(m[1])], 0, 0, 0, 0, now.Location())
+	} else {
+		return time.Time{}, false
+	}
+
+	if m := clockRe.FindStringSubmatch(text); m != nil {
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		date = date.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	return date, true
+}
@@ -0,0 +1,65 @@
+package enrich
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// deriveFuncs - функции, доступные в шаблонах вычисляемых полей
+var deriveFuncs = template.FuncMap{
+	"trim":  strings.TrimSpace,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"first": func(s string) string {
+		line, _, _ := strings.Cut(s, "\n")
+		return line
+	},
+	"join": func(sep, s string) string {
+		return strings.Join(strings.Split(s, "\n"), sep)
+	},
+}
+
+// ParseDerived компилирует шаблоны вычисляемых полей задачи
+func ParseDerived(fields map[string]string) (map[string]*template.Template, error) {
+	templates := make(map[string]*template.Template, len(fields))
+	for key, text := range fields {
+		tmpl, err := template.New(key).Funcs(deriveFuncs).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("derived field %q: %w", key, err)
+		}
+		templates[key] = tmpl
+	}
+	return templates, nil
+}
+
+// Derive вычисляет поля по шаблонам Go над извлеченными данными
+// (например, full_title = "{{.venue}} — {{.title}}") и записывает их в Data
+func Derive(result *models.ScrapingResult, fields map[string]string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	templates, err := ParseDerived(fields)
+	if err != nil {
+		return err
+	}
+
+	// Шаблоны видят только извлеченные значения, а не друг друга
+	source := make(map[string]string, len(result.Data))
+	for k, v := range result.Data {
+		source[k] = v
+	}
+
+	for key, tmpl := range templates {
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, source); err != nil {
+			return fmt.Errorf("derived field %q: %w", key, err)
+		}
+		result.Data[key] = sb.String()
+	}
+
+	return nil
+}
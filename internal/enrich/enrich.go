@@ -0,0 +1,31 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// Enricher дополняет результат скраппинга перед сохранением
+type Enricher interface {
+	Enrich(ctx context.Context, result *models.ScrapingResult) error
+}
+
+// Chain последовательно применяет несколько обогатителей
+type Chain []Enricher
+
+// Enrich применяет все обогатители по очереди, останавливаясь на первой ошибке
+func (c Chain) Enrich(ctx context.Context, result *models.ScrapingResult) error {
+	for _, e := range c {
+		spanCtx, span := tracing.Start(ctx, "enrich.step", tracing.String("enricher", fmt.Sprintf("%T", e)))
+		err := e.Enrich(spanCtx, result)
+		span.RecordError(err)
+		span.End()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package enrich
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// EventFields - ключи результата для полей события, пустое поле - одноименный ключ в нижнем регистре
+type EventFields struct {
+	Title           string
+	Description     string
+	Start           string
+	End             string
+	Venue           string
+	Address         string
+	Price           string
+	Link            string
+	Image           string
+	Category        string
+	DefaultCategory string
+}
+
+// MapEvents собирает события из результата: одно из Data или по одному на каждую
+// запись Items. Записи без названия или распознанной даты начала пропускаются.
+// Даты без года и часового пояса отсчитываются от now
+func MapEvents(result *models.ScrapingResult, fields EventFields, layouts []string, now time.Time) []*models.Event {
+	if result.Items != nil {
+		events := make([]*models.Event, 0, len(result.Items))
+		for _, item := range result.Items {
+			if event := mapEvent(result, item, nil, fields, layouts, now); event != nil {
+				events = append(events, event)
+			}
+		}
+		return events
+	}
+
+	if event := mapEvent(result, result.Data, &result.Metadata, fields, layouts, now); event != nil {
+		return []*models.Event{event}
+	}
+	return nil
+}
+
+// mapEvent собирает событие из значений ключей. meta с уже распознанными
+// значениями ключей с типами есть только у Data
+func mapEvent(result *models.ScrapingResult, values map[string]string, meta *models.ScrapeMeta, fields EventFields, layouts []string, now time.Time) *models.Event {
+	value := func(key, def string) string {
+		return strings.TrimSpace(values[cmp.Or(key, def)])
+	}
+	date := func(key, def string) (time.Time, bool) {
+		key = cmp.Or(key, def)
+		if meta != nil {
+			if t, ok := meta.Dates[key]; ok {
+				return t, true
+			}
+		}
+		return ParseDateTime(values[key], layouts, now)
+	}
+
+	title, _, _ := strings.Cut(value(fields.Title, "title"), "\n")
+	start, ok := date(fields.Start, "start")
+	if title == "" || !ok {
+		return nil
+	}
+
+	link, _, _ := strings.Cut(value(fields.Link, "link"), "\n")
+	image, _, _ := strings.Cut(value(fields.Image, "image"), "\n")
+	if meta != nil {
+		// Сохраненная копия изображения вместо ссылки на сайт источника
+		if ref, ok := meta.Images[cmp.Or(fields.Image, "image")]; ok {
+			image = ref
+		}
+	}
+	event := &models.Event{
+		Key:         models.EventKey(result.Project, result.URL, link, title, start),
+		Project:     result.Project,
+		Source:      result.Name,
+		SourceURL:   result.URL,
+		Title:       title,
+		Description: value(fields.Description, "description"),
+		Start:       start,
+		Venue:       value(fields.Venue, "venue"),
+		Address:     value(fields.Address, "address"),
+		Link:        link,
+		Image:       image,
+		Category:    cmp.Or(value(fields.Category, "category"), fields.DefaultCategory),
+	}
+	if end, ok := date(fields.End, "end"); ok && end.After(start) {
+		event.End = &end
+	}
+
+	priceKey := cmp.Or(fields.Price, "price")
+	if meta != nil {
+		if price, ok := meta.Prices[priceKey]; ok {
+			event.Price = &price
+		}
+	}
+	if event.Price == nil {
+		if price, ok := ParsePrice(values[priceKey]); ok {
+			event.Price = &price
+		}
+	}
+	return event
+}
@@ -0,0 +1,150 @@
+package enrich
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// Названия месяцев в родительном падеже, как они встречаются в афишах
+var ruMonths = map[string]time.Month{
+	"января":   time.January,
+	"февраля":  time.February,
+	"марта":    time.March,
+	"апреля":   time.April,
+	"мая":      time.May,
+	"июня":     time.June,
+	"июля":     time.July,
+	"августа":  time.August,
+	"сентября": time.September,
+	"октября":  time.October,
+	"ноября":   time.November,
+	"декабря":  time.December,
+}
+
+var (
+	isoDateRe     = regexp.MustCompile(`(\d{4})-(\d{2})-(\d{2})`)
+	numericDateRe = regexp.MustCompile(`\b(\d{1,2})\.(\d{1,2})(?:\.(\d{2,4}))?\b`)
+	ruDateRe      = regexp.MustCompile(`(?i)\b(\d{1,2})\s+(января|февраля|марта|апреля|мая|июня|июля|августа|сентября|октября|ноября|декабря)(?:\s+(\d{4}))?`)
+)
+
+const (
+	// ExpiryConfidenceKey - ключ оценки достоверности срока актуальности
+	ExpiryConfidenceKey = "expires_at"
+
+	// Оценка даты с выведенным годом ниже, чем с явно указанным
+	explicitYearConfidence = 1.0
+	inferredYearConfidence = 0.6
+)
+
+// ParsedDate - распознанная дата и признак того, что год был выведен
+type ParsedDate struct {
+	Time         time.Time
+	YearInferred bool
+}
+
+// Confidence возвращает оценку достоверности распознанной даты
+func (d ParsedDate) Confidence() float64 {
+	if d.YearInferred {
+		return inferredYearConfidence
+	}
+	return explicitYearConfidence
+}
+
+// ExpiryEnricher вычисляет ExpiresAt по самой поздней дате в полях результата
+type ExpiryEnricher struct {
+	Fields   []string
+	Location *time.Location
+	Now      func() time.Time
+}
+
+// NewExpiryEnricher создает обогатитель срока актуальности событий
+func NewExpiryEnricher(fields []string, loc *time.Location) *ExpiryEnricher {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &ExpiryEnricher{Fields: fields, Location: loc, Now: time.Now}
+}
+
+// Enrich устанавливает ExpiresAt на конец дня самой поздней найденной даты
+func (e *ExpiryEnricher) Enrich(ctx context.Context, result *models.ScrapingResult) error {
+	now := e.Now().In(e.Location)
+
+	var latest ParsedDate
+	for _, field := range e.Fields {
+		for _, date := range ParseDates(result.Data[field], now) {
+			if date.Time.After(latest.Time) {
+				latest = date
+			}
+		}
+	}
+
+	if latest.Time.IsZero() {
+		return nil
+	}
+
+	expires := latest.Time.AddDate(0, 0, 1)
+	result.ExpiresAt = &expires
+	result.SetConfidence(ExpiryConfidenceKey, latest.Confidence())
+	return nil
+}
+
+// ParseDates извлекает все распознанные даты из текста.
+// Даты без года относятся к ближайшему будущему относительно now
+func ParseDates(text string, now time.Time) []ParsedDate {
+	var dates []ParsedDate
+	loc := now.Location()
+
+	for _, m := range isoDateRe.FindAllStringSubmatch(text, -1) {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		day, _ := strconv.Atoi(m[3])
+		if valid(month, day) {
+			dates = append(dates, ParsedDate{Time: time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)})
+		}
+	}
+
+	for _, m := range numericDateRe.FindAllStringSubmatch(text, -1) {
+		day, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		if valid(month, day) {
+			dates = append(dates, withYear(m[3], time.Month(month), day, now))
+		}
+	}
+
+	for _, m := range ruDateRe.FindAllStringSubmatch(text, -1) {
+		day, _ := strconv.Atoi(m[1])
+		month := ruMonths[strings.ToLower(m[2])]
+		if valid(int(month), day) {
+			dates = append(dates, withYear(m[3], month, day, now))
+		}
+	}
+
+	return dates
+}
+
+// withYear собирает дату, подставляя год, если он не указан явно
+func withYear(yearStr string, month time.Month, day int, now time.Time) ParsedDate {
+	if yearStr != "" {
+		year, _ := strconv.Atoi(yearStr)
+		if year < 100 {
+			year += 2000
+		}
+		return ParsedDate{Time: time.Date(year, month, day, 0, 0, 0, 0, now.Location())}
+	}
+
+	date := time.Date(now.Year(), month, day, 0, 0, 0, 0, now.Location())
+	// Дата, ушедшая в прошлое больше чем на полгода, скорее всего относится к следующему году
+	if date.Before(now.AddDate(0, -6, 0)) {
+		date = date.AddDate(1, 0, 0)
+	}
+	return ParsedDate{Time: date, YearInferred: true}
+}
+
+func valid(month, day int) bool {
+	return month >= 1 && month <= 12 && day >= 1 && day <= 31
+}
@@ -0,0 +1,75 @@
+package enrich
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// currencies - обозначения валют в тексте цены. Белорусский рубль проверяется
+// раньше российского, иначе "бел. руб." распознается как RUB
+var currencies = []struct {
+	re   *regexp.Regexp
+	code string
+}{
+	{regexp.MustCompile(`(?i)бел\.?\s*руб|\bBYN\b`), "BYN"},
+	{regexp.MustCompile(`(?i)₽|руб|\d\s*р\.|\bRUB\b`), "RUB"},
+	{regexp.MustCompile(`(?i)\$|\bUSD\b`), "USD"},
+	{regexp.MustCompile(`(?i)€|\bEUR\b|евро`), "EUR"},
+	{regexp.MustCompile(`(?i)₸|тенге|\bKZT\b`), "KZT"},
+}
+
+var (
+	// Число с пробелами между разрядами и десятичной запятой или точкой: "1 500", "99,90"
+	numberRe = regexp.MustCompile(`\d+(?:[ \x{00A0}\x{202F}]\d{3})*(?:[.,]\d+)?`)
+	freeRe   = regexp.MustCompile(`(?i)бесплатн|свободный вход|\bfree\b`)
+
+	digitSeparators = strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "", ",", ".")
+)
+
+// ParseNumber возвращает первое число в тексте
+func ParseNumber(text string) (float64, bool) {
+	numbers := parseNumbers(text)
+	if len(numbers) == 0 {
+		return 0, false
+	}
+	return numbers[0], true
+}
+
+// ParsePrice распознает цену ("от 500 ₽", "1 200–2 500 руб.", "бесплатно").
+// Бесплатный вход распознается как нулевая цена
+func ParsePrice(text string) (models.Price, bool) {
+	numbers := parseNumbers(text)
+	if len(numbers) == 0 {
+		if freeRe.MatchString(text) {
+			return models.Price{}, true
+		}
+		return models.Price{}, false
+	}
+
+	price := models.Price{Amount: numbers[0]}
+	if len(numbers) > 1 && numbers[1] > price.Amount {
+		price.Max = numbers[1]
+	}
+	for _, c := range currencies {
+		if c.re.MatchString(text) {
+			price.Currency = c.code
+			break
+		}
+	}
+	return price, true
+}
+
+// parseNumbers возвращает все числа текста по порядку
+func parseNumbers(text string) []float64 {
+	var numbers []float64
+	for _, m := range numberRe.FindAllString(text, -1) {
+		m = digitSeparators.Replace(m)
+		if n, err := strconv.ParseFloat(m, 64); err == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers
+}
@@ -0,0 +1,79 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// TagRule - правило назначения метки по содержимому результата
+type TagRule struct {
+	Tag     string `json:"Tag"`
+	Type    string `json:"Type,omitempty"`  // Тип результата, пустое значение - любой
+	Field   string `json:"Field,omitempty"` // Поле Data, пустое значение - любое
+	Pattern string `json:"Pattern"`
+
+	re *regexp.Regexp
+}
+
+// TagEnricher добавляет метки к результату по набору правил
+type TagEnricher struct {
+	Rules []TagRule
+}
+
+// NewTagEnricher компилирует правила и создает обогатитель меток
+func NewTagEnricher(rules []TagRule) (*TagEnricher, error) {
+	for i := range rules {
+		re, err := regexp.Compile(rules[i].Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("tag rule %q: %w", rules[i].Tag, err)
+		}
+		rules[i].re = re
+	}
+
+	return &TagEnricher{Rules: rules}, nil
+}
+
+// LoadTagRules загружает правила меток из JSON-файла
+func LoadTagRules(path string) ([]TagRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []TagRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// Enrich назначает метки, правила которых совпали с данными результата
+func (t *TagEnricher) Enrich(ctx context.Context, result *models.ScrapingResult) error {
+	for _, rule := range t.Rules {
+		if rule.Type != "" && rule.Type != result.Type {
+			continue
+		}
+
+		if rule.Field != "" {
+			if rule.re.MatchString(result.Data[rule.Field]) {
+				result.AddTags(rule.Tag)
+			}
+			continue
+		}
+
+		for _, value := range result.Data {
+			if rule.re.MatchString(value) {
+				result.AddTags(rule.Tag)
+				break
+			}
+		}
+	}
+
+	return nil
+}
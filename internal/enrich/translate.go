@@ -0,0 +1,193 @@
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+const (
+	ProviderDeepL          = "deepl"
+	ProviderLibreTranslate = "libretranslate"
+
+	defaultTranslateTimeout = 10 * time.Second
+
+	// TranslationConfidence - оценка достоверности машинного перевода
+	TranslationConfidence = 0.8
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown translation provider")
+	ErrEmptyResponse   = errors.New("translation provider returned empty response")
+)
+
+// Translator - интерфейс провайдера машинного перевода
+type Translator interface {
+	Translate(ctx context.Context, text, sourceLang, targetLang string) (string, error)
+}
+
+// NewTranslator создает провайдера перевода по имени
+func NewTranslator(provider, endpoint, apiKey string) (Translator, error) {
+	client := &http.Client{Timeout: defaultTranslateTimeout}
+
+	switch strings.ToLower(provider) {
+	case ProviderDeepL:
+		if endpoint == "" {
+			endpoint = "https://api-free.deepl.com/v2/translate"
+		}
+		return &DeepL{Endpoint: endpoint, APIKey: apiKey, Client: client}, nil
+	case ProviderLibreTranslate:
+		if endpoint == "" {
+			endpoint = "https://libretranslate.com/translate"
+		}
+		return &LibreTranslate{Endpoint: endpoint, APIKey: apiKey, Client: client}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, provider)
+	}
+}
+
+// DeepL реализует Translator через DeepL API
+type DeepL struct {
+	Endpoint string
+	APIKey   string
+	Client   *http.Client
+}
+
+// Translate переводит текст через DeepL
+func (d *DeepL) Translate(ctx context.Context, text, sourceLang, targetLang string) (string, error) {
+	form := url.Values{}
+	form.Set("text", text)
+	form.Set("target_lang", strings.ToUpper(targetLang))
+	if sourceLang != "" {
+		form.Set("source_lang", strings.ToUpper(sourceLang))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "DeepL-Auth-Key "+d.APIKey)
+
+	var resp struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	if err := doJSON(d.Client, req, &resp); err != nil {
+		return "", err
+	}
+
+	if len(resp.Translations) == 0 {
+		return "", ErrEmptyResponse
+	}
+
+	return resp.Translations[0].Text, nil
+}
+
+// LibreTranslate реализует Translator через LibreTranslate API
+type LibreTranslate struct {
+	Endpoint string
+	APIKey   string
+	Client   *http.Client
+}
+
+// Translate переводит текст через LibreTranslate
+func (l *LibreTranslate) Translate(ctx context.Context, text, sourceLang, targetLang string) (string, error) {
+	if sourceLang == "" {
+		sourceLang = "auto"
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"q":       text,
+		"source":  sourceLang,
+		"target":  targetLang,
+		"format":  "text",
+		"api_key": l.APIKey,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var resp struct {
+		TranslatedText string `json:"translatedText"`
+	}
+	if err := doJSON(l.Client, req, &resp); err != nil {
+		return "", err
+	}
+
+	return resp.TranslatedText, nil
+}
+
+// doJSON выполняет запрос и декодирует JSON-ответ
+func doJSON(client *http.Client, req *http.Request, out any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("translation request failed: %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// TranslationEnricher сохраняет переводы выбранных полей под ключами с суффиксом языка (title_en)
+type TranslationEnricher struct {
+	Translator Translator
+	Fields     []string
+	SourceLang string
+	TargetLang string
+}
+
+// NewTranslationEnricher создает обогатитель переводов
+func NewTranslationEnricher(translator Translator, fields []string, sourceLang, targetLang string) *TranslationEnricher {
+	return &TranslationEnricher{
+		Translator: translator,
+		Fields:     fields,
+		SourceLang: sourceLang,
+		TargetLang: strings.ToLower(targetLang),
+	}
+}
+
+// Enrich переводит выбранные поля результата
+func (t *TranslationEnricher) Enrich(ctx context.Context, result *models.ScrapingResult) error {
+	for _, field := range t.Fields {
+		text, ok := result.Data[field]
+		if !ok || strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		translated, err := t.Translator.Translate(ctx, text, t.SourceLang, t.TargetLang)
+		if err != nil {
+			return fmt.Errorf("translate field %q: %w", field, err)
+		}
+
+		key := TranslatedKey(field, t.TargetLang)
+		result.Data[key] = translated
+		result.SetConfidence(key, TranslationConfidence)
+	}
+
+	return nil
+}
+
+// TranslatedKey возвращает ключ для перевода поля (Title, en -> Title_en)
+func TranslatedKey(field, lang string) string {
+	return field + "_" + strings.ToLower(lang)
+}
@@ -0,0 +1,165 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// Поля результата, доступные как источники колонок CSV. Остальные источники
+// считаются ключами Data, префикс "data." принудительно выбирает ключ Data
+var resultFields = map[string]func(*models.ScrapingResult) string{
+	"id":         func(r *models.ScrapingResult) string { return r.ID.Hex() },
+	"url":        func(r *models.ScrapingResult) string { return r.URL },
+	"type":       func(r *models.ScrapingResult) string { return r.Type },
+	"name":       func(r *models.ScrapingResult) string { return r.Name },
+	"project":    func(r *models.ScrapingResult) string { return r.Project },
+	"tags":       func(r *models.ScrapingResult) string { return strings.Join(r.Tags, ";") },
+	"created_at": func(r *models.ScrapingResult) string { return formatTime(r.CreatedAt) },
+	"updated_at": func(r *models.ScrapingResult) string { return formatTime(r.UpdatedAt) },
+}
+
+// defaultFields - поля результата в колонках по умолчанию, перед ключами Data
+var defaultFields = []string{"id", "url", "type", "name", "project", "tags", "created_at"}
+
+// CSVColumn - колонка CSV: заголовок и источник значения
+type CSVColumn struct {
+	Header string
+	Source string
+}
+
+// ParseCSVColumns разбирает сопоставление колонок в формате "Название=title,Дата=date,url".
+// Колонка без "=" использует источник как заголовок
+func ParseCSVColumns(s string) ([]CSVColumn, error) {
+	var columns []CSVColumn
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		header, source, ok := strings.Cut(part, "=")
+		if !ok {
+			source = header
+		}
+		header, source = strings.TrimSpace(header), strings.TrimSpace(source)
+		if header == "" || source == "" {
+			return nil, fmt.Errorf("invalid csv column %q: expected header=source", part)
+		}
+		columns = append(columns, CSVColumn{Header: header, Source: source})
+	}
+	return columns, nil
+}
+
+// DefaultCSVColumns возвращает поля результата и все встреченные ключи Data в алфавитном порядке
+func DefaultCSVColumns(results []*models.ScrapingResult) []CSVColumn {
+	columns := make([]CSVColumn, 0, len(defaultFields))
+	for _, field := range defaultFields {
+		columns = append(columns, CSVColumn{Header: field, Source: field})
+	}
+
+	var keys []string
+	for _, result := range results {
+		for _, data := range rowData(result) {
+			for key := range data {
+				if !slices.Contains(keys, key) {
+					keys = append(keys, key)
+				}
+			}
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		columns = append(columns, CSVColumn{Header: key, Source: "data." + key})
+	}
+	return columns
+}
+
+// WriteCSV записывает результаты в CSV, результат со списком записей (Items) дает строку
+// на каждую запись. Если columns пуст, используются DefaultCSVColumns
+func WriteCSV(w io.Writer, results []*models.ScrapingResult, columns []CSVColumn) error {
+	if len(columns) == 0 {
+		columns = DefaultCSVColumns(results)
+	}
+
+	cw := csv.NewWriter(w)
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Header
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	row := make([]string, len(columns))
+	for _, result := range results {
+		for _, data := range rowData(result) {
+			for i, column := range columns {
+				row[i] = csvValue(result, data, column.Source)
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// rowData возвращает данные строк результата: Data или Data, дополненные каждой записью Items
+func rowData(result *models.ScrapingResult) []map[string]string {
+	if len(result.Items) == 0 {
+		return []map[string]string{result.Data}
+	}
+
+	rows := make([]map[string]string, len(result.Items))
+	for i, item := range result.Items {
+		data := maps.Clone(result.Data)
+		if data == nil {
+			data = make(map[string]string, len(item))
+		}
+		maps.Copy(data, item)
+		rows[i] = data
+	}
+	return rows
+}
+
+// csvValue возвращает значение источника для строки результата
+func csvValue(result *models.ScrapingResult, data map[string]string, source string) string {
+	if key, ok := strings.CutPrefix(source, "data."); ok {
+		return dataValue(data, key)
+	}
+	if field, ok := resultFields[strings.ToLower(source)]; ok {
+		return field(result)
+	}
+	return dataValue(data, source)
+}
+
+// dataValue ищет ключ Data сначала точно, затем без учета регистра
+func dataValue(data map[string]string, key string) string {
+	if v, ok := data[key]; ok {
+		return v
+	}
+	for k, v := range data {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,232 @@
+package export
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rx3lixir/kultscraper/internal/enrich"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// Группировка событий по календарям
+const (
+	GroupNone = ""
+	GroupType = "type"
+	GroupName = "name"
+)
+
+var ErrUnknownGroup = errors.New("unknown calendar grouping")
+
+// timeOfDayRe находит время начала события в поле даты: "12 марта, 19:00"
+var timeOfDayRe = regexp.MustCompile(`\b([01]?\d|2[0-3]):([0-5]\d)\b`)
+
+// Calendar - календарь событий одной группы
+type Calendar struct {
+	Name    string
+	Results []*models.ScrapingResult
+}
+
+// GroupCalendars распределяет результаты по календарям: по типу, по имени источника
+// или в один календарь с именем defaultName. Календари упорядочены по имени
+func GroupCalendars(results []*models.ScrapingResult, by, defaultName string) ([]Calendar, error) {
+	key := func(*models.ScrapingResult) string { return defaultName }
+	switch by {
+	case GroupNone:
+	case GroupType:
+		key = func(r *models.ScrapingResult) string { return r.Type }
+	case GroupName:
+		key = func(r *models.ScrapingResult) string { return r.Name }
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownGroup, by)
+	}
+
+	groups := make(map[string][]*models.ScrapingResult)
+	for _, result := range results {
+		groups[key(result)] = append(groups[key(result)], result)
+	}
+
+	calendars := make([]Calendar, 0, len(groups))
+	for name, group := range groups {
+		calendars = append(calendars, Calendar{Name: name, Results: group})
+	}
+	sort.Slice(calendars, func(i, j int) bool { return calendars[i].Name < calendars[j].Name })
+	return calendars, nil
+}
+
+// WriteICS записывает календарь в формате iCalendar (RFC 5545). Дата начала берется из полей
+// даты результата, время - из того же поля, если указано. Результаты без даты пропускаются.
+// Даты без времени становятся событиями на весь день, время переводится в UTC из loc.
+// Возвращает число записанных событий
+func WriteICS(w io.Writer, cal Calendar, now time.Time, loc *time.Location) (int, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	now = now.In(loc)
+
+	iw := &icsWriter{w: bufio.NewWriter(w)}
+	iw.line("BEGIN:VCALENDAR")
+	iw.line("VERSION:2.0")
+	iw.line("PRODID:-//kultscraper//events//RU")
+	iw.line("CALSCALE:GREGORIAN")
+	if cal.Name != "" {
+		iw.prop("X-WR-CALNAME", escapeText(cal.Name))
+	}
+
+	written := 0
+	for _, result := range cal.Results {
+		if writeEvent(iw, result, now) {
+			written++
+		}
+	}
+
+	iw.line("END:VCALENDAR")
+	if iw.err != nil {
+		return written, iw.err
+	}
+	return written, iw.w.Flush()
+}
+
+func writeEvent(iw *icsWriter, result *models.ScrapingResult, now time.Time) bool {
+	startText := lookup(result.Data, startDateKeys)
+	dates := enrich.ParseDates(startText, now)
+	if len(dates) == 0 {
+		return false
+	}
+	start := dates[0].Time
+
+	// Окончание: отдельное поле или последняя дата диапазона "12 марта - 14 марта"
+	end := time.Time{}
+	if endDates := enrich.ParseDates(lookup(result.Data, endDateKeys), now); len(endDates) > 0 {
+		end = endDates[0].Time
+	} else if len(dates) > 1 && dates[len(dates)-1].Time.After(start) {
+		end = dates[len(dates)-1].Time
+	}
+
+	iw.line("BEGIN:VEVENT")
+	iw.prop("UID", result.ID.Hex()+"@kultscraper")
+	iw.prop("DTSTAMP", utcStamp(updatedAt(result), now))
+
+	if m := timeOfDayRe.FindStringSubmatch(startText); m != nil {
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		start = start.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+		iw.prop("DTSTART", start.UTC().Format("20060102T150405Z"))
+		if !end.IsZero() {
+			// Время окончания неизвестно, событие длится до конца последнего дня
+			iw.prop("DTEND", end.AddDate(0, 0, 1).UTC().Format("20060102T150405Z"))
+		}
+	} else {
+		if end.IsZero() {
+			end = start
+		}
+		// DTEND событий на весь день не включается в интервал
+		iw.prop("DTSTART;VALUE=DATE", start.Format("20060102"))
+		iw.prop("DTEND;VALUE=DATE", end.AddDate(0, 0, 1).Format("20060102"))
+	}
+
+	summary := lookup(result.Data, nameKeys)
+	if summary == "" {
+		summary = result.Name
+	}
+	iw.prop("SUMMARY", escapeText(summary))
+
+	var description []string
+	if v := lookup(result.Data, descriptionKeys); v != "" {
+		description = append(description, v)
+	}
+	if v := lookup(result.Data, priceKeys); v != "" {
+		description = append(description, v)
+	}
+	if len(description) > 0 {
+		iw.prop("DESCRIPTION", escapeText(strings.Join(description, "\n")))
+	}
+
+	var location []string
+	for _, keys := range [][]string{locationKeys, addressKeys} {
+		if v := lookup(result.Data, keys); v != "" {
+			location = append(location, v)
+		}
+	}
+	if len(location) > 0 {
+		iw.prop("LOCATION", escapeText(strings.Join(location, ", ")))
+	}
+
+	link := lookup(result.Data, urlKeys)
+	if link == "" {
+		link = result.URL
+	}
+	if link != "" {
+		iw.prop("URL", link)
+	}
+
+	var categories []string
+	if result.Type != "" {
+		categories = append(categories, escapeText(result.Type))
+	}
+	for _, tag := range result.Tags {
+		categories = append(categories, escapeText(tag))
+	}
+	if len(categories) > 0 {
+		iw.prop("CATEGORIES", strings.Join(categories, ","))
+	}
+
+	iw.line("END:VEVENT")
+	return true
+}
+
+func utcStamp(t, fallback time.Time) string {
+	if t.IsZero() {
+		t = fallback
+	}
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapeText экранирует значение типа TEXT
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icsWriter пишет строки с CRLF и переносом длиннее 75 байт, не разрывая символы UTF-8
+type icsWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (iw *icsWriter) prop(name, value string) {
+	iw.line(name + ":" + value)
+}
+
+func (iw *icsWriter) line(s string) {
+	const limit = 75
+	for first := true; ; first = false {
+		size := limit
+		if !first {
+			size-- // Продолжение начинается с пробела
+			iw.write(" ")
+		}
+		if len(s) <= size {
+			iw.write(s + "\r\n")
+			return
+		}
+		cut := size
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		iw.write(s[:cut] + "\r\n")
+		s = s[cut:]
+	}
+}
+
+func (iw *icsWriter) write(s string) {
+	if iw.err == nil {
+		_, iw.err = iw.w.WriteString(s)
+	}
+}
@@ -0,0 +1,123 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+const schemaContext = "https://schema.org"
+
+// Ключи Data, из которых берутся поля schema.org/Event (без учета регистра)
+var (
+	nameKeys        = []string{"title", "name"}
+	descriptionKeys = []string{"description", "descr"}
+	startDateKeys   = []string{"startdate", "date", "datetime"}
+	endDateKeys     = []string{"enddate"}
+	locationKeys    = []string{"venue", "location", "place"}
+	addressKeys     = []string{"address"}
+	imageKeys       = []string{"image", "poster"}
+	urlKeys         = []string{"link", "url"}
+	priceKeys       = []string{"price"}
+)
+
+// Event - представление события в формате schema.org/Event JSON-LD
+type Event struct {
+	Context     string   `json:"@context"`
+	Type        string   `json:"@type"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	StartDate   string   `json:"startDate,omitempty"`
+	EndDate     string   `json:"endDate,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	Image       string   `json:"image,omitempty"`
+	Location    *Place   `json:"location,omitempty"`
+	Offers      *Offer   `json:"offers,omitempty"`
+	Organizer   *Thing   `json:"organizer,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+}
+
+// Place - место проведения события
+type Place struct {
+	Type    string `json:"@type"`
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// Offer - предложение билетов
+type Offer struct {
+	Type  string `json:"@type"`
+	Price string `json:"price,omitempty"`
+	URL   string `json:"url,omitempty"`
+}
+
+// Thing - произвольная сущность schema.org с именем и ссылкой
+type Thing struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// ToJSONLD преобразует результат скраппинга в schema.org/Event
+func ToJSONLD(result *models.ScrapingResult) *Event {
+	event := &Event{
+		Context:     schemaContext,
+		Type:        "Event",
+		Name:        lookup(result.Data, nameKeys),
+		Description: lookup(result.Data, descriptionKeys),
+		StartDate:   lookup(result.Data, startDateKeys),
+		EndDate:     lookup(result.Data, endDateKeys),
+		URL:         lookup(result.Data, urlKeys),
+		Image:       lookup(result.Data, imageKeys),
+		Organizer:   &Thing{Type: "Organization", Name: result.Name, URL: result.URL},
+	}
+
+	if event.Name == "" {
+		event.Name = result.Name
+	}
+	if event.URL == "" {
+		event.URL = result.URL
+	}
+	if result.Type != "" {
+		event.Keywords = []string{result.Type}
+	}
+
+	venue, address := lookup(result.Data, locationKeys), lookup(result.Data, addressKeys)
+	if venue != "" || address != "" {
+		event.Location = &Place{Type: "Place", Name: venue, Address: address}
+	}
+
+	if price := lookup(result.Data, priceKeys); price != "" {
+		event.Offers = &Offer{Type: "Offer", Price: price, URL: event.URL}
+	}
+
+	return event
+}
+
+// WriteJSONLD записывает результаты как JSON-LD массив событий
+func WriteJSONLD(w io.Writer, results []*models.ScrapingResult) error {
+	events := make([]*Event, 0, len(results))
+	for _, result := range results {
+		events = append(events, ToJSONLD(result))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+
+	return enc.Encode(events)
+}
+
+// lookup ищет первое непустое значение по списку ключей без учета регистра
+func lookup(data map[string]string, keys []string) string {
+	for _, key := range keys {
+		for k, v := range data {
+			if strings.EqualFold(k, key) && strings.TrimSpace(v) != "" {
+				return strings.TrimSpace(v)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// DefaultFeedSize - число записей ленты по умолчанию
+const DefaultFeedSize = 50
+
+// Feed - описание ленты RSS
+type Feed struct {
+	Title       string
+	Link        string // Адрес ленты или сайта
+	Description string
+	Size        int // Число последних результатов, по умолчанию DefaultFeedSize
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description,omitempty"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// WriteRSS записывает последние по времени обновления результаты как ленту RSS 2.0.
+// Поля записи берутся из Data по тем же ключам, что и для JSON-LD
+func WriteRSS(w io.Writer, feed Feed, results []*models.ScrapingResult) error {
+	size := feed.Size
+	if size <= 0 {
+		size = DefaultFeedSize
+	}
+
+	latest := append([]*models.ScrapingResult(nil), results...)
+	sort.SliceStable(latest, func(i, j int) bool {
+		return updatedAt(latest[i]).After(updatedAt(latest[j]))
+	})
+	latest = latest[:min(size, len(latest))]
+
+	doc := rssDocument{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       feed.Title,
+			Link:        feed.Link,
+			Description: feed.Description,
+			Generator:   "kultscraper",
+		},
+	}
+	if len(latest) > 0 {
+		doc.Channel.LastBuildDate = updatedAt(latest[0]).Format(time.RFC1123Z)
+	}
+
+	for _, result := range latest {
+		doc.Channel.Items = append(doc.Channel.Items, toRSSItem(result))
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func toRSSItem(result *models.ScrapingResult) rssItem {
+	item := rssItem{
+		Title: lookup(result.Data, nameKeys),
+		Link:  lookup(result.Data, urlKeys),
+		GUID:  rssGUID{Value: result.ID.Hex()},
+	}
+	if item.Title == "" {
+		item.Title = result.Name
+	}
+	if item.Link == "" {
+		item.Link = result.URL
+	}
+	if t := updatedAt(result); !t.IsZero() {
+		item.PubDate = t.Format(time.RFC1123Z)
+	}
+
+	// Описание: текст события, затем дата и место, если они есть
+	var parts []string
+	for _, keys := range [][]string{descriptionKeys, startDateKeys, locationKeys, priceKeys} {
+		if v := lookup(result.Data, keys); v != "" {
+			parts = append(parts, v)
+		}
+	}
+	item.Description = strings.Join(parts, "\n")
+
+	if result.Type != "" {
+		item.Categories = append(item.Categories, result.Type)
+	}
+	item.Categories = append(item.Categories, result.Tags...)
+
+	return item
+}
+
+func updatedAt(result *models.ScrapingResult) time.Time {
+	if !result.UpdatedAt.IsZero() {
+		return result.UpdatedAt
+	}
+	return result.CreatedAt
+}
@@ -0,0 +1,56 @@
+// Package auth проверяет ключи доступа к API и ограничивает запросы проектом ключа
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Keys находит проект по ключу доступа, например config.APIKeys.
+// all - ключ открывает все проекты, ok - ключ известен
+type Keys interface {
+	Scope(key string) (project string, all, ok bool)
+}
+
+type projectKey struct{}
+
+// WithProject возвращает контекст запроса, ограниченного проектом ключа
+func WithProject(ctx context.Context, project string) context.Context {
+	return context.WithValue(ctx, projectKey{}, project)
+}
+
+// ProjectFrom возвращает проект ключа запроса. false - запрос не ограничен проектом
+func ProjectFrom(ctx context.Context) (string, bool) {
+	project, ok := ctx.Value(projectKey{}).(string)
+	return project, ok
+}
+
+// RequestKey возвращает ключ из заголовка Authorization: Bearer <key> или X-API-Key
+func RequestKey(r *http.Request) string {
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return bearer
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// Require пропускает к next только запросы с известным ключом и записывает в контекст
+// запроса проект ключа, если ключ не открывает все проекты. Браузерные EventSource
+// и WebSocket не умеют передавать заголовки, поэтому ключ принимается и в параметре ?key=
+func Require(keys Keys, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := RequestKey(r)
+		if key == "" {
+			key = r.URL.Query().Get("key")
+		}
+		project, all, ok := keys.Scope(key)
+		if !ok {
+			http.Error(w, "invalid or missing API key", http.StatusUnauthorized)
+			return
+		}
+		if !all {
+			r = r.WithContext(WithProject(r.Context(), project))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
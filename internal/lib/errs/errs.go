@@ -0,0 +1,114 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Code - категория ошибки для группировки сбоев в отчетах и метриках
+type Code string
+
+const (
+	CodeNavigation       Code = "navigation_error"
+	CodeSelectorNotFound Code = "selector_not_found"
+	CodeTimeout          Code = "timeout"
+	CodeCanceled         Code = "canceled"
+	CodeStorage          Code = "storage_error"
+	CodeBlocked          Code = "blocked"
+	CodeCaptcha          Code = "captcha"
+	CodeScript           Code = "script_error"
+	CodeAction           Code = "action_error"
+	CodeAuth             Code = "auth_error"
+	CodeBudgetExhausted  Code = "budget_exhausted"
+	CodeUnsupported      Code = "unsupported"    // Возможность задачи не поддерживается сборкой, например движок не подключен
+	CodeInvalidValue     Code = "invalid_value"  // Значение найдено, но не приводится к типу поля
+	CodeDownload         Code = "download_error" // Не удалось скачать файл по значению ключа, например изображение
+	CodeQuality          Code = "quality_check"  // Результат не прошел проверку заполненности и отклонен
+	CodeNotModified      Code = "not_modified"   // Страница не изменилась с прошлого сбора, задача пропущена
+	CodeUnknown          Code = "unknown"
+)
+
+// Error - ошибка с категорией и операцией, в которой она возникла
+type Error struct {
+	Code Code
+	Op   string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s: %s", e.Op, e.Code)
+	}
+	return fmt.Sprintf("%s: %s: %v", e.Op, e.Code, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap оборачивает ошибку в Error с заданной категорией. Для nil возвращает nil
+func Wrap(code Code, op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Op: op, Err: err}
+}
+
+// New создает ошибку с категорией без исходной ошибки
+func New(code Code, op string) error {
+	return &Error{Code: code, Op: op}
+}
+
+// CodeOf возвращает категорию ошибки. Ошибки без категории классифицируются
+// по истечению и отмене контекста, остальные считаются CodeUnknown
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	case errors.Is(err, context.Canceled):
+		return CodeCanceled
+	default:
+		return CodeUnknown
+	}
+}
+
+// Is сообщает, относится ли ошибка к категории code
+func Is(err error, code Code) bool {
+	return CodeOf(err) == code
+}
+
+// artifactsError прикрепляет к ошибке путь к каталогу с отладочными артефактами
+type artifactsError struct {
+	err  error
+	path string
+}
+
+func (e *artifactsError) Error() string { return e.err.Error() }
+func (e *artifactsError) Unwrap() error { return e.err }
+
+// WithArtifacts прикрепляет к ошибке путь к артефактам сбоя. Для nil возвращает nil
+func WithArtifacts(err error, path string) error {
+	if err == nil || path == "" {
+		return err
+	}
+	return &artifactsError{err: err, path: path}
+}
+
+// ArtifactsOf возвращает путь к артефактам сбоя, прикрепленный к ошибке
+func ArtifactsOf(err error) string {
+	var e *artifactsError
+	if errors.As(err, &e) {
+		return e.path
+	}
+	return ""
+}
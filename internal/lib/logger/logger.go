@@ -1,18 +1,148 @@
 package logger
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/log"
 )
 
-func InitLogger() *log.Logger {
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+// InitLogger создает логгер с заданными уровнем и форматом.
+// Пустые значения означают уровень info и текстовый формат
+func InitLogger(level, format string) *log.Logger {
 	logger := log.NewWithOptions(os.Stderr, log.Options{
 		ReportCaller:    true,
 		ReportTimestamp: true,
 		TimeFormat:      time.Kitchen,
+		Formatter:       parseFormat(format),
 	})
 
+	if level != "" {
+		parsed, err := log.ParseLevel(level)
+		if err != nil {
+			logger.Warn("Unknown log level, using info", "level", level)
+		} else {
+			logger.SetLevel(parsed)
+		}
+	}
+
+	// Для машинно-читаемых форматов используем полную метку времени
+	if f := strings.ToLower(format); f == FormatJSON || f == FormatLogfmt {
+		logger.SetTimeFormat(time.RFC3339)
+	}
+
 	return logger
 }
+
+// Имена подсистем для настройки уровней логирования
+const (
+	ModuleScraper   = "scraper"
+	ModuleDB        = "db"
+	ModulePool      = "pool"
+	ModuleScheduler = "scheduler"
+	ModuleNotify    = "notify"
+)
+
+// ForModule возвращает логгер подсистемы с полем module.
+// Если для подсистемы задан уровень в levels, он переопределяет уровень базового логгера
+func ForModule(base *log.Logger, levels map[string]string, module string) *log.Logger {
+	l := base.With("module", module)
+
+	if level, ok := levels[module]; ok {
+		parsed, err := log.ParseLevel(level)
+		if err != nil {
+			base.Warn("Unknown module log level, using default", "module", module, "level", level)
+		} else {
+			l.SetLevel(parsed)
+		}
+	}
+
+	return l
+}
+
+// parseFormat возвращает форматтер по имени
+func parseFormat(format string) log.Formatter {
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		return log.JSONFormatter
+	case FormatLogfmt:
+		return log.LogfmtFormatter
+	default:
+		return log.TextFormatter
+	}
+}
+
+// Adapter приводит *log.Logger к интерфейсам логгеров пакетов work и db,
+// которые принимают сообщение строкой
+type Adapter struct {
+	*log.Logger
+}
+
+// NewAdapter оборачивает логгер в Adapter
+func NewAdapter(l *log.Logger) Adapter {
+	return Adapter{Logger: l}
+}
+
+func (a Adapter) Debug(msg string, keyvals ...interface{}) {
+	a.Logger.Helper()
+	a.Logger.Debug(msg, keyvals...)
+}
+
+func (a Adapter) Info(msg string, keyvals ...interface{}) {
+	a.Logger.Helper()
+	a.Logger.Info(msg, keyvals...)
+}
+
+func (a Adapter) Warn(msg string, keyvals ...interface{}) {
+	a.Logger.Helper()
+	a.Logger.Warn(msg, keyvals...)
+}
+
+func (a Adapter) Error(msg string, keyvals ...interface{}) {
+	a.Logger.Helper()
+	a.Logger.Error(msg, keyvals...)
+}
+
+type executionIDKey struct{}
+
+// ExecutionIDKey - ключ, под которым идентификатор выполнения задачи попадает в логи
+const ExecutionIDKey = "exec_id"
+
+// WithExecutionID сохраняет идентификатор выполнения задачи в контексте
+func WithExecutionID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, executionIDKey{}, id)
+}
+
+// ExecutionID возвращает идентификатор выполнения задачи из контекста
+func ExecutionID(ctx context.Context) string {
+	id, _ := ctx.Value(executionIDKey{}).(string)
+	return id
+}
+
+// ContextKeyvals возвращает пары ключ-значение для логов из контекста
+func ContextKeyvals(ctx context.Context) []interface{} {
+	if id := ExecutionID(ctx); id != "" {
+		return []interface{}{ExecutionIDKey, id}
+	}
+	return nil
+}
+
+// NewExecutionID генерирует новый идентификатор выполнения задачи
+func NewExecutionID() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,380 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets - границы гистограмм по умолчанию (в секундах)
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// DefaultRegistry - общий реестр метрик приложения
+var DefaultRegistry = NewRegistry()
+
+// collector - метрика, которая умеет выводить себя в текстовом формате Prometheus
+type collector interface {
+	name() string
+	write(w io.Writer) error
+}
+
+// Registry хранит метрики и отдает их в текстовом формате Prometheus
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry создает пустой реестр метрик
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// register возвращает уже зарегистрированную метрику с тем же именем или регистрирует новую
+func (r *Registry) register(c collector) collector {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.collectors[c.name()]; ok {
+		return existing
+	}
+	r.collectors[c.name()] = c
+	return c
+}
+
+// WriteText выводит все метрики реестра в текстовом формате Prometheus
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	collectors := make([]collector, 0, len(names))
+	for _, name := range names {
+		collectors = append(collectors, r.collectors[name])
+	}
+	r.mu.Unlock()
+
+	for _, c := range collectors {
+		if err := c.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler возвращает HTTP-обработчик для эндпоинта /metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = r.WriteText(w)
+	})
+}
+
+// desc - общая часть описания метрики
+type desc struct {
+	fqName string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d desc) name() string { return d.fqName }
+
+func (d desc) header(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.fqName, d.help, d.fqName, d.kind)
+	return err
+}
+
+// labelKey склеивает значения меток в ключ серии
+func (d desc) labelKey(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metric %s: expected %d label values, got %d", d.fqName, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// formatLabels форматирует метки серии, extra добавляется в конец (например, le для гистограмм)
+func (d desc) formatLabels(key string, extra ...string) string {
+	var values []string
+	if len(d.labels) > 0 {
+		values = strings.Split(key, "\xff")
+	}
+
+	pairs := make([]string, 0, len(d.labels)+1)
+	for i, label := range d.labels {
+		pairs = append(pairs, label+`="`+escapeLabel(values[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escapeLabel(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Counter - монотонно возрастающий счетчик
+type Counter struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// Inc увеличивает счетчик на единицу
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add увеличивает счетчик на v (отрицательные значения игнорируются)
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.value += v
+	c.mu.Unlock()
+}
+
+// Value возвращает текущее значение счетчика
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value
+}
+
+// CounterVec - набор счетчиков с метками
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*Counter
+}
+
+// NewCounterVec регистрирует набор счетчиков в реестре
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		desc:   desc{fqName: name, help: help, kind: "counter", labels: labels},
+		series: make(map[string]*Counter),
+	}
+	return r.register(c).(*CounterVec)
+}
+
+// With возвращает счетчик для заданных значений меток
+func (v *CounterVec) With(values ...string) *Counter {
+	key := v.labelKey(values)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	c, ok := v.series[key]
+	if !ok {
+		c = &Counter{}
+		v.series[key] = c
+	}
+	return c
+}
+
+func (v *CounterVec) write(w io.Writer) error {
+	if err := v.header(w); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, key := range sortedKeys(v.series) {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", v.fqName, v.formatLabels(key), formatFloat(v.series[key].Value())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Gauge - значение, которое может расти и уменьшаться
+type Gauge struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// Set устанавливает значение
+func (g *Gauge) Set(v float64) {
+	g.mu.Lock()
+	g.value = v
+	g.mu.Unlock()
+}
+
+// Add изменяет значение на v
+func (g *Gauge) Add(v float64) {
+	g.mu.Lock()
+	g.value += v
+	g.mu.Unlock()
+}
+
+// Inc увеличивает значение на единицу
+func (g *Gauge) Inc() { g.Add(1) }
+
+// Dec уменьшает значение на единицу
+func (g *Gauge) Dec() { g.Add(-1) }
+
+// Value возвращает текущее значение
+func (g *Gauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.value
+}
+
+// GaugeVec - набор измерителей с метками
+type GaugeVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*Gauge
+}
+
+// NewGaugeVec регистрирует набор измерителей в реестре
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{
+		desc:   desc{fqName: name, help: help, kind: "gauge", labels: labels},
+		series: make(map[string]*Gauge),
+	}
+	return r.register(g).(*GaugeVec)
+}
+
+// With возвращает измеритель для заданных значений меток
+func (v *GaugeVec) With(values ...string) *Gauge {
+	key := v.labelKey(values)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	g, ok := v.series[key]
+	if !ok {
+		g = &Gauge{}
+		v.series[key] = g
+	}
+	return g
+}
+
+func (v *GaugeVec) write(w io.Writer) error {
+	if err := v.header(w); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, key := range sortedKeys(v.series) {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", v.fqName, v.formatLabels(key), formatFloat(v.series[key].Value())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Histogram - распределение наблюдаемых значений по корзинам
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// Observe добавляет наблюдение
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// HistogramVec - набор гистограмм с метками
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*Histogram
+}
+
+// NewHistogramVec регистрирует набор гистограмм в реестре. nil buckets означает DefaultBuckets
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	h := &HistogramVec{
+		desc:    desc{fqName: name, help: help, kind: "histogram", labels: labels},
+		buckets: buckets,
+		series:  make(map[string]*Histogram),
+	}
+	return r.register(h).(*HistogramVec)
+}
+
+// With возвращает гистограмму для заданных значений меток
+func (v *HistogramVec) With(values ...string) *Histogram {
+	key := v.labelKey(values)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	h, ok := v.series[key]
+	if !ok {
+		h = &Histogram{buckets: v.buckets, counts: make([]uint64, len(v.buckets))}
+		v.series[key] = h
+	}
+	return h
+}
+
+func (v *HistogramVec) write(w io.Writer) error {
+	if err := v.header(w); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, key := range sortedKeys(v.series) {
+		h := v.series[key]
+		h.mu.Lock()
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", v.fqName, v.formatLabels(key, "le", formatFloat(bound)), h.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", v.fqName, v.formatLabels(key, "le", "+Inf"), h.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", v.fqName, v.formatLabels(key), formatFloat(h.sum))
+		_, err := fmt.Fprintf(w, "%s_count%s %d\n", v.fqName, v.formatLabels(key), h.count)
+		h.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
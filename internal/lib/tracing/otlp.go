@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+)
+
+// NewOTLPExporter создает экспортер OTLP/HTTP с повторами при недоступности коллектора.
+// endpoint - базовый адрес коллектора (например, http://localhost:4318), путь /v1/traces
+// добавляется автоматически, схема http отключает TLS
+func NewOTLPExporter(ctx context.Context, endpoint string, headers map[string]string) (Exporter, error) {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+
+	return otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpointURL(endpoint),
+		otlptracehttp.WithHeaders(headers),
+		otlptracehttp.WithTimeout(10*time.Second),
+	)
+}
+
+// ParseHeaders разбирает заголовки в формате OTEL_EXPORTER_OTLP_HEADERS (key1=value1,key2=value2)
+func ParseHeaders(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return headers
+}
@@ -0,0 +1,225 @@
+// Package tracing - тонкая обертка над OpenTelemetry: спаны с атрибутами, пакетный экспорт,
+// выборка трасс и распространение контекста в формате W3C Trace Context
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const scopeName = "github.com/rx3lixir/kultscraper"
+
+// Exporter отправляет завершенные спаны во внешнюю систему
+type Exporter = sdktrace.SpanExporter
+
+// Attr - атрибут спана
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// String создает строковый атрибут
+func String(key, value string) Attr { return Attr{Key: key, Value: value} }
+
+// Int создает целочисленный атрибут
+func Int(key string, value int) Attr { return Attr{Key: key, Value: value} }
+
+// keyValue переводит атрибут в атрибут OpenTelemetry, длительности - в миллисекунды
+func (a Attr) keyValue() attribute.KeyValue {
+	switch val := a.Value.(type) {
+	case string:
+		return attribute.String(a.Key, val)
+	case bool:
+		return attribute.Bool(a.Key, val)
+	case int:
+		return attribute.Int(a.Key, val)
+	case int64:
+		return attribute.Int64(a.Key, val)
+	case float64:
+		return attribute.Float64(a.Key, val)
+	case time.Duration:
+		return attribute.Int64(a.Key, val.Milliseconds())
+	default:
+		return attribute.String(a.Key, fmt.Sprint(val))
+	}
+}
+
+func keyValues(attrs []Attr) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		out = append(out, a.keyValue())
+	}
+	return out
+}
+
+// Span - активный спан трассировки. Методы nil-спана ничего не делают
+type Span struct {
+	span trace.Span
+}
+
+// TraceID возвращает идентификатор трассы спана или пустую строку, если трасса не записывается
+func (s *Span) TraceID() string {
+	if s == nil || !s.span.SpanContext().IsSampled() {
+		return ""
+	}
+	return s.span.SpanContext().TraceID().String()
+}
+
+// SpanID возвращает идентификатор спана или пустую строку, если трасса не записывается
+func (s *Span) SpanID() string {
+	if s == nil || !s.span.SpanContext().IsSampled() {
+		return ""
+	}
+	return s.span.SpanContext().SpanID().String()
+}
+
+// SetAttrs добавляет атрибуты к спану
+func (s *Span) SetAttrs(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.span.SetAttributes(keyValues(attrs)...)
+}
+
+// RecordError добавляет ошибку событием спана и помечает спан как завершившийся ошибкой
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End завершает спан и передает его пакетному экспорту. Повторный вызов ничего не делает
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.span.End()
+}
+
+// Options - настройки трассировщика
+type Options struct {
+	ServiceName string
+	// SampleRatio - доля записываемых корневых трасс от 0 до 1, дочерние спаны следуют решению родителя
+	SampleRatio float64
+	// BatchSize - наибольший пакет спанов одного экспорта, 0 - 512
+	BatchSize int
+	// BatchTimeout - наибольшая задержка отправки неполного пакета, 0 - 5 секунд
+	BatchTimeout time.Duration
+}
+
+// Tracer создает спаны и пакетно отправляет их экспортеру
+type Tracer struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// NewTracer создает трассировщик с фоновой пакетной отправкой спанов в exporter
+func NewTracer(exporter Exporter, opts Options) *Tracer {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = sdktrace.DefaultMaxExportBatchSize
+	}
+	batchTimeout := opts.BatchTimeout
+	if batchTimeout <= 0 {
+		batchTimeout = sdktrace.DefaultScheduleDelay * time.Millisecond
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter,
+			sdktrace.WithMaxExportBatchSize(batchSize),
+			sdktrace.WithBatchTimeout(batchTimeout),
+		),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(opts.ServiceName))),
+	)
+	return &Tracer{provider: provider, tracer: provider.Tracer(scopeName)}
+}
+
+// Start начинает новый спан. Если в контексте уже есть спан, новый становится дочерним
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(keyValues(attrs)...))
+	return ctx, &Span{span: span}
+}
+
+// Shutdown отправляет оставшиеся спаны и останавливает экспортер
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.provider.Shutdown(ctx)
+}
+
+// ContextWithParent возвращает контекст, в котором новые спаны станут дочерними
+// для спана с указанными идентификаторами (например, сохраненными в метаданных результата).
+// Идентификаторы сохраняются только у записываемых трасс, поэтому родитель считается записанным
+func ContextWithParent(ctx context.Context, traceID, spanID string) context.Context {
+	tid, err := trace.TraceIDFromHex(traceID)
+	if err != nil {
+		return ctx
+	}
+	sid, err := trace.SpanIDFromHex(spanID)
+	if err != nil {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    tid,
+		SpanID:     sid,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+}
+
+// propagator передает контекст трассы в заголовках traceparent и tracestate (W3C Trace Context)
+var propagator = propagation.TraceContext{}
+
+// Extract возвращает контекст, в котором новые спаны станут дочерними для трассы
+// из заголовков входящего запроса. Без traceparent возвращает ctx
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Глобальный трассировщик, по умолчанию спаны не создаются
+var (
+	globalMu     sync.RWMutex
+	globalTracer *Tracer
+)
+
+// SetTracer устанавливает глобальный трассировщик, в том числе для библиотек,
+// использующих OpenTelemetry напрямую
+func SetTracer(t *Tracer) {
+	globalMu.Lock()
+	globalTracer = t
+	globalMu.Unlock()
+
+	if t != nil {
+		otel.SetTracerProvider(t.provider)
+	}
+	otel.SetTextMapPropagator(propagator)
+}
+
+// Start начинает спан с помощью глобального трассировщика.
+// Без настроенного трассировщика возвращает nil-спан, методы которого ничего не делают
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	globalMu.RLock()
+	t := globalTracer
+	globalMu.RUnlock()
+
+	return t.Start(ctx, name, attrs...)
+}
@@ -0,0 +1,65 @@
+package work
+
+import (
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/lib/metrics"
+)
+
+// defaultSchedule - метка расписания для задач, запущенных не по расписанию
+const defaultSchedule = "adhoc"
+
+// Scheduled - необязательный интерфейс задачи, запущенной по расписанию.
+// Используется для меток метрик и расчета задержки запуска
+type Scheduled interface {
+	TaskSchedule() string
+	PlannedStart() time.Time
+}
+
+// Метрики пула регистрируются в общем реестре при загрузке пакета
+var (
+	queueDepth = metrics.DefaultRegistry.NewGaugeVec(
+		"kultscraper_pool_queue_depth",
+		"Number of tasks waiting in the pool queue.",
+	)
+	busyWorkers = metrics.DefaultRegistry.NewGaugeVec(
+		"kultscraper_pool_busy_workers",
+		"Number of workers currently executing a task.",
+	)
+	schedulingLag = metrics.DefaultRegistry.NewHistogramVec(
+		"kultscraper_pool_scheduling_lag_seconds",
+		"Delay between planned and actual task start.",
+		[]float64{0.1, 0.5, 1, 5, 15, 30, 60, 300, 900},
+		"schedule",
+	)
+	tasksTotal = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_pool_tasks_total",
+		"Number of executed tasks by schedule and status.",
+		"schedule", "status",
+	)
+	taskDuration = metrics.DefaultRegistry.NewHistogramVec(
+		"kultscraper_pool_task_duration_seconds",
+		"Task execution duration.",
+		nil,
+		"schedule",
+	)
+)
+
+// scheduleOf возвращает метку расписания задачи и, если observeLag, наблюдает задержку ее запуска
+func scheduleOf(task any, started time.Time, observeLag bool) string {
+	s, ok := task.(Scheduled)
+	if !ok {
+		return defaultSchedule
+	}
+
+	schedule := s.TaskSchedule()
+	if schedule == "" {
+		schedule = defaultSchedule
+	}
+
+	if planned := s.PlannedStart(); observeLag && !planned.IsZero() {
+		schedulingLag.With(schedule).Observe(max(started.Sub(planned), 0).Seconds())
+	}
+
+	return schedule
+}
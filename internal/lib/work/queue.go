@@ -0,0 +1,161 @@
+package work
+
+import (
+	"container/heap"
+	"context"
+	"strings"
+	"sync"
+)
+
+// Priority - приоритет задачи в очереди пула, задачи с большим приоритетом выполняются раньше
+type Priority int
+
+const (
+	PriorityLow    Priority = -1 // Периодические перепроверки
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1 // Срочные задачи, например переходы на страницы деталей
+)
+
+// Prioritized - необязательный интерфейс задачи с приоритетом, используется в AddTask
+type Prioritized interface {
+	Priority() Priority
+}
+
+// ParsePriority разбирает приоритет "low", "normal" или "high", пустое значение - PriorityNormal
+func ParsePriority(s string) (Priority, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "normal":
+		return PriorityNormal, true
+	case "low":
+		return PriorityLow, true
+	case "high":
+		return PriorityHigh, true
+	}
+	return PriorityNormal, false
+}
+
+func priorityOf(task any) Priority {
+	if p, ok := task.(Prioritized); ok {
+		return p.Priority()
+	}
+	return PriorityNormal
+}
+
+// taskQueue - ограниченная очередь с приоритетами, внутри приоритета задачи идут в порядке добавления
+type taskQueue[T any] struct {
+	mu       sync.Mutex
+	items    taskHeap[T]
+	capacity int
+	seq      uint64
+	closed   bool
+	avail    chan struct{} // Сигнал "в очереди есть задачи"
+	space    chan struct{} // Сигнал "в очереди есть место"
+}
+
+func newTaskQueue[T any](capacity int) *taskQueue[T] {
+	return &taskQueue[T]{
+		capacity: capacity,
+		avail:    make(chan struct{}, 1),
+		space:    make(chan struct{}, 1),
+	}
+}
+
+// push добавляет задачу, ожидая места в очереди
+func (q *taskQueue[T]) push(ctx context.Context, t queuedTask[T]) error {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return context.Canceled
+		}
+		if len(q.items) < q.capacity {
+			q.seq++
+			t.seq = q.seq
+			heap.Push(&q.items, t)
+			notify(q.avail)
+			if len(q.items) < q.capacity {
+				notify(q.space)
+			}
+			q.mu.Unlock()
+			return nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.space:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// pop извлекает задачу с наибольшим приоритетом, ожидая ее появления.
+// Возвращает false, если контекст отменен или очередь закрыта и пуста
+func (q *taskQueue[T]) pop(ctx context.Context) (queuedTask[T], bool) {
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			t := heap.Pop(&q.items).(queuedTask[T])
+			notify(q.space)
+			if len(q.items) > 0 {
+				notify(q.avail)
+			}
+			q.mu.Unlock()
+			return t, true
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return queuedTask[T]{}, false
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.avail:
+		case <-ctx.Done():
+			return queuedTask[T]{}, false
+		}
+	}
+}
+
+// len возвращает число задач в очереди
+func (q *taskQueue[T]) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// close запрещает добавление задач и будит ожидающих
+func (q *taskQueue[T]) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	notify(q.avail)
+	notify(q.space)
+}
+
+// notify отправляет сигнал, не блокируясь, если предыдущий еще не получен
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// taskHeap реализует heap.Interface: больший приоритет, затем меньший порядковый номер
+type taskHeap[T any] []queuedTask[T]
+
+func (h taskHeap[T]) Len() int { return len(h) }
+func (h taskHeap[T]) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h taskHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *taskHeap[T]) Push(x any)   { *h = append(*h, x.(queuedTask[T])) }
+func (h *taskHeap[T]) Pop() any {
+	old := *h
+	t := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return t
+}
@@ -0,0 +1,62 @@
+package work
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy - политика повторов задачи с экспоненциальной задержкой
+type RetryPolicy struct {
+	MaxAttempts    int              // Всего попыток, включая первую; 0 и 1 отключают повторы
+	InitialBackoff time.Duration    // Задержка перед второй попыткой
+	MaxBackoff     time.Duration    // Предел задержки, 0 - без предела
+	Multiplier     float64          // Множитель задержки, по умолчанию 2
+	Jitter         float64          // Случайное отклонение задержки в долях, 0.2 - ±20%
+	Retryable      func(error) bool // Какие ошибки повторять, nil - все, кроме отмены
+}
+
+// Retrier - необязательный интерфейс задачи с политикой повторов
+type Retrier interface {
+	RetryPolicy() RetryPolicy
+}
+
+// Attempter - необязательный интерфейс задачи, которой сообщается номер попытки перед выполнением
+type Attempter interface {
+	SetAttempt(attempt int)
+}
+
+// ShouldRetry сообщает, нужно ли повторить задачу после неудачной попытки attempt (с 1)
+func (p RetryPolicy) ShouldRetry(attempt int, err error) bool {
+	if err == nil || attempt >= p.MaxAttempts || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// Backoff возвращает задержку перед попыткой, следующей за attempt
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	d := float64(p.InitialBackoff) * math.Pow(multiplier, float64(max(attempt-1, 0)))
+	if p.MaxBackoff > 0 {
+		d = math.Min(d, float64(p.MaxBackoff))
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(max(d, 0))
+}
+
+// retryPolicyOf возвращает политику повторов задачи или нулевую политику без повторов
+func retryPolicyOf(task any) RetryPolicy {
+	if r, ok := task.(Retrier); ok {
+		return r.RetryPolicy()
+	}
+	return RetryPolicy{}
+}
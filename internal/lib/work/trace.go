@@ -0,0 +1,46 @@
+package work
+
+import (
+	"context"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+)
+
+// Traced - необязательный интерфейс задачи с контекстом трассировки. Пул начинает в нем
+// спан pool.task на каждую попытку и передает задаче контекст этого спана перед выполнением
+type Traced interface {
+	TraceContext() context.Context
+	SetExecContext(ctx context.Context)
+}
+
+// startTaskSpan начинает спан попытки задачи. Для задач без Traced спан не создается
+func startTaskSpan(task any, workerID, attempt int, priority Priority, queueWait time.Duration) *tracing.Span {
+	t, ok := task.(Traced)
+	if !ok {
+		return nil
+	}
+
+	ctx, span := tracing.Start(t.TraceContext(), "pool.task",
+		tracing.Int("pool.worker", workerID),
+		tracing.Int("pool.attempt", attempt),
+		tracing.Int("pool.priority", int(priority)),
+		tracing.Attr{Key: "pool.queue_wait_ms", Value: queueWait.Milliseconds()},
+	)
+	t.SetExecContext(ctx)
+	return span
+}
+
+// startRetrySpan начинает спан ожидания перед повтором задачи
+func startRetrySpan(task any, attempt int, delay time.Duration) *tracing.Span {
+	t, ok := task.(Traced)
+	if !ok {
+		return nil
+	}
+
+	_, span := tracing.Start(t.TraceContext(), "pool.retry_wait",
+		tracing.Int("pool.attempt", attempt),
+		tracing.Attr{Key: "pool.backoff_ms", Value: delay.Milliseconds()},
+	)
+	return span
+}
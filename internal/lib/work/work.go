@@ -7,16 +7,37 @@ import (
 	"time"
 )
 
-type Executor interface {
-	Execute() (interface{}, error)
+// Task - задача пула, результат которой имеет тип T
+type Task[T any] interface {
+	Execute() (T, error)
 	OnError(error)
 }
 
-type Pool struct {
+// Executor - задача нетипизированного пула UntypedPool
+type Executor = Task[interface{}]
+
+// ExecutionIDer - необязательный интерфейс задачи, идентификатор которой
+// добавляется ко всем логам пула по этой задаче
+type ExecutionIDer interface {
+	ExecutionID() string
+}
+
+// queuedTask - задача в очереди пула с номером попытки и приоритетом
+type queuedTask[T any] struct {
+	task     Task[T]
+	attempt  int
+	priority Priority
+	seq      uint64    // Порядок добавления внутри приоритета
+	enqueued time.Time // Время постановки в очередь
+}
+
+// Pool - пул воркеров, результаты задач которого имеют тип T
+type Pool[T any] struct {
 	numWorkers int
-	tasks      chan Executor
-	results    chan interface{}
+	tasks      *taskQueue[T]
+	results    chan T
 	wg         sync.WaitGroup
+	retries    sync.WaitGroup // Задачи, ожидающие повтора
 	ctx        context.Context
 	cancel     context.CancelFunc
 	started    bool
@@ -38,23 +59,36 @@ func (n NoopLogger) Info(msg string, keyvals ...interface{})  {}
 func (n NoopLogger) Error(msg string, keyvals ...interface{}) {}
 func (n NoopLogger) Debug(msg string, keyvals ...interface{}) {}
 
-// NewPool создает новый пул воркеров с заданными параметрами
-func NewPool(numWorkers int, taskChannelSize int) (*Pool, error) {
-	return NewPoolWithLogger(numWorkers, taskChannelSize, NoopLogger{})
+// UntypedPool - пул с результатами interface{}, сохранен для обратной совместимости
+type UntypedPool = Pool[interface{}]
+
+// NewPool создает новый нетипизированный пул воркеров с заданными параметрами
+func NewPool(numWorkers int, taskChannelSize int) (*UntypedPool, error) {
+	return NewTypedPool[interface{}](numWorkers, taskChannelSize)
 }
 
-// NewPoolWithLogger создает новый пул воркеров с заданными параметрами и логгером
-func NewPoolWithLogger(numWorkers int, taskChannelSize int, logger Logger) (*Pool, error) {
+// NewPoolWithLogger создает новый нетипизированный пул воркеров с заданными параметрами и логгером
+func NewPoolWithLogger(numWorkers int, taskChannelSize int, logger Logger) (*UntypedPool, error) {
+	return NewTypedPoolWithLogger[interface{}](numWorkers, taskChannelSize, logger)
+}
+
+// NewTypedPool создает пул воркеров с результатами типа T
+func NewTypedPool[T any](numWorkers int, taskChannelSize int) (*Pool[T], error) {
+	return NewTypedPoolWithLogger[T](numWorkers, taskChannelSize, NoopLogger{})
+}
+
+// NewTypedPoolWithLogger создает пул воркеров с результатами типа T и логгером
+func NewTypedPoolWithLogger[T any](numWorkers int, taskChannelSize int, logger Logger) (*Pool[T], error) {
 	if numWorkers <= 0 || taskChannelSize <= 0 {
 		return nil, errors.New("invalid parameters: number of workers and tasks must be more than zero")
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Pool{
+	return &Pool[T]{
 		numWorkers: numWorkers,
-		tasks:      make(chan Executor, taskChannelSize),
-		results:    make(chan interface{}, taskChannelSize), // Буферизированный канал для результатов
+		tasks:      newTaskQueue[T](taskChannelSize),
+		results:    make(chan T, taskChannelSize), // Буферизированный канал для результатов
 		ctx:        ctx,
 		cancel:     cancel,
 		logger:     logger,
@@ -62,12 +96,12 @@ func NewPoolWithLogger(numWorkers int, taskChannelSize int, logger Logger) (*Poo
 }
 
 // Results возвращает канал результатов
-func (p *Pool) Results() <-chan interface{} {
+func (p *Pool[T]) Results() <-chan T {
 	return p.results
 }
 
 // Start запускает пул работников
-func (p *Pool) Start(parentCtx context.Context) error {
+func (p *Pool[T]) Start(parentCtx context.Context) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -90,7 +124,7 @@ func (p *Pool) Start(parentCtx context.Context) error {
 }
 
 // Stop останавливает пул работников и ожидает завершения всех задач
-func (p *Pool) Stop() {
+func (p *Pool[T]) Stop() {
 	p.mu.Lock()
 	if !p.started {
 		p.mu.Unlock()
@@ -102,18 +136,26 @@ func (p *Pool) Stop() {
 	// Отменяем контекст
 	p.cancel()
 
-	// Закрываем канал задач, чтобы работники завершились
-	close(p.tasks)
-
-	// Ожидаем завершения всех работников
+	// Ожидаем завершения всех работников, затем задач, ожидающих повтора:
+	// новые повторы планируют только работники
 	p.wg.Wait()
+	p.retries.Wait()
+
+	// Закрываем очередь задач, в нее больше никто не пишет
+	p.tasks.close()
 
 	// Закрываем канал результатов
 	close(p.results)
 }
 
-// AddTask добавляет задачу в пул
-func (p *Pool) AddTask(t Executor) error {
+// AddTask добавляет задачу в пул с приоритетом задачи (Prioritized) или PriorityNormal
+func (p *Pool[T]) AddTask(t Task[T]) error {
+	return p.AddTaskWithPriority(t, priorityOf(t))
+}
+
+// AddTaskWithPriority добавляет задачу в пул с заданным приоритетом.
+// Задачи с большим приоритетом выполняются раньше, с равным - в порядке добавления
+func (p *Pool[T]) AddTaskWithPriority(t Task[T], priority Priority) error {
 	p.mu.Lock()
 	if !p.started {
 		p.mu.Unlock()
@@ -121,16 +163,41 @@ func (p *Pool) AddTask(t Executor) error {
 	}
 	p.mu.Unlock()
 
+	if err := p.tasks.push(p.ctx, queuedTask[T]{task: t, attempt: 1, priority: priority, enqueued: time.Now()}); err != nil {
+		return err
+	}
+	queueDepth.With().Set(float64(p.tasks.len()))
+	return nil
+}
+
+// retry возвращает задачу в очередь после задержки. Если пул останавливается
+// раньше, задача завершается с последней ошибкой
+func (p *Pool[T]) retry(q queuedTask[T], delay time.Duration, lastErr error) {
+	defer p.retries.Done()
+
+	span := startRetrySpan(q.task, q.attempt+1, delay)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
 	select {
-	case p.tasks <- t:
-		return nil
+	case <-timer.C:
+		span.End()
 	case <-p.ctx.Done():
-		return p.ctx.Err()
+		span.RecordError(p.ctx.Err())
+		span.End()
+		q.task.OnError(lastErr)
+		return
+	}
+
+	if err := p.tasks.push(p.ctx, queuedTask[T]{task: q.task, attempt: q.attempt + 1, priority: q.priority, enqueued: time.Now()}); err != nil {
+		q.task.OnError(lastErr)
+		return
 	}
+	queueDepth.With().Set(float64(p.tasks.len()))
 }
 
 // worker запускает работника для обработки задач
-func (p *Pool) worker(id int) {
+func (p *Pool[T]) worker(id int) {
 	defer p.wg.Done()
 
 	p.logger.Info("Worker started", "worker_id", id)
@@ -138,50 +205,84 @@ func (p *Pool) worker(id int) {
 	tasksProcessed := 0
 
 	for {
-		select {
-		case <-p.ctx.Done():
+		q, ok := p.tasks.pop(p.ctx)
+		if !ok {
 			p.logger.Info("Worker stopping due to context cancellation",
 				"worker_id", id,
 				"tasks_processed", tasksProcessed,
 				"uptime", time.Since(startTime))
 			return
-		case task, ok := <-p.tasks:
-			if !ok {
-				p.logger.Info("Worker stopping due to closed tasks channel",
-					"worker_id", id,
-					"tasks_processed", tasksProcessed,
-					"uptime", time.Since(startTime))
-				return
-			}
+		}
+		task := q.task
 
-			taskStartTime := time.Now()
-			p.logger.Debug("Worker processing task", "worker_id", id)
+		taskStartTime := time.Now()
+		p.logger.Debug("Worker processing task", taskKeyvals(task, "worker_id", id, "attempt", q.attempt)...)
 
-			res, err := task.Execute()
+		queueDepth.With().Set(float64(p.tasks.len()))
+		schedule := scheduleOf(task, taskStartTime, q.attempt == 1)
+		busyWorkers.With().Inc()
 
-			if err != nil {
-				task.OnError(err)
-				p.logger.Error("Worker encountered error processing task",
+		if a, ok := task.(Attempter); ok {
+			a.SetAttempt(q.attempt)
+		}
+		span := startTaskSpan(task, id, q.attempt, q.priority, taskStartTime.Sub(q.enqueued))
+		res, err := task.Execute()
+		span.RecordError(err)
+		span.End()
+
+		busyWorkers.With().Dec()
+		taskDuration.With(schedule).Observe(time.Since(taskStartTime).Seconds())
+
+		if err != nil {
+			policy := retryPolicyOf(task)
+			if policy.ShouldRetry(q.attempt, err) {
+				delay := policy.Backoff(q.attempt)
+				tasksTotal.With(schedule, "retry").Inc()
+				p.logger.Info("Retrying task", taskKeyvals(task,
 					"worker_id", id,
-					"error", err,
-					"task_duration", time.Since(taskStartTime))
+					"attempt", q.attempt,
+					"max_attempts", policy.MaxAttempts,
+					"backoff", delay,
+					"error", err)...)
+
+				p.retries.Add(1)
+				go p.retry(q, delay, err)
 				continue
 			}
 
-			// Отправляем результат, учитывая возможность отмены контекста
-			select {
-			case p.results <- res:
-				// Успешно отправили результат
-				tasksProcessed++
-				p.logger.Debug("Worker completed task successfully",
-					"worker_id", id,
-					"task_duration", time.Since(taskStartTime))
-			case <-p.ctx.Done():
-				// Контекст был отменен
-				p.logger.Info("Worker stopping while sending results due to context cancellation",
-					"worker_id", id)
-				return
-			}
+			tasksTotal.With(schedule, "error").Inc()
+			task.OnError(err)
+			p.logger.Error("Worker encountered error processing task", taskKeyvals(task,
+				"worker_id", id,
+				"attempt", q.attempt,
+				"error", err,
+				"task_duration", time.Since(taskStartTime))...)
+			continue
 		}
+
+		tasksTotal.With(schedule, "success").Inc()
+
+		// Отправляем результат, учитывая возможность отмены контекста
+		select {
+		case p.results <- res:
+			// Успешно отправили результат
+			tasksProcessed++
+			p.logger.Debug("Worker completed task successfully", taskKeyvals(task,
+				"worker_id", id,
+				"task_duration", time.Since(taskStartTime))...)
+		case <-p.ctx.Done():
+			// Контекст был отменен
+			p.logger.Info("Worker stopping while sending results due to context cancellation",
+				"worker_id", id)
+			return
+		}
+	}
+}
+
+// taskKeyvals дополняет пары ключ-значение идентификатором выполнения задачи, если задача его предоставляет
+func taskKeyvals(task any, keyvals ...interface{}) []interface{} {
+	if t, ok := task.(ExecutionIDer); ok && t.ExecutionID() != "" {
+		return append(keyvals, "exec_id", t.ExecutionID())
 	}
+	return keyvals
 }
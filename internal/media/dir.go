@@ -0,0 +1,54 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DirStore хранит файлы в локальном каталоге
+type DirStore struct {
+	Dir     string
+	BaseURL string // Адрес, по которому раздается каталог, пусто - ссылкой служит путь к файлу
+}
+
+// NewDirStore создает каталог хранилища, если его нет
+func NewDirStore(dir, baseURL string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirStore{Dir: dir, BaseURL: baseURL}, nil
+}
+
+// Put записывает файл, существующий файл с тем же именем не перезаписывается
+func (s *DirStore) Put(ctx context.Context, name, contentType string, data []byte) (string, error) {
+	target := filepath.Join(s.Dir, name)
+	ref := objectURL(s.BaseURL, name, target)
+
+	if _, err := os.Stat(target); err == nil {
+		return ref, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	// Запись через временный файл, чтобы одновременные задачи не видели недописанный файл
+	tmp, err := os.CreateTemp(s.Dir, name+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", err
+	}
+	return ref, nil
+}
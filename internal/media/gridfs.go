@@ -0,0 +1,82 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFSStore хранит файлы в GridFS базы MongoDB
+type GridFSStore struct {
+	client  *mongo.Client
+	bucket  *gridfs.Bucket
+	name    string
+	baseURL string
+
+	mu sync.Mutex // Срок записи задается на весь бакет
+}
+
+// NewGridFSStore подключается к MongoDB и открывает бакет GridFS
+func NewGridFSStore(ctx context.Context, cfg config.MongoDBConfig, bucketName, baseURL string) (*GridFSStore, error) {
+	connCfg := db.NewDefaultConfig(cfg.URI, cfg.Database, "")
+	connCfg.Username = cfg.Username
+	connCfg.Password = cfg.Password
+	connCfg.Timeout = cfg.ConnectTimeout
+
+	client, err := db.ConnectMongo(ctx, connCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket, err := gridfs.NewBucket(client.Database(cfg.Database), options.GridFSBucket().SetName(bucketName))
+	if err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+	return &GridFSStore{client: client, bucket: bucket, name: bucketName, baseURL: baseURL}, nil
+}
+
+// Put загружает файл, если файла с таким именем еще нет.
+// Без IMAGE_BASE_URL ссылкой служит gridfs://<бакет>/<имя>
+func (s *GridFSStore) Put(ctx context.Context, name, contentType string, data []byte) (string, error) {
+	ref := objectURL(s.baseURL, name, "gridfs://"+s.name+"/"+name)
+
+	count, err := s.client.Database(s.bucket.GetFilesCollection().Database().Name()).
+		Collection(s.bucket.GetFilesCollection().Name()).
+		CountDocuments(ctx, bson.M{"filename": name}, options.Count().SetLimit(1))
+	if err != nil {
+		return "", err
+	}
+	if count > 0 {
+		return ref, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(db.DefaultTimeout)
+	}
+	if err := s.bucket.SetWriteDeadline(deadline); err != nil {
+		return "", err
+	}
+	opts := options.GridFSUpload().SetMetadata(bson.M{"content_type": contentType})
+	if _, err := s.bucket.UploadFromStream(name, bytes.NewReader(data), opts); err != nil {
+		return "", err
+	}
+	return ref, nil
+}
+
+// Close закрывает соединение с MongoDB
+func (s *GridFSStore) Close() error {
+	return s.client.Disconnect(context.Background())
+}
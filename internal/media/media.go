@@ -0,0 +1,155 @@
+// Package media скачивает изображения со страниц источников и сохраняет копии,
+// чтобы результаты не зависели от ссылок на сайты площадок
+package media
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+)
+
+var (
+	ErrTooLarge = errors.New("file exceeds size limit")
+	ErrNotImage = errors.New("response is not an image")
+)
+
+// Store сохраняет файл под именем name и возвращает ссылку на сохраненный объект.
+// Имена строятся по содержимому, поэтому повторное сохранение того же имени можно пропустить
+type Store interface {
+	Put(ctx context.Context, name, contentType string, data []byte) (string, error)
+}
+
+// NewStore создает хранилище по настройкам IMAGE_STORE. Для пустого Backend возвращает nil
+func NewStore(ctx context.Context, cfg config.ImageStoreConfig, mongoCfg config.MongoDBConfig) (Store, error) {
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case "dir":
+		return NewDirStore(cfg.Dir, cfg.BaseURL)
+	case "gridfs":
+		return NewGridFSStore(ctx, mongoCfg, cfg.Bucket, cfg.BaseURL)
+	case "s3":
+		return NewS3Store(cfg.S3, cfg.Bucket, cfg.BaseURL)
+	default:
+		return nil, fmt.Errorf("unknown image store %q", cfg.Backend)
+	}
+}
+
+// Расширения файлов по типу содержимого
+var imageExtensions = map[string]string{
+	"image/jpeg":    ".jpg",
+	"image/png":     ".png",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/avif":    ".avif",
+	"image/svg+xml": ".svg",
+}
+
+// Downloader скачивает изображения и сохраняет их в Store
+type Downloader struct {
+	Store     Store
+	Client    *http.Client
+	MaxBytes  int64         // Предел размера файла, 0 - без ограничения
+	Timeout   time.Duration // Таймаут скачивания одного файла, 0 - без таймаута
+	UserAgent string
+}
+
+// NewDownloader создает загрузчик изображений в store
+func NewDownloader(store Store, maxBytes int64, timeout time.Duration) *Downloader {
+	return &Downloader{Store: store, Client: http.DefaultClient, MaxBytes: maxBytes, Timeout: timeout}
+}
+
+// Download скачивает изображение и возвращает ссылку на сохраненную копию.
+// Имя файла - хеш содержимого, одинаковые постеры разных задач хранятся один раз
+func (d *Downloader) Download(ctx context.Context, rawURL string) (ref string, err error) {
+	defer func() { downloads.With(status(err)).Inc() }()
+
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("invalid image URL %q", rawURL)
+	}
+
+	if d.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if d.UserAgent != "" {
+		req.Header.Set("User-Agent", d.UserAgent)
+	}
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download %s: HTTP status %d", u, resp.StatusCode)
+	}
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(contentType, "image/") {
+		return "", fmt.Errorf("download %s: %w (%s)", u, ErrNotImage, contentType)
+	}
+
+	body := io.Reader(resp.Body)
+	if d.MaxBytes > 0 {
+		body = io.LimitReader(resp.Body, d.MaxBytes+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	if d.MaxBytes > 0 && int64(len(data)) > d.MaxBytes {
+		return "", fmt.Errorf("download %s: %w (%d bytes)", u, ErrTooLarge, d.MaxBytes)
+	}
+
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		ext = path.Ext(u.Path)
+	}
+	sum := sha256.Sum256(data)
+	return d.Store.Put(ctx, hex.EncodeToString(sum[:16])+ext, contentType, data)
+}
+
+// status возвращает исход скачивания для метрики
+func status(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrTooLarge):
+		return "too_large"
+	case errors.Is(err, ErrNotImage):
+		return "not_image"
+	default:
+		return "error"
+	}
+}
+
+// objectURL возвращает адрес раздачи объекта name или fallback, если адрес раздачи не задан
+func objectURL(baseURL, name, fallback string) string {
+	if baseURL == "" {
+		return fallback
+	}
+	return strings.TrimRight(baseURL, "/") + "/" + name
+}
@@ -0,0 +1,10 @@
+package media
+
+import "github.com/rx3lixir/kultscraper/internal/lib/metrics"
+
+// Метрики скачивания изображений
+var downloads = metrics.DefaultRegistry.NewCounterVec(
+	"kultscraper_images_downloaded_total",
+	"Number of image downloads by status (ok, too_large, not_image, error).",
+	"status",
+)
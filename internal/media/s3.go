@@ -0,0 +1,146 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+)
+
+// S3Store хранит файлы в бакете S3-совместимого хранилища. Запросы подписываются
+// AWS Signature V4, адрес объекта - <endpoint>/<бакет>/<имя> (path-style)
+type S3Store struct {
+	cfg      config.S3Config
+	endpoint *url.URL
+	bucket   string
+	baseURL  string
+	client   *http.Client
+}
+
+// NewS3Store проверяет адрес хранилища и создает S3Store
+func NewS3Store(cfg config.S3Config, bucket, baseURL string) (*S3Store, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+	return &S3Store{cfg: cfg, endpoint: u, bucket: bucket, baseURL: baseURL, client: http.DefaultClient}, nil
+}
+
+// Put загружает объект, если объекта с таким именем еще нет. Без IMAGE_BASE_URL
+// ссылкой служит адрес объекта в хранилище
+func (s *S3Store) Put(ctx context.Context, name, contentType string, data []byte) (string, error) {
+	u := *s.endpoint
+	u.Path = strings.TrimRight(u.Path, "/") + "/" + s.bucket + "/" + name
+	ref := objectURL(s.baseURL, name, u.String())
+
+	resp, err := s.do(ctx, http.MethodHead, &u, "", nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return ref, nil
+	}
+
+	resp, err = s.do(ctx, http.MethodPut, &u, contentType, data)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("s3 put %s: HTTP status %d: %s", name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return ref, nil
+}
+
+// do выполняет подписанный запрос к объекту
+func (s *S3Store) do(ctx context.Context, method string, u *url.URL, contentType string, data []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, data, time.Now().UTC())
+	return s.client.Do(req)
+}
+
+// sign добавляет заголовки подписи AWS Signature V4
+func (s *S3Store) sign(req *http.Request, payload []byte, now time.Time) {
+	const service = "s3"
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Подписываются host и заголовки x-amz-*, имена в нижнем регистре по алфавиту
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		signed = []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+		headers["content-type"] = ct
+	}
+	var canonicalHeaders strings.Builder
+	for _, h := range signed {
+		canonicalHeaders.WriteString(h + ":" + strings.TrimSpace(headers[h]) + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.cfg.Region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), day)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Источники запуска скраппинга
+const (
+	TriggerCLI      = "cli"
+	TriggerAPI      = "api"
+	TriggerSchedule = "schedule"
+)
+
+// Исходы выполнения задачи
+const (
+	OutcomeSuccess   = "success"
+	OutcomeFailed    = "failed"
+	OutcomeSaveError = "save_error"
+	OutcomePending   = "pending"
+	OutcomeSkipped   = "skipped"
+)
+
+// AuditEntry - запись журнала аудита о запуске скраппинга
+type AuditEntry struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RunID             string             `bson:"run_id" json:"run_id"`
+	Trigger           string             `bson:"trigger" json:"trigger"`
+	TriggeredBy       string             `bson:"triggered_by" json:"triggered_by"`
+	Host              string             `bson:"host,omitempty" json:"host,omitempty"`
+	ConfigFingerprint string             `bson:"config_fingerprint" json:"config_fingerprint"`
+	StartedAt         time.Time          `bson:"started_at" json:"started_at"`
+	FinishedAt        time.Time          `bson:"finished_at" json:"finished_at"`
+	Tasks             []AuditTask        `bson:"tasks" json:"tasks"`
+	Succeeded         int                `bson:"succeeded" json:"succeeded"`
+	Failed            int                `bson:"failed" json:"failed"`
+	Skipped           int                `bson:"skipped,omitempty" json:"skipped,omitempty"`
+	SlowTasks         []string           `bson:"slow_tasks,omitempty" json:"slow_tasks,omitempty"`
+	ErrorsByCode      map[string]int     `bson:"errors_by_code,omitempty" json:"errors_by_code,omitempty"`
+}
+
+// AuditTask - исход одной задачи в рамках запуска
+type AuditTask struct {
+	Name      string        `bson:"name" json:"name"`
+	URL       string        `bson:"url" json:"url"`
+	Project   string        `bson:"project,omitempty" json:"project,omitempty"`
+	Type      string        `bson:"type" json:"type"`
+	Outcome   string        `bson:"outcome" json:"outcome"`
+	ResultID  string        `bson:"result_id,omitempty" json:"result_id,omitempty"`
+	Error     string        `bson:"error,omitempty" json:"error,omitempty"`
+	ErrorCode string        `bson:"error_code,omitempty" json:"error_code,omitempty"`
+	Artifacts string        `bson:"artifacts,omitempty" json:"artifacts,omitempty"` // Каталог с артефактами сбоя
+	Duration  time.Duration `bson:"duration,omitempty" json:"duration,omitempty"`
+	Slow      bool          `bson:"slow,omitempty" json:"slow,omitempty"`
+}
+
+// Finish подсчитывает итоги запуска и фиксирует время завершения.
+// Задачи, оставшиеся в ожидании, считаются неудавшимися
+func (a *AuditEntry) Finish() {
+	a.FinishedAt = time.Now()
+	a.Succeeded, a.Failed, a.Skipped = 0, 0, 0
+	a.SlowTasks = nil
+	a.ErrorsByCode = nil
+
+	for i := range a.Tasks {
+		if a.Tasks[i].Slow {
+			a.SlowTasks = append(a.SlowTasks, a.Tasks[i].Name)
+		}
+		if a.Tasks[i].Outcome == OutcomePending {
+			a.Tasks[i].Outcome = OutcomeFailed
+		}
+		switch a.Tasks[i].Outcome {
+		case OutcomeSuccess:
+			a.Succeeded++
+			continue
+		case OutcomeSkipped:
+			a.Skipped++
+		default:
+			a.Failed++
+		}
+
+		if code := a.Tasks[i].ErrorCode; code != "" {
+			if a.ErrorsByCode == nil {
+				a.ErrorsByCode = make(map[string]int)
+			}
+			a.ErrorsByCode[code]++
+		}
+	}
+}
+
+// SetOutcome фиксирует исход задачи по URL и типу
+func (a *AuditEntry) SetOutcome(url, scrapeType, outcome, resultID, errMsg string) {
+	for i := range a.Tasks {
+		if a.Tasks[i].URL == url && a.Tasks[i].Type == scrapeType {
+			a.Tasks[i].Outcome = outcome
+			a.Tasks[i].ResultID = resultID
+			a.Tasks[i].Error = errMsg
+			a.Tasks[i].ErrorCode = ""
+			a.Tasks[i].Artifacts = ""
+			return
+		}
+	}
+}
+
+// SetError фиксирует неудачный исход задачи вместе с категорией ошибки
+func (a *AuditEntry) SetError(url, scrapeType, outcome string, err error) {
+	for i := range a.Tasks {
+		if a.Tasks[i].URL == url && a.Tasks[i].Type == scrapeType {
+			a.Tasks[i].Outcome = outcome
+			a.Tasks[i].ResultID = ""
+			a.Tasks[i].Error = err.Error()
+			a.Tasks[i].ErrorCode = string(errs.CodeOf(err))
+			a.Tasks[i].Artifacts = errs.ArtifactsOf(err)
+			return
+		}
+	}
+}
+
+// SetTiming фиксирует длительность задачи и признак превышения порога
+func (a *AuditEntry) SetTiming(url, scrapeType string, duration time.Duration, slow bool) {
+	for i := range a.Tasks {
+		if a.Tasks[i].URL == url && a.Tasks[i].Type == scrapeType {
+			a.Tasks[i].Duration = duration
+			a.Tasks[i].Slow = slow
+			return
+		}
+	}
+}
+
+// ForProject возвращает копию записи только с задачами проекта и итогами по ним,
+// nil - в запуске нет задач проекта
+func (a *AuditEntry) ForProject(project string) *AuditEntry {
+	scoped := *a
+	scoped.Tasks = nil
+	for _, task := range a.Tasks {
+		if task.Project == project {
+			scoped.Tasks = append(scoped.Tasks, task)
+		}
+	}
+	if len(scoped.Tasks) == 0 {
+		return nil
+	}
+	scoped.Finish()
+	scoped.FinishedAt = a.FinishedAt
+	return &scoped
+}
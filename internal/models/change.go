@@ -0,0 +1,108 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// ChangeType - тип изменения результата при сохранении
+type ChangeType string
+
+const (
+	ChangeCreated   ChangeType = "created"
+	ChangeUpdated   ChangeType = "updated"
+	ChangeUnchanged ChangeType = "unchanged"
+)
+
+// ResultChange - изменение результата скраппинга, отдаваемое подписчикам
+type ResultChange struct {
+	ResultID      string            `bson:"result_id" json:"result_id"`
+	URL           string            `bson:"url" json:"url"`
+	Type          string            `bson:"type" json:"type"`
+	Name          string            `bson:"name" json:"name"`
+	ChangeType    ChangeType        `bson:"change_type" json:"change_type"`
+	ChangedFields []string          `bson:"changed_fields,omitempty" json:"changed_fields,omitempty"`
+	Diffs         []FieldDiff       `bson:"diffs,omitempty" json:"diffs,omitempty"`
+	Previous      map[string]string `bson:"previous,omitempty" json:"previous,omitempty"`
+	Current       map[string]string `bson:"current,omitempty" json:"current,omitempty"`
+	ChangedAt     time.Time         `bson:"changed_at" json:"changed_at"`
+}
+
+// FieldDiff - изменение одного поля данных. Пустое Old - поле добавлено, пустое New - удалено
+type FieldDiff struct {
+	Field string `bson:"field" json:"field"`
+	Old   string `bson:"old,omitempty" json:"old,omitempty"`
+	New   string `bson:"new,omitempty" json:"new,omitempty"`
+}
+
+// NewResultChange сравнивает предыдущие и текущие данные и формирует изменение.
+// previous == nil означает, что результат создан впервые
+func NewResultChange(id string, previous map[string]string, current *ScrapingResult) *ResultChange {
+	change := &ResultChange{
+		ResultID:  id,
+		URL:       current.URL,
+		Type:      current.Type,
+		Name:      current.Name,
+		Current:   current.Data,
+		ChangedAt: time.Now(),
+	}
+
+	if previous == nil {
+		change.ChangeType = ChangeCreated
+		change.ChangedFields = sortedKeys(current.Data)
+		return change
+	}
+
+	change.Previous = previous
+	change.ChangedFields = ChangedFields(previous, current.Data)
+	change.Diffs = Diff(previous, current.Data)
+	if len(change.ChangedFields) == 0 {
+		change.ChangeType = ChangeUnchanged
+	} else {
+		change.ChangeType = ChangeUpdated
+	}
+
+	return change
+}
+
+// ChangedFields возвращает отсортированный список полей, отличающихся между двумя наборами данных
+func ChangedFields(previous, current map[string]string) []string {
+	var fields []string
+
+	for key, value := range current {
+		if old, ok := previous[key]; !ok || old != value {
+			fields = append(fields, key)
+		}
+	}
+	for key := range previous {
+		if _, ok := current[key]; !ok {
+			fields = append(fields, key)
+		}
+	}
+
+	sort.Strings(fields)
+	return fields
+}
+
+// Diff возвращает старые и новые значения отличающихся полей в порядке ChangedFields
+func Diff(previous, current map[string]string) []FieldDiff {
+	fields := ChangedFields(previous, current)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	diffs := make([]FieldDiff, 0, len(fields))
+	for _, field := range fields {
+		diffs = append(diffs, FieldDiff{Field: field, Old: previous[field], New: current[field]})
+	}
+	return diffs
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
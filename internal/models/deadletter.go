@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Статусы записи очереди недоставленных задач
+const (
+	DeadLetterPending  = "pending"  // Ожидает разбора
+	DeadLetterRequeued = "requeued" // Возвращена в очередь, выполнится со следующим запуском
+)
+
+// DeadLetter - задача, не выполненная после всех попыток, с историей ошибок.
+// На отпечаток задачи приходится одна запись, повторная неудача обновляет ее
+type DeadLetter struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TaskFingerprint string             `bson:"task_fingerprint" json:"task_fingerprint"`
+	URL             string             `bson:"url" json:"url"`
+	Type            string             `bson:"type" json:"type"`
+	Name            string             `bson:"name" json:"name"`
+	Project         string             `bson:"project,omitempty" json:"project,omitempty"`
+	Task            json.RawMessage    `bson:"task" json:"task"` // Задача в формате файла задач, по ней запись возвращается в очередь
+	Status          string             `bson:"status" json:"status"`
+	Error           string             `bson:"error" json:"error"`
+	ErrorCode       string             `bson:"error_code,omitempty" json:"error_code,omitempty"`
+	Attempts        []AttemptError     `bson:"attempts" json:"attempts"` // Ошибки попыток последнего запуска
+	Failures        int                `bson:"failures" json:"failures"` // Запуски, в которых задача не выполнилась
+	RunID           string             `bson:"run_id" json:"run_id"`
+	ExecutionID     string             `bson:"execution_id,omitempty" json:"execution_id,omitempty"`
+	FirstFailedAt   time.Time          `bson:"first_failed_at" json:"first_failed_at"`
+	FailedAt        time.Time          `bson:"failed_at" json:"failed_at"`
+	RequeuedAt      *time.Time         `bson:"requeued_at,omitempty" json:"requeued_at,omitempty"`
+}
+
+// AttemptError - ошибка одной попытки задачи
+type AttemptError struct {
+	Attempt int       `bson:"attempt" json:"attempt"`
+	Error   string    `bson:"error" json:"error"`
+	Code    string    `bson:"code,omitempty" json:"code,omitempty"`
+	At      time.Time `bson:"at" json:"at"`
+}
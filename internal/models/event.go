@@ -0,0 +1,42 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Event - культурное событие, собранное из результата скраппинга.
+// События разных источников хранятся в одной коллекции с общей схемой
+type Event struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Key         string             `bson:"key" json:"key"` // Идентификатор события в источнике, по нему событие обновляется при повторном сборе
+	Project     string             `bson:"project,omitempty" json:"project,omitempty"`
+	Source      string             `bson:"source" json:"source"` // Имя задачи
+	SourceURL   string             `bson:"source_url" json:"source_url"`
+	ResultID    string             `bson:"result_id,omitempty" json:"result_id,omitempty"`
+	Title       string             `bson:"title" json:"title"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Start       time.Time          `bson:"start" json:"start"`
+	End         *time.Time         `bson:"end,omitempty" json:"end,omitempty"`
+	Venue       string             `bson:"venue,omitempty" json:"venue,omitempty"`
+	Address     string             `bson:"address,omitempty" json:"address,omitempty"`
+	Price       *Price             `bson:"price,omitempty" json:"price,omitempty"`
+	Link        string             `bson:"link,omitempty" json:"link,omitempty"`
+	Image       string             `bson:"image,omitempty" json:"image,omitempty"`
+	Category    string             `bson:"category,omitempty" json:"category,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// EventKey вычисляет Key события: ссылка на событие, если она есть, иначе название и время начала
+func EventKey(project, sourceURL, link, title string, start time.Time) string {
+	id := link
+	if id == "" {
+		id = title + "|" + start.UTC().Format(time.RFC3339)
+	}
+	sum := sha256.Sum256([]byte(project + "|" + sourceURL + "|" + id))
+	return hex.EncodeToString(sum[:12])
+}
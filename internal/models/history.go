@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HistoryEntry - запись истории изменений сохраненного результата
+type HistoryEntry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ResultID  string             `bson:"result_id" json:"result_id"`
+	RunID     string             `bson:"run_id,omitempty" json:"run_id,omitempty"`
+	URL       string             `bson:"url" json:"url"`
+	Type      string             `bson:"type" json:"type"`
+	Name      string             `bson:"name" json:"name"`
+	Diffs     []FieldDiff        `bson:"diffs" json:"diffs"`
+	ChangedAt time.Time          `bson:"changed_at" json:"changed_at"`
+}
+
+// NewHistoryEntry создает запись истории по изменению результата в запуске runID
+func NewHistoryEntry(runID string, change *ResultChange) *HistoryEntry {
+	return &HistoryEntry{
+		ResultID:  change.ResultID,
+		RunID:     runID,
+		URL:       change.URL,
+		Type:      change.Type,
+		Name:      change.Name,
+		Diffs:     change.Diffs,
+		ChangedAt: change.ChangedAt,
+	}
+}
@@ -1,21 +1,145 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
 	"time"
 
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Sraping result - модель для созранения результатов скраппинга в базу данных
 type ScrapingResult struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	URL       string             `bson:"url" json:"url"`
-	Type      string             `bson:"type" json:"type"`
-	Name      string             `bson:"name" json:"name"`
-	Data      map[string]string  `bson:"data" json:"data"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
-	Metadata  map[string]any     `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Project    string              `bson:"project,omitempty" json:"project,omitempty"`
+	URL        string              `bson:"url" json:"url"`
+	Type       string              `bson:"type" json:"type"`
+	Name       string              `bson:"name" json:"name"`
+	Data       map[string]string   `bson:"data" json:"data"`
+	Items      []map[string]string `bson:"items,omitempty" json:"items,omitempty"` // Записи списка для задач в режиме items
+	Tags       []string            `bson:"tags,omitempty" json:"tags,omitempty"`
+	Confidence map[string]float64  `bson:"confidence,omitempty" json:"confidence,omitempty"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time           `bson:"updated_at" json:"updated_at"`
+	ExpiresAt  *time.Time          `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	Metadata   ScrapeMeta          `bson:"metadata" json:"metadata"`
+	Debug      *DebugInfo          `bson:"debug,omitempty" json:"debug,omitempty"`
+}
+
+// DebugInfo - отладочная информация, собранная со страницы во время скраппинга
+type DebugInfo struct {
+	Console    []ConsoleMessage `bson:"console,omitempty" json:"console,omitempty"`
+	PageErrors []string         `bson:"page_errors,omitempty" json:"page_errors,omitempty"`
+}
+
+// ConsoleMessage - сообщение консоли браузера
+type ConsoleMessage struct {
+	Level string    `bson:"level" json:"level"`
+	Text  string    `bson:"text" json:"text"`
+	Time  time.Time `bson:"time" json:"time"`
+}
+
+// ScrapeMeta - типизированные метаданные скраппинга
+type ScrapeMeta struct {
+	HTTPStatus         int                  `bson:"http_status,omitempty" json:"http_status,omitempty"`
+	ETag               string               `bson:"etag,omitempty" json:"etag,omitempty"` // ETag и LastModified - валидаторы HTTP-кэша страницы
+	LastModified       string               `bson:"last_modified,omitempty" json:"last_modified,omitempty"`
+	ContentHash        string               `bson:"content_hash,omitempty" json:"content_hash,omitempty"` // Хэш содержимого, по нему пропускается сохранение без изменений
+	CheckedAt          *time.Time           `bson:"checked_at,omitempty" json:"checked_at,omitempty"`     // Последний сбор, в том числе без изменений
+	FinalURL           string               `bson:"final_url,omitempty" json:"final_url,omitempty"`
+	Duration           time.Duration        `bson:"duration,omitempty" json:"duration,omitempty"`
+	Slow               bool                 `bson:"slow,omitempty" json:"slow,omitempty"`
+	NavigationDuration time.Duration        `bson:"navigation_duration,omitempty" json:"navigation_duration,omitempty"`
+	ExtractionDuration time.Duration        `bson:"extraction_duration,omitempty" json:"extraction_duration,omitempty"`
+	Engine             string               `bson:"engine,omitempty" json:"engine,omitempty"`
+	Attempt            int                  `bson:"attempt,omitempty" json:"attempt,omitempty"`
+	TaskFingerprint    string               `bson:"task_fingerprint,omitempty" json:"task_fingerprint,omitempty"`
+	RunID              string               `bson:"run_id,omitempty" json:"run_id,omitempty"`
+	ExecutionID        string               `bson:"exec_id,omitempty" json:"exec_id,omitempty"`
+	TraceID            string               `bson:"trace_id,omitempty" json:"trace_id,omitempty"`
+	SpanID             string               `bson:"span_id,omitempty" json:"span_id,omitempty"`
+	Errors             map[string]string    `bson:"errors,omitempty" json:"errors,omitempty"`         // Коды ошибок извлечения по ключам полей
+	Screenshot         string               `bson:"screenshot,omitempty" json:"screenshot,omitempty"` // Путь к снимку страницы
+	Snapshot           string               `bson:"snapshot,omitempty" json:"snapshot,omitempty"`     // Путь к сжатому HTML страницы
+	HAR                string               `bson:"har,omitempty" json:"har,omitempty"`               // Путь к записи сетевых запросов страницы
+	PDF                string               `bson:"pdf,omitempty" json:"pdf,omitempty"`               // Путь к PDF-копии страницы
+	Dates              map[string]time.Time `bson:"dates,omitempty" json:"dates,omitempty"`           // Распознанные даты ключей с типом date
+	Numbers            map[string]float64   `bson:"numbers,omitempty" json:"numbers,omitempty"`       // Числа ключей с типом number
+	Prices             map[string]Price     `bson:"prices,omitempty" json:"prices,omitempty"`         // Цены ключей с типом price
+	Images             map[string]string    `bson:"images,omitempty" json:"images,omitempty"`         // Ссылки на сохраненные копии изображений ключей с типом image
+	Status             string               `bson:"status,omitempty" json:"status,omitempty"`         // StatusInvalid или StatusError, пусто - результат получен и прошел проверки
+	Error              string               `bson:"error,omitempty" json:"error,omitempty"`           // Ошибка последней неудачной попытки для StatusError
+	ErrorCode          string               `bson:"error_code,omitempty" json:"error_code,omitempty"` // Категория ошибки для StatusError
+	Captcha            string               `bson:"captcha,omitempty" json:"captcha,omitempty"`       // Капча на странице: cloudflare, hcaptcha или recaptcha
+	Issues             []string             `bson:"issues,omitempty" json:"issues,omitempty"`         // Непройденные правила Quality задачи
+	Truncated          []string             `bson:"truncated,omitempty" json:"truncated,omitempty"`   // Ключи, значения которых обрезаны по пределам размера
+	Extras             map[string]any       `bson:"extras,omitempty" json:"extras,omitempty"`
+}
+
+// Статусы результата в Metadata.Status
+const (
+	StatusInvalid = "invalid"
+	StatusError   = "error" // Задача не выполнилась после всех попыток
+)
+
+// NewFailedResult создает результат неудавшейся задачи со статусом StatusError
+func NewFailedResult(url, scrapeType, name string, err error, attempt int) *ScrapingResult {
+	result := NewScrapingResult(url, scrapeType, name, map[string]string{})
+	result.Metadata.Status = StatusError
+	result.Metadata.Error = err.Error()
+	result.Metadata.ErrorCode = string(errs.CodeOf(err))
+	result.Metadata.Attempt = attempt
+	return result
+}
+
+// SetFailure переносит сведения о неудачной попытке из failed. Остальные поля, включая
+// данные последнего успешного сбора, сохраняются
+func (m *ScrapeMeta) SetFailure(failed ScrapeMeta) {
+	m.Status = failed.Status
+	m.Error = failed.Error
+	m.ErrorCode = failed.ErrorCode
+	m.Attempt = failed.Attempt
+	m.TaskFingerprint = failed.TaskFingerprint
+	m.RunID = failed.RunID
+	m.ExecutionID = failed.ExecutionID
+}
+
+// SetChecked переносит время проверки и валидаторы HTTP-кэша сбора, не изменившего содержимое
+func (m *ScrapeMeta) SetChecked(checked ScrapeMeta) {
+	m.CheckedAt = checked.CheckedAt
+	m.ETag = checked.ETag
+	m.LastModified = checked.LastModified
+}
+
+// ContentHash возвращает хэш имени, данных, записей и меток результата
+func (r *ScrapingResult) ContentHash() string {
+	encoded, _ := json.Marshal(struct {
+		Name  string
+		Data  map[string]string
+		Items []map[string]string
+		Tags  []string
+	}{r.Name, r.Data, r.Items, r.Tags})
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// SameContent сообщает, что результат совпадает с сохраненным previous по ContentHash.
+// Смена статуса или версии задачи требует полного сохранения даже при тех же данных
+func (r *ScrapingResult) SameContent(previous *ScrapingResult) bool {
+	return r.Metadata.ContentHash != "" &&
+		r.Metadata.ContentHash == previous.Metadata.ContentHash &&
+		r.Metadata.Status == previous.Metadata.Status &&
+		r.Metadata.TaskFingerprint == previous.Metadata.TaskFingerprint
+}
+
+// Price - цена, распознанная в тексте. Для диапазона "500–1500 ₽" Amount - нижняя граница, Max - верхняя
+type Price struct {
+	Amount   float64 `bson:"amount" json:"amount"`
+	Max      float64 `bson:"max,omitempty" json:"max,omitempty"`
+	Currency string  `bson:"currency,omitempty" json:"currency,omitempty"` // Код ISO 4217, пусто - валюта не указана
 }
 
 // NewScrapingResult создает новый результат скраппинга
@@ -29,6 +153,69 @@ func NewScrapingResult(url, scrapeType, name string, data map[string]string) *Sc
 		Data:      data,
 		CreatedAt: now,
 		UpdatedAt: now,
-		Metadata:  make(map[string]any),
+		Metadata:  ScrapeMeta{Extras: make(map[string]any)},
 	}
 }
+
+// AddTags добавляет метки к результату, пропуская пустые и повторяющиеся
+func (r *ScrapingResult) AddTags(tags ...string) {
+	for _, tag := range tags {
+		if tag == "" || r.HasTag(tag) {
+			continue
+		}
+		r.Tags = append(r.Tags, tag)
+	}
+}
+
+// HasTag проверяет наличие метки у результата
+func (r *ScrapingResult) HasTag(tag string) bool {
+	for _, t := range r.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired сообщает, истек ли срок актуальности события
+func (r *ScrapingResult) Expired(now time.Time) bool {
+	return r.ExpiresAt != nil && !r.ExpiresAt.After(now)
+}
+
+// SetConfidence задает оценку достоверности поля, ограничивая ее диапазоном 0..1
+func (r *ScrapingResult) SetConfidence(field string, score float64) {
+	if r.Confidence == nil {
+		r.Confidence = make(map[string]float64)
+	}
+	r.Confidence[field] = min(max(score, 0), 1)
+}
+
+// LowConfidenceFields возвращает поля с оценкой ниже порога
+func (r *ScrapingResult) LowConfidenceFields(threshold float64) []string {
+	var fields []string
+	for field, score := range r.Confidence {
+		if score < threshold {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Ключи Data со сводкой по записям списка, по ним отслеживаются изменения Items
+const (
+	ItemsCountKey  = "ItemsCount"
+	ItemsDigestKey = "ItemsDigest"
+)
+
+// SetItems сохраняет записи списка и их сводку в Data
+func (r *ScrapingResult) SetItems(items []map[string]string) {
+	r.Items = items
+	if r.Data == nil {
+		r.Data = make(map[string]string)
+	}
+
+	encoded, _ := json.Marshal(items)
+	sum := sha256.Sum256(encoded)
+	r.Data[ItemsCountKey] = strconv.Itoa(len(items))
+	r.Data[ItemsDigestKey] = hex.EncodeToString(sum[:8])
+}
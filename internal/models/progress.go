@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// RunProgress - ход выполнения запуска скраппинга
+type RunProgress struct {
+	RunID     string        `json:"run_id"`
+	Total     int           `json:"total"`   // Задачи запуска
+	Queued    int           `json:"queued"`  // Ожидают в очереди пула или повтора
+	Running   int           `json:"running"` // Выполняются
+	Done      int           `json:"done"`    // Завершены успешно
+	Failed    int           `json:"failed"`  // Завершены с ошибкой после всех попыток
+	Skipped   int           `json:"skipped"` // Пропущены: страница не изменилась с прошлого сбора
+	StartedAt time.Time     `json:"started_at"`
+	Elapsed   time.Duration `json:"elapsed"`
+	ETA       time.Duration `json:"eta,omitempty"` // Оценка оставшегося времени по средней скорости, 0 - еще не известна
+}
+
+// Finished возвращает число завершенных задач
+func (p RunProgress) Finished() int {
+	return p.Done + p.Failed + p.Skipped
+}
+
+// Percent возвращает долю завершенных задач в процентах
+func (p RunProgress) Percent() float64 {
+	if p.Total == 0 {
+		return 100
+	}
+	return float64(p.Finished()) * 100 / float64(p.Total)
+}
+
+// Estimate заполняет Elapsed и ETA на момент now. ETA считается по среднему
+// времени завершенных задач и остается нулевым, пока ни одна задача не завершилась
+func (p *RunProgress) Estimate(now time.Time) {
+	p.Elapsed = now.Sub(p.StartedAt)
+	p.ETA = 0
+	if finished := p.Finished(); finished > 0 && finished < p.Total {
+		p.ETA = p.Elapsed / time.Duration(finished) * time.Duration(p.Total-finished)
+	}
+}
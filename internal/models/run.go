@@ -0,0 +1,108 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Статусы запуска скраппинга
+const (
+	RunRunning   = "running"
+	RunCompleted = "completed" // Все задачи выполнены или пропущены
+	RunPartial   = "partial"   // Часть задач не удалась
+	RunFailed    = "failed"    // Не удалась ни одна задача
+	RunCanceled  = "canceled"  // Запуск прерван до обработки всех задач
+)
+
+// maxRunErrors - предел групп ошибок в сводке запуска
+const maxRunErrors = 20
+
+// ScrapeRun - запуск скраппинга и его состояние. Сохраняется при старте запуска,
+// поэтому запись остается, даже если все задачи завершились ошибкой или процесс упал
+type ScrapeRun struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RunID        string             `bson:"run_id" json:"run_id"`
+	Status       string             `bson:"status" json:"status"`
+	Trigger      string             `bson:"trigger" json:"trigger"`
+	TriggeredBy  string             `bson:"triggered_by,omitempty" json:"triggered_by,omitempty"`
+	Schedule     string             `bson:"schedule,omitempty" json:"schedule,omitempty"`
+	Host         string             `bson:"host,omitempty" json:"host,omitempty"`
+	StartedAt    time.Time          `bson:"started_at" json:"started_at"`
+	FinishedAt   *time.Time         `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+	Tasks        int                `bson:"tasks" json:"tasks"` // Задачи запуска, включая страницы sitemap и обхода
+	Succeeded    int                `bson:"succeeded" json:"succeeded"`
+	Failed       int                `bson:"failed" json:"failed"`
+	Skipped      int                `bson:"skipped,omitempty" json:"skipped,omitempty"`
+	ErrorsByCode map[string]int     `bson:"errors_by_code,omitempty" json:"errors_by_code,omitempty"`
+	Errors       []RunError         `bson:"errors,omitempty" json:"errors,omitempty"` // Частые ошибки задач, по убыванию числа
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// RunError - группа одинаковых ошибок задач запуска
+type RunError struct {
+	Code    string `bson:"code" json:"code"`
+	Message string `bson:"message" json:"message"`
+	Count   int    `bson:"count" json:"count"`
+	URL     string `bson:"url" json:"url"` // Адрес первой задачи с этой ошибкой
+}
+
+// NewScrapeRun создает запуск в статусе running по записи аудита
+func NewScrapeRun(audit *AuditEntry, schedule string) *ScrapeRun {
+	return &ScrapeRun{
+		RunID:       audit.RunID,
+		Status:      RunRunning,
+		Trigger:     audit.Trigger,
+		TriggeredBy: audit.TriggeredBy,
+		Schedule:    schedule,
+		Host:        audit.Host,
+		StartedAt:   audit.StartedAt,
+		Tasks:       len(audit.Tasks),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// Finish переносит итоги завершенного запуска из записи аудита и выставляет статус.
+// canceled - запуск прерван, необработанные задачи учтены в аудите как неудавшиеся
+func (r *ScrapeRun) Finish(audit *AuditEntry, canceled bool) {
+	finished := audit.FinishedAt
+	r.FinishedAt = &finished
+	r.UpdatedAt = time.Now()
+	r.Tasks = len(audit.Tasks)
+	r.Succeeded, r.Failed, r.Skipped = audit.Succeeded, audit.Failed, audit.Skipped
+	r.ErrorsByCode = audit.ErrorsByCode
+
+	switch {
+	case canceled:
+		r.Status = RunCanceled
+	case r.Failed == 0:
+		r.Status = RunCompleted
+	case r.Succeeded == 0:
+		r.Status = RunFailed
+	default:
+		r.Status = RunPartial
+	}
+
+	r.Errors = nil
+	groups := make(map[string]int)
+	for _, task := range audit.Tasks {
+		if task.Error == "" || task.Outcome == OutcomeSuccess {
+			continue
+		}
+		key := task.ErrorCode + "\x00" + task.Error
+		if i, ok := groups[key]; ok {
+			r.Errors[i].Count++
+			continue
+		}
+		groups[key] = len(r.Errors)
+		r.Errors = append(r.Errors, RunError{Code: task.ErrorCode, Message: task.Error, Count: 1, URL: task.URL})
+	}
+	slices.SortStableFunc(r.Errors, func(a, b RunError) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+	if len(r.Errors) > maxRunErrors {
+		r.Errors = r.Errors[:maxRunErrors]
+	}
+}
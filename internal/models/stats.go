@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Stats - сводка по сохраненным результатам и запускам для панели состояния
+type Stats struct {
+	Total         int64         `bson:"total" json:"total"`
+	Types         []TypeStats   `bson:"types" json:"types"`
+	Sources       []SourceStats `bson:"sources" json:"sources"`
+	FailuresSince time.Time     `bson:"failures_since" json:"failures_since"` // Начало периода журнала аудита для FailureRate
+	GeneratedAt   time.Time     `bson:"generated_at" json:"generated_at"`
+}
+
+// TypeStats - сводка по типу результатов
+type TypeStats struct {
+	Type         string    `bson:"_id" json:"type"`
+	Count        int64     `bson:"count" json:"count"`
+	Completeness float64   `bson:"completeness" json:"completeness"` // Средняя доля непустых полей Data, 0..1
+	LastScraped  time.Time `bson:"last_scraped" json:"last_scraped"`
+	Tasks        int64     `bson:"-" json:"tasks"`  // Выполненные задачи типа за период, без пропущенных
+	Failed       int64     `bson:"-" json:"failed"` // Из них завершились ошибкой скраппинга или сохранения
+	FailureRate  float64   `bson:"-" json:"failure_rate"`
+}
+
+// SourceStats - сводка по источнику: задаче с именем Name внутри типа
+type SourceStats struct {
+	Type        string    `bson:"type" json:"type"`
+	Name        string    `bson:"name" json:"name"`
+	Count       int64     `bson:"count" json:"count"`
+	LastScraped time.Time `bson:"last_scraped" json:"last_scraped"`
+}
+
+// FailureStats - число выполненных и неудачных задач типа в журнале аудита
+type FailureStats struct {
+	Type   string `bson:"_id" json:"type"`
+	Tasks  int64  `bson:"tasks" json:"tasks"`
+	Failed int64  `bson:"failed" json:"failed"`
+}
+
+// Completeness возвращает долю непустых полей данных результата
+func (r *ScrapingResult) Completeness() float64 {
+	if len(r.Data) == 0 {
+		return 0
+	}
+	filled := 0
+	for _, v := range r.Data {
+		if v != "" {
+			filled++
+		}
+	}
+	return float64(filled) / float64(len(r.Data))
+}
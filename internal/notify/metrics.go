@@ -0,0 +1,12 @@
+package notify
+
+import "github.com/rx3lixir/kultscraper/internal/lib/metrics"
+
+// Метрики уведомлений регистрируются в общем реестре при загрузке пакета
+var (
+	deliveries = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_webhook_deliveries_total",
+		"Number of webhook notifications by event and delivery status (ok, error, dropped).",
+		"event", "status",
+	)
+)
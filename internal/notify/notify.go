@@ -0,0 +1,295 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/hooks"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+const (
+	// queueSize - число недоставленных уведомлений, после которого новые отбрасываются
+	queueSize = 256
+	// workers - число параллельных отправок
+	workers = 2
+	// maxAttempts - число попыток доставки, включая первую
+	maxAttempts = 3
+	// retryBackoff - задержка перед второй попыткой, удваивается с каждой следующей
+	retryBackoff = time.Second
+)
+
+// Заголовки запроса вебхука
+const (
+	HeaderEvent     = "X-Kultscraper-Event"
+	HeaderSignature = "X-Kultscraper-Signature" // sha256=<hex HMAC-SHA256 тела>
+)
+
+// Payload - тело запроса вебхука
+type Payload struct {
+	Event         string                 `json:"event"`
+	Time          time.Time              `json:"time"`
+	RunID         string                 `json:"run_id,omitempty"`
+	ExecID        string                 `json:"exec_id,omitempty"`
+	Task          TaskInfo               `json:"task"`
+	DurationMS    int64                  `json:"duration_ms,omitempty"`
+	Attempt       int                    `json:"attempt,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+	ErrorCode     string                 `json:"error_code,omitempty"`
+	ResultID      string                 `json:"result_id,omitempty"`
+	ChangeType    models.ChangeType      `json:"change_type,omitempty"`
+	ChangedFields []string               `json:"changed_fields,omitempty"`
+	Diffs         []models.FieldDiff     `json:"diffs,omitempty"`
+	Result        *models.ScrapingResult `json:"result,omitempty"`
+}
+
+// TaskInfo - задача, к которой относится уведомление
+type TaskInfo struct {
+	URL     string `json:"url"`
+	Type    string `json:"type"`
+	Name    string `json:"name,omitempty"`
+	Project string `json:"project,omitempty"`
+}
+
+// Logger - интерфейс для логирования
+type Logger interface {
+	Info(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+	Debug(msg string, keyvals ...interface{})
+}
+
+// NoopLogger - реализация Logger, которая ничего не делает
+type NoopLogger struct{}
+
+func (n NoopLogger) Info(msg string, keyvals ...interface{})  {}
+func (n NoopLogger) Error(msg string, keyvals ...interface{}) {}
+func (n NoopLogger) Debug(msg string, keyvals ...interface{}) {}
+
+// delivery - уведомление для одного адреса
+type delivery struct {
+	url   string
+	event string
+	body  []byte
+}
+
+// Notifier отправляет уведомления на вебхуки в фоне, не задерживая запуск.
+// Неудачная доставка повторяется до maxAttempts раз, при переполнении очереди
+// уведомления отбрасываются
+type Notifier struct {
+	cfg    config.WebhookConfig
+	client *http.Client
+	logger Logger
+
+	mu     sync.RWMutex // Защищает closed от отправки в закрытую очередь
+	closed bool
+	queue  chan delivery
+	done   chan struct{} // Закрывается в Close, прерывает ожидание повторов
+	wg     sync.WaitGroup
+}
+
+// New создает и запускает отправителя уведомлений
+func New(cfg config.WebhookConfig) *Notifier {
+	return NewWithLogger(cfg, NoopLogger{})
+}
+
+// NewWithLogger создает и запускает отправителя уведомлений с логгером
+func NewWithLogger(cfg config.WebhookConfig, logger Logger) *Notifier {
+	n := &Notifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		logger: logger,
+		queue:  make(chan delivery, queueSize),
+		done:   make(chan struct{}),
+	}
+
+	for i := 0; i < workers; i++ {
+		n.wg.Add(1)
+		go n.worker()
+	}
+	return n
+}
+
+// Attach подписывает отправителя на события жизненного цикла запуска
+func Attach(n *Notifier, r *hooks.Registry) {
+	r.OnTaskFinish(func(ctx context.Context, e hooks.TaskEvent) {
+		if e.Retrying {
+			return
+		}
+
+		p := Payload{
+			Event:      config.WebhookTaskFinished,
+			RunID:      e.RunID,
+			ExecID:     e.ExecID,
+			Task:       TaskInfo{URL: e.Task.URL, Type: e.Task.Type, Name: e.Task.Name, Project: e.Task.Project},
+			DurationMS: e.Duration.Milliseconds(),
+			Attempt:    e.Attempt,
+		}
+		switch {
+		case errs.CodeOf(e.Err) == errs.CodeNotModified:
+			// Страница не изменилась с прошлого сбора: задача пропущена, а не провалена
+			p.Event = config.WebhookTaskSkipped
+		case e.Err != nil:
+			p.Event = config.WebhookTaskFailed
+			p.Error = e.Err.Error()
+			p.ErrorCode = string(errs.CodeOf(e.Err))
+		}
+		n.Notify(p)
+	})
+
+	r.OnResultSaved(func(ctx context.Context, e hooks.ResultEvent) {
+		if e.Change == nil || e.Change.ChangeType == models.ChangeUnchanged {
+			return
+		}
+		n.Notify(Payload{
+			Event:         config.WebhookResultChanged,
+			RunID:         e.RunID,
+			ExecID:        e.Result.Metadata.ExecutionID,
+			Task:          TaskInfo{URL: e.Result.URL, Type: e.Result.Type, Name: e.Result.Name, Project: e.Result.Project},
+			ResultID:      e.Change.ResultID,
+			ChangeType:    e.Change.ChangeType,
+			ChangedFields: e.Change.ChangedFields,
+			Diffs:         e.Change.Diffs,
+			Result:        e.Result,
+		})
+	})
+}
+
+// Notify ставит уведомление в очередь для всех адресов типа задачи, если событие
+// включено для этого типа
+func (n *Notifier) Notify(p Payload) {
+	if !n.cfg.Events.Has(p.Task.Type, p.Event) {
+		return
+	}
+	targets := n.cfg.Targets.For(p.Task.Type)
+	if len(targets) == 0 {
+		return
+	}
+
+	if p.Time.IsZero() {
+		p.Time = time.Now()
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		n.logger.Error("Failed to encode webhook payload", "event", p.Event, "error", err)
+		return
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.closed {
+		return
+	}
+
+	for _, target := range targets {
+		select {
+		case n.queue <- delivery{url: target, event: p.Event, body: body}:
+		default:
+			deliveries.With(p.Event, "dropped").Inc()
+			n.logger.Error("Webhook queue is full, dropping notification", "event", p.Event, "url", target)
+		}
+	}
+}
+
+// Close прекращает прием уведомлений и ожидает доставки очереди до отмены ctx.
+// После отмены ctx недоставленные уведомления теряются
+func (n *Notifier) Close(ctx context.Context) error {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return nil
+	}
+	n.closed = true
+	close(n.queue)
+	n.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		close(n.done)
+		return ctx.Err()
+	}
+}
+
+func (n *Notifier) worker() {
+	defer n.wg.Done()
+
+	for d := range n.queue {
+		n.deliver(d)
+	}
+}
+
+// deliver отправляет уведомление, повторяя попытку при сетевой ошибке, 429 и 5xx
+func (n *Notifier) deliver(d delivery) {
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		retryable, err := n.post(d)
+		if err == nil {
+			deliveries.With(d.event, "ok").Inc()
+			n.logger.Debug("Webhook delivered", "event", d.event, "url", d.url, "attempt", attempt)
+			return
+		}
+		if !retryable || attempt >= maxAttempts {
+			deliveries.With(d.event, "error").Inc()
+			n.logger.Error("Webhook delivery failed", "event", d.event, "url", d.url, "attempts", attempt, "error", err)
+			return
+		}
+
+		n.logger.Info("Retrying webhook", "event", d.event, "url", d.url, "attempt", attempt, "backoff", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-n.done:
+			deliveries.With(d.event, "error").Inc()
+			return
+		}
+		backoff *= 2
+	}
+}
+
+func (n *Notifier) post(d delivery) (retryable bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, d.url, bytes.NewReader(d.body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.event)
+	if n.cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(n.cfg.Secret, d.body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, fmt.Errorf("unexpected status %s", resp.Status)
+}
+
+// Sign возвращает значение заголовка подписи для тела запроса. Получатель проверяет
+// подпись, вычисляя HMAC-SHA256 тела с тем же ключом
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
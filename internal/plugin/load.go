@@ -0,0 +1,19 @@
+// Package plugin загружает разделяемые библиотеки плагинов. Сам реестр,
+// который вызывают плагины, находится в публичном пакете kultscraper/plugin
+package plugin
+
+import (
+	"fmt"
+	goplugin "plugin"
+)
+
+// Load загружает плагины из разделяемых библиотек Go (.so, собранных с -buildmode=plugin).
+// Плагин регистрирует свои компоненты в init() через функции Register* пакета kultscraper/plugin
+func Load(paths []string) error {
+	for _, path := range paths {
+		if _, err := goplugin.Open(path); err != nil {
+			return fmt.Errorf("load plugin %s: %w", path, err)
+		}
+	}
+	return nil
+}
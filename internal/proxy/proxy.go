@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// Стратегии выбора прокси из списка
+const (
+	RotationRoundRobin = "round_robin"
+	RotationRandom     = "random"
+)
+
+// Direct - значение прокси задачи, отключающее прокси
+const Direct = "direct"
+
+var ErrInvalidProxy = errors.New("invalid proxy URL")
+
+// Rotator выбирает прокси из списка для каждого нового подключения.
+// Поддерживаются схемы http, https, socks5 и socks4
+type Rotator struct {
+	proxies  []*url.URL
+	strategy string
+	next     atomic.Uint64
+}
+
+// NewRotator разбирает список прокси. Пустой список дает Rotator без прокси
+func NewRotator(urls []string, strategy string) (*Rotator, error) {
+	if strategy == "" {
+		strategy = RotationRoundRobin
+	}
+	if strategy != RotationRoundRobin && strategy != RotationRandom {
+		return nil, fmt.Errorf("unknown proxy rotation %q", strategy)
+	}
+
+	r := &Rotator{strategy: strategy}
+	for _, raw := range urls {
+		u, err := Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		r.proxies = append(r.proxies, u)
+	}
+	return r, nil
+}
+
+// Parse разбирает адрес прокси, адрес без схемы считается HTTP-прокси
+func Parse(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidProxy, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks4":
+	default:
+		return nil, fmt.Errorf("%w: unsupported scheme %q", ErrInvalidProxy, u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("%w: missing host in %q", ErrInvalidProxy, raw)
+	}
+	return u, nil
+}
+
+// Enabled сообщает, настроен ли хотя бы один прокси
+func (r *Rotator) Enabled() bool {
+	return r != nil && len(r.proxies) > 0
+}
+
+// Next возвращает следующий прокси или nil, если список пуст
+func (r *Rotator) Next() *url.URL {
+	if !r.Enabled() {
+		return nil
+	}
+
+	var i int
+	if r.strategy == RotationRandom {
+		i = rand.IntN(len(r.proxies))
+	} else {
+		i = int((r.next.Add(1) - 1) % uint64(len(r.proxies)))
+	}
+	u := *r.proxies[i]
+	return &u
+}
+
+// For возвращает прокси для задачи: пустое значение - следующий из списка,
+// Direct - без прокси, иначе - адрес прокси задачи
+func (r *Rotator) For(taskProxy string) (*url.URL, error) {
+	switch strings.ToLower(strings.TrimSpace(taskProxy)) {
+	case "":
+		return r.Next(), nil
+	case Direct:
+		return nil, nil
+	}
+	return Parse(taskProxy)
+}
+
+// Proxy подходит для http.Transport.Proxy и выбирает прокси для каждого запроса
+func (r *Rotator) Proxy(*http.Request) (*url.URL, error) {
+	return r.Next(), nil
+}
+
+// Server возвращает адрес прокси без учетных данных в формате Chromium (--proxy-server)
+func Server(u *url.URL) string {
+	return u.Scheme + "://" + u.Host
+}
@@ -0,0 +1,10 @@
+package robots
+
+import "github.com/rx3lixir/kultscraper/internal/lib/metrics"
+
+// Метрики проверок регистрируются в общем реестре при загрузке пакета
+var checks = metrics.DefaultRegistry.NewCounterVec(
+	"kultscraper_robots_checks_total",
+	"Number of robots.txt checks by result (allowed, disallowed, error).",
+	"result",
+)
@@ -0,0 +1,171 @@
+// Package robots проверяет адреса по правилам robots.txt сайтов (RFC 9309)
+package robots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTTL - время жизни правил хоста в кэше
+	DefaultTTL = 24 * time.Hour
+	// maxRobotsSize - предел разбираемой части robots.txt (RFC 9309 - не менее 500 КиБ)
+	maxRobotsSize = 500 << 10
+)
+
+// ErrDisallowed - адрес запрещен правилами robots.txt
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// Checker загружает robots.txt хостов и кэширует их правила.
+// Методы nil-проверки разрешают все адреса без задержки
+type Checker struct {
+	UserAgent string // Имя агента для выбора группы правил
+	TTL       time.Duration
+	Client    *http.Client
+
+	mu    sync.Mutex
+	hosts map[string]*hostRules
+}
+
+// hostRules - правила хоста, ready закрывается после загрузки
+type hostRules struct {
+	ready   chan struct{}
+	rules   *Rules
+	expires time.Time
+}
+
+// NewChecker создает проверку для агента userAgent с временем жизни кэша ttl
+func NewChecker(userAgent string, ttl time.Duration) *Checker {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Checker{
+		UserAgent: userAgent,
+		TTL:       ttl,
+		Client:    &http.Client{Timeout: 10 * time.Second},
+		hosts:     make(map[string]*hostRules),
+	}
+}
+
+// Check возвращает ErrDisallowed, если адрес запрещен robots.txt его хоста
+func (c *Checker) Check(ctx context.Context, rawURL string) error {
+	if c == nil {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("robots: invalid URL %q", rawURL)
+	}
+	rules, err := c.rules(ctx, u)
+	if err != nil {
+		return err
+	}
+	if !rules.Allowed(u) {
+		checks.With("disallowed").Inc()
+		return fmt.Errorf("%w: %s", ErrDisallowed, rawURL)
+	}
+	checks.With("allowed").Inc()
+	return nil
+}
+
+// CrawlDelay возвращает Crawl-delay хоста адреса, 0 - без задержки
+func (c *Checker) CrawlDelay(ctx context.Context, rawURL string) time.Duration {
+	if c == nil {
+		return 0
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return 0
+	}
+	rules, err := c.rules(ctx, u)
+	if err != nil {
+		return 0
+	}
+	return rules.CrawlDelay
+}
+
+// rules возвращает правила хоста из кэша или загружает их. Одновременные
+// запросы к одному хосту ждут одной загрузки
+func (c *Checker) rules(ctx context.Context, u *url.URL) (*Rules, error) {
+	key := strings.ToLower(u.Scheme + "://" + u.Host)
+
+	c.mu.Lock()
+	if c.hosts == nil {
+		c.hosts = make(map[string]*hostRules)
+	}
+	entry, ok := c.hosts[key]
+	if ok && entry.rules != nil && time.Now().After(entry.expires) {
+		ok = false
+	}
+	if !ok {
+		entry = &hostRules{ready: make(chan struct{})}
+		c.hosts[key] = entry
+		c.mu.Unlock()
+
+		// Загрузка не привязана к контексту первого запроса: ее результат нужен всем ожидающим
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		rules := c.fetch(fetchCtx, key+"/robots.txt")
+		cancel()
+
+		c.mu.Lock()
+		entry.rules = rules
+		entry.expires = time.Now().Add(c.TTL)
+		c.mu.Unlock()
+		close(entry.ready)
+		return rules, nil
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-entry.ready:
+		return entry.rules, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch загружает robots.txt. Отсутствующий файл (4xx) разрешает все адреса,
+// недоступный (5xx, ошибка сети) запрещает все, как требует RFC 9309
+func (c *Checker) fetch(ctx context.Context, robotsURL string) *Rules {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		checks.With("error").Inc()
+		return &Rules{disallowAll: true}
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		checks.With("error").Inc()
+		return &Rules{disallowAll: true}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+		if err != nil {
+			checks.With("error").Inc()
+			return &Rules{disallowAll: true}
+		}
+		return Parse(string(data), c.UserAgent)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return &Rules{}
+	default:
+		checks.With("error").Inc()
+		return &Rules{disallowAll: true}
+	}
+}
@@ -0,0 +1,159 @@
+package robots
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rules - правила robots.txt, относящиеся к одному агенту
+type Rules struct {
+	CrawlDelay time.Duration
+
+	rules       []rule
+	disallowAll bool // robots.txt недоступен
+}
+
+type rule struct {
+	allow   bool
+	length  int // Длина шаблона: побеждает самое длинное совпадение
+	pattern *regexp.Regexp
+}
+
+// group - группа правил для перечисленных агентов
+type group struct {
+	agents []string
+	rules  []rule
+	delay  time.Duration
+}
+
+// Parse разбирает robots.txt и выбирает группы агента userAgent, а если их нет - группы "*".
+// Сравнивается имя агента без версии, без учета регистра
+func Parse(text, userAgent string) *Rules {
+	agent := strings.ToLower(userAgent)
+	if name, _, ok := strings.Cut(agent, "/"); ok {
+		agent = name
+	}
+
+	var (
+		groups  []*group
+		current *group
+		inRules bool // После правил строка user-agent начинает новую группу
+	)
+	for line := range strings.Lines(text) {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || inRules {
+				current = &group{}
+				groups = append(groups, current)
+				inRules = false
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			// Пустой Disallow ничего не запрещает
+			if value == "" {
+				continue
+			}
+			current.rules = append(current.rules, rule{
+				allow:   key == "allow",
+				length:  len(value),
+				pattern: compilePattern(value),
+			})
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.delay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	rules := &Rules{}
+	for _, name := range []string{agent, "*"} {
+		if name == "" {
+			continue
+		}
+		matched := false
+		for _, g := range groups {
+			for _, a := range g.agents {
+				if a == name {
+					matched = true
+					rules.rules = append(rules.rules, g.rules...)
+					rules.CrawlDelay = max(rules.CrawlDelay, g.delay)
+					break
+				}
+			}
+		}
+		if matched {
+			break
+		}
+	}
+	return rules
+}
+
+// Allowed сообщает, разрешен ли адрес. При равной длине совпадений побеждает Allow
+func (r *Rules) Allowed(u *url.URL) bool {
+	if r.disallowAll {
+		return false
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	// robots.txt всегда доступен
+	if path == "/robots.txt" {
+		return true
+	}
+
+	allowed, best := true, -1
+	for _, rl := range r.rules {
+		if rl.length < best || !rl.pattern.MatchString(path) {
+			continue
+		}
+		if rl.length > best || rl.allow {
+			allowed, best = rl.allow, rl.length
+		}
+	}
+	return allowed
+}
+
+// compilePattern переводит шаблон пути в регулярное выражение:
+// "*" - любая последовательность, "$" в конце - конец адреса
+func compilePattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i, part := range strings.Split(pattern, "*") {
+		if i > 0 {
+			b.WriteString(".*")
+		}
+		b.WriteString(regexp.QuoteMeta(part))
+	}
+	if anchored {
+		b.WriteString("$")
+	}
+	return regexp.MustCompile(b.String())
+}
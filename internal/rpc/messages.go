@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"time"
+
+	scraperv1 "github.com/rx3lixir/kultscraper/api/kultscraper/v1"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Преобразования между сообщениями scraper.proto и моделями приложения
+
+// taskFromProto преобразует сообщение в задачу конфигурации
+func taskFromProto(t *scraperv1.Task) config.ScraperTask {
+	return config.ScraperTask{
+		URL:              t.GetUrl(),
+		Type:             t.GetType(),
+		Name:             t.GetName(),
+		Project:          t.GetProject(),
+		Selectors:        t.GetSelectors(),
+		Tags:             t.GetTags(),
+		SelectorType:     t.GetSelectorType(),
+		Mode:             t.GetMode(),
+		ItemSelector:     t.GetItemSelector(),
+		NextPageSelector: t.GetNextPageSelector(),
+		MaxPages:         int(t.GetMaxPages()),
+		Priority:         t.GetPriority(),
+		Schedule:         t.GetSchedule(),
+		Script:           t.GetScript(),
+	}
+}
+
+// resultToProto преобразует сохраненный результат в сообщение kultscraper.v1.Result
+func resultToProto(res *models.ScrapingResult) *scraperv1.Result {
+	msg := &scraperv1.Result{
+		Project:   res.Project,
+		Url:       res.URL,
+		Type:      res.Type,
+		Name:      res.Name,
+		Data:      res.Data,
+		Tags:      res.Tags,
+		CreatedAt: timestamp(res.CreatedAt),
+		UpdatedAt: timestamp(res.UpdatedAt),
+		RunId:     res.Metadata.RunID,
+	}
+	if !res.ID.IsZero() {
+		msg.Id = res.ID.Hex()
+	}
+	for _, item := range res.Items {
+		msg.Items = append(msg.Items, &scraperv1.Item{Fields: item})
+	}
+	if res.ExpiresAt != nil {
+		msg.ExpiresAt = timestamp(*res.ExpiresAt)
+	}
+	return msg
+}
+
+// timestamp возвращает nil для нулевого времени, как поле без значения в proto3
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
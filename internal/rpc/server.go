@@ -0,0 +1,247 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	scraperv1 "github.com/rx3lixir/kultscraper/api/kultscraper/v1"
+	"github.com/rx3lixir/kultscraper/internal/api"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/db"
+	"github.com/rx3lixir/kultscraper/internal/lib/auth"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/rx3lixir/kultscraper/internal/stream"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceName - полное имя сервиса из scraper.proto
+const ServiceName = "kultscraper.v1.Scraper"
+
+// Logger - интерфейс для логирования
+type Logger interface {
+	Info(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+	Debug(msg string, keyvals ...interface{})
+}
+
+// NoopLogger - реализация Logger, которая ничего не делает
+type NoopLogger struct{}
+
+func (n NoopLogger) Info(msg string, keyvals ...interface{})  {}
+func (n NoopLogger) Error(msg string, keyvals ...interface{}) {}
+func (n NoopLogger) Debug(msg string, keyvals ...interface{}) {}
+
+// Server реализует сервис kultscraper.v1.Scraper. Если заданы Keys, клиенты должны
+// передавать ключ в метаданных authorization: Bearer <key> или x-api-key. Ключ проекта
+// ограничивает задачи, результаты и поток результатов своим проектом
+type Server struct {
+	scraperv1.UnimplementedScraperServer
+
+	Keys config.APIKeys
+
+	tasks   api.TaskStore
+	results db.ScraperRepository
+	broker  *stream.Broker
+	runner  api.Runner
+	logger  Logger
+}
+
+// NewServer создает gRPC-сервис. broker может быть nil, тогда StreamResults недоступен
+func NewServer(tasks api.TaskStore, results db.ScraperRepository, broker *stream.Broker, runner api.Runner) *Server {
+	return NewServerWithLogger(tasks, results, broker, runner, NoopLogger{})
+}
+
+// NewServerWithLogger создает gRPC-сервис с логгером
+func NewServerWithLogger(tasks api.TaskStore, results db.ScraperRepository, broker *stream.Broker, runner api.Runner, logger Logger) *Server {
+	return &Server{
+		tasks:   tasks,
+		results: results,
+		broker:  broker,
+		runner:  runner,
+		logger:  logger,
+	}
+}
+
+// GRPCServer создает gRPC-сервер с этим сервисом и проверкой ключей. Потоки StreamResults
+// завершаются вместе с ctx, чтобы не задерживать остановку сервера
+func (s *Server) GRPCServer(ctx context.Context, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(s.unaryAuth),
+		grpc.ChainStreamInterceptor(s.streamAuth(ctx)),
+	)
+	srv := grpc.NewServer(opts...)
+	scraperv1.RegisterScraperServer(srv, s)
+	return srv
+}
+
+// authorize проверяет ключ из метаданных вызова и ограничивает контекст проектом ключа
+func (s *Server) authorize(ctx context.Context) (context.Context, error) {
+	if !s.Keys.Enabled() {
+		return ctx, nil
+	}
+	project, all, ok := s.Keys.Scope(requestKey(ctx))
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid or missing API key")
+	}
+	if !all {
+		ctx = auth.WithProject(ctx, project)
+	}
+	return ctx, nil
+}
+
+// requestKey возвращает ключ из метаданных authorization: Bearer <key> или x-api-key
+func requestKey(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		if bearer, ok := strings.CutPrefix(v, "Bearer "); ok {
+			return bearer
+		}
+	}
+	if keys := md.Get("x-api-key"); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+func (s *Server) unaryAuth(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := s.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) streamAuth(stop context.Context) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := s.authorize(ss.Context())
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		defer context.AfterFunc(stop, cancel)()
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// serverStream - поток вызова с контекстом, ограниченным проектом ключа и остановкой сервера
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (ss *serverStream) Context() context.Context {
+	return ss.ctx
+}
+
+func (s *Server) SubmitTask(ctx context.Context, req *scraperv1.SubmitTaskRequest) (*scraperv1.SubmitTaskResponse, error) {
+	if req.GetTask() == nil {
+		return nil, status.Error(codes.InvalidArgument, "task is required")
+	}
+	task := taskFromProto(req.GetTask())
+	if project, ok := auth.ProjectFrom(ctx); ok {
+		if task.Project != "" && task.Project != project {
+			return nil, status.Errorf(codes.PermissionDenied, "task project %q is not available to this API key", task.Project)
+		}
+		task.Project = project
+	}
+	if err := config.ValidateTask(task); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	taskID := task.Fingerprint()
+
+	// Сохраненную задачу запускает демон по ее расписанию, отдельный запуск дублировал бы его
+	if req.GetPersist() {
+		_, err := s.tasks.Add(task)
+		if errors.Is(err, api.ErrReadOnly) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		if err != nil && !errors.Is(err, api.ErrTaskExists) {
+			return nil, s.internal("add task", err)
+		}
+		s.logger.Info("Task submitted", "task_id", taskID, "persist", true)
+		return &scraperv1.SubmitTaskResponse{TaskId: taskID}, nil
+	}
+
+	runID, err := s.runner.Start([]config.ScraperTask{task})
+	if err != nil {
+		return nil, s.internal("start run", err)
+	}
+	s.logger.Info("Task submitted", "task_id", taskID, "run_id", runID)
+	return &scraperv1.SubmitTaskResponse{RunId: runID, TaskId: taskID}, nil
+}
+
+func (s *Server) GetResult(ctx context.Context, req *scraperv1.GetResultRequest) (*scraperv1.Result, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	result, err := s.results.GetResultByID(ctx, req.GetId())
+	switch {
+	case errors.Is(err, db.ErrNotFound), errors.Is(err, db.ErrInvalidID):
+		return nil, status.Error(codes.NotFound, "result not found")
+	case err != nil:
+		return nil, s.internal("get result", err)
+	}
+	if project, ok := auth.ProjectFrom(ctx); ok && result.Project != project {
+		return nil, status.Error(codes.NotFound, "result not found")
+	}
+	return resultToProto(result), nil
+}
+
+// StreamResults передает события сохраненных результатов до отключения клиента.
+// Сохранения без изменений пропускаются, как и в SSE
+func (s *Server) StreamResults(req *scraperv1.StreamResultsRequest, out grpc.ServerStreamingServer[scraperv1.ResultEvent]) error {
+	if s.broker == nil {
+		return status.Error(codes.Unimplemented, "result streaming is disabled")
+	}
+
+	ctx := out.Context()
+	filter := stream.Filter{Kinds: []string{stream.KindResult}, Types: req.GetTypes(), Tags: req.GetTags()}
+	if project, ok := auth.ProjectFrom(ctx); ok {
+		filter.Project = &project
+	}
+	sub, backlog := s.broker.SubscribeFrom(filter, req.GetLastEventId())
+	defer s.broker.Unsubscribe(sub)
+
+	send := func(e stream.Event) error {
+		p, ok := e.Payload.(stream.ResultPayload)
+		if !ok || p.ChangeType == models.ChangeUnchanged {
+			return nil
+		}
+		event := &scraperv1.ResultEvent{Id: e.ID, ChangeType: string(p.ChangeType), ChangedFields: p.ChangedFields}
+		if p.Result != nil {
+			event.Result = resultToProto(p.Result)
+		}
+		return out.Send(event)
+	}
+
+	for _, e := range backlog {
+		if err := send(e); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Клиент отключился или сервер останавливается
+			return status.Error(codes.Unavailable, "stream closed")
+		case e, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+			if err := send(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) internal(op string, err error) error {
+	s.logger.Error("gRPC request failed", "op", op, "error", err)
+	return status.Error(codes.Internal, "internal error")
+}
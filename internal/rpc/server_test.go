@@ -0,0 +1,261 @@
+package rpc_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	scraperv1 "github.com/rx3lixir/kultscraper/api/kultscraper/v1"
+	"github.com/rx3lixir/kultscraper/internal/api"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/db"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/rx3lixir/kultscraper/internal/rpc"
+	"github.com/rx3lixir/kultscraper/internal/stream"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeRunner запоминает запущенные задачи
+type fakeRunner struct {
+	mu    sync.Mutex
+	tasks []config.ScraperTask
+}
+
+func (r *fakeRunner) Start(tasks []config.ScraperTask) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tasks = append(r.tasks, tasks...)
+	return "run-1", nil
+}
+
+func (r *fakeRunner) Running(string) bool                        { return false }
+func (r *fakeRunner) Progress(string) (models.RunProgress, bool) { return models.RunProgress{}, false }
+func (r *fakeRunner) Projects(string) ([]string, bool)           { return nil, false }
+
+// memoryTasks - хранилище задач в памяти
+type memoryTasks struct {
+	mu    sync.Mutex
+	tasks []config.ScraperTask
+}
+
+func (m *memoryTasks) List() ([]config.ScraperTask, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]config.ScraperTask(nil), m.tasks...), nil
+}
+
+func (m *memoryTasks) Add(task config.ScraperTask) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tasks = append(m.tasks, task)
+	return task.Fingerprint(), nil
+}
+
+func (m *memoryTasks) Delete(string) error { return api.ErrTaskNotFound }
+
+type env struct {
+	client  scraperv1.ScraperClient
+	runner  *fakeRunner
+	tasks   *memoryTasks
+	results *db.MemoryScraperRepo
+	broker  *stream.Broker
+}
+
+// newEnv запускает сервис на соединении в памяти и возвращает клиента к нему
+func newEnv(t *testing.T, keys config.APIKeys) *env {
+	t.Helper()
+	e := &env{
+		runner:  &fakeRunner{},
+		tasks:   &memoryTasks{},
+		results: db.NewMemoryScraperRepo(),
+		broker:  stream.NewBroker(),
+	}
+	service := rpc.NewServer(e.tasks, e.results, e.broker, e.runner)
+	service.Keys = keys
+
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := service.GRPCServer(ctx)
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		srv.Stop()
+	})
+	e.client = scraperv1.NewScraperClient(conn)
+	return e
+}
+
+func withKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+key)
+}
+
+func TestSubmitTask(t *testing.T) {
+	e := newEnv(t, config.APIKeys{})
+	task := &scraperv1.Task{
+		Url:       "https://example.com/afisha",
+		Type:      "event",
+		Name:      "Афиша",
+		Project:   "Кино",
+		Selectors: map[string]string{"title": "h1"},
+		Tags:      []string{"kino"},
+		MaxPages:  3,
+	}
+
+	resp, err := e.client.SubmitTask(context.Background(), &scraperv1.SubmitTaskRequest{Task: task})
+	if err != nil {
+		t.Fatalf("SubmitTask: %v", err)
+	}
+	if len(e.runner.tasks) != 1 {
+		t.Fatalf("started %d tasks, want 1", len(e.runner.tasks))
+	}
+	got := e.runner.tasks[0]
+	if got.URL != task.Url || got.Name != task.Name || got.Project != task.Project ||
+		got.Selectors["title"] != "h1" || len(got.Tags) != 1 || got.MaxPages != 3 {
+		t.Errorf("started task = %+v, want fields of %v", got, task)
+	}
+	if resp.RunId != "run-1" || resp.TaskId != got.Fingerprint() {
+		t.Errorf("response = %v, want run-1 and task fingerprint %s", resp, got.Fingerprint())
+	}
+
+	resp, err = e.client.SubmitTask(context.Background(), &scraperv1.SubmitTaskRequest{Task: task, Persist: true})
+	if err != nil {
+		t.Fatalf("SubmitTask(persist): %v", err)
+	}
+	if resp.RunId != "" || len(e.tasks.tasks) != 1 || len(e.runner.tasks) != 1 {
+		t.Errorf("persisted task: response %v, stored %d, started %d; want stored without a run", resp, len(e.tasks.tasks), len(e.runner.tasks))
+	}
+
+	_, err = e.client.SubmitTask(context.Background(), &scraperv1.SubmitTaskRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("SubmitTask without task: code %v, want %v", status.Code(err), codes.InvalidArgument)
+	}
+}
+
+func TestGetResult(t *testing.T) {
+	e := newEnv(t, config.APIKeys{})
+	expires := time.Date(2025, 3, 2, 19, 0, 0, 0, time.UTC)
+	result := &models.ScrapingResult{
+		Project:   "Кино",
+		URL:       "https://example.com/afisha",
+		Type:      "event",
+		Name:      "Афиша",
+		Data:      map[string]string{"title": "Премьера"},
+		Items:     []map[string]string{{"title": "Сеанс 1"}, {"title": "Сеанс 2"}},
+		Tags:      []string{"kino"},
+		ExpiresAt: &expires,
+		Metadata:  models.ScrapeMeta{RunID: "run-1"},
+	}
+	id, err := e.results.SaveResult(context.Background(), result)
+	if err != nil {
+		t.Fatalf("SaveResult: %v", err)
+	}
+
+	got, err := e.client.GetResult(context.Background(), &scraperv1.GetResultRequest{Id: id})
+	if err != nil {
+		t.Fatalf("GetResult: %v", err)
+	}
+	if got.Id != id || got.Project != "Кино" || got.Url != result.URL || got.Data["title"] != "Премьера" || got.RunId != "run-1" {
+		t.Errorf("GetResult = %v, want saved result %s", got, id)
+	}
+	if len(got.Items) != 2 || got.Items[1].Fields["title"] != "Сеанс 2" {
+		t.Errorf("items = %v, want 2 items", got.Items)
+	}
+	stored, _ := e.results.GetResultByID(context.Background(), id)
+	if !got.CreatedAt.AsTime().Equal(stored.CreatedAt) || !got.ExpiresAt.AsTime().Equal(expires) {
+		t.Errorf("created_at = %v, expires_at = %v, want %v and %v", got.CreatedAt.AsTime(), got.ExpiresAt.AsTime(), stored.CreatedAt, expires)
+	}
+
+	_, err = e.client.GetResult(context.Background(), &scraperv1.GetResultRequest{Id: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("GetResult(missing): code %v, want %v", status.Code(err), codes.NotFound)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	e := newEnv(t, config.APIKeys{Admin: "admin", Projects: map[string]string{"kino-key": "Кино"}})
+	id, err := e.results.SaveResult(context.Background(), &models.ScrapingResult{Project: "Театр", URL: "https://example.com/teatr", Type: "event"})
+	if err != nil {
+		t.Fatalf("SaveResult: %v", err)
+	}
+
+	if _, err := e.client.GetResult(context.Background(), &scraperv1.GetResultRequest{Id: id}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("GetResult without key: code %v, want %v", status.Code(err), codes.Unauthenticated)
+	}
+	if _, err := e.client.GetResult(withKey("admin"), &scraperv1.GetResultRequest{Id: id}); err != nil {
+		t.Errorf("GetResult with admin key: %v", err)
+	}
+	if _, err := e.client.GetResult(withKey("kino-key"), &scraperv1.GetResultRequest{Id: id}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetResult of another project: code %v, want %v", status.Code(err), codes.NotFound)
+	}
+
+	task := &scraperv1.Task{Url: "https://example.com/teatr", Type: "event", Project: "Театр", Selectors: map[string]string{"title": "h1"}}
+	if _, err := e.client.SubmitTask(withKey("kino-key"), &scraperv1.SubmitTaskRequest{Task: task}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("SubmitTask to another project: code %v, want %v", status.Code(err), codes.PermissionDenied)
+	}
+
+	stream, err := e.client.StreamResults(context.Background(), &scraperv1.StreamResultsRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("StreamResults without key: code %v, want %v", status.Code(err), codes.Unauthenticated)
+	}
+}
+
+func TestStreamResults(t *testing.T) {
+	e := newEnv(t, config.APIKeys{})
+	publish := func(name string, change models.ChangeType) {
+		e.broker.Publish(stream.Event{
+			Kind: stream.KindResult,
+			Type: "event",
+			Payload: stream.ResultPayload{
+				Result:        &models.ScrapingResult{URL: "https://example.com/" + name, Type: "event", Name: name},
+				ChangeType:    change,
+				ChangedFields: []string{"title"},
+			},
+		})
+	}
+	publish("before", models.ChangeCreated)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results, err := e.client.StreamResults(ctx, &scraperv1.StreamResultsRequest{Types: []string{"event"}})
+	if err != nil {
+		t.Fatalf("StreamResults: %v", err)
+	}
+
+	event, err := results.Recv()
+	if err != nil {
+		t.Fatalf("Recv backlog: %v", err)
+	}
+	if event.Result.GetName() != "before" || event.ChangeType != string(models.ChangeCreated) || event.Id == 0 {
+		t.Errorf("backlog event = %v, want created result before", event)
+	}
+
+	// Событие без изменений не передается, следующим приходит обновление
+	publish("unchanged", models.ChangeUnchanged)
+	publish("after", models.ChangeUpdated)
+	event, err = results.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if event.Result.GetName() != "after" || event.ChangeType != string(models.ChangeUpdated) || len(event.ChangedFields) != 1 {
+		t.Errorf("event = %v, want updated result after", event)
+	}
+}
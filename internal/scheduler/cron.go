@@ -0,0 +1,261 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSchedule - ошибка разбора cron-выражения
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// Schedule вычисляет время следующего запуска
+type Schedule interface {
+	// Next возвращает ближайшее время запуска строго после t
+	Next(t time.Time) time.Time
+}
+
+// Сокращенные формы cron-выражений
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field - допустимый диапазон поля cron-выражения и имена значений
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// cronSchedule - расписание из пяти полей: минута, час, день месяца, месяц, день недели.
+// Каждое поле хранится битовой маской допустимых значений
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool // Поле задано как "*", тогда условие по дням проверяется по другому полю
+}
+
+// everySchedule - запуск через фиксированный интервал ("@every 10m")
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Parse разбирает cron-выражение из пяти полей ("0 */6 * * *"),
+// сокращенную форму (@hourly, @daily, ...) или интервал "@every <duration>".
+// Время расписания вычисляется в часовом поясе переданного в Next времени
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("%w: %q: interval must be a duration of at least 1s", ErrInvalidSchedule, spec)
+		}
+		return everySchedule{interval: d}, nil
+	}
+	if expanded, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("%w: %q: expected 5 fields, got %d", ErrInvalidSchedule, spec, len(parts))
+	}
+
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseField(parts[0], minuteField); err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrInvalidSchedule, spec, err)
+	}
+	if s.hour, err = parseField(parts[1], hourField); err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrInvalidSchedule, spec, err)
+	}
+	if s.dom, err = parseField(parts[2], domField); err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrInvalidSchedule, spec, err)
+	}
+	if s.month, err = parseField(parts[3], monthField); err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrInvalidSchedule, spec, err)
+	}
+	if s.dow, err = parseField(parts[4], dowField); err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrInvalidSchedule, spec, err)
+	}
+	// Воскресенье можно записать как 0 или 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = parts[2] == "*" || parts[2] == "?"
+	s.dowAny = parts[4] == "*" || parts[4] == "?"
+
+	return s, nil
+}
+
+// parseField разбирает поле: список через запятую из "*", "N", "N-M" с необязательным шагом "/S"
+func parseField(expr string, f field) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepExpr); err != nil || step <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %q", f.name, stepExpr)
+			}
+		}
+
+		var lo, hi int
+		switch {
+		case rangeExpr == "*" || rangeExpr == "?":
+			lo, hi = f.min, f.max
+		case strings.Contains(rangeExpr, "-"):
+			from, to, _ := strings.Cut(rangeExpr, "-")
+			var err error
+			if lo, err = f.value(from); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(to); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s: invalid range %q", f.name, rangeExpr)
+			}
+		default:
+			var err error
+			if lo, err = f.value(rangeExpr); err != nil {
+				return 0, err
+			}
+			// "N/S" означает от N до конца диапазона с шагом S
+			hi = lo
+			if hasStep {
+				hi = f.max
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
+
+// value разбирает число или имя значения поля и проверяет диапазон
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid value %q", f.name, s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: value %d out of range %d-%d", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// allHours - маска поля часа без ограничений
+const allHours = 1<<24 - 1
+
+// Next возвращает ближайшую минуту после t, подходящую под все поля.
+// Расписание с ограниченным часом следует настенным часам, как cron: время, пропущенное
+// при переводе часов вперед, сдвигается на величину перевода, повторившееся при переводе
+// назад выполняется один раз, при первом наступлении. Расписание на каждый час идет
+// по реальному времени и выполняется в обоих повторах часа.
+// Если подходящего времени нет в ближайшие 5 лет (например, 30 февраля), возвращает нулевое время
+func (s cronSchedule) Next(t time.Time) time.Time {
+	if s.hour == allHours {
+		return s.next(t)
+	}
+
+	// Перебираем время настенных часов в UTC, где нет переводов, и переносим его в пояс t
+	loc := t.Location()
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	for {
+		if wall = s.next(wall); wall.IsZero() {
+			return wall
+		}
+		next := firstOccurrence(time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), 0, 0, loc))
+		if next.After(t) {
+			return next
+		}
+	}
+}
+
+// next ищет подходящую минуту после t, перебирая время в поясе t
+func (s cronSchedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	loc := t.Location()
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// firstOccurrence возвращает первое наступление времени t на настенных часах. time.Date
+// не гарантирует, какое из двух наступлений выберет в час, повторившийся при переводе назад
+func firstOccurrence(t time.Time) time.Time {
+	_, offset := t.Zone()
+	_, before := t.Add(-3 * time.Hour).Zone()
+	if before <= offset {
+		return t
+	}
+	earlier := t.Add(-time.Duration(before-offset) * time.Second)
+	if earlier.Hour() == t.Hour() && earlier.Minute() == t.Minute() && earlier.Day() == t.Day() {
+		return earlier
+	}
+	return t
+}
+
+// dayMatches проверяет день месяца и день недели. Как в cron, если оба поля
+// ограничены, достаточно совпадения любого из них
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next возвращает время через интервал после t
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
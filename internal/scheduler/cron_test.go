@@ -0,0 +1,146 @@
+package scheduler_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/rx3lixir/kultscraper/internal/scheduler"
+)
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"10-5 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"@every 500ms",
+		"@every soon",
+		"@weekday",
+	}
+	for _, spec := range tests {
+		t.Run(spec, func(t *testing.T) {
+			if _, err := scheduler.Parse(spec); !errors.Is(err, scheduler.ErrInvalidSchedule) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalidSchedule", spec, err)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	at := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+	// 16.10.2026 - пятница
+	base := at(2026, 10, 16, 10, 7)
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		// Шаги и диапазоны
+		{"every minute", "* * * * *", base, at(2026, 10, 16, 10, 8)},
+		{"strictly after", "7 10 * * *", base, at(2026, 10, 17, 10, 7)},
+		{"seconds truncated", "8 10 * * *", base.Add(30 * time.Second), at(2026, 10, 16, 10, 8)},
+		{"minute step", "*/15 * * * *", base, at(2026, 10, 16, 10, 15)},
+		{"hour step", "0 */6 * * *", base, at(2026, 10, 16, 12, 0)},
+		{"step from value", "10/20 * * * *", at(2026, 10, 16, 10, 50), at(2026, 10, 16, 11, 10)},
+		{"range", "0 9-17 * * *", at(2026, 10, 16, 17, 0), at(2026, 10, 17, 9, 0)},
+		{"range with step", "5-10/2 * * * *", at(2026, 10, 16, 10, 9), at(2026, 10, 16, 11, 5)},
+		{"range wraps to next month", "0 0 30-31 * *", at(2026, 10, 31, 0, 0), at(2026, 11, 30, 0, 0)},
+
+		// Списки и имена
+		{"list", "0 8,12,18 * * *", at(2026, 10, 16, 12, 0), at(2026, 10, 16, 18, 0)},
+		{"list of ranges", "0 1-2,22-23 * * *", at(2026, 10, 16, 3, 0), at(2026, 10, 16, 22, 0)},
+		{"month names", "0 0 1 jan,jul *", base, at(2027, 1, 1, 0, 0)},
+		{"weekday names", "0 9 * * MON-fri", base, at(2026, 10, 19, 9, 0)},
+		{"sunday as 7", "0 0 * * 7", base, at(2026, 10, 18, 0, 0)},
+		{"sunday as 0", "0 0 * * 0", base, at(2026, 10, 18, 0, 0)},
+
+		// День месяца и день недели: если ограничены оба, достаточно любого
+		{"dom or dow", "0 0 13 * fri", at(2026, 10, 1, 0, 0), at(2026, 10, 2, 0, 0)},
+		{"dom or dow, dom first", "0 0 13 * mon", at(2026, 10, 12, 1, 0), at(2026, 10, 13, 0, 0)},
+		{"dom only", "0 0 13 * *", base, at(2026, 11, 13, 0, 0)},
+		{"dow only", "0 0 * * fri", base, at(2026, 10, 23, 0, 0)},
+		{"dom with dow ?", "0 0 13 * ?", base, at(2026, 11, 13, 0, 0)},
+		{"leap day", "0 0 29 2 *", base, at(2028, 2, 29, 0, 0)},
+		{"impossible date", "0 0 30 2 *", base, time.Time{}},
+
+		// Сокращенные формы
+		{"hourly", "@hourly", base, at(2026, 10, 16, 11, 0)},
+		{"daily", "@daily", base, at(2026, 10, 17, 0, 0)},
+		{"weekly", "@weekly", base, at(2026, 10, 18, 0, 0)},
+		{"monthly", "@monthly", base, at(2026, 11, 1, 0, 0)},
+		{"yearly", "@yearly", base, at(2027, 1, 1, 0, 0)},
+		{"every", "@every 90m", base, base.Add(90 * time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := scheduler.Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.spec, err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Parse(%q).Next(%v) = %v, want %v", tt.spec, tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	cet := time.FixedZone("CET", 1*60*60)
+	cest := time.FixedZone("CEST", 2*60*60)
+	at := func(loc *time.Location, month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, loc)
+	}
+
+	// 29.03.2026 в 02:00 CET часы переводятся на 03:00 CEST,
+	// 25.10.2026 в 03:00 CEST - обратно на 02:00 CET
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"spring: skipped time shifts forward", "30 2 * * *", at(cet, 3, 29, 1, 0), at(cest, 3, 29, 3, 30)},
+		{"spring: next day back to normal", "30 2 * * *", at(cest, 3, 29, 3, 30), at(cest, 3, 30, 2, 30)},
+		{"spring: time after the gap", "30 3 * * *", at(cet, 3, 29, 1, 0), at(cest, 3, 29, 3, 30)},
+		{"spring: hourly skips missing hour", "0 * * * *", at(cet, 3, 29, 1, 0), at(cest, 3, 29, 3, 0)},
+		{"fall: repeated time runs at first occurrence", "30 2 * * *", at(cest, 10, 25, 1, 0), at(cest, 10, 25, 2, 30)},
+		{"fall: repeated time runs once", "30 2 * * *", at(cest, 10, 25, 2, 30), at(cet, 10, 26, 2, 30)},
+		{"fall: second occurrence is skipped", "45 2 * * *", at(cet, 10, 25, 2, 40), at(cet, 10, 26, 2, 45)},
+		{"fall: hourly runs in both occurrences", "0 * * * *", at(cest, 10, 25, 2, 0), at(cet, 10, 25, 2, 0)},
+		{"fall: steps run in both occurrences", "*/30 * * * *", at(cest, 10, 25, 2, 30), at(cet, 10, 25, 2, 0)},
+		{"fall: time after the repeat", "0 3 * * *", at(cest, 10, 25, 2, 30), at(cet, 10, 25, 3, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := scheduler.Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.spec, err)
+			}
+			if got := s.Next(tt.from.In(berlin)); !got.Equal(tt.want) {
+				t.Errorf("Parse(%q).Next(%v) = %v, want %v", tt.spec, tt.from.In(berlin), got, tt.want.In(berlin))
+			}
+		})
+	}
+}
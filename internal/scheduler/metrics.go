@@ -0,0 +1,16 @@
+package scheduler
+
+import "github.com/rx3lixir/kultscraper/internal/lib/metrics"
+
+// Метрики планировщика регистрируются в общем реестре при загрузке пакета
+var (
+	runsTotal = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_scheduler_runs_total",
+		"Number of scheduled runs by schedule and status (started, skipped).",
+		"schedule", "status",
+	)
+	runningJobs = metrics.DefaultRegistry.NewGaugeVec(
+		"kultscraper_scheduler_running_jobs",
+		"Number of scheduled jobs currently running.",
+	)
+)
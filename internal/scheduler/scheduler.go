@@ -0,0 +1,251 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Job - работа, запускаемая по расписанию. planned - плановое время запуска
+type Job func(ctx context.Context, planned time.Time)
+
+// Logger - интерфейс для логирования
+type Logger interface {
+	Info(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+	Debug(msg string, keyvals ...interface{})
+}
+
+// NoopLogger - реализация Logger, которая ничего не делает
+type NoopLogger struct{}
+
+func (n NoopLogger) Info(msg string, keyvals ...interface{})  {}
+func (n NoopLogger) Error(msg string, keyvals ...interface{}) {}
+func (n NoopLogger) Debug(msg string, keyvals ...interface{}) {}
+
+// entry - зарегистрированная работа с расписанием.
+// Поля, кроме running, защищены мьютексом планировщика
+type entry struct {
+	name     string
+	spec     string
+	schedule Schedule
+	job      Job
+	next     time.Time
+	running  atomic.Bool // Предыдущий запуск еще выполняется
+}
+
+// Scheduler запускает работы по cron-расписанию. Если предыдущий запуск работы
+// еще не завершился, очередной пропускается, чтобы медленный скраппинг не шел дважды.
+// Работы можно добавлять, заменять и удалять во время работы планировщика
+type Scheduler struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	running bool
+	wake    chan struct{} // Сигнал циклу Run пересчитать ближайший запуск
+	wg      sync.WaitGroup
+	logger  Logger
+}
+
+// New создает планировщик
+func New() *Scheduler {
+	return NewWithLogger(NoopLogger{})
+}
+
+// NewWithLogger создает планировщик с логгером
+func NewWithLogger(logger Logger) *Scheduler {
+	return &Scheduler{
+		entries: make(map[string]*entry),
+		wake:    make(chan struct{}, 1),
+		logger:  logger,
+	}
+}
+
+// Validate разбирает cron-выражение и проверяет, что по нему будет хотя бы один запуск
+func Validate(spec string) (Schedule, error) {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("%w: %q never fires", ErrInvalidSchedule, spec)
+	}
+	return schedule, nil
+}
+
+// Add регистрирует работу name с cron-выражением spec (см. Parse).
+// Работа с тем же именем заменяется, уже идущий ее запуск продолжает учитываться
+// защитой от наложения
+func (s *Scheduler) Add(name, spec string, job Job) error {
+	schedule, err := Validate(spec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok {
+		e = &entry{name: name}
+		s.entries[name] = e
+	}
+	if !ok || e.spec != spec {
+		e.next = time.Time{}
+		if s.running {
+			e.next = schedule.Next(time.Now())
+			s.logger.Info("Job scheduled", "job", name, "schedule", spec, "next_run", e.next)
+		}
+	}
+	e.spec, e.schedule, e.job = spec, schedule, job
+
+	s.notify()
+	return nil
+}
+
+// Remove удаляет работу, уже идущий запуск не прерывается
+func (s *Scheduler) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[name]; ok {
+		delete(s.entries, name)
+		s.logger.Info("Job removed", "job", name)
+		s.notify()
+	}
+}
+
+// Trigger запускает работу name как можно скорее, не дожидаясь расписания.
+// Защита от наложения действует и для такого запуска
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok {
+		return fmt.Errorf("job %q not found", name)
+	}
+	e.next = time.Now()
+	s.notify()
+	return nil
+}
+
+// Len возвращает число зарегистрированных работ
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// notify будит цикл Run, вызывается под мьютексом
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run запускает работы по расписанию до отмены контекста, затем ожидает
+// завершения уже запущенных работ. Работы получают тот же контекст
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return errors.New("scheduler already running")
+	}
+	s.running = true
+	now := time.Now()
+	for _, e := range s.entries {
+		// Работы, для которых вызван Trigger, уже имеют время запуска
+		if e.next.IsZero() {
+			e.next = e.schedule.Next(now)
+		}
+		s.logger.Info("Job scheduled", "job", e.name, "schedule", e.spec, "next_run", e.next)
+	}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+		s.wg.Wait()
+	}()
+
+	for {
+		s.mu.Lock()
+		next, ok := s.earliest()
+		s.mu.Unlock()
+
+		// Без работ ждем их добавления
+		var timer *time.Timer
+		var fire <-chan time.Time
+		if ok {
+			timer = time.NewTimer(time.Until(next))
+			fire = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-s.wake:
+		case <-fire:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			s.logger.Info("Scheduler stopping due to context cancellation")
+			return ctx.Err()
+		}
+
+		s.mu.Lock()
+		now := time.Now()
+		for _, e := range s.entries {
+			if e.next.IsZero() || e.next.After(now) {
+				continue
+			}
+			s.start(ctx, e, e.next)
+			e.next = e.schedule.Next(now)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// start запускает работу, если ее предыдущий запуск уже завершился. Вызывается под мьютексом
+func (s *Scheduler) start(ctx context.Context, e *entry, planned time.Time) {
+	if !e.running.CompareAndSwap(false, true) {
+		runsTotal.With(e.spec, "skipped").Inc()
+		s.logger.Info("Skipping scheduled run, previous run still in progress",
+			"job", e.name, "schedule", e.spec, "planned", planned)
+		return
+	}
+
+	runsTotal.With(e.spec, "started").Inc()
+	runningJobs.With().Inc()
+	s.logger.Debug("Starting scheduled run", "job", e.name, "schedule", e.spec, "planned", planned)
+
+	job := e.job
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer runningJobs.With().Dec()
+		defer e.running.Store(false)
+
+		job(ctx, planned)
+	}()
+}
+
+// earliest возвращает ближайшее время запуска среди работ. Вызывается под мьютексом
+func (s *Scheduler) earliest() (time.Time, bool) {
+	var next time.Time
+	for _, e := range s.entries {
+		if e.next.IsZero() {
+			continue
+		}
+		if next.IsZero() || e.next.Before(next) {
+			next = e.next
+		}
+	}
+	return next, !next.IsZero()
+}
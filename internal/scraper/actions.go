@@ -0,0 +1,177 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+)
+
+// actionTimeout - ожидание элемента действия, если Ms не задан
+const actionTimeout = 5 * time.Second
+
+// actionKeys - именованные клавиши для действия press, одиночный символ передается как есть
+var actionKeys = map[string]input.Key{
+	"enter":      input.Enter,
+	"escape":     input.Escape,
+	"tab":        input.Tab,
+	"space":      input.Space,
+	"backspace":  input.Backspace,
+	"arrowup":    input.ArrowUp,
+	"arrowdown":  input.ArrowDown,
+	"arrowleft":  input.ArrowLeft,
+	"arrowright": input.ArrowRight,
+	"pageup":     input.PageUp,
+	"pagedown":   input.PageDown,
+	"home":       input.Home,
+	"end":        input.End,
+}
+
+// runActions выполняет действия задачи по порядку: раскрывает вкладки, кнопки "показать еще" и т.п.
+func (r *RodScraper) runActions(ctx context.Context, page *rod.Page, task config.ScraperTask) error {
+	logger := r.loggerFrom(ctx)
+
+	for i, action := range task.Actions {
+		_, span := tracing.Start(ctx, "scrape.action",
+			tracing.String("type", action.Type),
+			tracing.String("selector", action.Selector),
+		)
+
+		err := r.runAction(ctx, page, task.SelectorType, action)
+		if err != nil && action.Optional && errs.Is(err, errs.CodeSelectorNotFound) {
+			logger.Debug("Optional action skipped", "index", i, "type", action.Type, "selector", action.Selector)
+			err = nil
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.End()
+			return errs.Wrap(errs.CodeAction, fmt.Sprintf("action %d (%s)", i, action.Type), err)
+		}
+		span.End()
+	}
+	return nil
+}
+
+// runAction выполняет одно действие
+func (r *RodScraper) runAction(ctx context.Context, page *rod.Page, selectorType string, action config.TaskAction) error {
+	timeout := actionTimeout
+	if action.Ms > 0 {
+		timeout = time.Duration(action.Ms) * time.Millisecond
+	}
+
+	actionType := strings.ToLower(action.Type)
+	if actionType == config.ActionWait && action.Selector == "" {
+		select {
+		case <-time.After(timeout):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	actionCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var el *rod.Element
+	if action.Selector != "" {
+		var err error
+		if el, err = waitElement(actionCtx, page, selectorType, action.Selector); err != nil {
+			return err
+		}
+	}
+
+	switch actionType {
+	case config.ActionWait:
+		return nil
+	case config.ActionClick:
+		if el == nil {
+			return fmt.Errorf("click requires a selector")
+		}
+		if err := el.Click(proto.InputMouseButtonLeft, 1); err != nil {
+			return err
+		}
+		return settle(actionCtx, page)
+	case config.ActionHover:
+		if el == nil {
+			return fmt.Errorf("hover requires a selector")
+		}
+		if err := el.Hover(); err != nil {
+			return err
+		}
+		return settle(actionCtx, page)
+	case config.ActionPress:
+		key, err := parseKey(action.Value)
+		if err != nil {
+			return err
+		}
+		if el != nil {
+			if err := el.Focus(); err != nil {
+				return err
+			}
+		}
+		if err := page.Context(actionCtx).Keyboard.Type(key); err != nil {
+			return err
+		}
+		return settle(actionCtx, page)
+	case config.ActionSelect:
+		if el == nil {
+			return fmt.Errorf("select requires a selector")
+		}
+		// Сначала ищем опцию по value, затем по тексту
+		err := el.Select([]string{fmt.Sprintf("[value=%q]", action.Value)}, true, rod.SelectorTypeCSSSector)
+		if err != nil {
+			err = el.Select([]string{action.Value}, true, rod.SelectorTypeText)
+		}
+		if err != nil {
+			return err
+		}
+		return settle(actionCtx, page)
+	}
+	return fmt.Errorf("unknown action type %q", action.Type)
+}
+
+// waitElement ждет появления элемента до истечения контекста
+func waitElement(ctx context.Context, page *rod.Page, selectorType, selector string) (*rod.Element, error) {
+	for {
+		elements, err := findElements(page.Context(ctx), selectorType, selector)
+		if err == nil && len(elements) > 0 {
+			return elements.First(), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errs.Wrap(errs.CodeSelectorNotFound, "wait element", fmt.Errorf("%s: %w", selector, ctx.Err()))
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// settle ждет, пока страница отреагирует на действие и DOM перестанет меняться
+func settle(ctx context.Context, page *rod.Page) error {
+	err := page.Context(ctx).WaitDOMStable(300*time.Millisecond, 0)
+	if err != nil && ctx.Err() != nil {
+		// Страница продолжает меняться (анимации, таймеры) - это не ошибка действия
+		return nil
+	}
+	return err
+}
+
+// parseKey возвращает клавишу по имени или одиночному символу
+func parseKey(name string) (input.Key, error) {
+	if key, ok := actionKeys[strings.ToLower(name)]; ok {
+		return key, nil
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		return input.Key(r), nil
+	}
+	return 0, fmt.Errorf("unknown key %q", name)
+}
@@ -0,0 +1,376 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+	applog "github.com/rx3lixir/kultscraper/internal/lib/logger"
+)
+
+const (
+	// maxHAREntries ограничивает число сетевых запросов в выдержке HAR
+	maxHAREntries = 50
+	// maxHARBodyBytes ограничивает тело ответа в полной записи HAR
+	maxHARBodyBytes = 1 << 20
+	// artifactTimeout - время на сбор артефактов со страницы после сбоя
+	artifactTimeout = 10 * time.Second
+)
+
+type runIDKey struct{}
+
+// withRunID сохраняет идентификатор запуска в контексте для путей артефактов
+func withRunID(ctx context.Context, runID string) context.Context {
+	if runID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+func runIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(runIDKey{}).(string)
+	return id
+}
+
+// harEntry - запись HAR 1.2 о сетевом запросе. В выдержке для артефактов сбоя
+// заполняются только метод, адрес и статус
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	ResourceType    string      `json:"_resourceType,omitempty"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+}
+
+type harRequest struct {
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Headers  []harHeader `json:"headers,omitempty"`
+	PostData *harContent `json:"postData,omitempty"`
+}
+
+type harResponse struct {
+	Status     int         `json:"status"`
+	StatusText string      `json:"statusText"`
+	MimeType   string      `json:"mimeType,omitempty"`
+	Headers    []harHeader `json:"headers,omitempty"`
+	Content    *harContent `json:"content,omitempty"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harContent struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// networkRecorder хранит сетевые запросы страницы для HAR: последние maxHAREntries
+// для артефактов сбоя или все запросы с заголовками и телами ответов в полном режиме
+type networkRecorder struct {
+	page    *rod.Page
+	full    bool
+	mu      sync.Mutex
+	entries map[proto.NetworkRequestID]*harEntry
+	order   []proto.NetworkRequestID
+	stop    context.CancelFunc
+	doneCh  chan struct{}
+}
+
+// startNetworkCapture подписывается на сетевые события страницы до вызова Stop
+func startNetworkCapture(ctx context.Context, page *rod.Page) *networkRecorder {
+	return startRecorder(ctx, page, false)
+}
+
+// startHARCapture записывает все запросы страницы с заголовками и телами ответов
+// документов, XHR и fetch, в которых обычно приходят данные страницы
+func startHARCapture(ctx context.Context, page *rod.Page) *networkRecorder {
+	_ = proto.NetworkEnable{}.Call(page)
+	return startRecorder(ctx, page, true)
+}
+
+func startRecorder(ctx context.Context, page *rod.Page, full bool) *networkRecorder {
+	captureCtx, cancel := context.WithCancel(ctx)
+	n := &networkRecorder{
+		page:    page.Context(captureCtx),
+		full:    full,
+		entries: make(map[proto.NetworkRequestID]*harEntry),
+		stop:    cancel,
+		doneCh:  make(chan struct{}),
+	}
+
+	wait := n.page.EachEvent(
+		func(e *proto.NetworkRequestWillBeSent) {
+			n.addRequest(e)
+		},
+		func(e *proto.NetworkResponseReceived) {
+			n.addResponse(e)
+		},
+		func(e *proto.NetworkLoadingFinished) {
+			n.addBody(e)
+		},
+	)
+
+	go func() {
+		defer close(n.doneCh)
+		wait()
+	}()
+
+	return n
+}
+
+// Stop прекращает запись сетевых событий
+func (n *networkRecorder) Stop() {
+	n.stop()
+	<-n.doneCh
+}
+
+func (n *networkRecorder) addRequest(e *proto.NetworkRequestWillBeSent) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.full && len(n.order) >= maxHAREntries {
+		delete(n.entries, n.order[0])
+		n.order = n.order[1:]
+	}
+	entry := &harEntry{
+		StartedDateTime: e.WallTime.Time(),
+		Request:         harRequest{Method: e.Request.Method, URL: e.Request.URL},
+	}
+	if n.full {
+		entry.ResourceType = string(e.Type)
+		entry.Request.Headers = harHeaders(e.Request.Headers)
+		if e.Request.HasPostData {
+			entry.Request.PostData = &harContent{MimeType: e.Request.Headers["Content-Type"].Str(), Text: e.Request.PostData}
+		}
+	}
+	// Редирект приходит с тем же RequestID: запись заменяется, порядок не меняется
+	if _, ok := n.entries[e.RequestID]; !ok {
+		n.order = append(n.order, e.RequestID)
+	}
+	n.entries[e.RequestID] = entry
+}
+
+func (n *networkRecorder) addResponse(e *proto.NetworkResponseReceived) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	entry, ok := n.entries[e.RequestID]
+	if !ok || e.Response == nil {
+		return
+	}
+	entry.Response = harResponse{
+		Status:     e.Response.Status,
+		StatusText: e.Response.StatusText,
+		MimeType:   e.Response.MIMEType,
+	}
+	if n.full {
+		entry.Response.Headers = harHeaders(e.Response.Headers)
+		entry.Response.Content = &harContent{MimeType: e.Response.MIMEType}
+	}
+}
+
+// addBody сохраняет тело ответа документа, XHR или fetch в полном режиме
+func (n *networkRecorder) addBody(e *proto.NetworkLoadingFinished) {
+	if !n.full || e.EncodedDataLength > maxHARBodyBytes {
+		return
+	}
+	n.mu.Lock()
+	entry, ok := n.entries[e.RequestID]
+	n.mu.Unlock()
+	if !ok || entry.Response.Content == nil {
+		return
+	}
+	switch proto.NetworkResourceType(entry.ResourceType) {
+	case proto.NetworkResourceTypeDocument, proto.NetworkResourceTypeXHR, proto.NetworkResourceTypeFetch:
+	default:
+		return
+	}
+
+	body, err := proto.NetworkGetResponseBody{RequestID: e.RequestID}.Call(n.page)
+	if err != nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	entry.Response.Content.Text = body.Body
+	if body.Base64Encoded {
+		entry.Response.Content.Encoding = "base64"
+	}
+}
+
+// harHeaders переводит заголовки CDP в список HAR
+func harHeaders(headers proto.NetworkHeaders) []harHeader {
+	out := make([]harHeader, 0, len(headers))
+	for name, value := range headers {
+		out = append(out, harHeader{Name: name, Value: value.Str()})
+	}
+	return out
+}
+
+// har возвращает выдержку в формате HAR 1.2
+func (n *networkRecorder) har() map[string]any {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	entries := make([]harEntry, 0, len(n.order))
+	for _, id := range n.order {
+		entries = append(entries, *n.entries[id])
+	}
+
+	return map[string]any{
+		"log": map[string]any{
+			"version": "1.2",
+			"creator": map[string]string{"name": "kultscraper", "version": "1"},
+			"entries": entries,
+		},
+	}
+}
+
+// Причины сохранения артефактов в DebugDir
+const (
+	debugEmpty      = "empty"      // Селекторы не извлекли данных
+	debugNavigation = "navigation" // Страница не загрузилась
+)
+
+// saveFailureBundle сохраняет артефакты неудавшейся задачи и прикрепляет путь к каталогу
+// к ошибке. Страница, которая не загрузилась, при заданном DebugDir сохраняется туда,
+// остальные сбои - в ArtifactDir
+func (r *RodScraper) saveFailureBundle(ctx context.Context, page *rod.Page, task config.ScraperTask,
+	console *consoleCollector, network *networkRecorder, cause error) error {
+	reason := ""
+	if code := errs.CodeOf(cause); code == errs.CodeNavigation || code == errs.CodeTimeout {
+		reason = debugNavigation
+	}
+	dir := r.bundleDir(ctx, task, reason)
+	if !r.saveBundle(ctx, dir, page, task, console, network, cause) {
+		return cause
+	}
+	return errs.WithArtifacts(cause, dir)
+}
+
+// bundleDir возвращает каталог артефактов: DebugDir/<имя задачи>/<время>-<reason> для причин
+// отладки, иначе ArtifactDir/<run_id>/<exec_id>. Пустая строка - сбор выключен
+func (r *RodScraper) bundleDir(ctx context.Context, task config.ScraperTask, reason string) string {
+	if r.DebugDir != "" && reason != "" {
+		return filepath.Join(r.DebugDir, debugDirName(task), time.Now().Format("20060102T150405.000")+"-"+reason)
+	}
+	if r.ArtifactDir == "" {
+		return ""
+	}
+
+	runID := runIDFrom(ctx)
+	if runID == "" {
+		runID = "norun"
+	}
+	execID := applog.ExecutionID(ctx)
+	if execID == "" {
+		execID = task.Fingerprint()
+	}
+	return filepath.Join(r.ArtifactDir, runID, execID)
+}
+
+// saveBundle сохраняет в dir снимок и HTML страницы, консоль, сетевые запросы и ошибку cause,
+// если она есть. Возвращает false, если каталог не создан. Ошибки сбора отдельных артефактов только логируются
+func (r *RodScraper) saveBundle(ctx context.Context, dir string, page *rod.Page, task config.ScraperTask,
+	console *consoleCollector, network *networkRecorder, cause error) bool {
+	if dir == "" {
+		return false
+	}
+	logger := r.loggerFrom(ctx)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		logger.Error("Failed to create artifact directory", "dir", dir, "error", err)
+		return false
+	}
+
+	write := func(name string, data []byte) {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			logger.Warn("Failed to write artifact", "file", name, "error", err)
+		}
+	}
+	writeJSON := func(name string, v any) {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			logger.Warn("Failed to encode artifact", "file", name, "error", err)
+			return
+		}
+		write(name, data)
+	}
+
+	if cause != nil {
+		write("error.txt", []byte(errorChain(task, cause)))
+	}
+
+	// Контекст задачи мог истечь, поэтому артефакты собираются с отдельным таймаутом
+	captureCtx, cancel := context.WithTimeout(context.Background(), artifactTimeout)
+	defer cancel()
+
+	if page != nil {
+		if img, err := page.Context(captureCtx).Screenshot(true, nil); err != nil {
+			logger.Warn("Failed to capture screenshot", "error", err)
+		} else {
+			write("screenshot.png", img)
+		}
+
+		if html, err := page.Context(captureCtx).HTML(); err != nil {
+			logger.Warn("Failed to capture page HTML", "error", err)
+		} else {
+			write("page.html", []byte(html))
+		}
+	}
+
+	if console != nil {
+		if debug := console.Stop(); debug != nil {
+			writeJSON("console.json", debug)
+		}
+	}
+
+	if network != nil {
+		network.Stop()
+		writeJSON("network.har", network.har())
+	}
+
+	logger.Info("Saved artifacts", "url", task.URL, "dir", dir)
+	return true
+}
+
+// debugDirName возвращает имя задачи, пригодное для каталога, или ее отпечаток для задач без имени
+func debugDirName(task config.ScraperTask) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(task.Name))
+	name = strings.Trim(name, ".")
+	if name == "" {
+		return task.Fingerprint()
+	}
+	return name
+}
+
+// errorChain форматирует цепочку ошибок для error.txt
+func errorChain(task config.ScraperTask, err error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "url: %s\ntype: %s\nname: %s\ncode: %s\ntime: %s\n\n",
+		task.URL, task.Type, task.Name, errs.CodeOf(err), time.Now().Format(time.RFC3339))
+
+	for depth := 0; err != nil; depth++ {
+		fmt.Fprintf(&b, "%s%v\n", strings.Repeat("  ", depth), err)
+		err = errors.Unwrap(err)
+	}
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// ErrBlockPage - сайт показал страницу блокировки вместо содержимого
+var ErrBlockPage = errors.New("block page")
+
+// blockPageScript ищет страницу блокировки, отданную со статусом 200: короткую страницу
+// с типичными фразами антибот-защит (Cloudflare, Imperva, Akamai) и отечественных WAF
+const blockPageScript = `() => {
+	const text = ((document.title || "") + "\n" + (document.body ? document.body.innerText : "")).toLowerCase();
+	if (text.length > 3000) {
+		return false;
+	}
+	return [
+		"access denied",
+		"you have been blocked",
+		"request blocked",
+		"request unsuccessful. incapsula",
+		"pardon our interruption",
+		"unusual traffic",
+		"too many requests",
+		"доступ запрещен",
+		"доступ ограничен",
+		"ваш запрос заблокирован",
+	].some((marker) => text.includes(marker));
+}`
+
+// isBlockPage сообщает, что вместо содержимого сайт показал страницу блокировки
+func (r *RodScraper) isBlockPage(ctx context.Context, page *rod.Page) bool {
+	checkCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := page.Context(checkCtx).Eval(blockPageScript)
+	if err != nil {
+		r.loggerFrom(ctx).Debug("Failed to check page for block markers", "error", err)
+		return false
+	}
+	return res.Value.Bool()
+}
@@ -0,0 +1,197 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+
+	"github.com/charmbracelet/log"
+	"github.com/go-rod/rod"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+)
+
+// browserInstance - браузер из набора скрапера со своим пулом страниц
+type browserInstance struct {
+	id      int
+	browser *rod.Browser
+	pages   *pagePool
+	active  int // Страниц браузера, занятых задачами
+}
+
+// NewRodScraper создает новый скрапер на основе Rod
+func NewRodScraper(browser *rod.Browser, logger log.Logger, maxPages int) *RodScraper {
+	return NewRodScraperWithBrowsers([]*rod.Browser{browser}, logger, maxPages)
+}
+
+// NewRodScraperWithBrowsers создает скрапер над набором браузеров. Страницы задач
+// открываются в наименее загруженном браузере, maxPages ограничивает их общее число
+func NewRodScraperWithBrowsers(browsers []*rod.Browser, logger log.Logger, maxPages int) *RodScraper {
+	if maxPages <= 0 {
+		maxPages = 10 // Значение по умолчанию
+	}
+
+	scraper := &RodScraper{
+		Logger:         logger,
+		CaptureConsole: true,
+		Stealth:        true,
+		sessions:       newSessionStore(),
+		slots:          make(chan struct{}, maxPages),
+	}
+	for id, browser := range browsers {
+		scraper.browsers = append(scraper.browsers, &browserInstance{
+			id:      id,
+			browser: browser,
+			pages:   scraper.newPagePool(browser),
+		})
+	}
+
+	return scraper
+}
+
+// newPagePool создает пул страниц браузера
+func (r *RodScraper) newPagePool(browser *rod.Browser) *pagePool {
+	return newPagePool(browser, cap(r.slots), r.Logger)
+}
+
+// Browsers возвращает число браузеров набора
+func (r *RodScraper) Browsers() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.browsers)
+}
+
+// browserAt возвращает текущий браузер набора с номером id
+func (r *RodScraper) browserAt(id int) *rod.Browser {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.browsers[id].browser
+}
+
+// ActivePages возвращает число страниц, занятых задачами
+func (r *RodScraper) ActivePages() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.activePages
+}
+
+// activePagesOf возвращает число занятых страниц браузера id
+func (r *RodScraper) activePagesOf(id int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.browsers[id].active
+}
+
+// ReplaceBrowser заменяет браузер id, если у него нет активных страниц, и закрывает старый.
+// Возвращает false, если замена отложена из-за активных страниц
+func (r *RodScraper) ReplaceBrowser(id int, browser *rod.Browser) bool {
+	r.mu.Lock()
+	if r.browsers[id].active > 0 {
+		r.mu.Unlock()
+		return false
+	}
+	r.mu.Unlock()
+
+	r.swapBrowser(id, browser)
+	return true
+}
+
+// swapBrowser заменяет браузер id независимо от активных страниц и закрывает старый.
+// Занятые страницы старого браузера закрываются при освобождении
+func (r *RodScraper) swapBrowser(id int, browser *rod.Browser) {
+	r.mu.Lock()
+	inst := r.browsers[id]
+	old, oldPages := inst.browser, inst.pages
+	inst.browser = browser
+	inst.pages = r.newPagePool(browser)
+	r.mu.Unlock()
+
+	oldPages.close()
+	if err := old.Close(); err != nil {
+		r.Logger.Warn("Failed to close old browser", "browser", id, "error", err)
+	}
+}
+
+// reserve ждет свободного места под страницу и занимает его в наименее загруженном браузере
+func (r *RodScraper) reserve(ctx context.Context) (*browserInstance, error) {
+	select {
+	case r.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, errs.Wrap(errs.CodeTimeout, "wait for page", ctx.Err())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var inst *browserInstance
+	for _, b := range r.browsers {
+		if inst == nil || b.active < inst.active {
+			inst = b
+		}
+	}
+	if inst == nil {
+		<-r.slots
+		return nil, errors.New("no browsers available")
+	}
+
+	inst.active++
+	r.activePages++
+	return inst, nil
+}
+
+// unreserve освобождает место, занятое reserve
+func (r *RodScraper) unreserve(inst *browserInstance) {
+	r.mu.Lock()
+	inst.active--
+	r.activePages--
+	r.mu.Unlock()
+	<-r.slots
+}
+
+// acquirePage получает страницу из пула или, если задан прокси, в отдельном контексте браузера.
+// release возвращает страницу в пул или закрывает контекст, повторные вызовы ничего не делают
+func (r *RodScraper) acquirePage(ctx context.Context, proxyURL *url.URL) (*rod.Page, func(), error) {
+	if proxyURL != nil {
+		return r.getProxyPage(ctx, proxyURL)
+	}
+	return r.getPage(ctx)
+}
+
+// getPage получает страницу из пула наименее загруженного браузера или создает новую
+func (r *RodScraper) getPage(ctx context.Context) (*rod.Page, func(), error) {
+	inst, err := r.reserve(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.Lock()
+	pool := inst.pages
+	r.mu.Unlock()
+
+	page, err := pool.get(ctx)
+	if err != nil {
+		r.unreserve(inst)
+		return nil, nil, err
+	}
+	return page, sync.OnceFunc(func() {
+		pool.put(page, r.MaxPageUses)
+		r.unreserve(inst)
+	}), nil
+}
+
+// Close закрывает свободные страницы и все браузеры скрапера
+func (r *RodScraper) Close() error {
+	r.mu.Lock()
+	browsers := make([]*rod.Browser, 0, len(r.browsers))
+	for _, inst := range r.browsers {
+		inst.pages.close()
+		browsers = append(browsers, inst.browser)
+	}
+	r.mu.Unlock()
+
+	var errs []error
+	for _, b := range browsers {
+		errs = append(errs, b.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,19 @@
+package scraper
+
+import "context"
+
+// RequestBudget учитывает запрос страницы в дневном лимите запросов к ее домену.
+// Движки вызывают Consume перед каждой загрузкой: страницы задачи, следующих страниц
+// списка, страниц обхода ссылок и каждой повторной попытки. Ошибка отменяет запрос,
+// исчерпанный лимит - ошибка с кодом CodeBudgetExhausted
+type RequestBudget interface {
+	Consume(ctx context.Context, rawURL string) error
+}
+
+// consumeBudget учитывает запрос rawURL в budget, nil - без лимитов
+func consumeBudget(ctx context.Context, budget RequestBudget, rawURL string) error {
+	if budget == nil {
+		return nil
+	}
+	return budget.Consume(ctx, rawURL)
+}
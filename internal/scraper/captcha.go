@@ -0,0 +1,134 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// Виды капчи
+const (
+	CaptchaCloudflare = "cloudflare"
+	CaptchaHCaptcha   = "hcaptcha"
+	CaptchaReCAPTCHA  = "recaptcha"
+)
+
+// ErrCaptcha - страница закрыта капчей, которую не удалось пройти
+var ErrCaptcha = errors.New("captcha challenge")
+
+// Captcha - капча, найденная на странице
+type Captcha struct {
+	Kind    string // CaptchaCloudflare, CaptchaHCaptcha или CaptchaReCAPTCHA
+	SiteKey string // data-sitekey виджета, нужен сервисам решения
+	URL     string // Адрес страницы с капчей
+	Wall    bool   // Капча закрывает страницу, а не встроена в нее, например в форму
+}
+
+// CaptchaSolver проходит капчу на странице: через сервис решения или ручную очередь.
+// После успешного Solve страница должна показывать содержимое сайта, иначе задача
+// завершается ошибкой с кодом captcha
+type CaptchaSolver interface {
+	Solve(ctx context.Context, page *rod.Page, captcha Captcha) error
+}
+
+// captchaScript ищет признаки капчи. Страница проверки Cloudflare всегда закрывает сайт,
+// виджеты hCaptcha и reCAPTCHA считаются стеной, только если кроме них на странице почти нет текста
+const captchaScript = `() => {
+	const has = (selector) => document.querySelector(selector) !== null;
+	const sitekey = (selector) => {
+		const el = document.querySelector(selector);
+		return el ? el.getAttribute("data-sitekey") || "" : "";
+	};
+	const short = (document.body ? document.body.innerText : "").trim().length < 1500;
+
+	if (/^(Just a moment|Attention Required)/.test(document.title) || has("#challenge-form") ||
+		has("#cf-challenge-running") || has("script[src*='/cdn-cgi/challenge-platform/']")) {
+		return {kind: "cloudflare", sitekey: sitekey(".cf-turnstile"), wall: true};
+	}
+	if (has(".h-captcha") || has("iframe[src*='hcaptcha.com']")) {
+		return {kind: "hcaptcha", sitekey: sitekey(".h-captcha"), wall: short};
+	}
+	if (has(".g-recaptcha") || has("iframe[src*='/recaptcha/']")) {
+		return {kind: "recaptcha", sitekey: sitekey(".g-recaptcha"), wall: short};
+	}
+	return null;
+}`
+
+// detectCaptcha возвращает капчу на странице или nil
+func detectCaptcha(ctx context.Context, page *rod.Page) (*Captcha, error) {
+	res, err := page.Context(ctx).Eval(captchaScript)
+	if err != nil {
+		return nil, err
+	}
+	if res.Value.Nil() {
+		return nil, nil
+	}
+	info, _ := page.Context(ctx).Info()
+	captcha := &Captcha{
+		Kind:    res.Value.Get("kind").Str(),
+		SiteKey: res.Value.Get("sitekey").Str(),
+		Wall:    res.Value.Get("wall").Bool(),
+	}
+	if info != nil {
+		captcha.URL = info.URL
+	}
+	return captcha, nil
+}
+
+// checkCaptcha проверяет страницу после загрузки. Капча, встроенная в страницу, только
+// отмечается в meta. Стена капчи передается Captcha, а без него или при неудаче решения
+// задача завершается ошибкой с кодом captcha, чтобы не сохранять страницу проверки как результат
+func (r *RodScraper) checkCaptcha(ctx context.Context, page *rod.Page, task config.ScraperTask, meta *models.ScrapeMeta) error {
+	logger := r.loggerFrom(ctx)
+
+	detectCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	captcha, err := detectCaptcha(detectCtx, page)
+	cancel()
+	if err != nil {
+		logger.Debug("Failed to check page for captcha", "error", err)
+		return nil
+	}
+	if captcha == nil {
+		return nil
+	}
+	meta.Captcha = captcha.Kind
+	if !captcha.Wall {
+		captchaDetections.With(captcha.Kind, "embedded").Inc()
+		return nil
+	}
+
+	if r.Captcha == nil {
+		captchaDetections.With(captcha.Kind, "unsolved").Inc()
+		logger.Warn("Page is behind a captcha", "url", task.URL, "captcha", captcha.Kind)
+		return errs.Wrap(errs.CodeCaptcha, captcha.Kind, ErrCaptcha)
+	}
+
+	if err := r.Captcha.Solve(ctx, page, *captcha); err != nil {
+		captchaDetections.With(captcha.Kind, "unsolved").Inc()
+		logger.Warn("Failed to solve captcha", "url", task.URL, "captcha", captcha.Kind, "error", err)
+		return errs.Wrap(errs.CodeCaptcha, captcha.Kind, errors.Join(ErrCaptcha, err))
+	}
+	if err := page.Context(ctx).WaitLoad(); err != nil {
+		return navigationError("wait load", err)
+	}
+
+	detectCtx, cancel = context.WithTimeout(ctx, 2*time.Second)
+	again, err := detectCaptcha(detectCtx, page)
+	cancel()
+	if err == nil && again != nil && again.Wall {
+		captchaDetections.With(captcha.Kind, "unsolved").Inc()
+		logger.Warn("Captcha is still shown after solving", "url", task.URL, "captcha", captcha.Kind)
+		return errs.Wrap(errs.CodeCaptcha, captcha.Kind, ErrCaptcha)
+	}
+
+	// Статус и адрес страницы проверки заменяются данными страницы сайта
+	r.fillPageInfo(ctx, page, meta)
+	captchaDetections.With(captcha.Kind, "solved").Inc()
+	logger.Info("Captcha solved", "url", task.URL, "captcha", captcha.Kind)
+	return nil
+}
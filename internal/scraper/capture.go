@@ -0,0 +1,134 @@
+package scraper
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	applog "github.com/rx3lixir/kultscraper/internal/lib/logger"
+)
+
+// captureTimeout - время на снимок страницы
+const captureTimeout = 15 * time.Second
+
+// capturePath возвращает путь <dir>/<run_id>/<exec_id><ext> и создает каталог запуска
+func capturePath(ctx context.Context, dir string, task config.ScraperTask, ext string) (string, error) {
+	runID := runIDFrom(ctx)
+	if runID == "" {
+		runID = "norun"
+	}
+	name := applog.ExecutionID(ctx)
+	if name == "" {
+		name = task.Fingerprint() + "-" + time.Now().Format("20060102T150405")
+	}
+
+	runDir := filepath.Join(dir, runID)
+	if err := os.MkdirAll(runDir, 0o755); err != nil {
+		return "", err
+	}
+	return filepath.Join(runDir, name+ext), nil
+}
+
+// captureScreenshot сохраняет снимок всей страницы в ScreenshotDir и возвращает путь к файлу
+func (r *RodScraper) captureScreenshot(ctx context.Context, page *rod.Page, task config.ScraperTask) (string, error) {
+	path, err := capturePath(ctx, r.ScreenshotDir, task, ".png")
+	if err != nil {
+		return "", err
+	}
+
+	shotCtx, cancel := context.WithTimeout(ctx, captureTimeout)
+	defer cancel()
+
+	img, err := page.Context(shotCtx).Screenshot(true, &proto.PageCaptureScreenshot{
+		Format: proto.PageCaptureScreenshotFormatPng,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, img, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// capturePDF печатает страницу в PDF в PDFDir и возвращает путь к файлу.
+// PDF сохраняет анонс события в читаемом виде, даже если страница потом исчезнет
+func (r *RodScraper) capturePDF(ctx context.Context, page *rod.Page, task config.ScraperTask) (string, error) {
+	path, err := capturePath(ctx, r.PDFDir, task, ".pdf")
+	if err != nil {
+		return "", err
+	}
+
+	pdfCtx, cancel := context.WithTimeout(ctx, captureTimeout)
+	defer cancel()
+
+	stream, err := page.Context(pdfCtx).PDF(&proto.PagePrintToPDF{PrintBackground: true})
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, stream); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// saveHAR записывает сетевые запросы страницы в path. Запись нужна и после сбоя задачи,
+// поэтому ошибка только логируется
+func (r *RodScraper) saveHAR(ctx context.Context, har *networkRecorder, path string) {
+	data, err := json.Marshal(har.har())
+	if err == nil {
+		err = os.WriteFile(path, data, 0o644)
+	}
+	if err != nil {
+		r.loggerFrom(ctx).Warn("Failed to save HAR", "path", path, "error", err)
+		return
+	}
+	r.loggerFrom(ctx).Debug("Saved HAR", "path", path)
+}
+
+// captureSnapshot сохраняет отрисованный HTML страницы в SnapshotDir в gzip и возвращает путь к файлу.
+// По снимку можно повторно извлечь поля без повторного скраппинга
+func (r *RodScraper) captureSnapshot(ctx context.Context, page *rod.Page, task config.ScraperTask) (string, error) {
+	path, err := capturePath(ctx, r.SnapshotDir, task, ".html.gz")
+	if err != nil {
+		return "", err
+	}
+
+	htmlCtx, cancel := context.WithTimeout(ctx, captureTimeout)
+	defer cancel()
+
+	html, err := page.Context(htmlCtx).HTML()
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	zw := gzip.NewWriter(f)
+	zw.Name = task.URL
+	if _, err := zw.Write([]byte(html)); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
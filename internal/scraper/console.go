@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// maxConsoleEntries ограничивает число сохраняемых сообщений консоли и ошибок страницы
+const maxConsoleEntries = 100
+
+// consoleCollector собирает сообщения консоли и необработанные ошибки страницы
+type consoleCollector struct {
+	mu     sync.Mutex
+	debug  models.DebugInfo
+	stop   context.CancelFunc
+	doneCh chan struct{}
+}
+
+// startConsoleCapture подписывается на события консоли страницы до вызова Stop
+func startConsoleCapture(ctx context.Context, page *rod.Page) *consoleCollector {
+	captureCtx, cancel := context.WithCancel(ctx)
+	c := &consoleCollector{stop: cancel, doneCh: make(chan struct{})}
+
+	wait := page.Context(captureCtx).EachEvent(
+		func(e *proto.RuntimeConsoleAPICalled) {
+			c.addConsole(e)
+		},
+		func(e *proto.RuntimeExceptionThrown) {
+			c.addError(e)
+		},
+	)
+
+	go func() {
+		defer close(c.doneCh)
+		wait()
+	}()
+
+	return c
+}
+
+// Stop прекращает сбор и возвращает собранную информацию или nil, если ничего не собрано
+func (c *consoleCollector) Stop() *models.DebugInfo {
+	c.stop()
+	<-c.doneCh
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.debug.Console) == 0 && len(c.debug.PageErrors) == 0 {
+		return nil
+	}
+	debug := c.debug
+	return &debug
+}
+
+func (c *consoleCollector) addConsole(e *proto.RuntimeConsoleAPICalled) {
+	parts := make([]string, 0, len(e.Args))
+	for _, arg := range e.Args {
+		parts = append(parts, remoteObjectText(arg))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.debug.Console) >= maxConsoleEntries {
+		return
+	}
+	c.debug.Console = append(c.debug.Console, models.ConsoleMessage{
+		Level: string(e.Type),
+		Text:  strings.Join(parts, " "),
+		Time:  time.UnixMilli(int64(e.Timestamp)),
+	})
+}
+
+func (c *consoleCollector) addError(e *proto.RuntimeExceptionThrown) {
+	if e.ExceptionDetails == nil {
+		return
+	}
+
+	text := e.ExceptionDetails.Text
+	if e.ExceptionDetails.Exception != nil && e.ExceptionDetails.Exception.Description != "" {
+		text = e.ExceptionDetails.Exception.Description
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.debug.PageErrors) >= maxConsoleEntries {
+		return
+	}
+	c.debug.PageErrors = append(c.debug.PageErrors, text)
+}
+
+// remoteObjectText возвращает текстовое представление аргумента console.*
+func remoteObjectText(obj *proto.RuntimeRemoteObject) string {
+	if obj.Description != "" {
+		return obj.Description
+	}
+	if !obj.Value.Nil() {
+		return obj.Value.String()
+	}
+	return string(obj.Type)
+}
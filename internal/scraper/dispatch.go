@@ -0,0 +1,139 @@
+package scraper
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+const (
+	// EngineHTTP - встроенный движок без браузера HTTPScraper, плагин с этим именем его заменяет
+	EngineHTTP = "http"
+	// EngineAuto - сначала EngineHTTP, при пустом результате или ошибке - EngineRod
+	EngineAuto = "auto"
+)
+
+// ErrUnknownEngine - движок задачи не подключен
+var ErrUnknownEngine = errors.New("unknown scraper engine")
+
+// Dispatcher направляет задачи движкам по полю Engine задачи.
+// Задачи без Engine выполняет движок Default
+type Dispatcher struct {
+	Default string
+	Engines map[string]Scraper
+	Logger  log.Logger
+	Cache   *HTTPCache // Пропуск неизмененных страниц для EngineHTTP, nil - выключен. Задачи rod кэш не проверяют
+}
+
+// NewDispatcher создает диспетчер движков. Движки закрывает создавший их код
+func NewDispatcher(defaultEngine string, engines map[string]Scraper, logger log.Logger) *Dispatcher {
+	return &Dispatcher{Default: cmp.Or(defaultEngine, EngineRod), Engines: engines, Logger: logger}
+}
+
+// Scrape выполняет задачу движком из task.Engine
+func (d *Dispatcher) Scrape(ctx context.Context, task config.ScraperTask) (*models.ScrapingResult, error) {
+	name := cmp.Or(task.Engine, d.Default)
+	if name == EngineAuto {
+		return d.scrapeAuto(ctx, task)
+	}
+	engine, err := d.engine(name)
+	if err != nil {
+		return nil, err
+	}
+	if name == EngineHTTP && d.Cache != nil {
+		return d.Cache.Scrape(ctx, engine, task)
+	}
+	return engine.Scrape(ctx, task)
+}
+
+// scrapeAuto пробует движок без браузера и переходит на rod, если тот не извлек данных.
+// Без подключенного EngineHTTP задача сразу выполняется rod
+func (d *Dispatcher) scrapeAuto(ctx context.Context, task config.ScraperTask) (*models.ScrapingResult, error) {
+	rod, err := d.engine(EngineRod)
+	if err != nil {
+		return nil, err
+	}
+	light, ok := d.Engines[EngineHTTP]
+	if !ok {
+		return rod.Scrape(ctx, task)
+	}
+
+	var result *models.ScrapingResult
+	if d.Cache != nil {
+		result, err = d.Cache.Scrape(ctx, light, task)
+	} else {
+		result, err = light.Scrape(ctx, task)
+	}
+	if err == nil && hasData(result) {
+		return result, nil
+	}
+	// Отмена задачи и неизмененная страница не повод запускать браузер
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if errors.Is(err, ErrNotModified) {
+		return nil, err
+	}
+
+	reason := "empty"
+	if err != nil {
+		reason = "error"
+	}
+	engineFallbacks.With(reason).Inc()
+	d.Logger.Debug("HTTP engine extracted no data, falling back to rod", "url", task.URL, "reason", reason, "error", err)
+	return rod.Scrape(ctx, task)
+}
+
+// Links собирает ссылки страницы движком задачи, а если он этого не умеет - движком rod
+func (d *Dispatcher) Links(ctx context.Context, task config.ScraperTask) ([]string, error) {
+	type linkSource interface {
+		Links(ctx context.Context, task config.ScraperTask) ([]string, error)
+	}
+	if engine, err := d.engine(cmp.Or(task.Engine, d.Default)); err == nil {
+		if links, ok := engine.(linkSource); ok {
+			return links.Links(ctx, task)
+		}
+	}
+	engine, err := d.engine(EngineRod)
+	if err != nil {
+		return nil, err
+	}
+	links, ok := engine.(linkSource)
+	if !ok {
+		return nil, fmt.Errorf("engine %s does not collect links", EngineRod)
+	}
+	return links.Links(ctx, task)
+}
+
+// Close ничего не делает: движки закрывает создавший их код
+func (d *Dispatcher) Close() error { return nil }
+
+func (d *Dispatcher) engine(name string) (Scraper, error) {
+	engine, ok := d.Engines[name]
+	if !ok {
+		return nil, errs.Wrap(errs.CodeUnsupported, "engine "+name, ErrUnknownEngine)
+	}
+	return engine, nil
+}
+
+// hasData сообщает, что результат содержит хотя бы одно непустое поле или запись
+func hasData(result *models.ScrapingResult) bool {
+	if result == nil {
+		return false
+	}
+	if len(result.Items) > 0 {
+		return true
+	}
+	for _, v := range result.Data {
+		if v != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+package scraper
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+)
+
+// extraction накапливает значения селекторов со всех страниц задачи
+type extraction struct {
+	values   map[string][]string
+	elements map[string]int // Найдено элементов по ключу
+	visited  map[string]bool
+
+	// Режим items
+	items    []map[string]string
+	itemHits map[string]int // Число записей, в которых найден ключ
+}
+
+func newExtraction() *extraction {
+	return &extraction{
+		values:   make(map[string][]string),
+		elements: make(map[string]int),
+		visited:  make(map[string]bool),
+		itemHits: make(map[string]int),
+	}
+}
+
+// data объединяет значения каждого ключа через перевод строки
+func (e *extraction) data() map[string]string {
+	data := make(map[string]string, len(e.values))
+	for key, values := range e.values {
+		data[key] = strings.Join(values, "\n")
+	}
+	return data
+}
+
+// confidence возвращает долю элементов, из которых удалось извлечь значение,
+// а в режиме items - долю записей, в которых найден ключ
+func (e *extraction) confidence() map[string]float64 {
+	if e.items != nil {
+		confidence := make(map[string]float64, len(e.itemHits))
+		for key, hits := range e.itemHits {
+			confidence[key] = float64(hits) / float64(len(e.items))
+		}
+		return confidence
+	}
+
+	confidence := make(map[string]float64, len(e.elements))
+	for key, found := range e.elements {
+		if found == 0 {
+			confidence[key] = 0
+			continue
+		}
+		confidence[key] = float64(len(e.values[key])) / float64(found)
+	}
+	return confidence
+}
+
+// missing возвращает ключи, для которых не найдено ни одного элемента
+func (e *extraction) missing() []string {
+	var keys []string
+	if e.items != nil {
+		for key, hits := range e.itemHits {
+			if hits == 0 {
+				keys = append(keys, key)
+			}
+		}
+		return keys
+	}
+	for key, found := range e.elements {
+		if found == 0 {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// extractSelectors извлекает значения всех селекторов задачи с текущей страницы.
+// Возвращает ошибку только при отмене контекста
+func (r *RodScraper) extractSelectors(ctx context.Context, page *rod.Page, task config.ScraperTask, baseURL *url.URL, e *extraction) error {
+	logger := r.loggerFrom(ctx)
+
+	for key, selector := range task.Selectors {
+		// Проверяем, отменен ли контекст
+		select {
+		case <-ctx.Done():
+			logger.Warn("Scraping canceled during selector processing", "key", key)
+			return ctx.Err()
+		default:
+		}
+
+		if _, ok := e.values[key]; !ok {
+			e.values[key] = nil
+		}
+		if selector == "" {
+			continue
+		}
+		if _, ok := e.elements[key]; !ok {
+			e.elements[key] = 0
+		}
+
+		if wait, ok := task.Waits[key]; ok {
+			if err := r.waitReady(ctx, page, task, wait, selector); err != nil {
+				return err
+			}
+		}
+
+		_, selSpan := tracing.Start(ctx, "scrape.selector",
+			tracing.String("key", key),
+			tracing.String("selector", selector),
+		)
+
+		// Устанавливаем таймаут для поиска элементов
+		elemCtx, cancel := context.WithTimeout(ctx, r.timeouts(task).Selector)
+		elements, err := findElements(page.Context(elemCtx), task.SelectorType, selector)
+		cancel()
+
+		if err != nil || len(elements) == 0 {
+			logger.Warn("No elements found", "selector", selector, "page", baseURL)
+			selectorMisses.With(task.Type).Inc()
+			selSpan.SetAttrs(tracing.Int("elements", 0))
+			selSpan.End()
+			continue
+		}
+
+		var texts []string
+		for _, element := range elements {
+			// Проверяем, отменен ли контекст
+			select {
+			case <-ctx.Done():
+				logger.Warn("Scraping canceled during element processing", "key", key)
+				selSpan.RecordError(ctx.Err())
+				selSpan.End()
+				return ctx.Err()
+			default:
+			}
+
+			// Устанавливаем таймаут для получения значения
+			textCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			text, err := extractValue(element.Context(textCtx), task.Extract[key], baseURL)
+			cancel()
+
+			if err != nil {
+				logger.Warn("Failed to extract value from element", "selector", selector, "mode", task.Extract[key], "error", err)
+				continue
+			}
+
+			texts = append(texts, text)
+		}
+
+		e.values[key] = append(e.values[key], texts...)
+		e.elements[key] += len(elements)
+		selSpan.SetAttrs(tracing.Int("elements", len(elements)), tracing.Int("texts", len(texts)))
+		selSpan.End()
+		logger.Info("Successfully scraped", "key", key, "count", len(texts))
+	}
+	return nil
+}
+
+// extractItems извлекает запись из каждого контейнера ItemSelector на текущей странице.
+// Селекторы ищутся внутри контейнера, пустой селектор берет значение самого контейнера
+func (r *RodScraper) extractItems(ctx context.Context, page *rod.Page, task config.ScraperTask, baseURL *url.URL, e *extraction) error {
+	logger := r.loggerFrom(ctx)
+
+	_, span := tracing.Start(ctx, "scrape.items", tracing.String("selector", task.ItemSelector))
+	defer span.End()
+
+	// Селекторы записи ищутся внутри контейнеров, поэтому ожидания ключей выполняются заранее
+	for key, wait := range task.Waits {
+		if err := r.waitReady(ctx, page, task, wait, task.Selectors[key]); err != nil {
+			return err
+		}
+	}
+
+	findCtx, cancel := context.WithTimeout(ctx, r.timeouts(task).Selector)
+	containers, err := findElements(page.Context(findCtx), task.SelectorType, task.ItemSelector)
+	cancel()
+
+	if e.items == nil {
+		e.items = []map[string]string{}
+	}
+	if err != nil || len(containers) == 0 {
+		logger.Warn("No items found", "selector", task.ItemSelector, "page", baseURL)
+		selectorMisses.With(task.Type).Inc()
+		span.SetAttrs(tracing.Int("items", 0))
+		return nil
+	}
+
+	for _, container := range containers {
+		// Проверяем, отменен ли контекст
+		select {
+		case <-ctx.Done():
+			logger.Warn("Scraping canceled during item processing", "items", len(e.items))
+			span.RecordError(ctx.Err())
+			return ctx.Err()
+		default:
+		}
+
+		item := make(map[string]string, len(task.Selectors))
+		for key, selector := range task.Selectors {
+			if _, ok := e.itemHits[key]; !ok {
+				e.itemHits[key] = 0
+			}
+
+			value, ok := r.extractItemField(ctx, container, task, key, selector, baseURL)
+			item[key] = value
+			if ok {
+				e.itemHits[key]++
+			}
+		}
+		e.items = append(e.items, item)
+	}
+
+	span.SetAttrs(tracing.Int("items", len(containers)))
+	logger.Info("Successfully scraped items", "count", len(containers))
+	return nil
+}
+
+// extractItemField извлекает значение поля записи внутри контейнера
+func (r *RodScraper) extractItemField(ctx context.Context, container *rod.Element, task config.ScraperTask, key, selector string, baseURL *url.URL) (string, bool) {
+	fieldCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	elements := rod.Elements{container}
+	if selector != "" {
+		found, err := findElements(container.Context(fieldCtx), task.SelectorType, selector)
+		if err != nil || len(found) == 0 {
+			return "", false
+		}
+		elements = found
+	}
+
+	var values []string
+	for _, element := range elements {
+		value, err := extractValue(element.Context(fieldCtx), task.Extract[key], baseURL)
+		if err != nil {
+			r.loggerFrom(ctx).Debug("Failed to extract item field", "key", key, "error", err)
+			continue
+		}
+		values = append(values, value)
+	}
+	return strings.Join(values, "\n"), len(values) > 0
+}
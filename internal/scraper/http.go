@@ -0,0 +1,408 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/charmbracelet/log"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+	applog "github.com/rx3lixir/kultscraper/internal/lib/logger"
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/rx3lixir/kultscraper/internal/proxy"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// httpMaxBody - предел размера страницы для движка без браузера
+	httpMaxBody = 10 << 20
+	// httpUserAgent - User-Agent запросов, если задача не задает свой в Headers
+	httpUserAgent = "Mozilla/5.0 (compatible; kultscraper)"
+)
+
+// HTTPScraper - встроенный движок без браузера: загружает страницу GET-запросом
+// и извлекает значения CSS-селекторов из HTML через goquery. Задачи, которым нужен
+// браузер (XPath, ожидания, действия, вход, скрипт), завершаются ошибкой CodeUnsupported,
+// с Engine "auto" их выполняет rod
+type HTTPScraper struct {
+	Logger   log.Logger
+	Client   *http.Client
+	Proxies  *proxy.Rotator      // Общий список прокси, nil - без прокси
+	Timeouts config.TaskTimeouts // Таймауты загрузки страницы для задач без собственных значений
+	Budget   RequestBudget       // Дневные лимиты запросов к сайтам, nil - без лимитов
+}
+
+// proxyKey - ключ контекста с прокси запроса движка без браузера
+type proxyKey struct{}
+
+// NewHTTPScraper создает движок без браузера. Прокси выбирается по задаче, как и у rod
+func NewHTTPScraper(logger log.Logger, proxies *proxy.Rotator) *HTTPScraper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		u, _ := req.Context().Value(proxyKey{}).(*url.URL)
+		return u, nil
+	}
+	return &HTTPScraper{
+		Logger:  logger,
+		Client:  &http.Client{Transport: transport},
+		Proxies: proxies,
+	}
+}
+
+// Close закрывает простаивающие соединения
+func (h *HTTPScraper) Close() error {
+	h.Client.CloseIdleConnections()
+	return nil
+}
+
+// Scrape загружает страницу задачи и следующие страницы списка и извлекает значения селекторов
+func (h *HTTPScraper) Scrape(ctx context.Context, task config.ScraperTask) (*models.ScrapingResult, error) {
+	logger := h.loggerFrom(ctx)
+	logger.Info("Scraping", "url", task.URL, "engine", EngineHTTP)
+
+	if ctx.Err() != nil {
+		return nil, errs.Wrap(errs.CodeCanceled, "scrape", ErrContextCancelled)
+	}
+	if reason := browserOnly(task); reason != "" {
+		return nil, errs.Wrap(errs.CodeUnsupported, "http engine", fmt.Errorf("task needs a browser: %s", reason))
+	}
+
+	proxyURL, err := h.Proxies.For(task.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := task.RequestHeaders()
+	if err != nil {
+		return nil, errs.Wrap(errs.CodeAuth, "request headers", err)
+	}
+
+	meta := models.ScrapeMeta{Engine: EngineHTTP, Extras: make(map[string]any)}
+	if proxyURL != nil {
+		meta.Extras["proxy"] = proxy.Server(proxyURL)
+	}
+
+	// Первая страница запрашивается условно, если HTTPCache передал валидаторы прошлого сбора
+	navStart := time.Now()
+	doc, resp, err := h.fetch(ctx, task, task.URL, headers, proxyURL, ValidatorsFrom(ctx))
+	if errors.Is(err, ErrNotModified) {
+		logger.Info("Page not modified", "url", task.URL)
+		return nil, err
+	}
+	if err != nil {
+		logger.Error("Failed to load page", "url", task.URL, "error", err)
+		return nil, err
+	}
+	meta.ETag = resp.Header.Get("ETag")
+	meta.LastModified = resp.Header.Get("Last-Modified")
+	meta.NavigationDuration = time.Since(navStart)
+	meta.HTTPStatus = resp.StatusCode
+	meta.FinalURL = resp.Request.URL.String()
+	navigationDuration.With().Observe(meta.NavigationDuration.Seconds())
+
+	extractStart := time.Now()
+	extracted := newExtraction()
+	_, extractSpan := tracing.Start(ctx, "scrape.extract", tracing.String("mode", task.Mode), tracing.String("engine", EngineHTTP))
+	defer extractSpan.End()
+
+	baseURL := resp.Request.URL
+	for pageNum := 1; ; pageNum++ {
+		if task.ItemsMode() {
+			extractDocumentItems(doc, task, baseURL, extracted)
+		} else {
+			extractDocument(doc, task, baseURL, extracted)
+		}
+		extractSpan.SetAttrs(tracing.Int("pages", pageNum))
+		if pageNum >= task.PageLimit() || task.NextPageSelector == "" {
+			break
+		}
+
+		extracted.visited[baseURL.String()] = true
+		next := nextPageURL(doc, task, baseURL)
+		if next == nil || extracted.visited[next.String()] {
+			break
+		}
+		nextDoc, nextResp, err := h.fetch(ctx, task, next.String(), headers, proxyURL, Validators{})
+		if err != nil {
+			logger.Warn("Failed to follow next page", "url", task.URL, "page", pageNum, "error", err)
+			break
+		}
+		logger.Info("Following next page", "url", next)
+		doc, baseURL = nextDoc, nextResp.Request.URL
+		meta.Extras["pages"] = pageNum + 1
+	}
+
+	data := extracted.data()
+	missing := extracted.missing()
+	if task.ItemsMode() && len(extracted.items) == 0 {
+		missing = append(missing, "ItemSelector")
+	}
+	for _, key := range missing {
+		if meta.Errors == nil {
+			meta.Errors = make(map[string]string)
+		}
+		meta.Errors[key] = string(errs.CodeSelectorNotFound)
+	}
+
+	if task.Script != "" {
+		html, err := doc.Html()
+		if err != nil {
+			err = errs.Wrap(errs.CodeScript, "task script", err)
+		} else {
+			err = runTaskScript(ctx, baseURL.String(), html, task.Script, data)
+		}
+		if err != nil {
+			logger.Error("Task script failed", "url", task.URL, "error", err)
+			extractSpan.RecordError(err)
+			return nil, err
+		}
+	}
+	meta.ExtractionDuration = time.Since(extractStart)
+	extractSpan.SetAttrs(tracing.Int("missing", len(missing)))
+	scrapeDuration.With(task.Type).Observe((meta.NavigationDuration + meta.ExtractionDuration).Seconds())
+
+	result := models.NewScrapingResult(task.URL, task.Type, task.Name, data)
+	result.Metadata = meta
+	if task.ItemsMode() {
+		result.SetItems(extracted.items)
+	}
+	for key, score := range extracted.confidence() {
+		result.SetConfidence(key, score)
+	}
+	return result, nil
+}
+
+// Links загружает страницу задачи и возвращает абсолютные адреса всех ссылок на ней
+func (h *HTTPScraper) Links(ctx context.Context, task config.ScraperTask) ([]string, error) {
+	proxyURL, err := h.Proxies.For(task.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := task.RequestHeaders()
+	if err != nil {
+		return nil, errs.Wrap(errs.CodeAuth, "request headers", err)
+	}
+	doc, resp, err := h.fetch(ctx, task, task.URL, headers, proxyURL, Validators{})
+	if err != nil {
+		return nil, err
+	}
+
+	var links []string
+	doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		if href, _ := a.Attr("href"); isNavigableHref(href) {
+			links = append(links, resolveURL(resp.Request.URL, href))
+		}
+	})
+	return links, nil
+}
+
+// fetch загружает страницу rawURL и разбирает ее HTML. С непустыми conditional запрос
+// условный, и ответ 304 дает ErrNotModified. Статусы 403 и 429 дают ошибку CodeBlocked,
+// остальные ошибочные статусы и сбои соединения - CodeNavigation
+func (h *HTTPScraper) fetch(ctx context.Context, task config.ScraperTask, rawURL string, headers map[string]string, proxyURL *url.URL, conditional Validators) (*goquery.Document, *http.Response, error) {
+	if err := consumeBudget(ctx, h.Budget, rawURL); err != nil {
+		return nil, nil, err
+	}
+
+	_, span := tracing.Start(ctx, "scrape.navigate", tracing.String("url", rawURL), tracing.String("engine", EngineHTTP))
+	defer span.End()
+
+	navCtx, cancel := context.WithTimeout(ctx, task.Timeouts(h.Timeouts).Navigation)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(context.WithValue(navCtx, proxyKey{}, proxyURL), http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, errs.Wrap(errs.CodeNavigation, "request", err)
+	}
+	req.Header.Set("User-Agent", httpUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if conditional.ETag != "" {
+		req.Header.Set("If-None-Match", conditional.ETag)
+	}
+	if conditional.LastModified != "" {
+		req.Header.Set("If-Modified-Since", conditional.LastModified)
+	}
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		navigations.With("error").Inc()
+		return nil, nil, navigationError("navigate", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && !conditional.Empty():
+		navigations.With("ok").Inc()
+		return nil, resp, errs.Wrap(errs.CodeNotModified, "http cache", ErrNotModified)
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		navigations.With("error").Inc()
+		return nil, resp, errs.Wrap(errs.CodeBlocked, "navigate", fmt.Errorf("HTTP status %d", resp.StatusCode))
+	case resp.StatusCode >= http.StatusBadRequest:
+		navigations.With("error").Inc()
+		return nil, resp, errs.Wrap(errs.CodeNavigation, "navigate", fmt.Errorf("HTTP status %d", resp.StatusCode))
+	}
+
+	// Кодировка из Content-Type или meta страницы, многие афиши отдают windows-1251
+	body, err := charset.NewReader(io.LimitReader(resp.Body, httpMaxBody), resp.Header.Get("Content-Type"))
+	if err != nil {
+		navigations.With("error").Inc()
+		return nil, resp, errs.Wrap(errs.CodeNavigation, "decode page", err)
+	}
+	doc, err := goquery.NewDocumentFromReader(body)
+	if err != nil {
+		span.RecordError(err)
+		navigations.With("error").Inc()
+		return nil, resp, navigationError("read page", err)
+	}
+	navigations.With("ok").Inc()
+	span.SetAttrs(tracing.Int("status", resp.StatusCode))
+	return doc, resp, nil
+}
+
+// browserOnly возвращает причину, по которой задачу может выполнить только браузер,
+// или пустую строку
+func browserOnly(task config.ScraperTask) string {
+	switch {
+	case task.Login != nil:
+		return "Login"
+	case len(task.Actions) > 0:
+		return "Actions"
+	case task.Wait != nil || len(task.Waits) > 0:
+		return "Wait"
+	}
+	selectors := []string{task.ItemSelector, task.NextPageSelector}
+	for _, selector := range task.Selectors {
+		selectors = append(selectors, selector)
+	}
+	for _, selector := range selectors {
+		if selector == "" {
+			continue
+		}
+		if selectorType, _ := parseSelector(task.SelectorType, selector); selectorType == config.SelectorXPath {
+			return "XPath selector"
+		}
+	}
+	return ""
+}
+
+// extractDocument извлекает значения всех селекторов задачи из документа
+func extractDocument(doc *goquery.Document, task config.ScraperTask, baseURL *url.URL, e *extraction) {
+	for key, selector := range task.Selectors {
+		if _, ok := e.values[key]; !ok {
+			e.values[key] = nil
+		}
+		if selector == "" {
+			continue
+		}
+		if _, ok := e.elements[key]; !ok {
+			e.elements[key] = 0
+		}
+
+		_, expr := parseSelector(task.SelectorType, selector)
+		found := doc.Find(expr)
+		if found.Length() == 0 {
+			selectorMisses.With(task.Type).Inc()
+			continue
+		}
+		found.Each(func(_ int, s *goquery.Selection) {
+			if value, err := selectionValue(s, task.Extract[key], baseURL); err == nil {
+				e.values[key] = append(e.values[key], value)
+			}
+		})
+		e.elements[key] += found.Length()
+	}
+}
+
+// extractDocumentItems извлекает запись из каждого контейнера ItemSelector документа.
+// Пустой селектор поля берет значение самого контейнера
+func extractDocumentItems(doc *goquery.Document, task config.ScraperTask, baseURL *url.URL, e *extraction) {
+	if e.items == nil {
+		e.items = []map[string]string{}
+	}
+	_, expr := parseSelector(task.SelectorType, task.ItemSelector)
+	containers := doc.Find(expr)
+	if containers.Length() == 0 {
+		selectorMisses.With(task.Type).Inc()
+		return
+	}
+
+	containers.Each(func(_ int, container *goquery.Selection) {
+		item := make(map[string]string, len(task.Selectors))
+		for key, selector := range task.Selectors {
+			if _, ok := e.itemHits[key]; !ok {
+				e.itemHits[key] = 0
+			}
+
+			found := container
+			if selector != "" {
+				_, expr := parseSelector(task.SelectorType, selector)
+				found = container.Find(expr)
+			}
+			var values []string
+			found.Each(func(_ int, s *goquery.Selection) {
+				if value, err := selectionValue(s, task.Extract[key], baseURL); err == nil {
+					values = append(values, value)
+				}
+			})
+			item[key] = strings.Join(values, "\n")
+			if len(values) > 0 {
+				e.itemHits[key]++
+			}
+		}
+		e.items = append(e.items, item)
+	})
+}
+
+// selectionValue извлекает значение элемента в режиме extractValue.
+// Текст берется без разметки, пробелы схлопываются, как в innerText
+func selectionValue(s *goquery.Selection, mode string, base *url.URL) (string, error) {
+	mode = strings.TrimSpace(mode)
+	switch {
+	case mode == "" || strings.EqualFold(mode, config.ExtractText):
+		return strings.Join(strings.Fields(s.Text()), " "), nil
+	case strings.EqualFold(mode, config.ExtractHTML):
+		return goquery.OuterHtml(s)
+	case strings.HasPrefix(strings.ToLower(mode), config.ExtractAttrPrefix):
+		name := strings.TrimSpace(mode[len(config.ExtractAttrPrefix):])
+		value, ok := s.Attr(name)
+		if !ok {
+			return "", fmt.Errorf("attribute %q not found", name)
+		}
+		if base != nil && (strings.EqualFold(name, "href") || strings.EqualFold(name, "src")) {
+			return resolveURL(base, value), nil
+		}
+		return value, nil
+	}
+	return "", fmt.Errorf("unknown extraction mode %q", mode)
+}
+
+// nextPageURL возвращает адрес ссылки на следующую страницу списка. Кнопки без ссылки
+// движок без браузера не нажимает, обход на них заканчивается
+func nextPageURL(doc *goquery.Document, task config.ScraperTask, current *url.URL) *url.URL {
+	_, expr := parseSelector(task.SelectorType, task.NextPageSelector)
+	href, ok := doc.Find(expr).First().Attr("href")
+	if !ok || !isNavigableHref(href) {
+		return nil
+	}
+	target, err := current.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return nil
+	}
+	return target
+}
+
+// loggerFrom возвращает логгер с идентификатором выполнения задачи из контекста
+func (h *HTTPScraper) loggerFrom(ctx context.Context) *log.Logger {
+	return h.Logger.With(applog.ContextKeyvals(ctx)...)
+}
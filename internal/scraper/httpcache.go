@@ -0,0 +1,108 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// ErrNotModified - страница не изменилась с прошлого сбора, задача пропущена
+var ErrNotModified = errors.New("page not modified")
+
+// Validators - валидаторы HTTP-кэша страницы
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// Empty сообщает, что сервер не прислал ни одного валидатора
+func (v Validators) Empty() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// ValidatorStore возвращает валидаторы, сохраненные с последним результатом задачи.
+// Результат, собранный по другой версии задачи, валидаторов не дает: иначе новые
+// селекторы не применились бы, пока страница не изменится
+type ValidatorStore interface {
+	Validators(ctx context.Context, task config.ScraperTask) (Validators, error)
+}
+
+// validatorsKey - ключ контекста с валидаторами условного запроса
+type validatorsKey struct{}
+
+// WithValidators передает движку валидаторы прошлого сбора страницы задачи
+func WithValidators(ctx context.Context, v Validators) context.Context {
+	return context.WithValue(ctx, validatorsKey{}, v)
+}
+
+// ValidatorsFrom возвращает валидаторы, с которыми движок делает запрос страницы условным:
+// If-None-Match и If-Modified-Since. На ответ 304 движок возвращает ErrNotModified
+func ValidatorsFrom(ctx context.Context) Validators {
+	v, _ := ctx.Value(validatorsKey{}).(Validators)
+	return v
+}
+
+// HTTPCache выполняет задачи движка без браузера, только если страница изменилась.
+// Валидаторы прошлого результата передаются движку через контекст (WithValidators),
+// и он отправляет их в своем GET-запросе страницы: тем же клиентом, через тот же прокси
+// и в пределах того же бюджета запросов. Ответ 304 завершает задачу ошибкой ErrNotModified.
+// Валидаторы ответа движок записывает в метаданные результата.
+// Кэш применяется только к движку EngineHTTP: встроенный движок rod страницы всегда
+// загружает заново, а плагин "http" пропускает неизмененные страницы, только если
+// читает ValidatorsFrom
+type HTTPCache struct {
+	Store ValidatorStore // Валидаторы сохраненных результатов, nil - только полученные этим процессом
+
+	mu    sync.Mutex
+	known map[string]Validators // Валидаторы, полученные в этом процессе, по отпечатку задачи
+}
+
+// NewHTTPCache создает кэш с валидаторами из store
+func NewHTTPCache(store ValidatorStore) *HTTPCache {
+	return &HTTPCache{
+		Store: store,
+		known: make(map[string]Validators),
+	}
+}
+
+// Scrape выполняет задачу движком engine с условным запросом по валидаторам прошлого сбора
+func (c *HTTPCache) Scrape(ctx context.Context, engine Scraper, task config.ScraperTask) (*models.ScrapingResult, error) {
+	previous := c.validators(ctx, task)
+
+	result, err := engine.Scrape(WithValidators(ctx, previous), task)
+	if errors.Is(err, ErrNotModified) {
+		httpCacheChecks.With("not_modified").Inc()
+		return nil, err
+	}
+	httpCacheChecks.With("modified").Inc()
+	if err != nil {
+		return result, err
+	}
+
+	current := Validators{ETag: result.Metadata.ETag, LastModified: result.Metadata.LastModified}
+	if !current.Empty() {
+		c.mu.Lock()
+		c.known[task.Fingerprint()] = current
+		c.mu.Unlock()
+	}
+	return result, nil
+}
+
+// validators возвращает валидаторы прошлого сбора страницы задачи
+func (c *HTTPCache) validators(ctx context.Context, task config.ScraperTask) Validators {
+	c.mu.Lock()
+	v, ok := c.known[task.Fingerprint()]
+	c.mu.Unlock()
+	if ok || c.Store == nil {
+		return v
+	}
+
+	v, err := c.Store.Validators(ctx, task)
+	if err != nil {
+		return Validators{}
+	}
+	return v
+}
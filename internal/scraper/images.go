@@ -0,0 +1,71 @@
+package scraper
+
+import (
+	"cmp"
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// ImageDownloader скачивает изображение и возвращает ссылку на сохраненную копию
+type ImageDownloader interface {
+	Download(ctx context.Context, rawURL string) (string, error)
+}
+
+// downloadImages сохраняет изображения ключей с типом image. Ссылка на копию значения
+// из Data записывается в Metadata.Images, в записях списка значение заменяется ссылкой
+// на копию. Неудачное скачивание оставляет исходную ссылку и отмечается в Metadata.Errors
+func downloadImages(ctx context.Context, result *models.ScrapingResult, task config.ScraperTask, images ImageDownloader, logger log.Logger) {
+	if images == nil {
+		return
+	}
+	// Относительные ссылки отсчитываются от адреса страницы после переходов
+	base, _ := url.Parse(cmp.Or(result.Metadata.FinalURL, result.URL))
+
+	download := func(key, value string) (string, bool) {
+		value, _, _ = strings.Cut(strings.TrimSpace(value), "\n")
+		if base != nil {
+			if u, err := base.Parse(value); err == nil {
+				value = u.String()
+			}
+		}
+		ref, err := images.Download(ctx, value)
+		if err != nil {
+			logger.Warn("Failed to download image", "url", task.URL, "key", key, "image", value, "error", err)
+			if result.Metadata.Errors == nil {
+				result.Metadata.Errors = make(map[string]string)
+			}
+			result.Metadata.Errors[key] = string(errs.CodeDownload)
+			return "", false
+		}
+		return ref, true
+	}
+
+	for key, typ := range task.Types {
+		if strings.ToLower(typ) != config.TypeImage {
+			continue
+		}
+
+		if value := result.Data[key]; value != "" {
+			if ref, ok := download(key, value); ok {
+				if result.Metadata.Images == nil {
+					result.Metadata.Images = make(map[string]string)
+				}
+				result.Metadata.Images[key] = ref
+			}
+		}
+
+		for _, item := range result.Items {
+			if value := item[key]; value != "" {
+				if ref, ok := download(key, value); ok {
+					item[key] = ref
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package scraper
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Категории блокируемых запросов задачи
+const (
+	BlockImage      = "image"
+	BlockMedia      = "media"
+	BlockFont       = "font"
+	BlockStylesheet = "stylesheet"
+	BlockThirdParty = "third_party"
+)
+
+var blockResourceTypes = map[string]proto.NetworkResourceType{
+	BlockImage:      proto.NetworkResourceTypeImage,
+	BlockMedia:      proto.NetworkResourceTypeMedia,
+	BlockFont:       proto.NetworkResourceTypeFont,
+	BlockStylesheet: proto.NetworkResourceTypeStylesheet,
+}
+
+// interceptOptions - что перехватывать на странице
+type interceptOptions struct {
+	Block     []string          // Категории блокируемых запросов
+	SiteURL   string            // Адрес задачи для определения сторонних доменов
+	ProxyAuth *url.Userinfo     // Учетные данные прокси
+	Headers   map[string]string // Дополнительные заголовки запросов к сайту задачи
+}
+
+// interceptor перехватывает запросы страницы через Fetch: блокирует лишние ресурсы,
+// добавляет заголовки задачи и отвечает на авторизацию прокси
+type interceptor struct {
+	page   *rod.Page
+	stop   context.CancelFunc
+	doneCh chan struct{}
+}
+
+// startInterception включает перехват запросов. Возвращает nil, если перехватывать нечего
+func startInterception(ctx context.Context, page *rod.Page, opts interceptOptions) (*interceptor, error) {
+	types := make(map[proto.NetworkResourceType]bool)
+	thirdParty := false
+	for _, b := range opts.Block {
+		b = strings.ToLower(strings.TrimSpace(b))
+		if t, ok := blockResourceTypes[b]; ok {
+			types[t] = true
+		}
+		if b == BlockThirdParty {
+			thirdParty = true
+		}
+	}
+	if len(types) == 0 && !thirdParty && opts.ProxyAuth == nil && len(opts.Headers) == 0 {
+		return nil, nil
+	}
+
+	site := ""
+	if u, err := url.Parse(opts.SiteURL); err == nil {
+		site = siteDomain(u.Hostname())
+	}
+
+	// Приостанавливаем только запросы, которые могут быть заблокированы
+	var patterns []*proto.FetchRequestPattern
+	if thirdParty || opts.ProxyAuth != nil || len(opts.Headers) > 0 {
+		patterns = []*proto.FetchRequestPattern{{URLPattern: "*"}}
+	} else {
+		for t := range types {
+			patterns = append(patterns, &proto.FetchRequestPattern{URLPattern: "*", ResourceType: t})
+		}
+	}
+
+	interceptCtx, cancel := context.WithCancel(ctx)
+	i := &interceptor{page: page, stop: cancel, doneCh: make(chan struct{})}
+
+	p := page.Context(interceptCtx)
+	wait := p.EachEvent(func(e *proto.FetchRequestPaused) {
+		if reason := blockReason(e, types, thirdParty, site); reason != "" {
+			blockedRequests.With(reason).Inc()
+			_ = proto.FetchFailRequest{RequestID: e.RequestID, ErrorReason: proto.NetworkErrorReasonBlockedByClient}.Call(p)
+			return
+		}
+		_ = proto.FetchContinueRequest{RequestID: e.RequestID, Headers: requestHeaders(e, opts.Headers, site)}.Call(p)
+	}, func(e *proto.FetchAuthRequired) {
+		response := &proto.FetchAuthChallengeResponse{Response: proto.FetchAuthChallengeResponseResponseDefault}
+		if opts.ProxyAuth != nil && e.AuthChallenge != nil && e.AuthChallenge.Source == proto.FetchAuthChallengeSourceProxy {
+			password, _ := opts.ProxyAuth.Password()
+			response = &proto.FetchAuthChallengeResponse{
+				Response: proto.FetchAuthChallengeResponseResponseProvideCredentials,
+				Username: opts.ProxyAuth.Username(),
+				Password: password,
+			}
+		}
+		_ = proto.FetchContinueWithAuth{RequestID: e.RequestID, AuthChallengeResponse: response}.Call(p)
+	})
+
+	go func() {
+		defer close(i.doneCh)
+		wait()
+	}()
+
+	err := proto.FetchEnable{Patterns: patterns, HandleAuthRequests: opts.ProxyAuth != nil}.Call(page)
+	if err != nil {
+		i.Stop()
+		return nil, err
+	}
+	return i, nil
+}
+
+// Stop выключает перехват, чтобы страницу можно было вернуть в пул
+func (i *interceptor) Stop() {
+	if i == nil {
+		return
+	}
+	_ = proto.FetchDisable{}.Call(i.page)
+	i.stop()
+	<-i.doneCh
+}
+
+// blockReason возвращает категорию, по которой запрос блокируется, или пустую строку
+func blockReason(e *proto.FetchRequestPaused, types map[proto.NetworkResourceType]bool, thirdParty bool, site string) string {
+	if types[e.ResourceType] {
+		return strings.ToLower(string(e.ResourceType))
+	}
+	if thirdParty && site != "" && e.ResourceType != proto.NetworkResourceTypeDocument {
+		if u, err := url.Parse(e.Request.URL); err == nil && u.Hostname() != "" && siteDomain(u.Hostname()) != site {
+			return BlockThirdParty
+		}
+	}
+	return ""
+}
+
+// requestHeaders возвращает заголовки запроса с добавленными заголовками задачи или nil,
+// если запрос остается без изменений. Заголовки добавляются только к запросам на сайт
+// задачи, чтобы Referer и X-Requested-With не уходили сторонним доменам
+func requestHeaders(e *proto.FetchRequestPaused, extra map[string]string, site string) []*proto.FetchHeaderEntry {
+	if len(extra) == 0 || site == "" {
+		return nil
+	}
+	if u, err := url.Parse(e.Request.URL); err != nil || siteDomain(u.Hostname()) != site {
+		return nil
+	}
+
+	headers := make([]*proto.FetchHeaderEntry, 0, len(e.Request.Headers)+len(extra))
+	for name, value := range e.Request.Headers {
+		if hasHeader(extra, name) {
+			continue
+		}
+		headers = append(headers, &proto.FetchHeaderEntry{Name: name, Value: value.Str()})
+	}
+	for name, value := range extra {
+		headers = append(headers, &proto.FetchHeaderEntry{Name: name, Value: value})
+	}
+	return headers
+}
+
+// hasHeader сообщает, есть ли заголовок name в headers без учета регистра
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// siteDomain возвращает домен второго уровня хоста: afisha.ru для www.afisha.ru.
+// Публичные суффиксы вида co.uk не учитываются
+func siteDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	labels := strings.Split(host, ".")
+	if len(labels) <= 2 {
+		return host
+	}
+	return strings.Join(labels[len(labels)-2:], ".")
+}
@@ -0,0 +1,61 @@
+package scraper
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// BrowserKeepAlive проверяет соединение с браузером и переподключается при его обрыве,
+// например когда удаленный браузер перезапущен или упал локальный Chromium
+type BrowserKeepAlive struct {
+	Scraper  *RodScraper
+	Interval time.Duration
+	Connect  func(id int) (*rod.Browser, error) // Создает браузер взамен браузера набора с номером id
+}
+
+// Run проверяет соединение до отмены контекста
+func (k *BrowserKeepAlive) Run(ctx context.Context) {
+	ticker := time.NewTicker(k.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for id := range k.Scraper.Browsers() {
+				if err := k.ping(ctx, id); err != nil && ctx.Err() == nil {
+					k.reconnect(id, err)
+				}
+			}
+		}
+	}
+}
+
+// ping запрашивает версию браузера, ответ означает живое соединение
+func (k *BrowserKeepAlive) ping(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, max(k.Interval/2, time.Second))
+	defer cancel()
+	_, err := proto.BrowserGetVersion{}.Call(k.Scraper.browserAt(id).Context(ctx))
+	return err
+}
+
+// reconnect подключает новый браузер. Страницы оборванного соединения не работают,
+// поэтому замена не ждет их освобождения: задачи на них завершатся ошибкой и будут повторены
+func (k *BrowserKeepAlive) reconnect(id int, cause error) {
+	logger := k.Scraper.Logger.With("browser", id)
+	logger.Warn("Browser connection lost, reconnecting", "error", cause)
+
+	browser, err := k.Connect(id)
+	if err != nil {
+		browserReconnects.With("error").Inc()
+		logger.Error("Failed to reconnect browser", "error", err)
+		return
+	}
+	k.Scraper.swapBrowser(id, browser)
+	browserReconnects.With("ok").Inc()
+	logger.Info("Browser reconnected")
+}
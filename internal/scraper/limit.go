@@ -0,0 +1,195 @@
+package scraper
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+)
+
+// SourceLimiter ограничивает число одновременных задач одного источника (хоста).
+// Предел берется из MaxConcurrency задачи, иначе используется Default.
+// Заблокировавший скрапер источник ставится на паузу CoolDown
+type SourceLimiter struct {
+	Default int // Предел для задач без MaxConcurrency, 0 - без ограничения
+	// Delay возвращает минимальный интервал между запусками задач источника
+	// (например Crawl-delay из robots.txt), nil - без интервала
+	Delay    func(ctx context.Context, rawURL string) time.Duration
+	CoolDown time.Duration // Пауза источника после блокировки, 0 - без паузы
+
+	mu      sync.Mutex
+	active  map[string]int
+	waiters map[string]chan struct{}
+	next    map[string]time.Time // Самое раннее время следующего запуска источника
+	paused  map[string]time.Time // Конец паузы заблокировавших скрапер источников
+}
+
+// NewSourceLimiter создает ограничитель с пределом по умолчанию
+func NewSourceLimiter(defaultLimit int) *SourceLimiter {
+	return &SourceLimiter{
+		Default: defaultLimit,
+		active:  make(map[string]int),
+		waiters: make(map[string]chan struct{}),
+		next:    make(map[string]time.Time),
+		paused:  make(map[string]time.Time),
+	}
+}
+
+// Acquire ждет конца паузы источника, свободного места для задачи и интервала Delay
+// и возвращает функцию освобождения. Для nil-ограничителя и задач без предела
+// и интервала возвращается сразу
+func (l *SourceLimiter) Acquire(ctx context.Context, task config.ScraperTask) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if err := l.waitPause(ctx, task.URL); err != nil {
+		return nil, err
+	}
+	release, err := l.acquireSlot(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.pace(ctx, task.URL); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// acquireSlot ждет свободного места среди одновременных задач источника
+func (l *SourceLimiter) acquireSlot(ctx context.Context, task config.ScraperTask) (func(), error) {
+	limit := task.MaxConcurrency
+	if limit <= 0 {
+		limit = l.Default
+	}
+	if limit <= 0 {
+		return func() {}, nil
+	}
+
+	source := sourceOf(task.URL)
+	for {
+		l.mu.Lock()
+		if l.active[source] < limit {
+			l.active[source]++
+			l.mu.Unlock()
+			return sync.OnceFunc(func() { l.release(source) }), nil
+		}
+		wait, ok := l.waiters[source]
+		if !ok {
+			wait = make(chan struct{})
+			l.waiters[source] = wait
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// pace резервирует время запуска не раньше Delay после предыдущего запуска источника и ждет его
+func (l *SourceLimiter) pace(ctx context.Context, rawURL string) error {
+	if l.Delay == nil {
+		return nil
+	}
+	delay := l.Delay(ctx, rawURL)
+	if delay <= 0 {
+		return nil
+	}
+
+	source := sourceOf(rawURL)
+	l.mu.Lock()
+	if l.next == nil {
+		l.next = make(map[string]time.Time)
+	}
+	at := time.Now()
+	if next := l.next[source]; next.After(at) {
+		at = next
+	}
+	l.next[source] = at.Add(delay)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Block ставит источник адреса на паузу CoolDown: задачи источника не запускаются до ее конца.
+// Повторная блокировка продлевает паузу. Возвращает конец паузы и false, если пауза выключена
+func (l *SourceLimiter) Block(rawURL string) (time.Time, bool) {
+	if l == nil || l.CoolDown <= 0 {
+		return time.Time{}, false
+	}
+
+	source := sourceOf(rawURL)
+	until := time.Now().Add(l.CoolDown)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.paused == nil {
+		l.paused = make(map[string]time.Time)
+	}
+	if until.After(l.paused[source]) {
+		l.paused[source] = until
+	}
+	sourcePauses.With().Inc()
+	return l.paused[source], true
+}
+
+// waitPause ждет конца паузы источника адреса
+func (l *SourceLimiter) waitPause(ctx context.Context, rawURL string) error {
+	source := sourceOf(rawURL)
+	for {
+		l.mu.Lock()
+		until, ok := l.paused[source]
+		if ok && !time.Now().Before(until) {
+			delete(l.paused, source)
+			ok = false
+		}
+		l.mu.Unlock()
+		if !ok {
+			return nil
+		}
+
+		// Пауза могла продлиться, пока задача ждала: проверяем снова
+		timer := time.NewTimer(time.Until(until))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// release освобождает место и будит ожидающие задачи источника
+func (l *SourceLimiter) release(source string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.active[source]--; l.active[source] <= 0 {
+		delete(l.active, source)
+	}
+	if wait, ok := l.waiters[source]; ok {
+		close(wait)
+		delete(l.waiters, source)
+	}
+}
+
+// sourceOf возвращает хост задачи, для неразбираемых адресов - сам адрес
+func sourceOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,94 @@
+package scraper
+
+import (
+	"slices"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// TruncatedMarker дописывается к обрезанному значению
+const TruncatedMarker = "…[truncated]"
+
+// applyLimits обрезает значения Data и записей Items по пределам limits. Сначала каждое
+// значение ограничивается MaxFieldBytes, затем, пока сумма превышает MaxDataBytes,
+// обрезаются самые длинные значения. Ключи обрезанных значений записываются
+// в Metadata.Truncated. Возвращает true, если что-то обрезано
+func applyLimits(result *models.ScrapingResult, limits config.ResultLimits) bool {
+	if limits.MaxFieldBytes <= 0 && limits.MaxDataBytes <= 0 {
+		return false
+	}
+
+	// value - значение результата, которое можно заменить обрезанным
+	type value struct {
+		key string
+		get func() string
+		set func(string)
+	}
+	var values []value
+	for key := range result.Data {
+		values = append(values, value{
+			key: key,
+			get: func() string { return result.Data[key] },
+			set: func(v string) { result.Data[key] = v },
+		})
+	}
+	for _, item := range result.Items {
+		for key := range item {
+			values = append(values, value{
+				key: key,
+				get: func() string { return item[key] },
+				set: func(v string) { item[key] = v },
+			})
+		}
+	}
+
+	truncated := false
+	cut := func(v value, size int) {
+		v.set(truncate(v.get(), size))
+		truncated = true
+		if !slices.Contains(result.Metadata.Truncated, v.key) {
+			result.Metadata.Truncated = append(result.Metadata.Truncated, v.key)
+		}
+	}
+
+	total := 0
+	for _, v := range values {
+		if limits.MaxFieldBytes > 0 && len(v.get()) > limits.MaxFieldBytes {
+			cut(v, limits.MaxFieldBytes)
+		}
+		total += len(v.get())
+	}
+
+	if limits.MaxDataBytes > 0 && total > limits.MaxDataBytes {
+		sort.SliceStable(values, func(i, j int) bool { return len(values[i].get()) > len(values[j].get()) })
+		for _, v := range values {
+			if total <= limits.MaxDataBytes {
+				break
+			}
+			size := len(v.get())
+			if size <= len(TruncatedMarker) {
+				continue
+			}
+			cut(v, max(size-(total-limits.MaxDataBytes), 0))
+			total += len(v.get()) - size
+		}
+	}
+
+	sort.Strings(result.Metadata.Truncated)
+	return truncated
+}
+
+// truncate обрезает s до size байт вместе с TruncatedMarker, не разрывая символы UTF-8
+func truncate(s string, size int) string {
+	if len(s) <= size {
+		return s
+	}
+	n := max(size-len(TruncatedMarker), 0)
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + TruncatedMarker
+}
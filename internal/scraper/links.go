@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"context"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+)
+
+// linksScript собирает абсолютные адреса ссылок страницы
+const linksScript = `() => Array.from(document.querySelectorAll("a[href]"), a => a.href)`
+
+// Links открывает страницу задачи и возвращает адреса всех ссылок на ней.
+// Используется для обхода ссылок задач с Crawl
+func (r *RodScraper) Links(ctx context.Context, task config.ScraperTask) ([]string, error) {
+	if err := consumeBudget(ctx, r.Budget, task.URL); err != nil {
+		return nil, err
+	}
+	proxyURL, err := r.Proxies.For(task.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	page, release, err := r.acquirePage(ctx, proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	resetStealth, err := r.applyStealth(page, task)
+	defer resetStealth()
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := task.RequestHeaders()
+	if err != nil {
+		return nil, errs.Wrap(errs.CodeAuth, "request headers", err)
+	}
+	intercept, err := startInterception(ctx, page, interceptOptions{SiteURL: task.URL, Headers: headers})
+	if err != nil {
+		return nil, err
+	}
+	defer intercept.Stop()
+
+	if task.Login != nil {
+		if err := r.ensureLogin(ctx, page, task); err != nil {
+			return nil, err
+		}
+	}
+
+	navCtx, cancel := context.WithTimeout(ctx, r.timeouts(task).Navigation)
+	defer cancel()
+	if err := page.Context(navCtx).Navigate(task.URL); err != nil {
+		navigations.With("error").Inc()
+		return nil, navigationError("navigate", err)
+	}
+	if err := page.Context(navCtx).WaitLoad(); err != nil {
+		navigations.With("error").Inc()
+		return nil, navigationError("wait load", err)
+	}
+	navigations.With("ok").Inc()
+
+	res, err := page.Context(navCtx).Eval(linksScript)
+	if err != nil {
+		return nil, errs.Wrap(errs.CodeScript, "collect links", err)
+	}
+	var links []string
+	for _, href := range res.Value.Arr() {
+		links = append(links, href.Str())
+	}
+	return links, nil
+}
@@ -0,0 +1,146 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+)
+
+// loginTimeout ограничивает время входа на сайт
+const loginTimeout = 30 * time.Second
+
+// session - cookies входа на сайт. Мьютекс не дает задачам одного домена входить одновременно
+type session struct {
+	mu      sync.Mutex
+	cookies []*proto.NetworkCookie
+}
+
+// sessionStore хранит сессии по доменам
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+func newSessionStore() *sessionStore {
+	return &sessionStore{sessions: make(map[string]*session)}
+}
+
+// get возвращает сессию домена, создавая пустую при необходимости
+func (s *sessionStore) get(domain string) *session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[domain]
+	if !ok {
+		sess = &session{}
+		s.sessions[domain] = sess
+	}
+	return sess
+}
+
+// ensureLogin входит на сайт задачи или восстанавливает cookies сохраненной сессии домена
+func (r *RodScraper) ensureLogin(ctx context.Context, page *rod.Page, task config.ScraperTask) error {
+	login := task.Login
+	loginURL, err := url.Parse(login.URL)
+	if err != nil || loginURL.Hostname() == "" {
+		return errs.Wrap(errs.CodeAuth, "login", fmt.Errorf("invalid login URL %q", login.URL))
+	}
+	domain := strings.ToLower(loginURL.Hostname())
+
+	sess := r.sessions.get(domain)
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if sess.cookies != nil {
+		// Cookies общие для браузера, но после замены браузера их нужно восстановить
+		if err := page.Context(ctx).SetCookies(proto.CookiesToParams(sess.cookies)); err != nil {
+			return errs.Wrap(errs.CodeAuth, "restore session", err)
+		}
+		return nil
+	}
+
+	logger := r.loggerFrom(ctx)
+	_, span := tracing.Start(ctx, "scrape.login", tracing.String("domain", domain))
+	defer span.End()
+
+	cookies, err := r.login(ctx, page, login)
+	if err != nil {
+		span.RecordError(err)
+		logger.Error("Login failed", "domain", domain, "error", err)
+		return errs.Wrap(errs.CodeAuth, "login", err)
+	}
+
+	sess.cookies = cookies
+	logger.Info("Logged in", "domain", domain, "cookies", len(cookies))
+	return nil
+}
+
+// login заполняет и отправляет форму входа, возвращает cookies сайта
+func (r *RodScraper) login(ctx context.Context, page *rod.Page, login *config.LoginConfig) ([]*proto.NetworkCookie, error) {
+	username, password, err := login.Credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	loginCtx, cancel := context.WithTimeout(ctx, loginTimeout)
+	defer cancel()
+	p := page.Context(loginCtx)
+
+	if err := consumeBudget(ctx, r.Budget, login.URL); err != nil {
+		return nil, err
+	}
+	if err := p.Navigate(login.URL); err != nil {
+		return nil, err
+	}
+	if err := p.WaitLoad(); err != nil {
+		return nil, err
+	}
+
+	for _, field := range []struct{ selector, value string }{
+		{login.UsernameSelector, username},
+		{login.PasswordSelector, password},
+	} {
+		el, err := waitElement(loginCtx, p, "", field.selector)
+		if err != nil {
+			return nil, err
+		}
+		if err := el.SelectAllText(); err != nil {
+			return nil, err
+		}
+		if err := el.Input(field.value); err != nil {
+			return nil, err
+		}
+	}
+
+	if login.SubmitSelector != "" {
+		submit, err := waitElement(loginCtx, p, "", login.SubmitSelector)
+		if err != nil {
+			return nil, err
+		}
+		if err := submit.Click(proto.InputMouseButtonLeft, 1); err != nil {
+			return nil, err
+		}
+	} else if err := p.Keyboard.Type(input.Enter); err != nil {
+		return nil, err
+	}
+
+	if login.SuccessSelector != "" {
+		if _, err := waitElement(loginCtx, p, "", login.SuccessSelector); err != nil {
+			return nil, fmt.Errorf("login did not succeed: %w", err)
+		}
+	} else if err := settle(loginCtx, p); err != nil {
+		return nil, err
+	}
+
+	return p.Cookies(nil)
+}
@@ -0,0 +1,121 @@
+package scraper
+
+import "github.com/rx3lixir/kultscraper/internal/lib/metrics"
+
+// Метрики скрапера регистрируются в общем реестре при загрузке пакета
+var (
+	pagesCreated = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_scraper_pages_created_total",
+		"Number of browser pages created by the scraper.",
+	)
+	navigations = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_scraper_navigations_total",
+		"Number of page navigations by outcome.",
+		"status",
+	)
+	selectorMisses = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_scraper_selector_misses_total",
+		"Number of selectors that matched no elements.",
+		"type",
+	)
+	scrapeDuration = metrics.DefaultRegistry.NewHistogramVec(
+		"kultscraper_scraper_scrape_duration_seconds",
+		"Duration of a full page scrape.",
+		nil,
+		"type",
+	)
+	slowTasks = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_scraper_slow_tasks_total",
+		"Number of tasks that exceeded their slow task threshold.",
+		"type",
+	)
+	taskErrors = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_scraper_task_errors_total",
+		"Number of failed scrape tasks by error code.",
+		"type", "code",
+	)
+	tasksSkipped = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_scraper_tasks_skipped_total",
+		"Number of scrape tasks skipped because the page was not modified.",
+		"type",
+	)
+	blockedRequests = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_scraper_blocked_requests_total",
+		"Number of page requests blocked by resource filters.",
+		"reason",
+	)
+	navigationDuration = metrics.DefaultRegistry.NewHistogramVec(
+		"kultscraper_scraper_navigation_duration_seconds",
+		"Duration of page navigation and load.",
+		nil,
+	)
+	pagesDiscarded = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_scraper_pages_discarded_total",
+		"Number of pooled pages closed by reason (unhealthy, max_uses).",
+		"reason",
+	)
+	engineFallbacks = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_scraper_engine_fallbacks_total",
+		"Number of auto engine tasks retried with rod by reason (empty, error).",
+		"reason",
+	)
+	qualityFailures = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_scraper_quality_failures_total",
+		"Number of results that failed task quality rules by action (flag, reject).",
+		"type", "action",
+	)
+	httpCacheChecks = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_scraper_http_cache_checks_total",
+		"Number of HTTP engine pages checked with conditional requests by outcome (modified, not_modified).",
+		"outcome",
+	)
+	captchaDetections = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_scraper_captcha_detections_total",
+		"Number of pages with a captcha by kind and outcome (embedded, solved, unsolved).",
+		"kind", "outcome",
+	)
+	sourcePauses = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_scraper_source_pauses_total",
+		"Number of times a source was paused after blocking the scraper.",
+	)
+	truncatedResults = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_scraper_truncated_results_total",
+		"Number of results with values truncated by size limits.",
+		"type",
+	)
+)
+
+// Метрики ресурсов браузера
+var (
+	browserHeap = metrics.DefaultRegistry.NewGaugeVec(
+		"kultscraper_browser_js_heap_bytes",
+		"Total used JS heap across open browser pages.",
+	)
+	browserCPU = metrics.DefaultRegistry.NewGaugeVec(
+		"kultscraper_browser_cpu_seconds",
+		"Cumulative CPU time of browser processes.",
+	)
+	browserTargets = metrics.DefaultRegistry.NewGaugeVec(
+		"kultscraper_browser_targets",
+		"Number of open DevTools targets by type.",
+		"type",
+	)
+	browserActivePages = metrics.DefaultRegistry.NewGaugeVec(
+		"kultscraper_browser_active_pages",
+		"Number of pages currently used by the scraper.",
+	)
+	browserRestarts = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_browser_restarts_total",
+		"Number of browser restarts triggered by resource limits.",
+	)
+	browserReconnects = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_browser_reconnects_total",
+		"Number of reconnects after a lost browser connection by status (ok, error).",
+		"status",
+	)
+	waitTimeouts = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_wait_timeouts_total",
+		"Number of page waits that ran out of time by kind (selector, network).",
+		"kind",
+	)
+)
@@ -0,0 +1,246 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Действия при превышении лимитов ресурсов браузера
+const (
+	LimitActionLog     = "log"
+	LimitActionRestart = "restart"
+)
+
+// BrowserUsage - снимок потребления ресурсов браузером
+type BrowserUsage struct {
+	JSHeapBytes float64
+	CPUSeconds  float64
+	CPUPercent  float64
+	Targets     map[string]int
+	ActivePages int
+}
+
+// BrowserLimits - лимиты ресурсов браузера (нулевые значения не проверяются)
+type BrowserLimits struct {
+	MaxJSHeapBytes float64
+	MaxTargets     int
+	MaxCPUPercent  float64
+}
+
+// BrowserMonitor периодически снимает метрики браузеров через DevTools
+// и выполняет заданное действие, когда браузер превышает лимиты
+type BrowserMonitor struct {
+	Scraper  *RodScraper
+	Interval time.Duration
+	Limits   BrowserLimits
+	Action   string
+	// Connect создает новый браузер взамен браузера набора с номером id для действия restart
+	Connect func(id int) (*rod.Browser, error)
+
+	cpu map[int]cpuSample // Предыдущий замер CPU по браузерам
+}
+
+// cpuSample - замер времени CPU браузера для расчета загрузки
+type cpuSample struct {
+	seconds float64
+	at      time.Time
+}
+
+// NewBrowserMonitor создает монитор ресурсов браузеров скрапера
+func NewBrowserMonitor(scraper *RodScraper, interval time.Duration, limits BrowserLimits, action string) *BrowserMonitor {
+	if action == "" {
+		action = LimitActionLog
+	}
+	return &BrowserMonitor{
+		Scraper:  scraper,
+		Interval: interval,
+		Limits:   limits,
+		Action:   action,
+		cpu:      make(map[int]cpuSample),
+	}
+}
+
+// Run снимает метрики до отмены контекста
+func (m *BrowserMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			usages, err := m.collectAll(ctx)
+			if err != nil {
+				m.Scraper.Logger.Warn("Failed to collect browser metrics", "error", err)
+				continue
+			}
+			for id, usage := range usages {
+				m.check(id, usage)
+			}
+		}
+	}
+}
+
+// Collect снимает суммарное потребление ресурсов браузерами и обновляет метрики
+func (m *BrowserMonitor) Collect(ctx context.Context) (*BrowserUsage, error) {
+	usages, err := m.collectAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	total := &BrowserUsage{Targets: make(map[string]int), ActivePages: m.Scraper.ActivePages()}
+	for _, usage := range usages {
+		total.JSHeapBytes += usage.JSHeapBytes
+		total.CPUSeconds += usage.CPUSeconds
+		total.CPUPercent += usage.CPUPercent
+		for kind, count := range usage.Targets {
+			total.Targets[kind] += count
+		}
+	}
+	return total, nil
+}
+
+// collectAll снимает потребление каждого браузера набора и обновляет суммарные метрики
+func (m *BrowserMonitor) collectAll(ctx context.Context) ([]*BrowserUsage, error) {
+	usages := make([]*BrowserUsage, m.Scraper.Browsers())
+	var (
+		heap, cpu float64
+		targets   = make(map[string]int)
+	)
+	for id := range usages {
+		usage, err := m.collect(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("browser %d: %w", id, err)
+		}
+		usages[id] = usage
+		heap += usage.JSHeapBytes
+		cpu += usage.CPUSeconds
+		for kind, count := range usage.Targets {
+			targets[kind] += count
+		}
+	}
+
+	browserHeap.With().Set(heap)
+	browserCPU.With().Set(cpu)
+	browserActivePages.With().Set(float64(m.Scraper.ActivePages()))
+	for kind, count := range targets {
+		browserTargets.With(kind).Set(float64(count))
+	}
+	return usages, nil
+}
+
+// collect снимает текущее потребление ресурсов браузером id
+func (m *BrowserMonitor) collect(ctx context.Context, id int) (*BrowserUsage, error) {
+	browser := m.Scraper.browserAt(id).Context(ctx)
+
+	usage := &BrowserUsage{
+		Targets:     make(map[string]int),
+		ActivePages: m.Scraper.activePagesOf(id),
+	}
+
+	targets, err := proto.TargetGetTargets{}.Call(browser)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range targets.TargetInfos {
+		usage.Targets[string(t.Type)]++
+	}
+
+	// Процессная информация доступна не во всех сборках Chromium
+	if info, err := (proto.SystemInfoGetProcessInfo{}).Call(browser); err == nil {
+		for _, p := range info.ProcessInfo {
+			usage.CPUSeconds += p.CPUTime
+		}
+		now := time.Now()
+		if m.cpu == nil {
+			m.cpu = make(map[int]cpuSample)
+		}
+		if last, ok := m.cpu[id]; ok && usage.CPUSeconds >= last.seconds {
+			usage.CPUPercent = (usage.CPUSeconds - last.seconds) / now.Sub(last.at).Seconds() * 100
+		}
+		m.cpu[id] = cpuSample{seconds: usage.CPUSeconds, at: now}
+	}
+
+	pages, err := browser.Pages()
+	if err != nil {
+		return nil, err
+	}
+	for _, page := range pages {
+		usage.JSHeapBytes += pageHeap(page)
+	}
+
+	return usage, nil
+}
+
+// check сравнивает потребление браузера id с лимитами и выполняет действие
+func (m *BrowserMonitor) check(id int, usage *BrowserUsage) {
+	logger := m.Scraper.Logger.With("browser", id)
+
+	exceeded := ""
+	switch {
+	case m.Limits.MaxJSHeapBytes > 0 && usage.JSHeapBytes > m.Limits.MaxJSHeapBytes:
+		exceeded = "js_heap"
+	case m.Limits.MaxTargets > 0 && usage.Targets["page"] > m.Limits.MaxTargets:
+		exceeded = "targets"
+	case m.Limits.MaxCPUPercent > 0 && usage.CPUPercent > m.Limits.MaxCPUPercent:
+		exceeded = "cpu"
+	}
+
+	if exceeded == "" {
+		logger.Debug("Browser resource usage",
+			"js_heap_mb", usage.JSHeapBytes/1024/1024,
+			"cpu_percent", usage.CPUPercent,
+			"pages", usage.Targets["page"])
+		return
+	}
+
+	logger.Warn("Browser resource limit exceeded",
+		"limit", exceeded,
+		"js_heap_mb", usage.JSHeapBytes/1024/1024,
+		"cpu_percent", usage.CPUPercent,
+		"pages", usage.Targets["page"],
+		"action", m.Action)
+
+	if m.Action != LimitActionRestart || m.Connect == nil {
+		return
+	}
+
+	browser, err := m.Connect(id)
+	if err != nil {
+		logger.Error("Failed to start replacement browser", "error", err)
+		return
+	}
+
+	if !m.Scraper.ReplaceBrowser(id, browser) {
+		// Есть активные страницы, попробуем на следующем тике
+		_ = browser.Close()
+		logger.Info("Browser restart postponed, pages are still active")
+		return
+	}
+
+	delete(m.cpu, id)
+	browserRestarts.With().Inc()
+	logger.Info("Browser restarted due to resource limits")
+}
+
+// pageHeap возвращает объем используемой JS-кучи страницы
+func pageHeap(page *rod.Page) float64 {
+	if err := (proto.PerformanceEnable{}).Call(page); err != nil {
+		return 0
+	}
+	res, err := proto.PerformanceGetMetrics{}.Call(page)
+	if err != nil {
+		return 0
+	}
+	for _, metric := range res.Metrics {
+		if metric.Name == "JSHeapUsedSize" {
+			return metric.Value
+		}
+	}
+	return 0
+}
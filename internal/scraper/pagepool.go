@@ -0,0 +1,153 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+const (
+	// pageHealthTimeout - время ответа страницы при проверке перед выдачей из пула
+	pageHealthTimeout = 2 * time.Second
+	// pageResetTimeout - время очистки страницы и ее закрытия при возврате в пул.
+	// Зависший рендерер не должен блокировать воркер, вернувший страницу
+	pageResetTimeout = 5 * time.Second
+)
+
+// errPoolClosed - пул закрыт вместе с браузером
+var errPoolClosed = errors.New("page pool is closed")
+
+// pagePool хранит свободные страницы одного браузера, не больше size.
+// Страница закрывается после предела использований или при сбое проверки
+type pagePool struct {
+	browser *rod.Browser
+	logger  log.Logger
+	idle    chan *rod.Page
+
+	mu     sync.Mutex
+	uses   map[*rod.Page]int // Задач, выполненных на странице, для всех открытых страниц пула
+	closed bool
+}
+
+func newPagePool(browser *rod.Browser, size int, logger log.Logger) *pagePool {
+	return &pagePool{
+		browser: browser,
+		logger:  logger,
+		idle:    make(chan *rod.Page, size),
+		uses:    make(map[*rod.Page]int),
+	}
+}
+
+// get выдает исправную свободную страницу или открывает новую
+func (p *pagePool) get(ctx context.Context) (*rod.Page, error) {
+	for page := range p.idlePages {
+		if p.healthy(ctx, page) {
+			return page, nil
+		}
+		pagesDiscarded.With("unhealthy").Inc()
+		p.discard(page)
+	}
+
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return nil, errPoolClosed
+	}
+
+	// Маскировка применяется к странице для каждой задачи отдельно
+	page, err := p.browser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		p.logger.Error("Failed to create page", "error", err)
+		return nil, err
+	}
+	pagesCreated.With().Inc()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		_ = page.Close()
+		return nil, errPoolClosed
+	}
+	p.uses[page] = 0
+	return page, nil
+}
+
+// put возвращает страницу после задачи. Страница закрывается, если пул закрыт,
+// она выполнила maxUses задач (0 - без ограничения) или не открывает about:blank
+func (p *pagePool) put(page *rod.Page, maxUses int) {
+	p.mu.Lock()
+	p.uses[page]++
+	worn := maxUses > 0 && p.uses[page] >= maxUses
+	closed := p.closed
+	p.mu.Unlock()
+
+	switch {
+	case closed:
+		p.discard(page)
+		return
+	case worn:
+		pagesDiscarded.With("max_uses").Inc()
+		p.discard(page)
+		return
+	}
+
+	// Очищаем страницу перед возвратом в пул. Страница, не ответившая вовремя, не переиспользуется
+	if err := page.Timeout(pageResetTimeout).Navigate("about:blank"); err != nil {
+		pagesDiscarded.With("unhealthy").Inc()
+		p.logger.Debug("Failed to reset pooled page, discarding it", "error", err)
+		p.discard(page)
+		return
+	}
+	select {
+	case p.idle <- page:
+	default:
+		p.discard(page)
+	}
+}
+
+// healthy проверяет, что страница отвечает на выполнение скрипта
+func (p *pagePool) healthy(ctx context.Context, page *rod.Page) bool {
+	ctx, cancel := context.WithTimeout(ctx, pageHealthTimeout)
+	defer cancel()
+	_, err := page.Context(ctx).Eval(`() => true`)
+	return err == nil
+}
+
+// discard закрывает страницу и забывает ее
+func (p *pagePool) discard(page *rod.Page) {
+	p.mu.Lock()
+	delete(p.uses, page)
+	p.mu.Unlock()
+	_ = page.Timeout(pageResetTimeout).Close()
+}
+
+// close закрывает свободные страницы. Занятые страницы закрываются при возврате
+func (p *pagePool) close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	for page := range p.idlePages {
+		p.discard(page)
+	}
+}
+
+// idlePages перебирает свободные страницы, забирая их из пула, пока они есть
+func (p *pagePool) idlePages(yield func(*rod.Page) bool) {
+	for {
+		select {
+		case page := <-p.idle:
+			if !yield(page) {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rx3lixir/kultscraper/internal/config"
+)
+
+// nextPage переходит на следующую страницу списка и возвращает ее адрес.
+// Ссылки открываются навигацией, остальные элементы нажимаются. Возвращает nil,
+// если следующей страницы нет или ссылка ведет на уже посещенную страницу
+func (r *RodScraper) nextPage(ctx context.Context, page *rod.Page, task config.ScraperTask, current *url.URL, visited map[string]bool) (*url.URL, error) {
+	logger := r.loggerFrom(ctx)
+	visited[current.String()] = true
+
+	findCtx, cancel := context.WithTimeout(ctx, r.timeouts(task).Selector)
+	elements, err := findElements(page.Context(findCtx), task.SelectorType, task.NextPageSelector)
+	cancel()
+	if err != nil || len(elements) == 0 {
+		logger.Debug("No next page", "url", current)
+		return nil, nil
+	}
+	next := elements.First()
+
+	if href, _ := next.Attribute("href"); href != nil && isNavigableHref(*href) {
+		target, err := current.Parse(strings.TrimSpace(*href))
+		if err != nil {
+			return nil, err
+		}
+		if visited[target.String()] {
+			logger.Debug("Next page already visited", "url", target)
+			return nil, nil
+		}
+		if err := consumeBudget(ctx, r.Budget, target.String()); err != nil {
+			return nil, err
+		}
+
+		navCtx, cancel := context.WithTimeout(ctx, r.timeouts(task).Navigation)
+		defer cancel()
+		if err := page.Context(navCtx).Navigate(target.String()); err != nil {
+			return nil, navigationError("next page", err)
+		}
+		if err := page.Context(navCtx).WaitLoad(); err != nil {
+			return nil, navigationError("next page", err)
+		}
+
+		logger.Info("Following next page", "url", target)
+		return target, nil
+	}
+
+	// Кнопка "дальше" без ссылки: нажимаем и ждем, пока DOM перестанет меняться.
+	// Нажатие загружает следующую страницу с того же сайта и учитывается как запрос к нему
+	if err := consumeBudget(ctx, r.Budget, current.String()); err != nil {
+		return nil, err
+	}
+	clickCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := next.Context(clickCtx).Click(proto.InputMouseButtonLeft, 1); err != nil {
+		return nil, err
+	}
+	if err := page.Context(clickCtx).WaitDOMStable(300*time.Millisecond, 0); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return nil, err
+	}
+
+	// Адрес после нажатия обычно не меняется, обход ограничен MaxPages
+	info, err := page.Context(clickCtx).Info()
+	if err != nil {
+		return nil, err
+	}
+	target, err := url.Parse(info.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Clicked next page", "url", info.URL)
+	return target, nil
+}
+
+// isNavigableHref отличает настоящие ссылки от заглушек "#" и "javascript:"
+func isNavigableHref(href string) bool {
+	href = strings.TrimSpace(href)
+	return href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(strings.ToLower(href), "javascript:")
+}
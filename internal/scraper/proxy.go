@@ -0,0 +1,53 @@
+package scraper
+
+import (
+	"context"
+	"net/url"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rx3lixir/kultscraper/internal/proxy"
+)
+
+// getProxyPage создает страницу в отдельном контексте наименее загруженного браузера с заданным прокси.
+// Такие страницы не переиспользуются: контекст удаляется при освобождении.
+// Учетные данные прокси передаются при перехвате запросов страницы
+func (r *RodScraper) getProxyPage(ctx context.Context, u *url.URL) (*rod.Page, func(), error) {
+	inst, err := r.reserve(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	r.mu.Lock()
+	browser := inst.browser
+	r.mu.Unlock()
+
+	release := func() { r.unreserve(inst) }
+
+	res, err := proto.TargetCreateBrowserContext{ProxyServer: proxy.Server(u)}.Call(browser)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+
+	contextBrowser := *browser
+	contextBrowser.BrowserContextID = res.BrowserContextID
+	closeContext := func() {
+		if err := contextBrowser.Close(); err != nil {
+			r.Logger.Warn("Failed to dispose proxy browser context", "error", err)
+		}
+		release()
+	}
+
+	page, err := contextBrowser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		closeContext()
+		return nil, nil, err
+	}
+	pagesCreated.With().Inc()
+
+	return page, sync.OnceFunc(func() {
+		page.Close()
+		closeContext()
+	}), nil
+}
@@ -0,0 +1,86 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// ErrQualityCheck - результат не прошел проверку Quality задачи
+var ErrQualityCheck = errors.New("result failed quality check")
+
+// checkQuality проверяет заполненность результата по правилам Quality задачи.
+// Непройденные правила записываются в Metadata.Issues, результат отмечается
+// StatusInvalid, а при OnFail reject возвращается ошибка с кодом CodeQuality
+func checkQuality(result *models.ScrapingResult, task config.ScraperTask) error {
+	q := task.Quality
+	if q == nil {
+		return nil
+	}
+
+	// Проверяемые значения: Data или записи списка
+	records := []map[string]string{result.Data}
+	if task.ItemsMode() {
+		records = result.Items
+	}
+	// filled возвращает долю записей с непустым значением ключа
+	filled := func(key string) float64 {
+		if len(records) == 0 {
+			return 0
+		}
+		n := 0
+		for _, record := range records {
+			if strings.TrimSpace(record[key]) != "" {
+				n++
+			}
+		}
+		return float64(n) / float64(len(records))
+	}
+
+	var issues []string
+	if q.MinItems > 0 && len(result.Items) < q.MinItems {
+		issues = append(issues, fmt.Sprintf("items: got %d, want at least %d", len(result.Items), q.MinItems))
+	}
+	for _, key := range q.Required {
+		if rate := filled(key); rate < 1 {
+			issues = append(issues, fmt.Sprintf("%s: required value is empty in %.0f%% of records", key, (1-rate)*100))
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(q.FillRates)) {
+		if rate := filled(key); rate < q.FillRates[key] {
+			issues = append(issues, fmt.Sprintf("%s: fill rate %.2f below %.2f", key, rate, q.FillRates[key]))
+		}
+	}
+	if q.MinFillRate > 0 && len(task.Selectors) > 0 {
+		var total float64
+		for key := range task.Selectors {
+			total += filled(key)
+		}
+		if rate := total / float64(len(task.Selectors)); rate < q.MinFillRate {
+			issues = append(issues, fmt.Sprintf("fill rate %.2f below %.2f", rate, q.MinFillRate))
+		}
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+	result.Metadata.Status = models.StatusInvalid
+	result.Metadata.Issues = issues
+
+	action := config.QualityFlag
+	if q.Rejects() {
+		action = config.QualityReject
+	}
+	qualityFailures.With(task.Type, action).Inc()
+
+	if q.Rejects() {
+		return errs.Wrap(errs.CodeQuality, "quality", fmt.Errorf("%w: %s", ErrQualityCheck, strings.Join(issues, "; ")))
+	}
+	return nil
+}
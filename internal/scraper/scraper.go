@@ -3,17 +3,26 @@ package scraper
 import (
 	"context"
 	"errors"
-	"strings"
+	"fmt"
+	"net/url"
 	"sync"
 	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/go-rod/rod"
-	"github.com/go-rod/stealth"
+	"github.com/rx3lixir/kultscraper/hooks"
 	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/enrich"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+	applog "github.com/rx3lixir/kultscraper/internal/lib/logger"
+	"github.com/rx3lixir/kultscraper/internal/lib/tracing"
+	"github.com/rx3lixir/kultscraper/internal/lib/work"
 	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/rx3lixir/kultscraper/internal/proxy"
 )
 
+const EngineRod = "rod"
+
 var (
 	ErrContextCancelled = errors.New("scraping cancelled due to context timeout")
 )
@@ -26,203 +35,597 @@ type Scraper interface {
 
 // RodScraper имплементация Scraper с использованием Rod
 type RodScraper struct {
-	Browser      *rod.Browser
-	Logger       log.Logger
-	pagePool     *sync.Pool
-	maxPageCount int
-	activePages  int
-	mu           sync.Mutex
+	Logger         log.Logger
+	CaptureConsole bool                // Сбор сообщений консоли и ошибок страницы в Debug результата
+	ArtifactDir    string              // Каталог для артефактов неудавшихся задач, пустое значение отключает сбор
+	Proxies        *proxy.Rotator      // Общий список прокси, nil - без прокси
+	Block          []string            // Блокируемые запросы для задач без своего списка: image, media, font, stylesheet, third_party
+	ScreenshotDir  string              // Каталог снимков страниц
+	ScreenshotAll  bool                // Снимать все страницы, а не только задачи с Screenshot
+	SnapshotDir    string              // Каталог снимков HTML
+	SnapshotAll    bool                // Сохранять HTML всех страниц, а не только задач с Snapshot
+	Timeouts       config.TaskTimeouts // Таймауты навигации и селекторов для задач без собственных значений
+	Stealth        bool                // Скрипт go-rod/stealth для задач без Stealth.Enabled
+	Captcha        CaptchaSolver       // Прохождение стены капчи, nil - задача с капчей завершается ошибкой
+	DebugDir       string              // Каталог артефактов страниц, которые не загрузились или не дали данных, по имени задачи и времени. Пустое значение отключает сбор
+	HARDir         string              // Каталог записей сетевых запросов HAR, пустое значение отключает запись
+	HARAll         bool                // Записывать HAR всех задач, а не только задач с HAR
+	PDFDir         string              // Каталог PDF-копий страниц
+	PDFAll         bool                // Сохранять PDF всех страниц, а не только задач с PDF
+	Budget         RequestBudget       // Дневные лимиты запросов к сайтам, nil - без лимитов
+	browsers       []*browserInstance  // Набор браузеров, страницы распределяются по наименее загруженному
+	sessions       *sessionStore
+	MaxPageUses    int           // Задач на одной странице пула до ее закрытия, 0 - без ограничения
+	slots          chan struct{} // Семафор страниц, занятых задачами, емкость - предел страниц
+	activePages    int
+	mu             sync.Mutex
 }
 
 // TaskToScrape структура для задачи скрапинга
 type TaskToScrape struct {
-	Task    config.ScraperTask
-	Context context.Context
-	Scraper Scraper
-	Logger  log.Logger
+	Task          config.ScraperTask
+	Context       context.Context
+	Scraper       Scraper
+	Logger        log.Logger
+	RunID         string
+	ExecID        string
+	SlowThreshold time.Duration
+	Schedule      string
+	PlannedAt     time.Time
+	Hooks         *hooks.Registry
+	Retry         work.RetryPolicy
+	Active        func() bool     // false - задача удалена из конфигурации до начала попытки, nil - всегда актуальна
+	Timeout       time.Duration   // Таймаут одной попытки, 0 - config.DefaultTaskTimeouts.Scrape
+	Limiter       *SourceLimiter  // Ограничение одновременных задач источника, nil - без ограничения
+	Images        ImageDownloader // Сохранение изображений ключей с типом image, nil - ссылки остаются как есть
+	Limits        config.ResultLimits
+
+	createdAt time.Time
+	attempt   int
+	execCtx   context.Context // Контекст текущей попытки со спаном пула
 }
 
 // Execute выполняет задачу скрапинга
-func (t TaskToScrape) Execute() (any, error) {
-	ctx, cancel := context.WithTimeout(t.Context, 30*time.Second)
+func (t TaskToScrape) Execute() (result *models.ScrapingResult, err error) {
+	base := t.Context
+	if t.execCtx != nil {
+		base = t.execCtx
+	}
+	start := time.Now()
+	ctx := applog.WithExecutionID(base, t.ExecID)
+	ctx = withRunID(ctx, t.RunID)
+
+	event := hooks.TaskEvent{RunID: t.RunID, ExecID: t.ExecID, Task: t.Task, Start: start, Attempt: t.Attempt()}
+	t.Hooks.TaskStarted(ctx, event)
+	defer func() {
+		event.Duration = time.Since(start)
+		event.Result = result
+		event.Err = err
+		event.Retrying = t.Retry.ShouldRetry(event.Attempt, err)
+		t.Hooks.TaskFinished(ctx, event)
+	}()
+
+	// Задача удалена при перезагрузке файла задач, пока ждала в очереди или повтора
+	if t.Active != nil && !t.Active() {
+		return nil, errs.Wrap(errs.CodeCanceled, "execute", ErrTaskRetired)
+	}
+
+	// Ожидание свободного места у источника не входит в таймаут попытки
+	release, err := t.Limiter.Acquire(ctx, t.Task)
+	if err != nil {
+		return nil, errs.Wrap(errs.CodeCanceled, "execute", err)
+	}
+	defer release()
+
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = config.DefaultTaskTimeouts.Scrape
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	ctx, span := tracing.Start(ctx, "scrape.task",
+		tracing.String("task.url", t.Task.URL),
+		tracing.String("task.type", t.Task.Type),
+		tracing.String("task.name", t.Task.Name),
+		tracing.String("run.id", t.RunID),
+	)
+	defer span.End()
+
+	// Время ожидания задачи в очереди пула
+	if !t.createdAt.IsZero() {
+		span.SetAttrs(tracing.Attr{Key: "pool.queue_wait_ms", Value: start.Sub(t.createdAt).Milliseconds()})
+	}
+
 	res, err := t.Scraper.Scrape(ctx, t.Task)
+	if errors.Is(err, ErrNotModified) {
+		// Неизмененная страница - пропуск, а не ошибка задачи
+		span.SetAttrs(tracing.String("task.skipped", "not_modified"))
+		tasksSkipped.With(t.Task.Type).Inc()
+		return nil, err
+	}
 	if err != nil {
+		span.RecordError(err)
+		span.SetAttrs(tracing.String("error.code", string(errs.CodeOf(err))))
+		taskErrors.With(t.Task.Type, string(errs.CodeOf(err))).Inc()
+		// Блокировка и стена капчи - признаки защиты от ботов: запросы к источнику приостанавливаются
+		if code := errs.CodeOf(err); code == errs.CodeBlocked || code == errs.CodeCaptcha {
+			if until, ok := t.Limiter.Block(t.Task.URL); ok {
+				t.Logger.Warn("Source blocked the scraper, pausing its tasks",
+					"url", t.Task.URL, "code", code, "until", until.Format(time.TimeOnly), applog.ExecutionIDKey, t.ExecID)
+			}
+		}
 		return nil, err
 	}
 
-	t.Logger.Info("Scraped Result", "url", t.Task.URL, "type", t.Task.Type)
+	res.Metadata.TraceID = span.TraceID()
+	res.Metadata.SpanID = span.SpanID()
+
+	res.Metadata.Duration = time.Since(start)
+	if t.SlowThreshold > 0 && res.Metadata.Duration > t.SlowThreshold {
+		res.Metadata.Slow = true
+		slowTasks.With(t.Task.Type).Inc()
+		t.Logger.Warn("Slow task detected",
+			"url", t.Task.URL,
+			"type", t.Task.Type,
+			"duration", res.Metadata.Duration,
+			"threshold", t.SlowThreshold,
+			applog.ExecutionIDKey, t.ExecID)
+	}
+	res.Metadata.Attempt = t.Attempt()
+	res.Metadata.TaskFingerprint = t.Task.Fingerprint()
+	res.Metadata.RunID = t.RunID
+	res.Metadata.ExecutionID = t.ExecID
+	res.Project = t.Task.Project
+	res.AddTags(t.Task.Tags...)
+
+	if err := applyTransforms(res, t.Task.Transforms); err != nil {
+		return nil, err
+	}
+	if err := enrich.Derive(res, t.Task.Derived); err != nil {
+		return nil, err
+	}
+	if applyLimits(res, t.Limits) {
+		truncatedResults.With(t.Task.Type).Inc()
+		t.Logger.Warn("Result values truncated by size limits",
+			"url", t.Task.URL, "keys", res.Metadata.Truncated, applog.ExecutionIDKey, t.ExecID)
+	}
+	applyTypes(res, t.Task, time.Now())
+	if err := checkQuality(res, t.Task); err != nil {
+		span.RecordError(err)
+		taskErrors.With(t.Task.Type, string(errs.CodeOf(err))).Inc()
+		return nil, err
+	}
+	if res.Metadata.Status == models.StatusInvalid {
+		t.Logger.Warn("Result failed quality check, saving as invalid",
+			"url", t.Task.URL, "issues", res.Metadata.Issues, applog.ExecutionIDKey, t.ExecID)
+	}
+	downloadImages(ctx, res, t.Task, t.Images, t.Logger)
+
+	t.Logger.Info("Scraped Result", "url", t.Task.URL, "type", t.Task.Type, applog.ExecutionIDKey, t.ExecID)
 	return res, nil
 }
 
+// ErrTaskRetired - задача удалена из конфигурации и пропущена
+var ErrTaskRetired = errors.New("task removed from configuration")
+
 // OnError обрабатывает ошибки
 func (t TaskToScrape) OnError(err error) {
-	t.Logger.Error("Failed to scrape task", "url", t.Task.URL, "error", err)
+	if errors.Is(err, ErrNotModified) {
+		t.Logger.Debug("Page not modified, task skipped", "url", t.Task.URL, applog.ExecutionIDKey, t.ExecID)
+		return
+	}
+	t.Logger.Error("Failed to scrape task",
+		"url", t.Task.URL,
+		"error", err,
+		"code", errs.CodeOf(err),
+		"attempts", t.Attempt(),
+		applog.ExecutionIDKey, t.ExecID)
 }
 
-// NewRodScraper создает новый скрапер на основе Rod
-func NewRodScraper(browser *rod.Browser, logger log.Logger, maxPages int) *RodScraper {
-	if maxPages <= 0 {
-		maxPages = 10 // Значение по умолчанию
-	}
+// RetryPolicy возвращает политику повторов задачи для пула
+func (t TaskToScrape) RetryPolicy() work.RetryPolicy {
+	return t.Retry
+}
 
-	scraper := &RodScraper{
-		Browser:      browser,
-		Logger:       logger,
-		maxPageCount: maxPages,
-		pagePool: &sync.Pool{
-			New: func() any {
-				page, err := stealth.Page(browser)
-				if err != nil {
-					logger.Error("Failed to create page", "error", err)
-					return nil
-				}
-				return page
-			},
-		},
-	}
+// Priority возвращает приоритет задачи в очереди пула, неизвестное значение считается обычным
+func (t TaskToScrape) Priority() work.Priority {
+	p, _ := work.ParsePriority(t.Task.Priority)
+	return p
+}
 
-	return scraper
+// SetAttempt сохраняет номер попытки, которую пул выполнит следующей
+func (t *TaskToScrape) SetAttempt(attempt int) {
+	t.attempt = attempt
 }
 
-// NewTaskToScrape создает новую задачу скрапинга
-func NewTaskToScrape(task config.ScraperTask, ctx context.Context, scraper Scraper, logger log.Logger) *TaskToScrape {
-	return &TaskToScrape{
-		Task:    task,
-		Context: ctx,
-		Scraper: scraper,
-		Logger:  logger,
-	}
+// TraceContext возвращает контекст, в котором пул начинает спаны попыток задачи
+func (t TaskToScrape) TraceContext() context.Context {
+	return t.Context
 }
 
-// getPage получает страницу из пула или создает новую
-func (r *RodScraper) getPage() (*rod.Page, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+// SetExecContext сохраняет контекст попытки, в котором выполнится Execute
+func (t *TaskToScrape) SetExecContext(ctx context.Context) {
+	t.execCtx = ctx
+}
 
-	if r.activePages >= r.maxPageCount {
-		return nil, errors.New("maximum number of active pages reached")
-	}
+// Attempt возвращает номер текущей попытки, с 1
+func (t TaskToScrape) Attempt() int {
+	return max(t.attempt, 1)
+}
 
-	page := r.pagePool.Get()
-	if page == nil {
-		return nil, errors.New("failed to get page from pool")
-	}
+// TaskSchedule возвращает имя расписания задачи для метрик пула
+func (t TaskToScrape) TaskSchedule() string {
+	return t.Schedule
+}
 
-	r.activePages++
-	return page.(*rod.Page), nil
+// PlannedStart возвращает запланированное время запуска (по умолчанию - время создания задачи)
+func (t TaskToScrape) PlannedStart() time.Time {
+	if !t.PlannedAt.IsZero() {
+		return t.PlannedAt
+	}
+	return t.createdAt
 }
 
-// releasePage возвращает страницу в пул
-func (r *RodScraper) releasePage(page *rod.Page) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+// ExecutionID возвращает идентификатор выполнения задачи для логов пула
+func (t TaskToScrape) ExecutionID() string {
+	return t.ExecID
+}
 
-	// Очищаем страницу перед возвратом в пул
-	page.MustNavigate("about:blank")
+// NewTaskToScrape создает новую задачу скрапинга
+func NewTaskToScrape(task config.ScraperTask, ctx context.Context, scraper Scraper, logger log.Logger) *TaskToScrape {
+	return &TaskToScrape{
+		Task:      task,
+		Context:   ctx,
+		Scraper:   scraper,
+		Logger:    logger,
+		ExecID:    applog.NewExecutionID(),
+		createdAt: time.Now(),
+	}
+}
 
-	r.pagePool.Put(page)
-	r.activePages--
+// timeouts возвращает таймауты задачи с учетом значений скрапера
+func (r *RodScraper) timeouts(task config.ScraperTask) config.TaskTimeouts {
+	return task.Timeouts(r.Timeouts)
 }
 
 // Scrape выполняет скрапинг страницы
-func (r *RodScraper) Scrape(ctx context.Context, task config.ScraperTask) (*models.ScrapingResult, error) {
-	r.Logger.Info("Scraping", "url", task.URL)
+func (r *RodScraper) Scrape(ctx context.Context, task config.ScraperTask) (_ *models.ScrapingResult, err error) {
+	logger := r.loggerFrom(ctx)
+	logger.Info("Scraping", "url", task.URL)
 
 	// Проверяем, отменен ли контекст
 	select {
 	case <-ctx.Done():
-		return nil, ErrContextCancelled
+		return nil, errs.Wrap(errs.CodeCanceled, "scrape", ErrContextCancelled)
 	default:
 	}
 
-	// Получаем страницу из пула
-	page, err := r.getPage()
+	if err := consumeBudget(ctx, r.Budget, task.URL); err != nil {
+		logger.Warn("Skipping page", "url", task.URL, "reason", err)
+		return nil, err
+	}
+
+	proxyURL, err := r.Proxies.For(task.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Получаем страницу из пула или отдельный контекст с прокси
+	_, pageSpan := tracing.Start(ctx, "scrape.page")
+	if proxyURL != nil {
+		pageSpan.SetAttrs(tracing.String("proxy", proxy.Server(proxyURL)))
+	}
+	page, release, err := r.acquirePage(ctx, proxyURL)
+	if err != nil {
+		logger.Error("Failed to get page", "error", err)
+		pageSpan.RecordError(err)
+		pageSpan.End()
+		return nil, err
+	}
+	pageSpan.End()
+	defer release()
+
+	resetStealth, err := r.applyStealth(page, task)
+	defer resetStealth()
+	if err != nil {
+		logger.Error("Failed to apply stealth profile", "error", err)
+		return nil, err
+	}
+
+	block := task.Block
+	if block == nil {
+		block = r.Block
+	}
+	var proxyAuth *url.Userinfo
+	if proxyURL != nil {
+		proxyAuth = proxyURL.User
+	}
+	headers, err := task.RequestHeaders()
+	if err != nil {
+		logger.Error("Failed to resolve request headers", "error", err)
+		return nil, errs.Wrap(errs.CodeAuth, "request headers", err)
+	}
+	intercept, err := startInterception(ctx, page, interceptOptions{
+		Block:     block,
+		SiteURL:   task.URL,
+		ProxyAuth: proxyAuth,
+		Headers:   headers,
+	})
 	if err != nil {
-		r.Logger.Error("Failed to get page", "error", err)
+		logger.Error("Failed to enable request interception", "error", err)
 		return nil, err
 	}
-	defer r.releasePage(page)
+	defer intercept.Stop()
 
 	// Навигация с учетом контекста
-	navCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	navCtx, cancel := context.WithTimeout(ctx, r.timeouts(task).Navigation)
 	defer cancel()
 
+	var console *consoleCollector
+	if r.CaptureConsole {
+		console = startConsoleCapture(ctx, page)
+		defer console.Stop()
+	}
+
+	// При сбое или пустом результате сохраняем артефакты до возврата страницы в пул
+	var network *networkRecorder
+	if r.ArtifactDir != "" || r.DebugDir != "" {
+		network = startNetworkCapture(ctx, page)
+		defer network.Stop()
+		defer func() {
+			if err != nil {
+				err = r.saveFailureBundle(ctx, page, task, console, network, err)
+			}
+		}()
+	}
+	// Полная запись сетевых запросов для отладки: тяжелая, поэтому только по HARDir и флагу задачи
+	var harPath string
+	if r.HARDir != "" && (r.HARAll || task.HAR) {
+		if path, err := capturePath(ctx, r.HARDir, task, ".har"); err != nil {
+			logger.Warn("Failed to create HAR directory", "error", err)
+		} else {
+			harPath = path
+			har := startHARCapture(ctx, page)
+			defer func() {
+				har.Stop()
+				r.saveHAR(ctx, har, harPath)
+			}()
+		}
+	}
+
+	if task.Login != nil {
+		if err := r.ensureLogin(ctx, page, task); err != nil {
+			return nil, err
+		}
+	}
+
+	navStart := time.Now()
+	_, navSpan := tracing.Start(ctx, "scrape.navigate", tracing.String("url", task.URL))
+
 	err = page.Context(navCtx).Navigate(task.URL)
 	if err != nil {
-		r.Logger.Error("Failed to navigate to page", "url", task.URL, "error", err)
-		return nil, err
+		logger.Error("Failed to navigate to page", "url", task.URL, "error", err)
+		navSpan.RecordError(err)
+		navSpan.End()
+		navigations.With("error").Inc()
+		return nil, navigationError("navigate", err)
 	}
 
 	// Ожидание загрузки страницы с таймаутом
 	err = page.Context(ctx).WaitLoad()
 	if err != nil {
-		r.Logger.Error("Failed to wait for page load", "url", task.URL, "error", err)
+		logger.Error("Failed to wait for page load", "url", task.URL, "error", err)
+		navSpan.RecordError(err)
+		navSpan.End()
+		navigations.With("error").Inc()
+		return nil, navigationError("wait load", err)
+	}
+	navSpan.End()
+	navigations.With("ok").Inc()
+	navigationDuration.With().Observe(time.Since(navStart).Seconds())
+
+	meta := models.ScrapeMeta{
+		Engine:             EngineRod,
+		NavigationDuration: time.Since(navStart),
+		Extras:             make(map[string]any),
+	}
+	r.fillPageInfo(ctx, page, &meta)
+	meta.HAR = harPath
+	if proxyURL != nil {
+		meta.Extras["proxy"] = proxy.Server(proxyURL)
+	}
+
+	if err := r.checkCaptcha(ctx, page, task, &meta); err != nil {
 		return nil, err
 	}
 
-	data := make(map[string]string)
+	// Страницы с отказом в доступе не разбираем, их содержимое не относится к задаче
+	if meta.HTTPStatus == 403 || meta.HTTPStatus == 429 {
+		logger.Warn("Access blocked by site", "url", task.URL, "status", meta.HTTPStatus)
+		return nil, errs.Wrap(errs.CodeBlocked, "navigate", fmt.Errorf("HTTP status %d", meta.HTTPStatus))
+	}
+	if r.isBlockPage(ctx, page) {
+		logger.Warn("Access blocked by site", "url", task.URL, "status", meta.HTTPStatus, "reason", "block page")
+		return nil, errs.Wrap(errs.CodeBlocked, "navigate", ErrBlockPage)
+	}
+
+	if err := r.waitTask(ctx, page, task); err != nil {
+		return nil, err
+	}
 
-	for key, selector := range task.Selectors {
-		// Проверяем, отменен ли контекст
-		select {
-		case <-ctx.Done():
-			r.Logger.Warn("Scraping canceled during selector processing", "key", key)
-			return models.NewScrapingResult(task.URL, task.Type, task.Name, data), ctx.Err()
-		default:
+	if len(task.Actions) > 0 {
+		if err := r.runActions(ctx, page, task); err != nil {
+			logger.Error("Page action failed", "url", task.URL, "error", err)
+			return nil, err
 		}
+	}
 
-		if selector == "" {
-			data[key] = ""
-			continue
+	if r.ScreenshotDir != "" && (r.ScreenshotAll || task.Screenshot) {
+		if path, err := r.captureScreenshot(ctx, page, task); err != nil {
+			logger.Warn("Failed to capture screenshot", "url", task.URL, "error", err)
+		} else {
+			meta.Screenshot = path
 		}
+	}
 
-		// Устанавливаем таймаут для поиска элементов
-		elemCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-		elements, err := page.Context(elemCtx).Elements(selector)
-		cancel()
+	if r.SnapshotDir != "" && (r.SnapshotAll || task.Snapshot) {
+		if path, err := r.captureSnapshot(ctx, page, task); err != nil {
+			logger.Warn("Failed to save HTML snapshot", "url", task.URL, "error", err)
+		} else {
+			meta.Snapshot = path
+		}
+	}
 
-		if err != nil || len(elements) == 0 {
-			r.Logger.Warn("No elements found", "selector", selector, "page", task.URL)
-			data[key] = ""
-			continue
+	if r.PDFDir != "" && (r.PDFAll || task.PDF) {
+		if path, err := r.capturePDF(ctx, page, task); err != nil {
+			logger.Warn("Failed to save page PDF", "url", task.URL, "error", err)
+		} else {
+			meta.PDF = path
 		}
+	}
 
-		var texts []string
-		for _, element := range elements {
-			// Проверяем, отменен ли контекст
-			select {
-			case <-ctx.Done():
-				r.Logger.Warn("Scraping canceled during element processing", "key", key)
-				return models.NewScrapingResult(task.URL, task.Type, task.Name, data), ctx.Err()
-			default:
-			}
+	// Базовый адрес для относительных ссылок с учетом редиректов
+	baseURL, _ := url.Parse(task.URL)
+	if final, err := url.Parse(meta.FinalURL); err == nil && meta.FinalURL != "" {
+		baseURL = final
+	}
 
-			// Устанавливаем таймаут для получения текста
-			textCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
-			text, err := element.Context(textCtx).Text()
-			cancel()
+	extractStart := time.Now()
+	extracted := newExtraction()
 
-			if err != nil {
-				r.Logger.Warn("Failed to get text from element", "selector", selector, "error", err)
-				continue
+	extractCtx, extractSpan := tracing.Start(ctx, "scrape.extract", tracing.String("mode", task.Mode))
+	defer extractSpan.End()
+
+	for pageNum := 1; ; pageNum++ {
+		if pageNum > 1 {
+			if err := r.waitTask(extractCtx, page, task); err != nil {
+				return models.NewScrapingResult(task.URL, task.Type, task.Name, extracted.data()), err
 			}
+		}
 
-			texts = append(texts, text)
+		extract := r.extractSelectors
+		if task.ItemsMode() {
+			extract = r.extractItems
+		}
+		extractSpan.SetAttrs(tracing.Int("pages", pageNum))
+		if err := extract(extractCtx, page, task, baseURL, extracted); err != nil {
+			extractSpan.RecordError(err)
+			return models.NewScrapingResult(task.URL, task.Type, task.Name, extracted.data()), err
 		}
 
-		data[key] = strings.Join(texts, "\n")
-		r.Logger.Info("Successfully scraped", "key", key, "count", len(texts))
+		if pageNum >= task.PageLimit() {
+			break
+		}
+		next, err := r.nextPage(extractCtx, page, task, baseURL, extracted.visited)
+		if err != nil {
+			logger.Warn("Failed to follow next page", "url", task.URL, "page", pageNum, "error", err)
+			break
+		}
+		if next == nil {
+			break
+		}
+		baseURL = next
+		meta.Extras["pages"] = pageNum + 1
+	}
+
+	data := extracted.data()
+	confidence := extracted.confidence()
+	missing := extracted.missing()
+	if task.ItemsMode() && len(extracted.items) == 0 {
+		missing = append(missing, "ItemSelector")
+	}
+	for _, key := range missing {
+		if meta.Errors == nil {
+			meta.Errors = make(map[string]string)
+		}
+		meta.Errors[key] = string(errs.CodeSelectorNotFound)
+	}
+
+	if task.Script != "" {
+		// Скрипт получает снимок HTML текущей, последней загруженной страницы
+		html, err := page.Context(extractCtx).HTML()
+		if err != nil {
+			err = errs.Wrap(errs.CodeScript, "task script", err)
+		} else {
+			err = runTaskScript(extractCtx, baseURL.String(), html, task.Script, data)
+		}
+		if err != nil {
+			logger.Error("Task script failed", "url", task.URL, "error", err)
+			extractSpan.RecordError(err)
+			return nil, err
+		}
 	}
 
+	meta.ExtractionDuration = time.Since(extractStart)
+	extractSpan.SetAttrs(tracing.Int("missing", len(missing)))
+	extractSpan.End()
+	scrapeDuration.With(task.Type).Observe((meta.NavigationDuration + meta.ExtractionDuration).Seconds())
+
 	result := models.NewScrapingResult(task.URL, task.Type, task.Name, data)
+	result.Metadata = meta
+	if task.ItemsMode() {
+		result.SetItems(extracted.items)
+	}
+	if console != nil {
+		result.Debug = console.Stop()
+		if result.Debug != nil && len(result.Debug.PageErrors) > 0 {
+			logger.Warn("Page reported JavaScript errors", "url", task.URL, "count", len(result.Debug.PageErrors))
+		}
+	}
+	for key, score := range confidence {
+		result.SetConfidence(key, score)
+	}
+	if r.DebugDir != "" && !hasData(result) {
+		dir := r.bundleDir(ctx, task, debugEmpty)
+		if r.saveBundle(ctx, dir, page, task, console, network, nil) {
+			result.Metadata.Extras["debug_artifacts"] = dir
+		}
+	}
 
 	return result, nil
 }
 
-// Close закрывает ресурсы скрапера
-func (r *RodScraper) Close() error {
-	// Закрываем браузер при завершении
-	return r.Browser.Close()
+// fillPageInfo заполняет итоговый URL и HTTP-статус загруженной страницы
+func (r *RodScraper) fillPageInfo(ctx context.Context, page *rod.Page, meta *models.ScrapeMeta) {
+	infoCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	if info, err := page.Context(infoCtx).Info(); err == nil {
+		meta.FinalURL = info.URL
+	}
+
+	// responseStatus доступен в Navigation Timing API начиная с Chromium 109
+	status, err := page.Context(infoCtx).Eval(`() => {
+		const nav = performance.getEntriesByType("navigation")[0];
+		return nav && nav.responseStatus ? nav.responseStatus : 0;
+	}`)
+	if err != nil {
+		r.loggerFrom(ctx).Debug("Failed to get response status", "error", err)
+		return
+	}
+	meta.HTTPStatus = status.Value.Int()
+}
+
+// RetryableError сообщает, может ли повтор задачи завершиться успешно:
+// таймауты, сбои навигации и временные блокировки сайтом
+func RetryableError(err error) bool {
+	switch errs.CodeOf(err) {
+	case errs.CodeTimeout, errs.CodeNavigation, errs.CodeBlocked, errs.CodeUnknown:
+		return true
+	}
+	return false
+}
+
+// navigationError классифицирует ошибку навигации: истечение таймаута или сбой загрузки страницы
+func navigationError(op string, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errs.Wrap(errs.CodeTimeout, op, err)
+	}
+	return errs.Wrap(errs.CodeNavigation, op, err)
+}
+
+// loggerFrom возвращает логгер с идентификатором выполнения задачи из контекста
+func (r *RodScraper) loggerFrom(ctx context.Context) *log.Logger {
+	return r.Logger.With(applog.ContextKeyvals(ctx)...)
 }
@@ -0,0 +1,108 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/dop251/goja"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+)
+
+// scriptTimeout ограничивает время выполнения пользовательского скрипта задачи
+const scriptTimeout = 5 * time.Second
+
+// errScriptTimeout прерывает скрипт, превысивший scriptTimeout
+var errScriptTimeout = errors.New("script timed out")
+
+// runTaskScript выполняет JavaScript-скрипт задачи во встроенном интерпретаторе goja над снимком
+// HTML страницы, одинаково для браузера и движка http. Скрипт - тело асинхронной функции
+// с доступом к html (HTML страницы), url (адрес страницы), извлеченным данным data и функции
+// query(selector[, attr]), которая возвращает тексты или значения атрибута найденных элементов.
+// DOM и сеть скрипту недоступны. Возвращенный объект дополняет data: строки сохраняются как есть,
+// остальные значения - в JSON, null удаляет ключ. Если скрипт ничего не вернул, data не изменяется
+func runTaskScript(ctx context.Context, pageURL, html, script string, data map[string]string) error {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return errs.Wrap(errs.CodeScript, "task script", err)
+	}
+
+	vm := goja.New()
+	input := make(map[string]any, len(data))
+	for key, value := range data {
+		input[key] = value
+	}
+	for name, value := range map[string]any{
+		"html":  html,
+		"url":   pageURL,
+		"data":  input,
+		"query": scriptQuery(doc),
+	} {
+		if err := vm.Set(name, value); err != nil {
+			return errs.Wrap(errs.CodeScript, "task script", err)
+		}
+	}
+
+	timer := time.AfterFunc(scriptTimeout, func() { vm.Interrupt(errScriptTimeout) })
+	defer timer.Stop()
+	stop := context.AfterFunc(ctx, func() { vm.Interrupt(ctx.Err()) })
+	defer stop()
+
+	// Задания промисов выполняются до возврата из RunString, await внутри скрипта не требует цикла событий
+	res, err := vm.RunString("(async (data) => {\n" + script + "\n})(data)")
+	if err != nil {
+		return errs.Wrap(errs.CodeScript, "task script", err)
+	}
+	if promise, ok := res.Export().(*goja.Promise); ok {
+		switch promise.State() {
+		case goja.PromiseStateRejected:
+			return errs.Wrap(errs.CodeScript, "task script", errors.New(promise.Result().String()))
+		case goja.PromiseStatePending:
+			return errs.Wrap(errs.CodeScript, "task script", errors.New("script awaits a promise that never settles"))
+		}
+		res = promise.Result()
+	}
+
+	obj, ok := res.(*goja.Object)
+	if !ok {
+		return nil
+	}
+	for _, key := range obj.Keys() {
+		value := obj.Get(key)
+		if goja.IsNull(value) || goja.IsUndefined(value) {
+			delete(data, key)
+			continue
+		}
+		if str, ok := value.Export().(string); ok {
+			data[key] = str
+			continue
+		}
+		encoded, err := json.Marshal(value.Export())
+		if err != nil {
+			return errs.Wrap(errs.CodeScript, "task script", err)
+		}
+		data[key] = string(encoded)
+	}
+	return nil
+}
+
+// scriptQuery возвращает функцию query скрипта: тексты элементов по CSS-селектору
+// или значения атрибута attr, если он задан. Элементы без атрибута пропускаются
+func scriptQuery(doc *goquery.Document) func(selector string, attr ...string) []string {
+	return func(selector string, attr ...string) []string {
+		values := []string{}
+		doc.Find(selector).Each(func(_ int, s *goquery.Selection) {
+			if len(attr) == 0 || attr[0] == "" {
+				values = append(values, strings.TrimSpace(s.Text()))
+				return
+			}
+			if value, ok := s.Attr(attr[0]); ok {
+				values = append(values, value)
+			}
+		})
+		return values
+	}
+}
@@ -0,0 +1,74 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/rx3lixir/kultscraper/internal/config"
+)
+
+// elementFinder - страница или элемент, внутри которых ищутся селекторы
+type elementFinder interface {
+	Elements(selector string) (rod.Elements, error)
+	ElementsX(xpath string) (rod.Elements, error)
+}
+
+// parseSelector определяет синтаксис селектора по префиксу "xpath:"/"css:",
+// без префикса используется синтаксис задачи по умолчанию
+func parseSelector(defaultType, selector string) (string, string) {
+	for _, t := range []string{config.SelectorXPath, config.SelectorCSS} {
+		if rest, ok := strings.CutPrefix(selector, t+":"); ok {
+			return t, strings.TrimSpace(rest)
+		}
+	}
+	if strings.EqualFold(defaultType, config.SelectorXPath) {
+		return config.SelectorXPath, selector
+	}
+	return config.SelectorCSS, selector
+}
+
+// findElements ищет элементы по CSS или XPath селектору
+func findElements(root elementFinder, defaultType, selector string) (rod.Elements, error) {
+	selectorType, expr := parseSelector(defaultType, selector)
+	if selectorType == config.SelectorXPath {
+		return root.ElementsX(expr)
+	}
+	return root.Elements(expr)
+}
+
+// extractValue извлекает значение элемента в заданном режиме.
+// Ссылки из атрибутов href и src приводятся к абсолютным относительно base
+func extractValue(el *rod.Element, mode string, base *url.URL) (string, error) {
+	mode = strings.TrimSpace(mode)
+	switch {
+	case mode == "" || strings.EqualFold(mode, config.ExtractText):
+		return el.Text()
+	case strings.EqualFold(mode, config.ExtractHTML):
+		return el.HTML()
+	case strings.HasPrefix(strings.ToLower(mode), config.ExtractAttrPrefix):
+		name := strings.TrimSpace(mode[len(config.ExtractAttrPrefix):])
+		value, err := el.Attribute(name)
+		if err != nil {
+			return "", err
+		}
+		if value == nil {
+			return "", fmt.Errorf("attribute %q not found", name)
+		}
+		if base != nil && (strings.EqualFold(name, "href") || strings.EqualFold(name, "src")) {
+			return resolveURL(base, *value), nil
+		}
+		return *value, nil
+	}
+	return "", fmt.Errorf("unknown extraction mode %q", mode)
+}
+
+// resolveURL приводит ссылку к абсолютной, некорректные ссылки возвращаются как есть
+func resolveURL(base *url.URL, ref string) string {
+	u, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
@@ -0,0 +1,121 @@
+package scraper
+
+import (
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/stealth"
+	"github.com/rx3lixir/kultscraper/internal/config"
+)
+
+// hideWebdriverScript скрывает navigator.webdriver без остального скрипта stealth
+const hideWebdriverScript = `Object.defineProperty(Navigator.prototype, "webdriver", {get: () => false, configurable: true})`
+
+// noiseScript добавляет шум в пиксели canvas и WebGL: отпечаток отличается
+// для каждой страницы, а изображение остается неизменным на глаз
+const noiseScript = `(() => {
+	const seed = Math.floor(Math.random() * 251) + 1;
+	const noisy = (data) => {
+		for (let i = (seed % 7) * 4; i < data.length; i += 4 * 97) data[i] ^= 1;
+		return data;
+	};
+
+	const getImageData = CanvasRenderingContext2D.prototype.getImageData;
+	CanvasRenderingContext2D.prototype.getImageData = function (...args) {
+		const image = getImageData.apply(this, args);
+		noisy(image.data);
+		return image;
+	};
+
+	const toDataURL = HTMLCanvasElement.prototype.toDataURL;
+	HTMLCanvasElement.prototype.toDataURL = function (...args) {
+		if (!this.width || !this.height) return toDataURL.apply(this, args);
+		const copy = document.createElement("canvas");
+		copy.width = this.width;
+		copy.height = this.height;
+		const ctx = copy.getContext("2d");
+		ctx.drawImage(this, 0, 0);
+		ctx.putImageData(ctx.getImageData(0, 0, copy.width, copy.height), 0, 0);
+		return toDataURL.apply(copy, args);
+	};
+
+	for (const name of ["WebGLRenderingContext", "WebGL2RenderingContext"]) {
+		const proto = window[name] && window[name].prototype;
+		if (!proto) continue;
+		const readPixels = proto.readPixels;
+		proto.readPixels = function (...args) {
+			readPixels.apply(this, args);
+			const pixels = args.find((arg) => ArrayBuffer.isView(arg));
+			if (pixels) noisy(pixels);
+		};
+	}
+})()`
+
+// applyStealth применяет к странице профиль маскировки задачи и возвращает функцию,
+// которая снимает его перед возвратом страницы в пул
+func (r *RodScraper) applyStealth(page *rod.Page, task config.ScraperTask) (func(), error) {
+	profile := task.Stealth
+	var undo []func() error
+	reset := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			_ = undo[i]()
+		}
+	}
+
+	addScript := func(js string) error {
+		remove, err := page.EvalOnNewDocument(js)
+		if err == nil {
+			undo = append(undo, remove)
+		}
+		return err
+	}
+
+	var err error
+	if profile.StealthEnabled(r.Stealth) {
+		err = addScript(stealth.JS)
+	} else if profile != nil && profile.HideWebdriver {
+		err = addScript(hideWebdriverScript)
+	}
+	if err != nil || profile == nil {
+		return reset, err
+	}
+
+	if profile.Noise {
+		if err := addScript(noiseScript); err != nil {
+			return reset, err
+		}
+	}
+	if profile.Timezone != "" {
+		if err := (proto.EmulationSetTimezoneOverride{TimezoneID: profile.Timezone}).Call(page); err != nil {
+			return reset, err
+		}
+		undo = append(undo, func() error { return proto.EmulationSetTimezoneOverride{}.Call(page) })
+	}
+	if profile.Locale != "" {
+		if err := r.setLocale(page, profile.Locale); err != nil {
+			return reset, err
+		}
+		undo = append(undo, func() error { return r.setLocale(page, "") })
+	}
+	if width, height, ok := profile.ViewportSize(); ok {
+		if err := page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{Width: width, Height: height, DeviceScaleFactor: 1}); err != nil {
+			return reset, err
+		}
+		undo = append(undo, func() error { return page.SetViewport(nil) })
+	}
+	return reset, nil
+}
+
+// setLocale задает локаль страницы и заголовок Accept-Language, пустая локаль снимает переопределение
+func (r *RodScraper) setLocale(page *rod.Page, locale string) error {
+	// Chromium ожидает локаль ICU ("ru_RU"), а заголовок - тег языка ("ru-RU")
+	if err := (proto.EmulationSetLocaleOverride{Locale: strings.ReplaceAll(locale, "-", "_")}).Call(page); err != nil {
+		return err
+	}
+	version, err := proto.BrowserGetVersion{}.Call(page)
+	if err != nil {
+		return err
+	}
+	return proto.NetworkSetUserAgentOverride{UserAgent: version.UserAgent, AcceptLanguage: locale}.Call(page)
+}
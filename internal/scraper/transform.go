@@ -0,0 +1,108 @@
+package scraper
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// htmlTag - тег или комментарий разметки для шага strip_html
+var htmlTag = regexp.MustCompile(`(?s)<!--.*?-->|<[^>]*>`)
+
+// transformFunc обрабатывает значение ключа
+type transformFunc func(string) string
+
+// compileTransform собирает функцию шага обработки
+func compileTransform(step config.FieldTransform) (transformFunc, error) {
+	switch strings.ToLower(step.Type) {
+	case config.TransformTrim:
+		return func(s string) string {
+			var lines []string
+			for line := range strings.Lines(s) {
+				if line = strings.TrimSpace(line); line != "" {
+					lines = append(lines, line)
+				}
+			}
+			return strings.Join(lines, "\n")
+		}, nil
+	case config.TransformLower:
+		return strings.ToLower, nil
+	case config.TransformUpper:
+		return strings.ToUpper, nil
+	case config.TransformStripHTML:
+		return func(s string) string {
+			return html.UnescapeString(htmlTag.ReplaceAllString(s, ""))
+		}, nil
+	case config.TransformSplit:
+		return func(s string) string {
+			return strings.ReplaceAll(s, step.Value, "\n")
+		}, nil
+	case config.TransformJoin:
+		sep := step.Value
+		if sep == "" {
+			sep = ", "
+		}
+		return func(s string) string {
+			return strings.ReplaceAll(s, "\n", sep)
+		}, nil
+	}
+
+	re, err := regexp.Compile(step.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(step.Type) {
+	case config.TransformRegexReplace:
+		return func(s string) string {
+			return re.ReplaceAllString(s, step.Value)
+		}, nil
+	case config.TransformRegexExtract:
+		return func(s string) string {
+			var lines []string
+			for _, line := range strings.Split(s, "\n") {
+				match := re.FindStringSubmatch(line)
+				if match == nil {
+					continue
+				}
+				lines = append(lines, match[min(1, len(match)-1)])
+			}
+			return strings.Join(lines, "\n")
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown transform %q", step.Type)
+}
+
+// applyTransforms обрабатывает значения ключей результата и записей списка
+// шагами задачи. Выполняется до вычисляемых полей, чтобы они видели очищенные значения
+func applyTransforms(result *models.ScrapingResult, transforms map[string][]config.FieldTransform) error {
+	for key, steps := range transforms {
+		funcs := make([]transformFunc, 0, len(steps))
+		for i, step := range steps {
+			fn, err := compileTransform(step)
+			if err != nil {
+				return fmt.Errorf("transform %s[%d]: %w", key, i, err)
+			}
+			funcs = append(funcs, fn)
+		}
+
+		apply := func(values map[string]string) {
+			value, ok := values[key]
+			if !ok {
+				return
+			}
+			for _, fn := range funcs {
+				value = fn(value)
+			}
+			values[key] = value
+		}
+		apply(result.Data)
+		for _, item := range result.Items {
+			apply(item)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package scraper
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/enrich"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// applyTypes приводит значения ключей к типам задачи. Значения из Data сохраняются
+// в типизированные поля Metadata, в записях списка значение заменяется на
+// нормализованную строку. Нераспознанное значение остается как есть и отмечается в Metadata.Errors
+func applyTypes(result *models.ScrapingResult, task config.ScraperTask, now time.Time) {
+	if len(task.Types) == 0 {
+		return
+	}
+	now = now.In(task.Location())
+	meta := &result.Metadata
+
+	for key, typ := range task.Types {
+		// parse распознает значение, сохраняет его для Data и возвращает строку для записей списка
+		var parse func(value string, store bool) (string, bool)
+
+		switch strings.ToLower(typ) {
+		case config.TypeDate:
+			parse = func(value string, store bool) (string, bool) {
+				t, ok := enrich.ParseDateTime(value, task.DateLayouts, now)
+				if ok && store {
+					if meta.Dates == nil {
+						meta.Dates = make(map[string]time.Time)
+					}
+					meta.Dates[key] = t
+				}
+				return t.Format(time.RFC3339), ok
+			}
+		case config.TypePrice:
+			parse = func(value string, store bool) (string, bool) {
+				price, ok := enrich.ParsePrice(value)
+				if ok && store {
+					if meta.Prices == nil {
+						meta.Prices = make(map[string]models.Price)
+					}
+					meta.Prices[key] = price
+				}
+				return strings.TrimSpace(formatNumber(price.Amount) + " " + price.Currency), ok
+			}
+		case config.TypeNumber:
+			parse = func(value string, store bool) (string, bool) {
+				n, ok := enrich.ParseNumber(value)
+				if ok && store {
+					if meta.Numbers == nil {
+						meta.Numbers = make(map[string]float64)
+					}
+					meta.Numbers[key] = n
+				}
+				return formatNumber(n), ok
+			}
+		default:
+			continue
+		}
+
+		if value := result.Data[key]; value != "" {
+			if _, ok := parse(value, true); !ok {
+				invalidValue(result, key)
+			}
+		}
+
+		for _, item := range result.Items {
+			if value := item[key]; value != "" {
+				normalized, ok := parse(value, false)
+				if !ok {
+					invalidValue(result, key)
+					continue
+				}
+				item[key] = normalized
+			}
+		}
+	}
+}
+
+// formatNumber записывает число без лишних нулей: 500, 99.9
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// invalidValue отмечает ключ, значение которого не приводится к типу
+func invalidValue(result *models.ScrapingResult, key string) {
+	if result.Metadata.Errors == nil {
+		result.Metadata.Errors = make(map[string]string)
+	}
+	result.Metadata.Errors[key] = string(errs.CodeInvalidValue)
+}
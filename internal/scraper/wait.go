@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/rx3lixir/kultscraper/internal/config"
+)
+
+// Виды ожидания для метрики
+const (
+	waitSelector = "selector"
+	waitNetwork  = "network"
+)
+
+// waitReady выполняет ожидание w на странице, пустой w.Selector заменяется на selector.
+// Несработавшее ожидание не прерывает задачу: отсутствующие поля отмечаются при извлечении.
+// Возвращает ошибку только при отмене контекста
+func (r *RodScraper) waitReady(ctx context.Context, page *rod.Page, task config.ScraperTask, w config.WaitConfig, selector string) error {
+	logger := r.loggerFrom(ctx)
+	timeout := w.TimeoutOr(r.timeouts(task).Selector)
+
+	if w.Selector != "" {
+		selector = w.Selector
+	}
+	if selector != "" {
+		waitCtx, cancel := context.WithTimeout(ctx, timeout)
+		_, err := waitElement(waitCtx, page, task.SelectorType, selector)
+		cancel()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			logger.Warn("Element did not appear", "selector", selector, "timeout", timeout)
+			waitTimeouts.With(waitSelector).Inc()
+		}
+	}
+
+	if w.NetworkIdle {
+		waitCtx, cancel := context.WithTimeout(ctx, timeout)
+		page.Context(waitCtx).WaitRequestIdle(config.DefaultNetworkIdle, nil, nil, nil)()
+		timedOut := waitCtx.Err() != nil
+		cancel()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if timedOut {
+			logger.Warn("Network did not become idle", "timeout", timeout)
+			waitTimeouts.With(waitNetwork).Inc()
+		}
+	}
+
+	if delay := w.DelayDuration(); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// waitTask выполняет ожидание задачи после загрузки страницы
+func (r *RodScraper) waitTask(ctx context.Context, page *rod.Page, task config.ScraperTask) error {
+	if task.Wait == nil {
+		return nil
+	}
+	return r.waitReady(ctx, page, task, *task.Wait, "")
+}
@@ -0,0 +1,161 @@
+package stream
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Виды событий потока
+const (
+	KindTaskStart   = "task_start"
+	KindTaskFinish  = "task_finish"
+	KindResult      = "result"
+	KindRunComplete = "run_complete"
+	KindProgress    = "progress"
+)
+
+const (
+	// subscriberBuffer - размер буфера подписчика, события для медленных подписчиков отбрасываются
+	subscriberBuffer = 64
+	// historySize - число последних событий, доступных для возобновления потока
+	historySize = 1000
+)
+
+// Event - событие запуска, передаваемое подписчикам
+type Event struct {
+	ID      int64     `json:"id"`
+	Kind    string    `json:"kind"`
+	Time    time.Time `json:"time"`
+	RunID   string    `json:"run_id,omitempty"`
+	Project string    `json:"project,omitempty"`
+	Type    string    `json:"type,omitempty"`
+	Tags    []string  `json:"tags,omitempty"`
+	Payload any       `json:"payload,omitempty"`
+}
+
+// Filter отбирает события по виду, проекту, типу задачи и тегам. Пустые списки пропускают все события
+type Filter struct {
+	Kinds   []string
+	Types   []string
+	Tags    []string
+	Project *string // nil - события всех проектов
+}
+
+// Match сообщает, проходит ли событие фильтр.
+// События без типа (например, завершение запуска) проходят фильтр по типу.
+// События запуска целиком относятся ко всем проектам и фильтр по проекту не проходят
+func (f Filter) Match(e Event) bool {
+	if len(f.Kinds) > 0 && !slices.Contains(f.Kinds, e.Kind) {
+		return false
+	}
+	if f.Project != nil && (!projectEvent(e.Kind) || e.Project != *f.Project) {
+		return false
+	}
+	if len(f.Types) > 0 && e.Type != "" && !slices.Contains(f.Types, e.Type) {
+		return false
+	}
+	if len(f.Tags) > 0 && e.Kind == KindResult {
+		for _, tag := range f.Tags {
+			if slices.Contains(e.Tags, tag) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// projectEvent сообщает, относится ли событие вида kind к одной задаче и ее проекту
+func projectEvent(kind string) bool {
+	return kind == KindTaskStart || kind == KindTaskFinish || kind == KindResult
+}
+
+// Subscription - подписка на события брокера
+type Subscription struct {
+	C      chan Event
+	filter Filter
+}
+
+// Broker рассылает события всем подписчикам
+type Broker struct {
+	mu      sync.Mutex
+	subs    map[*Subscription]struct{}
+	nextID  int64
+	history []Event
+}
+
+// NewBroker создает брокер событий
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe создает подписку с фильтром
+func (b *Broker) Subscribe(f Filter) *Subscription {
+	s := &Subscription{C: make(chan Event, subscriberBuffer), filter: f}
+
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+
+	return s
+}
+
+// SubscribeFrom создает подписку и возвращает сохраненные события с идентификатором больше lastID,
+// подходящие под фильтр. Между историей и подпиской события не теряются
+func (b *Broker) SubscribeFrom(f Filter, lastID int64) (*Subscription, []Event) {
+	s := &Subscription{C: make(chan Event, subscriberBuffer), filter: f}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var backlog []Event
+	for _, e := range b.history {
+		if e.ID > lastID && f.Match(e) {
+			backlog = append(backlog, e)
+		}
+	}
+	b.subs[s] = struct{}{}
+
+	return s, backlog
+}
+
+// Unsubscribe отменяет подписку и закрывает ее канал
+func (b *Broker) Unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.C)
+	}
+}
+
+// Publish присваивает событию идентификатор и рассылает его подписчикам без блокировки
+func (b *Broker) Publish(e Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	e.ID = b.nextID
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.history = append(b.history, e)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+
+	for s := range b.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+		select {
+		case s.C <- e:
+		default:
+			droppedEvents.With().Inc()
+		}
+	}
+	return e
+}
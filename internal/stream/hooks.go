@@ -0,0 +1,85 @@
+package stream
+
+import (
+	"context"
+
+	"github.com/rx3lixir/kultscraper/hooks"
+	"github.com/rx3lixir/kultscraper/internal/lib/errs"
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// TaskPayload - данные событий начала и завершения задачи
+type TaskPayload struct {
+	URL        string `json:"url"`
+	Name       string `json:"name"`
+	ExecID     string `json:"exec_id,omitempty"`
+	DurationMS int64  `json:"duration_ms,omitempty"`
+	Error      string `json:"error,omitempty"`
+	ErrorCode  string `json:"error_code,omitempty"`
+	Attempt    int    `json:"attempt,omitempty"`
+	Retrying   bool   `json:"retrying,omitempty"`
+	Skipped    bool   `json:"skipped,omitempty"` // Страница не изменилась с прошлого сбора, задача пропущена
+}
+
+// ResultPayload - данные события сохраненного результата
+type ResultPayload struct {
+	Result        *models.ScrapingResult `json:"result"`
+	ChangeType    models.ChangeType      `json:"change_type,omitempty"`
+	ChangedFields []string               `json:"changed_fields,omitempty"`
+}
+
+// Attach публикует события жизненного цикла запуска в брокер
+func Attach(b *Broker, r *hooks.Registry) {
+	r.OnTaskStart(func(ctx context.Context, e hooks.TaskEvent) {
+		b.Publish(Event{
+			Kind:    KindTaskStart,
+			RunID:   e.RunID,
+			Project: e.Task.Project,
+			Type:    e.Task.Type,
+			Payload: TaskPayload{URL: e.Task.URL, Name: e.Task.Name, ExecID: e.ExecID},
+		})
+	})
+
+	r.OnTaskFinish(func(ctx context.Context, e hooks.TaskEvent) {
+		payload := TaskPayload{
+			URL:        e.Task.URL,
+			Name:       e.Task.Name,
+			ExecID:     e.ExecID,
+			DurationMS: e.Duration.Milliseconds(),
+			Attempt:    e.Attempt,
+			Retrying:   e.Retrying,
+		}
+		switch {
+		case errs.CodeOf(e.Err) == errs.CodeNotModified:
+			payload.Skipped = true
+		case e.Err != nil:
+			payload.Error = e.Err.Error()
+			payload.ErrorCode = string(errs.CodeOf(e.Err))
+		}
+		b.Publish(Event{Kind: KindTaskFinish, RunID: e.RunID, Project: e.Task.Project, Type: e.Task.Type, Payload: payload})
+	})
+
+	r.OnResultSaved(func(ctx context.Context, e hooks.ResultEvent) {
+		payload := ResultPayload{Result: e.Result}
+		if e.Change != nil {
+			payload.ChangeType = e.Change.ChangeType
+			payload.ChangedFields = e.Change.ChangedFields
+		}
+		b.Publish(Event{
+			Kind:    KindResult,
+			RunID:   e.RunID,
+			Project: e.Result.Project,
+			Type:    e.Result.Type,
+			Tags:    e.Result.Tags,
+			Payload: payload,
+		})
+	})
+
+	r.OnRunComplete(func(ctx context.Context, e hooks.RunEvent) {
+		b.Publish(Event{Kind: KindRunComplete, RunID: e.RunID, Payload: e.Audit})
+	})
+
+	r.OnProgress(func(ctx context.Context, e hooks.ProgressEvent) {
+		b.Publish(Event{Kind: KindProgress, RunID: e.RunID, Payload: e.Progress})
+	})
+}
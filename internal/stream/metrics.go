@@ -0,0 +1,16 @@
+package stream
+
+import "github.com/rx3lixir/kultscraper/internal/lib/metrics"
+
+// Метрики потока событий регистрируются в общем реестре при загрузке пакета
+var (
+	droppedEvents = metrics.DefaultRegistry.NewCounterVec(
+		"kultscraper_stream_dropped_events_total",
+		"Number of events dropped for slow subscribers.",
+	)
+	activeSubscribers = metrics.DefaultRegistry.NewGaugeVec(
+		"kultscraper_stream_subscribers",
+		"Number of connected stream subscribers.",
+		"transport",
+	)
+)
@@ -0,0 +1,100 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/models"
+)
+
+// keepAliveInterval - период комментариев, не дающих прокси закрыть простаивающее соединение
+const keepAliveInterval = 15 * time.Second
+
+// SSEHandler возвращает обработчик Server-Sent Events с новыми и измененными результатами.
+// Поддерживает возобновление по заголовку Last-Event-ID (или параметру lastEventId)
+// в пределах истории брокера, а также фильтры ?type= и ?tag=
+func SSEHandler(b *Broker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming not supported", http.StatusInternalServerError)
+			return
+		}
+
+		lastID := r.Header.Get("Last-Event-ID")
+		if lastID == "" {
+			lastID = r.URL.Query().Get("lastEventId")
+		}
+		var from int64
+		if lastID != "" {
+			var err error
+			if from, err = strconv.ParseInt(lastID, 10, 64); err != nil {
+				http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+				return
+			}
+		}
+
+		filter := ParseFilter(r)
+		filter.Kinds = []string{KindResult}
+
+		sub, backlog := b.SubscribeFrom(filter, from)
+		defer b.Unsubscribe(sub)
+
+		activeSubscribers.With("sse").Inc()
+		defer activeSubscribers.With("sse").Dec()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		for _, e := range backlog {
+			if err := writeSSE(w, e); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		ticker := time.NewTicker(keepAliveInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case e, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				if err := writeSSE(w, e); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	})
+}
+
+// writeSSE записывает событие результата, пропуская сохранения без изменений
+func writeSSE(w http.ResponseWriter, e Event) error {
+	if p, ok := e.Payload.(ResultPayload); ok && p.ChangeType == models.ChangeUnchanged {
+		return nil
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Kind, data)
+	return err
+}
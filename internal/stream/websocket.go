@@ -0,0 +1,348 @@
+package stream
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rx3lixir/kultscraper/internal/lib/auth"
+)
+
+// websocketGUID - константа из RFC 6455 для вычисления Sec-WebSocket-Accept
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Коды операций фреймов WebSocket
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// Коды закрытия соединения из RFC 6455
+const (
+	closeProtocolError  = 1002
+	closeInvalidPayload = 1007
+	closeTooLarge       = 1009
+)
+
+const (
+	maxClientFrame   = 64 * 1024
+	maxClientMessage = 256 * 1024
+	writeTimeout     = 10 * time.Second
+	pingInterval     = 30 * time.Second
+)
+
+// protocolError - нарушение протокола клиентом, соединение закрывается с кодом code
+type protocolError struct {
+	code   uint16
+	reason string
+}
+
+func (e *protocolError) Error() string {
+	return "websocket: " + e.reason
+}
+
+var errFrameTooLarge = &protocolError{code: closeTooLarge, reason: "frame too large"}
+
+// errClosed - клиент закрыл соединение, ответный close уже отправлен
+var errClosed = errors.New("websocket closed")
+
+// frame - фрейм клиента после снятия маски
+type frame struct {
+	fin     bool
+	opcode  byte
+	payload []byte
+}
+
+// ParseFilter читает фильтр из параметров запроса: ?type=Кино&type=Театр&tag=free.
+// Запрос с ключом проекта (auth.Require) получает только события своего проекта
+func ParseFilter(r *http.Request) Filter {
+	q := r.URL.Query()
+	f := Filter{Types: splitValues(q["type"]), Tags: splitValues(q["tag"])}
+	if project, ok := auth.ProjectFrom(r.Context()); ok {
+		f.Project = &project
+	}
+	return f
+}
+
+func splitValues(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// WebSocketHandler возвращает обработчик, передающий события брокера по WebSocket
+// в виде текстовых JSON-фреймов. Сообщения клиента, кроме close и ping, игнорируются
+func WebSocketHandler(b *Broker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Sec-WebSocket-Key")
+		if !headerContains(r.Header, "Connection", "upgrade") ||
+			!headerContains(r.Header, "Upgrade", "websocket") ||
+			r.Header.Get("Sec-WebSocket-Version") != "13" || key == "" {
+			http.Error(w, "websocket upgrade required", http.StatusBadRequest)
+			return
+		}
+
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "websocket not supported", http.StatusInternalServerError)
+			return
+		}
+
+		filter := ParseFilter(r)
+
+		conn, rw, err := hj.Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\n" +
+			"Connection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n")
+		if err := rw.Flush(); err != nil {
+			return
+		}
+
+		ws := &wsConn{conn: conn}
+		sub := b.Subscribe(filter)
+		defer b.Unsubscribe(sub)
+
+		activeSubscribers.With("websocket").Inc()
+		defer activeSubscribers.With("websocket").Dec()
+
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			ws.readLoop(rw.Reader)
+		}()
+
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case <-r.Context().Done():
+				ws.writeFrame(opClose, nil)
+				return
+			case <-ticker.C:
+				if err := ws.writeFrame(opPing, nil); err != nil {
+					return
+				}
+			case e, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(e)
+				if err != nil {
+					continue
+				}
+				if err := ws.writeFrame(opText, data); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// wsConn - серверная сторона соединения WebSocket
+type wsConn struct {
+	conn net.Conn
+	mu   sync.Mutex
+}
+
+// writeFrame отправляет немаскированный фрейм (сервер не маскирует данные)
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readLoop читает фреймы клиента до закрытия соединения. Фрагментированные сообщения
+// собираются из фреймов продолжения; при нарушении протокола клиенту отправляется
+// close с кодом ошибки
+func (c *wsConn) readLoop(r *bufio.Reader) {
+	var (
+		message   []byte
+		messageOp byte // Код операции собираемого сообщения, 0 - сообщения нет
+	)
+	for {
+		f, err := readFrame(r)
+		if err == nil {
+			err = c.handleFrame(f, &message, &messageOp)
+		}
+		var perr *protocolError
+		if errors.As(err, &perr) {
+			c.writeFrame(opClose, closePayload(perr.code, perr.reason))
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handleFrame обрабатывает фрейм клиента: отвечает на ping и close, собирает
+// фрагменты сообщения в message. Собранные сообщения не используются
+func (c *wsConn) handleFrame(f frame, message *[]byte, messageOp *byte) error {
+	switch f.opcode {
+	case opClose:
+		if len(f.payload) == 1 {
+			return &protocolError{code: closeProtocolError, reason: "invalid close payload"}
+		}
+		c.writeFrame(opClose, f.payload)
+		return errClosed
+	case opPing:
+		return c.writeFrame(opPong, f.payload)
+	case opPong:
+		return nil
+	case opText, opBinary:
+		if *messageOp != 0 {
+			return &protocolError{code: closeProtocolError, reason: "new message before previous one finished"}
+		}
+		*messageOp = f.opcode
+		*message = append((*message)[:0], f.payload...)
+	case opContinuation:
+		if *messageOp == 0 {
+			return &protocolError{code: closeProtocolError, reason: "continuation frame without message"}
+		}
+		if len(*message)+len(f.payload) > maxClientMessage {
+			return &protocolError{code: closeTooLarge, reason: "message too large"}
+		}
+		*message = append(*message, f.payload...)
+	}
+
+	if !f.fin {
+		return nil
+	}
+	op := *messageOp
+	*messageOp = 0
+	if op == opText && !utf8.Valid(*message) {
+		return &protocolError{code: closeInvalidPayload, reason: "invalid UTF-8 in text message"}
+	}
+	return nil
+}
+
+// readFrame читает один фрейм клиента. Немаскированные фреймы, установленные биты RSV,
+// неизвестные коды операций и фрагментированные или длинные управляющие фреймы
+// нарушают RFC 6455 и возвращают protocolError
+func readFrame(r *bufio.Reader) (frame, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return frame{}, err
+	}
+
+	f := frame{fin: head[0]&0x80 != 0, opcode: head[0] & 0x0F}
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+
+	if head[0]&0x70 != 0 {
+		return frame{}, &protocolError{code: closeProtocolError, reason: "reserved bits set"}
+	}
+	switch f.opcode {
+	case opContinuation, opText, opBinary:
+	case opClose, opPing, opPong:
+		// Управляющие фреймы не фрагментируются и несут не больше 125 байт
+		if !f.fin || length > 125 {
+			return frame{}, &protocolError{code: closeProtocolError, reason: "invalid control frame"}
+		}
+	default:
+		return frame{}, &protocolError{code: closeProtocolError, reason: "unknown opcode"}
+	}
+	if !masked {
+		return frame{}, &protocolError{code: closeProtocolError, reason: "unmasked client frame"}
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return frame{}, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return frame{}, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxClientFrame {
+		return frame{}, errFrameTooLarge
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(r, mask[:]); err != nil {
+		return frame{}, err
+	}
+
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(r, f.payload); err != nil {
+		return frame{}, err
+	}
+	for i := range f.payload {
+		f.payload[i] ^= mask[i%4]
+	}
+
+	return f, nil
+}
+
+// closePayload строит тело фрейма close: код закрытия и причину
+func closePayload(code uint16, reason string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, code), reason...)
+}
+
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
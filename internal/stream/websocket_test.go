@@ -0,0 +1,231 @@
+package stream_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/internal/stream"
+)
+
+// Коды операций и закрытия из RFC 6455
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+
+	closeNormal         = 1000
+	closeProtocolError  = 1002
+	closeInvalidPayload = 1007
+)
+
+// clientFrame - фрейм, который тест отправляет серверу
+type clientFrame struct {
+	fin      bool
+	rsv      bool
+	opcode   byte
+	payload  string
+	unmasked bool
+}
+
+// dialWebSocket открывает соединение с обработчиком и выполняет рукопожатие
+func dialWebSocket(t *testing.T) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	srv := httptest.NewServer(stream.WebSocketHandler(stream.NewBroker()))
+	t.Cleanup(srv.Close)
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	io.WriteString(conn, "GET / HTTP/1.1\r\n"+
+		"Host: localhost\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatalf("ReadResponse() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status = %d, want %d", resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+	if got, want := resp.Header.Get("Sec-WebSocket-Accept"), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Fatalf("Sec-WebSocket-Accept = %q, want %q", got, want)
+	}
+	return conn, r
+}
+
+func writeClientFrame(t *testing.T, conn net.Conn, f clientFrame) {
+	t.Helper()
+	b0 := f.opcode
+	if f.fin {
+		b0 |= 0x80
+	}
+	if f.rsv {
+		b0 |= 0x40
+	}
+	payload := []byte(f.payload)
+	frame := []byte{b0, byte(len(payload))}
+	if !f.unmasked {
+		frame[1] |= 0x80
+		mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+		frame = append(frame, mask[:]...)
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	if _, err := conn.Write(append(frame, payload...)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+}
+
+// readServerFrame читает немаскированный фрейм сервера
+func readServerFrame(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		t.Fatalf("read frame header: %v", err)
+	}
+	if head[1]&0x80 != 0 {
+		t.Fatalf("server frame is masked")
+	}
+	length := int(head[1] & 0x7F)
+	if length >= 126 {
+		t.Fatalf("unexpected server frame length %d", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatalf("read frame payload: %v", err)
+	}
+	return head[0] & 0x0F, payload
+}
+
+func TestWebSocketFraming(t *testing.T) {
+	tests := []struct {
+		name        string
+		frames      []clientFrame
+		wantOpcode  byte
+		wantCode    uint16 // Код закрытия для wantOpcode == opClose
+		wantPayload string // Тело ответа для wantOpcode == opPong
+	}{
+		{
+			name:       "unmasked frame",
+			frames:     []clientFrame{{fin: true, opcode: opText, payload: "hi", unmasked: true}},
+			wantOpcode: opClose,
+			wantCode:   closeProtocolError,
+		},
+		{
+			name:       "reserved bits",
+			frames:     []clientFrame{{fin: true, rsv: true, opcode: opText, payload: "hi"}},
+			wantOpcode: opClose,
+			wantCode:   closeProtocolError,
+		},
+		{
+			name:       "unknown opcode",
+			frames:     []clientFrame{{fin: true, opcode: 0x3}},
+			wantOpcode: opClose,
+			wantCode:   closeProtocolError,
+		},
+		{
+			name:       "continuation without message",
+			frames:     []clientFrame{{fin: true, opcode: opContinuation, payload: "lo"}},
+			wantOpcode: opClose,
+			wantCode:   closeProtocolError,
+		},
+		{
+			name: "new message inside fragmented one",
+			frames: []clientFrame{
+				{fin: false, opcode: opText, payload: "hel"},
+				{fin: true, opcode: opText, payload: "lo"},
+			},
+			wantOpcode: opClose,
+			wantCode:   closeProtocolError,
+		},
+		{
+			name:       "fragmented ping",
+			frames:     []clientFrame{{fin: false, opcode: opPing, payload: "x"}},
+			wantOpcode: opClose,
+			wantCode:   closeProtocolError,
+		},
+		{
+			name:       "invalid UTF-8 text",
+			frames:     []clientFrame{{fin: true, opcode: opText, payload: "\xff\xfe"}},
+			wantOpcode: opClose,
+			wantCode:   closeInvalidPayload,
+		},
+		{
+			name: "invalid UTF-8 across fragments",
+			frames: []clientFrame{
+				{fin: false, opcode: opText, payload: "ok\xd0"},
+				{fin: true, opcode: opContinuation, payload: "x"},
+			},
+			wantOpcode: opClose,
+			wantCode:   closeInvalidPayload,
+		},
+		{
+			name: "fragmented message with ping between fragments",
+			frames: []clientFrame{
+				{fin: false, opcode: opText, payload: "при"},
+				{fin: true, opcode: opPing, payload: "p1"},
+				{fin: false, opcode: opContinuation, payload: "в"},
+				{fin: true, opcode: opContinuation, payload: "ет"},
+				{fin: true, opcode: opPing, payload: "p2"},
+			},
+			wantOpcode:  opPong,
+			wantPayload: "p2",
+		},
+		{
+			name:       "client close",
+			frames:     []clientFrame{{fin: true, opcode: opClose, payload: "\x03\xe8bye"}},
+			wantOpcode: opClose,
+			wantCode:   closeNormal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, r := dialWebSocket(t)
+			for _, f := range tt.frames {
+				writeClientFrame(t, conn, f)
+			}
+
+			// Ответы на ping до последнего фрейма подтверждают, что соединение живо
+			opcode, payload := readServerFrame(t, r)
+			for opcode == opPong && tt.wantOpcode == opPong && string(payload) != tt.wantPayload {
+				opcode, payload = readServerFrame(t, r)
+			}
+			if opcode != tt.wantOpcode {
+				t.Fatalf("opcode = %#x, want %#x (payload %q)", opcode, tt.wantOpcode, payload)
+			}
+
+			switch tt.wantOpcode {
+			case opClose:
+				if len(payload) < 2 {
+					t.Fatalf("close payload = %q, want status code", payload)
+				}
+				if code := binary.BigEndian.Uint16(payload); code != tt.wantCode {
+					t.Errorf("close code = %d, want %d (reason %q)", code, tt.wantCode, payload[2:])
+				}
+			case opPong:
+				if string(payload) != tt.wantPayload {
+					t.Errorf("pong payload = %q, want %q", payload, tt.wantPayload)
+				}
+			}
+		})
+	}
+}
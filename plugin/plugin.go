@@ -0,0 +1,176 @@
+// Package plugin - реестр плагинов kultscraper: движков, обогатителей, приемников
+// и решателей капчи. Плагин собирается отдельным модулем с -buildmode=plugin,
+// импортирует этот пакет и регистрирует компоненты в init() через функции Register*.
+// Типы, с которыми работают плагины, доступны здесь под собственными именами,
+// так как пакеты internal модуля из других модулей не импортируются
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/enrich"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/rx3lixir/kultscraper/internal/scraper"
+)
+
+// Типы приложения, которые получают и возвращают плагины
+type (
+	AppConfig     = config.AppConfig
+	Task          = config.ScraperTask
+	Result        = models.ScrapingResult
+	ResultChange  = models.ResultChange
+	Scraper       = scraper.Scraper
+	Enricher      = enrich.Enricher
+	CaptchaSolver = scraper.CaptchaSolver
+	Captcha       = scraper.Captcha
+)
+
+var (
+	ErrUnknownPlugin   = errors.New("unknown plugin")
+	ErrDuplicatePlugin = errors.New("plugin already registered")
+)
+
+// Sink получает каждый сохраненный результат, например для отправки во внешнюю систему
+type Sink interface {
+	Write(ctx context.Context, result *models.ScrapingResult, change *models.ResultChange) error
+	Close() error
+}
+
+// Фабрики плагинов получают конфигурацию приложения
+type (
+	EngineFactory   func(cfg *config.AppConfig) (scraper.Scraper, error)
+	EnricherFactory func(cfg *config.AppConfig) (enrich.Enricher, error)
+	SinkFactory     func(cfg *config.AppConfig) (Sink, error)
+	SolverFactory   func(cfg *config.AppConfig) (scraper.CaptchaSolver, error)
+)
+
+var (
+	mu        sync.RWMutex
+	engines   = make(map[string]EngineFactory)
+	enrichers = make(map[string]EnricherFactory)
+	sinks     = make(map[string]SinkFactory)
+	solvers   = make(map[string]SolverFactory)
+)
+
+// RegisterEngine регистрирует движок скраппинга. Обычно вызывается из init() пакета плагина
+// Заголовки запросов задачи, включая авторизацию, движок получает из task.RequestHeaders()
+func RegisterEngine(name string, f EngineFactory) error {
+	return register(engines, name, f)
+}
+
+// RegisterEnricher регистрирует обогатитель результатов
+func RegisterEnricher(name string, f EnricherFactory) error {
+	return register(enrichers, name, f)
+}
+
+// RegisterSink регистрирует приемник сохраненных результатов
+func RegisterSink(name string, f SinkFactory) error {
+	return register(sinks, name, f)
+}
+
+// RegisterCaptchaSolver регистрирует решатель капчи: сервис решения или ручную очередь
+func RegisterCaptchaSolver(name string, f SolverFactory) error {
+	return register(solvers, name, f)
+}
+
+func register[F any](m map[string]F, name string, f F) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := m[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicatePlugin, name)
+	}
+	m[name] = f
+	return nil
+}
+
+// NewEngine создает зарегистрированный движок скраппинга
+func NewEngine(name string, cfg *config.AppConfig) (scraper.Scraper, error) {
+	f, err := lookup(engines, name)
+	if err != nil {
+		return nil, err
+	}
+	return f(cfg)
+}
+
+// NewEnrichers создает зарегистрированные обогатители в заданном порядке
+func NewEnrichers(names []string, cfg *config.AppConfig) (enrich.Chain, error) {
+	var chain enrich.Chain
+	for _, name := range names {
+		f, err := lookup(enrichers, name)
+		if err != nil {
+			return nil, err
+		}
+		e, err := f(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("enricher %s: %w", name, err)
+		}
+		chain = append(chain, e)
+	}
+	return chain, nil
+}
+
+// NewSinks создает зарегистрированные приемники результатов
+func NewSinks(names []string, cfg *config.AppConfig) ([]Sink, error) {
+	var out []Sink
+	for _, name := range names {
+		f, err := lookup(sinks, name)
+		if err != nil {
+			return nil, err
+		}
+		s, err := f(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("sink %s: %w", name, err)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// NewCaptchaSolver создает зарегистрированный решатель капчи
+func NewCaptchaSolver(name string, cfg *config.AppConfig) (scraper.CaptchaSolver, error) {
+	f, err := lookup(solvers, name)
+	if err != nil {
+		return nil, err
+	}
+	return f(cfg)
+}
+
+func lookup[F any](m map[string]F, name string) (F, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	f, ok := m[name]
+	if !ok {
+		var zero F
+		return zero, fmt.Errorf("%w: %s", ErrUnknownPlugin, name)
+	}
+	return f, nil
+}
+
+// Names возвращает имена зарегистрированных плагинов по видам
+func Names() map[string][]string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return map[string][]string{
+		"engine":   sortedNames(engines),
+		"enricher": sortedNames(enrichers),
+		"sink":     sortedNames(sinks),
+		"captcha":  sortedNames(solvers),
+	}
+}
+
+func sortedNames[F any](m map[string]F) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,266 @@
+// Package scrapertest содержит заглушки и окружение для интеграционных тестов
+// кода, построенного на интерфейсах Scraper и ScraperRepository, без Chromium и MongoDB.
+// Типы приложения, которые принимают и возвращают заглушки, доступны здесь под
+// собственными именами, так как пакеты internal из других модулей не импортируются
+package scrapertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/rx3lixir/kultscraper/hooks"
+	"github.com/rx3lixir/kultscraper/internal/config"
+	"github.com/rx3lixir/kultscraper/internal/db"
+	"github.com/rx3lixir/kultscraper/internal/lib/work"
+	"github.com/rx3lixir/kultscraper/internal/models"
+	"github.com/rx3lixir/kultscraper/internal/scraper"
+)
+
+// Типы приложения, с которыми работают заглушки и Harness
+type (
+	Task         = config.ScraperTask
+	Result       = models.ScrapingResult
+	ResultChange = models.ResultChange
+	ChangeType   = models.ChangeType
+	Scraper      = scraper.Scraper
+	Repository   = db.ScraperRepository
+)
+
+// Типы изменений в Report.Changes
+const (
+	ChangeCreated   = models.ChangeCreated
+	ChangeUpdated   = models.ChangeUpdated
+	ChangeUnchanged = models.ChangeUnchanged
+)
+
+var ErrNoFixture = errors.New("no mock result for url")
+
+// ErrNotModified - ошибка пропуска неизмененной страницы. MockScraper, вернувший ее
+// через SetError, имитирует попадание в HTTP-кэш: Harness записывает задачу в Report.Skipped
+var ErrNotModified = scraper.ErrNotModified
+
+// MockScraper возвращает заранее заданные результаты по URL и запоминает вызовы
+type MockScraper struct {
+	// ScrapeFunc, если задана, полностью заменяет поведение по умолчанию
+	ScrapeFunc func(ctx context.Context, task config.ScraperTask) (*models.ScrapingResult, error)
+	// Delay имитирует время загрузки страницы
+	Delay time.Duration
+
+	mu      sync.Mutex
+	results map[string]map[string]string
+	errors  map[string]error
+	calls   []config.ScraperTask
+}
+
+var _ scraper.Scraper = (*MockScraper)(nil)
+
+// NewMockScraper создает заглушку без заданных результатов
+func NewMockScraper() *MockScraper {
+	return &MockScraper{
+		results: make(map[string]map[string]string),
+		errors:  make(map[string]error),
+	}
+}
+
+// SetResult задает данные, которые вернет скраппинг указанного URL
+func (m *MockScraper) SetResult(url string, data map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results[url] = data
+	delete(m.errors, url)
+}
+
+// SetError задает ошибку, которую вернет скраппинг указанного URL
+func (m *MockScraper) SetError(url string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors[url] = err
+}
+
+// Calls возвращает задачи, переданные в Scrape, в порядке вызова
+func (m *MockScraper) Calls() []config.ScraperTask {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]config.ScraperTask(nil), m.calls...)
+}
+
+// Scrape возвращает заданный результат или ошибку для URL задачи
+func (m *MockScraper) Scrape(ctx context.Context, task config.ScraperTask) (*models.ScrapingResult, error) {
+	m.mu.Lock()
+	m.calls = append(m.calls, task)
+	data, hasData := m.results[task.URL]
+	err := m.errors[task.URL]
+	m.mu.Unlock()
+
+	if m.Delay > 0 {
+		select {
+		case <-time.After(m.Delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if m.ScrapeFunc != nil {
+		return m.ScrapeFunc(ctx, task)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !hasData {
+		return nil, fmt.Errorf("%w: %s", ErrNoFixture, task.URL)
+	}
+
+	result := models.NewScrapingResult(task.URL, task.Type, task.Name, maps.Clone(data))
+	result.Metadata.Engine = "mock"
+	return result, nil
+}
+
+// Close ничего не делает
+func (m *MockScraper) Close() error {
+	return nil
+}
+
+// FixtureServer - локальный HTTP-сервер с тестовыми страницами
+type FixtureServer struct {
+	*httptest.Server
+}
+
+// NewFixtureServer запускает сервер, отдающий страницы по путям (например, "/events.html")
+func NewFixtureServer(pages map[string]string) *FixtureServer {
+	mux := http.NewServeMux()
+	for path, body := range pages {
+		body := body
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			io.WriteString(w, body)
+		})
+	}
+	return &FixtureServer{Server: httptest.NewServer(mux)}
+}
+
+// NewFixtureServerFS запускает сервер, отдающий файлы из fsys (например, os.DirFS("testdata"))
+func NewFixtureServerFS(fsys fs.FS) *FixtureServer {
+	return &FixtureServer{Server: httptest.NewServer(http.FileServer(http.FS(fsys)))}
+}
+
+// NewFixtureServerDir запускает сервер, отдающий файлы из каталога
+func NewFixtureServerDir(dir string) *FixtureServer {
+	return NewFixtureServerFS(os.DirFS(dir))
+}
+
+// URL возвращает полный адрес страницы на сервере
+func (s *FixtureServer) URL(path string) string {
+	return s.Server.URL + path
+}
+
+// Report - итоги прогона задач через Harness
+type Report struct {
+	Changes  []*models.ResultChange
+	Failures map[string]error // Ошибки по URL задачи
+	Skipped  []string         // URL задач, пропущенных из-за неизмененной страницы
+}
+
+// Harness собирает конвейер приложения (пул, задачи, хуки, репозиторий) на заглушках
+type Harness struct {
+	Server  *FixtureServer
+	Scraper scraper.Scraper
+	Repo    db.ScraperRepository
+	Hooks   *hooks.Registry
+	Workers int
+	RunID   string
+}
+
+// NewHarness создает окружение с сервером страниц, MockScraper и репозиторием в памяти
+func NewHarness(pages map[string]string) *Harness {
+	return &Harness{
+		Server:  NewFixtureServer(pages),
+		Scraper: NewMockScraper(),
+		Repo:    db.NewMemoryScraperRepo(),
+		Hooks:   hooks.New(),
+		Workers: 2,
+		RunID:   "test-run",
+	}
+}
+
+// Mock возвращает MockScraper окружения или nil, если скрапер заменен
+func (h *Harness) Mock() *MockScraper {
+	m, _ := h.Scraper.(*MockScraper)
+	return m
+}
+
+// Close останавливает сервер страниц
+func (h *Harness) Close() {
+	if h.Server != nil {
+		h.Server.Close()
+	}
+}
+
+// Run выполняет задачи через пул воркеров и сохраняет результаты в репозиторий
+func (h *Harness) Run(ctx context.Context, tasks []config.ScraperTask) (*Report, error) {
+	report := &Report{Failures: make(map[string]error)}
+	if len(tasks) == 0 {
+		return report, nil
+	}
+
+	pool, err := work.NewTypedPool[*models.ScrapingResult](max(h.Workers, 1), len(tasks))
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Start(ctx); err != nil {
+		return nil, err
+	}
+	defer pool.Stop()
+
+	failures := make(chan hooks.TaskEvent, len(tasks))
+	runHooks := hooks.New()
+	runHooks.OnTaskStart(h.Hooks.TaskStarted)
+	runHooks.OnTaskFinish(h.Hooks.TaskFinished)
+	runHooks.OnTaskFinish(func(ctx context.Context, e hooks.TaskEvent) {
+		if e.Err != nil && !e.Retrying {
+			failures <- e
+		}
+	})
+
+	logger := log.New(io.Discard)
+	for _, task := range tasks {
+		t := scraper.NewTaskToScrape(task, ctx, h.Scraper, *logger)
+		t.RunID = h.RunID
+		t.Hooks = runHooks
+		if err := pool.AddTask(t); err != nil {
+			return nil, err
+		}
+	}
+
+	for done := 0; done < len(tasks); done++ {
+		select {
+		case result := <-pool.Results():
+			change, err := h.Repo.UpsertResult(ctx, result)
+			if err != nil {
+				report.Failures[result.URL] = err
+				continue
+			}
+			report.Changes = append(report.Changes, change)
+			h.Hooks.ResultSaved(ctx, hooks.ResultEvent{RunID: h.RunID, Result: result, Change: change})
+		case e := <-failures:
+			if errors.Is(e.Err, scraper.ErrNotModified) {
+				report.Skipped = append(report.Skipped, e.Task.URL)
+				continue
+			}
+			report.Failures[e.Task.URL] = e.Err
+		case <-ctx.Done():
+			return report, ctx.Err()
+		}
+	}
+
+	return report, nil
+}
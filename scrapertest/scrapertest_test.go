@@ -0,0 +1,136 @@
+package scrapertest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rx3lixir/kultscraper/hooks"
+	"github.com/rx3lixir/kultscraper/scrapertest"
+)
+
+func TestMockScraper(t *testing.T) {
+	m := scrapertest.NewMockScraper()
+	m.SetResult("https://example.com/a", map[string]string{"title": "A"})
+	errBoom := errors.New("boom")
+	m.SetError("https://example.com/b", errBoom)
+
+	ctx := context.Background()
+	result, err := m.Scrape(ctx, scrapertest.Task{URL: "https://example.com/a", Type: "event", Name: "a"})
+	if err != nil {
+		t.Fatalf("Scrape(a): %v", err)
+	}
+	if result.Data["title"] != "A" || result.Metadata.Engine != "mock" {
+		t.Errorf("Scrape(a) = %+v, want title A from mock engine", result)
+	}
+
+	if _, err := m.Scrape(ctx, scrapertest.Task{URL: "https://example.com/b"}); !errors.Is(err, errBoom) {
+		t.Errorf("Scrape(b) error = %v, want %v", err, errBoom)
+	}
+	if _, err := m.Scrape(ctx, scrapertest.Task{URL: "https://example.com/c"}); !errors.Is(err, scrapertest.ErrNoFixture) {
+		t.Errorf("Scrape(c) error = %v, want %v", err, scrapertest.ErrNoFixture)
+	}
+
+	if calls := m.Calls(); len(calls) != 3 || calls[0].URL != "https://example.com/a" {
+		t.Errorf("Calls() = %v, want 3 calls starting with a", calls)
+	}
+
+	m.Delay = time.Second
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := m.Scrape(cancelled, scrapertest.Task{URL: "https://example.com/a"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Scrape with cancelled context error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestFixtureServer(t *testing.T) {
+	server := scrapertest.NewFixtureServer(map[string]string{"/events.html": "<h1>Events</h1>"})
+	defer server.Close()
+
+	resp, err := http.Get(server.URL("/events.html"))
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "<h1>Events</h1>" {
+		t.Errorf("body = %q, want fixture page", body)
+	}
+}
+
+func TestHarnessRun(t *testing.T) {
+	h := scrapertest.NewHarness(map[string]string{"/ok.html": "<p>ok</p>"})
+	defer h.Close()
+
+	okURL := h.Server.URL("/ok.html")
+	failURL := h.Server.URL("/fail.html")
+	h.Mock().SetResult(okURL, map[string]string{"title": "First"})
+	h.Mock().SetError(failURL, errors.New("page is broken"))
+
+	var saved atomic.Int32
+	h.Hooks.OnResultSaved(func(ctx context.Context, e hooks.ResultEvent) {
+		saved.Add(1)
+	})
+
+	tasks := []scrapertest.Task{
+		{URL: okURL, Type: "event", Name: "ok", Selectors: map[string]string{"title": "h1"}},
+		{URL: failURL, Type: "event", Name: "fail", Selectors: map[string]string{"title": "h1"}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	report, err := h.Run(ctx, tasks)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(report.Changes) != 1 || report.Changes[0].ChangeType != scrapertest.ChangeCreated {
+		t.Fatalf("first run changes = %+v, want one created result", report.Changes)
+	}
+	if report.Failures[failURL] == nil {
+		t.Errorf("first run failures = %v, want failure for %s", report.Failures, failURL)
+	}
+	if got := saved.Load(); got != 1 {
+		t.Errorf("result saved hooks = %d, want 1", got)
+	}
+
+	// Повторный прогон с теми же данными не меняет результат
+	report, err = h.Run(ctx, tasks[:1])
+	if err != nil {
+		t.Fatalf("second Run: %v", err)
+	}
+	if len(report.Changes) != 1 || report.Changes[0].ChangeType != scrapertest.ChangeUnchanged {
+		t.Errorf("second run changes = %+v, want one unchanged result", report.Changes)
+	}
+
+	h.Mock().SetResult(okURL, map[string]string{"title": "Second"})
+	report, err = h.Run(ctx, tasks[:1])
+	if err != nil {
+		t.Fatalf("third Run: %v", err)
+	}
+	if len(report.Changes) != 1 || report.Changes[0].ChangeType != scrapertest.ChangeUpdated {
+		t.Fatalf("third run changes = %+v, want one updated result", report.Changes)
+	}
+	if got := report.Changes[0].Current["title"]; got != "Second" {
+		t.Errorf("updated title = %q, want %q", got, "Second")
+	}
+}
+
+func TestHarnessSkipsNotModified(t *testing.T) {
+	h := scrapertest.NewHarness(nil)
+	defer h.Close()
+
+	url := h.Server.URL("/cached.html")
+	h.Mock().SetError(url, scrapertest.ErrNotModified)
+
+	report, err := h.Run(context.Background(), []scrapertest.Task{{URL: url, Type: "event", Name: "cached"}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(report.Failures) != 0 || len(report.Skipped) != 1 || report.Skipped[0] != url {
+		t.Errorf("report = %+v, want the task skipped without failures", report)
+	}
+}